@@ -35,6 +35,14 @@ func (vp *ClusterVectorPipeline) GetReason() *string {
 	return vp.Status.Reason
 }
 
+func (vp *ClusterVectorPipeline) SetConfigCheckReport(report *ConfigCheckReport) {
+	vp.Status.ConfigCheckReport = report
+}
+
+func (vp *ClusterVectorPipeline) GetConfigCheckReport() *ConfigCheckReport {
+	return vp.Status.ConfigCheckReport
+}
+
 func (vp *ClusterVectorPipeline) GetLastAppliedPipeline() *int64 {
 	return vp.Status.LastAppliedPipelineHash
 }
@@ -65,3 +73,39 @@ func (vp *ClusterVectorPipeline) SetRole(role *VectorPipelineRole) {
 func (vp *ClusterVectorPipeline) GetTypeMeta() metav1.TypeMeta {
 	return vp.TypeMeta
 }
+
+func (vp *ClusterVectorPipeline) GetConditions() []metav1.Condition {
+	return vp.Status.Conditions
+}
+
+func (vp *ClusterVectorPipeline) SetConditions(conditions []metav1.Condition) {
+	vp.Status.Conditions = conditions
+}
+
+func (vp *ClusterVectorPipeline) MarkSucceeded() {
+	vp.Status.MarkSucceeded(vp.Generation, len(vp.Spec.Secret) > 0)
+}
+
+func (vp *ClusterVectorPipeline) MarkFailed(conditionType, reason, message string) {
+	vp.Status.MarkFailed(vp.Generation, conditionType, reason, message)
+}
+
+func (vp *ClusterVectorPipeline) GetWorkloads() []WorkloadReference {
+	return vp.Status.Workloads
+}
+
+func (vp *ClusterVectorPipeline) SetWorkloads(workloads []WorkloadReference) {
+	vp.Status.Workloads = workloads
+}
+
+func (vp *ClusterVectorPipeline) GetExcludedFrom() []WorkloadReference {
+	return vp.Status.ExcludedFrom
+}
+
+func (vp *ClusterVectorPipeline) SetExcludedFrom(workloads []WorkloadReference) {
+	vp.Status.ExcludedFrom = workloads
+}
+
+func (vp *ClusterVectorPipeline) MarkSuspended() {
+	vp.Status.MarkSuspended(vp.Generation)
+}
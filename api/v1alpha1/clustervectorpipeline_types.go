@@ -29,6 +29,7 @@ import (
 //+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 //+kubebuilder:printcolumn:name="Valid",type="boolean",JSONPath=".status.configCheckResult"
 //+kubebuilder:printcolumn:name="Role",type="string",JSONPath=".status.role"
+//+kubebuilder:printcolumn:name="Suspended",type="boolean",JSONPath=".spec.suspend",priority=1
 
 // ClusterVectorPipeline is the Schema for the clustervectorpipelines API
 type ClusterVectorPipeline struct {
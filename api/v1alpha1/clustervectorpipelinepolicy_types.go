@@ -0,0 +1,106 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ClusterVectorPipelinePolicySpec restricts what the VectorPipelines of the namespaces
+// it selects may send their events through and to. Each list left unset leaves that
+// dimension unrestricted; a pipeline governed by several policies must satisfy every
+// one of them.
+type ClusterVectorPipelinePolicySpec struct {
+	// NamespaceSelector selects the namespaces whose VectorPipelines the policy governs.
+	// Unset selects every namespace. ClusterVectorPipelines are never governed: they are
+	// written by cluster administrators.
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+	// AllowedSinkTypes lists the sink types the pipelines may use, e.g. loki or
+	// elasticsearch.
+	// +optional
+	AllowedSinkTypes []string `json:"allowedSinkTypes,omitempty"`
+	// AllowedTransformTypes lists the transform types the pipelines may use, e.g. remap
+	// or filter.
+	// +optional
+	AllowedTransformTypes []string `json:"allowedTransformTypes,omitempty"`
+	// AllowedEndpointHosts lists the hosts sinks may connect to, as shell patterns matched
+	// against the host of every endpoint, endpoints, uri, address and bootstrap_servers
+	// option of a sink, e.g. *.logging.svc.cluster.local. A sink naming none is rejected,
+	// as it sends to a default destination, except blackhole, console and file; an
+	// endpoint that is not a literal host, such as a SECRET[] reference, never matches.
+	// +optional
+	AllowedEndpointHosts []string `json:"allowedEndpointHosts,omitempty"`
+	// Quota caps what the VectorPipelines of each selected namespace add to the config
+	// of a workload. Every namespace has a quota of its own.
+	// +optional
+	Quota *PipelineQuota `json:"quota,omitempty"`
+}
+
+// PipelineQuota caps what the VectorPipelines of one namespace add to the config of one
+// workload. A limit left unset is not enforced. Pipelines are admitted oldest first; one
+// that would take its namespace over a limit is left out of the workload's config.
+type PipelineQuota struct {
+	// MaxPipelines caps the number of pipelines.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxPipelines *int64 `json:"maxPipelines,omitempty"`
+	// MaxSources caps the number of sources across the pipelines.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxSources *int64 `json:"maxSources,omitempty"`
+	// MaxTransforms caps the number of transforms across the pipelines.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxTransforms *int64 `json:"maxTransforms,omitempty"`
+	// MaxSinks caps the number of sinks across the pipelines.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxSinks *int64 `json:"maxSinks,omitempty"`
+	// MaxConfigBytes caps the size of the sources, transforms and sinks of the pipelines,
+	// as JSON, with templates and outputs rendered.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxConfigBytes *int64 `json:"maxConfigBytes,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:scope=Cluster,shortName=cvpp
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// ClusterVectorPipelinePolicy restricts the sinks, transforms and destinations of the
+// VectorPipelines in the namespaces it selects. A pipeline that breaks it is invalid and
+// is left out of every config.
+type ClusterVectorPipelinePolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ClusterVectorPipelinePolicySpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ClusterVectorPipelinePolicyList contains a list of ClusterVectorPipelinePolicy
+type ClusterVectorPipelinePolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterVectorPipelinePolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ClusterVectorPipelinePolicy{}, &ClusterVectorPipelinePolicyList{})
+}
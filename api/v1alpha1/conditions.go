@@ -0,0 +1,156 @@
+package v1alpha1
+
+import (
+	"unicode/utf8"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Condition types reported in .status.conditions of every CR the operator reconciles.
+// ConfigCheckResult and Reason stay as they are for existing tooling; the conditions
+// break the same verdict down by stage so a reader can tell a bad spec from a missing
+// Secret from a config that is valid but not yet live.
+const (
+	// ConditionConfigValid: the generated config passed every check the operator runs
+	// on it (spec parsing, config build, configcheck).
+	ConditionConfigValid = "ConfigValid"
+	// ConditionSecretsResolved: every SECRET[] reference resolved and fits the shared
+	// secret-assets Secret. True with ReasonNoSecrets when nothing is referenced.
+	ConditionSecretsResolved = "SecretsResolved"
+	// ConditionPublished: on a workload, its config Secret holds the current config; on a
+	// pipeline, the pipeline is part of the config its workloads publish.
+	ConditionPublished = "Published"
+	// ConditionReady: the summary of the three above - True only when all of them are.
+	ConditionReady = "Ready"
+	// ConditionSuspended: pipelines only, present and True while spec.suspend is set.
+	ConditionSuspended = "Suspended"
+)
+
+// Condition reasons. Failure reasons for the secret attribution classes map one to one
+// onto the reason prefixes the workload reconcilers write to .status.reason.
+const (
+	ReasonConfigCheckPassed    = "ConfigCheckPassed"
+	ReasonConfigInvalid        = "ConfigInvalid"
+	ReasonPolicyViolation      = "PolicyViolation"
+	ReasonSecretsResolved      = "SecretsResolved"
+	ReasonNoSecrets            = "NoSecrets"
+	ReasonSecretResolveFailed  = "SecretResolveFailed"
+	ReasonSecretKeyCollision   = "SecretKeyCollision"
+	ReasonSecretAssetsTooLarge = "SecretAssetsTooLarge"
+	ReasonSecretAssetsWaiting  = "SecretAssetsWaiting"
+	ReasonPublished            = "Published"
+	ReasonNotPublished         = "NotPublished"
+	ReasonQuotaExceeded        = "QuotaExceeded"
+	ReasonReady                = "Ready"
+	ReasonSuspended            = "Suspended"
+
+	// ReasonWorkloadConfigCheckFailed: a pipeline that passes its own configcheck fails
+	// the configcheck of a workload it is part of. An event reason only: the workload
+	// leaves the pipeline out (.status.excludedFrom), the others keep running it.
+	ReasonWorkloadConfigCheckFailed = "WorkloadConfigCheckFailed"
+	// ReasonTestsFailed: the pipeline's config validates, but its spec.tests fail.
+	ReasonTestsFailed = "TestsFailed"
+	// ReasonConfigCheckTimeout: a configcheck gave no verdict in time. An event reason
+	// only: a workload keeps its status, with the last verdict, and retries the round,
+	// so without the event a timeout is only in the logs.
+	ReasonConfigCheckTimeout = "ConfigCheckTimeout"
+)
+
+// maxConditionMessageLength is the API server's limit on metav1.Condition.Message.
+// Reasons carrying configcheck output can exceed it, and a status write with an
+// over-long message is rejected whole.
+const maxConditionMessageLength = 32768
+
+// setCondition upserts one condition, stamping it with the generation it describes.
+// meta.SetStatusCondition keeps LastTransitionTime when the status does not change.
+func setCondition(conds *[]metav1.Condition, generation int64, condType string, status metav1.ConditionStatus, reason, message string) {
+	if len(message) > maxConditionMessageLength {
+		// cut on a rune boundary: the API server rejects a message that is not valid UTF-8
+		n := maxConditionMessageLength - len("...")
+		for n > 0 && !utf8.RuneStart(message[n]) {
+			n--
+		}
+		message = message[:n] + "..."
+	}
+	meta.SetStatusCondition(conds, metav1.Condition{
+		Type:               condType,
+		Status:             status,
+		ObservedGeneration: generation,
+		Reason:             reason,
+		Message:            message,
+	})
+}
+
+// markSucceeded sets every well-known condition True.
+func markSucceeded(conds *[]metav1.Condition, generation int64, secretsReferenced bool) {
+	secretsReason := ReasonSecretsResolved
+	if !secretsReferenced {
+		secretsReason = ReasonNoSecrets
+	}
+	setCondition(conds, generation, ConditionConfigValid, metav1.ConditionTrue, ReasonConfigCheckPassed, "")
+	setCondition(conds, generation, ConditionSecretsResolved, metav1.ConditionTrue, secretsReason, "")
+	setCondition(conds, generation, ConditionPublished, metav1.ConditionTrue, ReasonPublished, "")
+	setCondition(conds, generation, ConditionReady, metav1.ConditionTrue, ReasonReady, "")
+}
+
+// markFailed sets condType False with reason and message and mirrors the failure into
+// Ready. Conditions describing other stages are left as they were: a Secret that stops
+// resolving says nothing new about whether the rest of the config is valid.
+func markFailed(conds *[]metav1.Condition, generation int64, condType, reason, message string) {
+	setCondition(conds, generation, condType, metav1.ConditionFalse, reason, message)
+	setCondition(conds, generation, ConditionReady, metav1.ConditionFalse, reason, message)
+}
+
+// MarkSucceeded records a successful round on a workload: its config is valid, every
+// referenced Secret resolved and the config Secret holds the current config.
+func (s *VectorCommonStatus) MarkSucceeded(generation int64, secretsReferenced bool) {
+	markSucceeded(&s.Conditions, generation, secretsReferenced)
+	s.ObservedGeneration = generation
+}
+
+// MarkFailed records a failed round on a workload. Published is not touched: the
+// config published by the last successful round is still the one running.
+func (s *VectorCommonStatus) MarkFailed(generation int64, condType, reason, message string) {
+	markFailed(&s.Conditions, generation, condType, reason, message)
+	s.ObservedGeneration = generation
+}
+
+// MarkSucceeded records that the pipeline passed every check and is part of the
+// config its workloads publish.
+func (s *VectorPipelineStatus) MarkSucceeded(generation int64, secretsReferenced bool) {
+	markSucceeded(&s.Conditions, generation, secretsReferenced)
+	meta.RemoveStatusCondition(&s.Conditions, ConditionSuspended)
+	s.ObservedGeneration = generation
+}
+
+// MarkFailed records why the pipeline is invalid. Unlike a workload, a failed pipeline
+// is also left out of every config built from now on, so Published turns False too -
+// with reason, when the failure is Published itself.
+func (s *VectorPipelineStatus) MarkFailed(generation int64, condType, reason, message string) {
+	markFailed(&s.Conditions, generation, condType, reason, message)
+	if condType != ConditionPublished {
+		setCondition(&s.Conditions, generation, ConditionPublished, metav1.ConditionFalse, ReasonNotPublished, message)
+	}
+	meta.RemoveStatusCondition(&s.Conditions, ConditionSuspended)
+	s.ObservedGeneration = generation
+}
+
+// MarkSuspended records that spec.suspend took the pipeline out of every config.
+// ConfigValid and SecretsResolved keep the verdict of the last round that checked the
+// pipeline: a suspended one is not checked. Once the pipeline is edited while
+// suspended, that verdict is on a spec that no longer exists: they turn Unknown, and
+// ConfigCheckResult, Reason and ConfigCheckReport are dropped until it is resumed.
+func (s *VectorPipelineStatus) MarkSuspended(generation int64) {
+	const message = "spec.suspend is set"
+	if meta.IsStatusConditionTrue(s.Conditions, ConditionSuspended) && s.ObservedGeneration != generation {
+		const edited = "edited while suspended, checked again once resumed"
+		setCondition(&s.Conditions, generation, ConditionConfigValid, metav1.ConditionUnknown, ReasonSuspended, edited)
+		setCondition(&s.Conditions, generation, ConditionSecretsResolved, metav1.ConditionUnknown, ReasonSuspended, edited)
+		s.ConfigCheckResult, s.Reason, s.ConfigCheckReport = nil, nil, nil
+	}
+	setCondition(&s.Conditions, generation, ConditionSuspended, metav1.ConditionTrue, ReasonSuspended, message)
+	setCondition(&s.Conditions, generation, ConditionPublished, metav1.ConditionFalse, ReasonSuspended, message)
+	setCondition(&s.Conditions, generation, ConditionReady, metav1.ConditionFalse, ReasonSuspended, message)
+	s.ObservedGeneration = generation
+}
@@ -0,0 +1,52 @@
+package v1alpha1
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+)
+
+// configcheck output can run past the API server's limit on a condition message; the
+// status write is rejected whole when it does, so the message is cut, and cut to valid UTF-8.
+func TestSetConditionTruncatesMessage(t *testing.T) {
+	var s VectorCommonStatus
+	s.MarkFailed(1, ConditionConfigValid, ReasonConfigInvalid, "x"+strings.Repeat("é", maxConditionMessageLength))
+
+	c := meta.FindStatusCondition(s.Conditions, ConditionConfigValid)
+	require.NotNil(t, c)
+	require.LessOrEqual(t, len(c.Message), maxConditionMessageLength)
+	require.True(t, utf8.ValidString(c.Message))
+	require.True(t, strings.HasSuffix(c.Message, "..."))
+}
+
+func TestMarkFailedPublished(t *testing.T) {
+	var workload VectorCommonStatus
+	workload.MarkSucceeded(1, false)
+	workload.MarkFailed(2, ConditionSecretsResolved, ReasonSecretResolveFailed, "not found")
+	require.True(t, meta.IsStatusConditionTrue(workload.Conditions, ConditionPublished), "a workload keeps running its last published config")
+	require.True(t, meta.IsStatusConditionFalse(workload.Conditions, ConditionReady))
+	require.Equal(t, int64(2), workload.ObservedGeneration)
+
+	var pipeline VectorPipelineStatus
+	pipeline.MarkSucceeded(1, true)
+	pipeline.MarkFailed(2, ConditionConfigValid, ReasonConfigInvalid, "bad sink")
+	require.True(t, meta.IsStatusConditionFalse(pipeline.Conditions, ConditionPublished), "a failed pipeline drops out of the config")
+	require.True(t, meta.IsStatusConditionTrue(pipeline.Conditions, ConditionSecretsResolved))
+	require.Equal(t, int64(2), pipeline.ObservedGeneration)
+}
+
+// A pipeline left out of a config by a quota is valid: Published carries the reason
+// itself instead of the generic NotPublished.
+func TestMarkFailedPublishedKeepsReason(t *testing.T) {
+	var pipeline VectorPipelineStatus
+	pipeline.MarkSucceeded(1, false)
+	pipeline.MarkFailed(1, ConditionPublished, ReasonQuotaExceeded, "over maxSinks")
+	c := meta.FindStatusCondition(pipeline.Conditions, ConditionPublished)
+	require.NotNil(t, c)
+	require.Equal(t, ReasonQuotaExceeded, c.Reason)
+	require.True(t, meta.IsStatusConditionTrue(pipeline.Conditions, ConditionConfigValid))
+	require.True(t, meta.IsStatusConditionFalse(pipeline.Conditions, ConditionReady))
+}
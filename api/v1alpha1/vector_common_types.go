@@ -26,6 +26,58 @@ type VectorCommonStatus struct {
 	// ensureVectorAgentSecretAssets/ensureVectorAggregatorSecretAssets' doc comments.
 	// +optional
 	LastConfigPublishedAt *metav1.Time `json:"lastConfigPublishedAt,omitempty"`
+	// ObservedGeneration is the .metadata.generation the status was last written for.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Conditions break the workload's state down by stage: ConfigValid,
+	// SecretsResolved, Published and Ready.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// ConfigCheckReport breaks the last failed configcheck down by component. Cleared
+	// once the config checks and builds again.
+	// +optional
+	ConfigCheckReport *ConfigCheckReport `json:"configCheckReport,omitempty"`
+}
+
+// ConfigCheckReport is the output of a failed configcheck, parsed into one entry per
+// error vector reports. The list is cut to keep the status small, and the whole output
+// is kept in the ConfigMap Output references.
+type ConfigCheckReport struct {
+	// +optional
+	Errors []ConfigCheckError `json:"errors,omitempty"`
+	// OmittedErrors is the number of errors left out of Errors.
+	// +optional
+	OmittedErrors int32 `json:"omittedErrors,omitempty"`
+	// +optional
+	Output *ConfigCheckOutputReference `json:"output,omitempty"`
+}
+
+// ConfigCheckError is one error of a failed configcheck.
+type ConfigCheckError struct {
+	// Component is the ID of the component the error is about, as generated into the
+	// config: "<namespace>-<pipeline>-<component>".
+	// +optional
+	Component string `json:"component,omitempty"`
+	// Pipeline is the pipeline Component comes from, as namespace/name, or name for a
+	// ClusterVectorPipeline. Empty for the workload's own components and the ones
+	// several pipelines share.
+	// +optional
+	Pipeline string `json:"pipeline,omitempty"`
+	// Kind classifies the error: UnknownInput, NoInputs, DataTypeMismatch,
+	// DuplicateComponent, VRL, InvalidOption, Component or Config.
+	Kind string `json:"kind"`
+	// Message is the error as vector reports it, on one line and cut to 256 characters.
+	Message string `json:"message"`
+}
+
+// ConfigCheckOutputReference names the ConfigMap key holding the raw output of a
+// configcheck.
+type ConfigCheckOutputReference struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Key       string `json:"key"`
 }
 
 type VectorCommon struct {
@@ -169,8 +221,18 @@ type ConfigCheck struct {
 	Labels map[string]string `json:"labels,omitempty"`
 }
 
+// VectorSelectorSpec selects the pipelines a workload builds its config from. matchLabels
+// and matchExpressions are ANDed as in a metav1.LabelSelector and evaluated against the
+// pipeline's labels; namespaceSelector is evaluated against the labels of a
+// VectorPipeline's namespace and does not apply to ClusterVectorPipelines, which have none.
 type VectorSelectorSpec struct {
 	MatchLabels map[string]string `json:"matchLabels,omitempty"`
+	// +optional
+	MatchExpressions []metav1.LabelSelectorRequirement `json:"matchExpressions,omitempty"`
+	// NamespaceSelector restricts VectorPipelines to namespaces whose labels match.
+	// Unset, pipelines from every namespace are selected.
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
 }
 
 type VectorAggregatorAutoscaling struct {
@@ -308,4 +370,55 @@ type EventCollector struct {
 	Image           string        `json:"image,omitempty"`
 	ImagePullPolicy v1.PullPolicy `json:"imagePullPolicy,omitempty"`
 	MaxBatchSize    int32         `json:"maxBatchSize,omitempty"`
+	// MaxReplayWindow bounds how far back a restarted collector replays: it resumes after
+	// the newest event it delivered before the restart, but not from earlier than this
+	// long before it started. Defaults to 1h, the API server's default event TTL; 0s
+	// turns resuming off, so only events from after the start are delivered.
+	// +optional
+	MaxReplayWindow *metav1.Duration `json:"maxReplayWindow,omitempty"`
+	// Replicas of the event collector. Every replica contends for a Lease per receiver,
+	// and only the holder pushes that receiver's events, so more replicas mean a faster
+	// handover on a drain or crash, not more throughput. Defaults to 1.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Replicas *int32 `json:"replicas,omitempty"`
+	// PodDisruptionBudget for the event collector, created only with more than one
+	// replica, the same way as the aggregator's.
+	// +optional
+	PodDisruptionBudget PodDisruptionBudget `json:"podDisruptionBudget,omitempty"`
+	// QueueSize is the number of events buffered per receiver while the aggregator is
+	// slow or unreachable. Defaults to 10000.
+	// +optional
+	QueueSize int32 `json:"queueSize,omitempty"`
+	// OverflowPolicy is what a full queue does with another event: DropOldest (the
+	// default) makes room by dropping the oldest queued event, DropNewest drops the new
+	// one, and Block holds up the watch until there is room - nothing is dropped, but
+	// the events not yet handed over pile up in the collector's memory, unbounded.
+	// +kubebuilder:validation:Enum=DropOldest;DropNewest;Block
+	// +optional
+	OverflowPolicy string `json:"overflowPolicy,omitempty"`
+	// TLS has the collector push to the aggregator over TLS instead of plaintext.
+	// +optional
+	TLS *EventCollectorTLS `json:"tls,omitempty"`
+}
+
+// EventCollectorTLS secures the gRPC push from the event collector to the
+// kubernetes_events sources of the aggregator.
+type EventCollectorTLS struct {
+	// SecretName is a Secret in the namespace of the aggregator with ca.crt, tls.crt
+	// and tls.key, the keys cert-manager writes. It is mounted into the aggregator and
+	// the collector: the aggregator serves tls.crt, and the collector verifies it
+	// against ca.crt. The certificate must be valid for the name the collector dials,
+	// <service>.<namespace> of the pipeline's Service (a *.<namespace> wildcard covers
+	// them all), or for ServerName.
+	SecretName string `json:"secretName"`
+	// ServerName is the name the collector verifies the aggregator's certificate
+	// against, in place of <service>.<namespace>.
+	// +optional
+	ServerName string `json:"serverName,omitempty"`
+	// Mutual has the aggregator require a client certificate too: the collector
+	// presents tls.crt, and the aggregator verifies it against ca.crt. The certificate
+	// then needs both the server and the client auth usage.
+	// +optional
+	Mutual bool `json:"mutual,omitempty"`
 }
@@ -0,0 +1,63 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+)
+
+// VectorOutputSpec holds one sink definition that pipelines reference by name instead
+// of repeating it in their own spec.sinks.
+type VectorOutputSpec struct {
+	// Sink is the Vector sink, written as it would be under a pipeline's spec.sinks
+	// but without inputs: every pipeline that references the output feeds it its own.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Sink *runtime.RawExtension `json:"sink"`
+	// Secret declares the backends the sink's SECRET[alias.key] references resolve
+	// from, with the same rules as a pipeline's spec.secret. They join the spec.secret
+	// of every pipeline that references the output.
+	// +optional
+	// +kubebuilder:validation:XValidation:rule="self.all(k, k.matches('^[A-Za-z0-9_]+$'))",message="secret backend alias must match ^[A-Za-z0-9_]+$"
+	Secret map[string]PipelineSecretBackend `json:"secret,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:shortName=vo,categories=all
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+//+kubebuilder:printcolumn:name="Type",type="string",JSONPath=".spec.sink.type"
+
+// VectorOutput is a sink shared by the VectorPipelines of its namespace.
+type VectorOutput struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec VectorOutputSpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// VectorOutputList contains a list of VectorOutput
+type VectorOutputList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []VectorOutput `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&VectorOutput{}, &VectorOutputList{})
+}
@@ -43,6 +43,14 @@ func (vp *VectorPipeline) GetReason() *string {
 	return vp.Status.Reason
 }
 
+func (vp *VectorPipeline) SetConfigCheckReport(report *ConfigCheckReport) {
+	vp.Status.ConfigCheckReport = report
+}
+
+func (vp *VectorPipeline) GetConfigCheckReport() *ConfigCheckReport {
+	return vp.Status.ConfigCheckReport
+}
+
 func (vp *VectorPipeline) GetLastAppliedPipeline() *int64 {
 	return vp.Status.LastAppliedPipelineHash
 }
@@ -73,3 +81,39 @@ func (vp *VectorPipeline) SetRole(role *VectorPipelineRole) {
 func (vp *VectorPipeline) GetTypeMeta() metav1.TypeMeta {
 	return vp.TypeMeta
 }
+
+func (vp *VectorPipeline) GetConditions() []metav1.Condition {
+	return vp.Status.Conditions
+}
+
+func (vp *VectorPipeline) SetConditions(conditions []metav1.Condition) {
+	vp.Status.Conditions = conditions
+}
+
+func (vp *VectorPipeline) MarkSucceeded() {
+	vp.Status.MarkSucceeded(vp.Generation, len(vp.Spec.Secret) > 0)
+}
+
+func (vp *VectorPipeline) MarkFailed(conditionType, reason, message string) {
+	vp.Status.MarkFailed(vp.Generation, conditionType, reason, message)
+}
+
+func (vp *VectorPipeline) GetWorkloads() []WorkloadReference {
+	return vp.Status.Workloads
+}
+
+func (vp *VectorPipeline) SetWorkloads(workloads []WorkloadReference) {
+	vp.Status.Workloads = workloads
+}
+
+func (vp *VectorPipeline) GetExcludedFrom() []WorkloadReference {
+	return vp.Status.ExcludedFrom
+}
+
+func (vp *VectorPipeline) SetExcludedFrom(workloads []WorkloadReference) {
+	vp.Status.ExcludedFrom = workloads
+}
+
+func (vp *VectorPipeline) MarkSuspended() {
+	vp.Status.MarkSuspended(vp.Generation)
+}
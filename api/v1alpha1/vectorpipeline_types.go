@@ -38,6 +38,85 @@ type PipelineSecretBackend struct {
 	Namespace string `json:"namespace,omitempty"`
 }
 
+// AggregatorReference names the one aggregator a pipeline runs on.
+type AggregatorReference struct {
+	// Kind of the aggregator. A VectorPipeline can only name a VectorAggregator in
+	// its own namespace, a ClusterVectorPipeline only a ClusterVectorAggregator:
+	// those are the pipelines each kind ever builds from.
+	// +kubebuilder:validation:Enum=VectorAggregator;ClusterVectorAggregator
+	Kind string `json:"kind"`
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+}
+
+// WorkloadReference identifies a Vector, VectorAggregator or ClusterVectorAggregator.
+type WorkloadReference struct {
+	Kind string `json:"kind"`
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+}
+
+// TemplateReference names the VectorPipelineTemplate a pipeline is rendered from.
+type TemplateReference struct {
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+	// Values binds the template's parameters, as strings parsed by each parameter's
+	// type. Parameters left out take their default.
+	// +optional
+	Values map[string]string `json:"values,omitempty"`
+}
+
+// PipelineTest is a vector unit test of the pipeline's transforms, in vector's own
+// format (https://vector.dev/docs/reference/configuration/unit-tests/). Components are
+// named as in the pipeline's spec; the operator rewrites them to the names they get in
+// the generated config.
+type PipelineTest struct {
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+	// +kubebuilder:validation:MinItems=1
+	Inputs []PipelineTestInput `json:"inputs"`
+	// +optional
+	Outputs []PipelineTestOutput `json:"outputs,omitempty"`
+	// NoOutputsFrom lists the transforms that must not emit any event.
+	// +optional
+	NoOutputsFrom []string `json:"no_outputs_from,omitempty"`
+}
+
+// PipelineTestInput is an event inserted at a transform of the pipeline.
+type PipelineTestInput struct {
+	// +kubebuilder:validation:MinLength=1
+	InsertAt string `json:"insert_at"`
+	// Type of the event: raw (value), log (log_fields), metric (metric) or vrl
+	// (source). vector defaults to raw.
+	// +kubebuilder:validation:Enum=raw;log;metric;vrl
+	// +optional
+	Type string `json:"type,omitempty"`
+	// +optional
+	Value string `json:"value,omitempty"`
+	// +optional
+	Source string `json:"source,omitempty"`
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	LogFields *runtime.RawExtension `json:"log_fields,omitempty"`
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	Metric *runtime.RawExtension `json:"metric,omitempty"`
+}
+
+// PipelineTestOutput checks the events a transform of the pipeline emits.
+type PipelineTestOutput struct {
+	// ExtractFrom names the transforms whose output is checked. vector also takes a
+	// single name; here it is always a list.
+	// +kubebuilder:validation:MinItems=1
+	ExtractFrom []string `json:"extract_from"`
+	// Conditions are vector conditions, such as {type: vrl, source: ...}, every
+	// extracted event must meet.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	Conditions []runtime.RawExtension `json:"conditions,omitempty"`
+}
+
 // VectorPipelineSpec defines the desired state of VectorPipeline
 type VectorPipelineSpec struct {
 	// +kubebuilder:pruning:PreserveUnknownFields
@@ -49,6 +128,26 @@ type VectorPipelineSpec struct {
 	// +optional
 	// +kubebuilder:validation:XValidation:rule="self.all(k, k.matches('^[A-Za-z0-9_]+$'))",message="secret backend alias must match ^[A-Za-z0-9_]+$"
 	Secret map[string]PipelineSecretBackend `json:"secret,omitempty"`
+	// AggregatorRef pins an aggregator pipeline to exactly one aggregator. The named
+	// aggregator picks the pipeline up whatever its selector says, and no other
+	// aggregator does. Unset, every aggregator whose selector matches the pipeline's
+	// labels runs it.
+	// +optional
+	AggregatorRef *AggregatorReference `json:"aggregatorRef,omitempty"`
+	// TemplateRef renders sources, transforms and sinks from a VectorPipelineTemplate
+	// instead of spelling them out; the three must then be left unset.
+	// +optional
+	TemplateRef *TemplateReference `json:"templateRef,omitempty"`
+	// Suspend takes the pipeline out of every workload's config without deleting it.
+	// The pipeline keeps its status and is not validated while suspended; unsetting
+	// the flag puts it back through validation and into the config unchanged.
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+	// Tests are unit tests of the pipeline's transforms. The pipeline's configchecks
+	// run them with vector test, and a pipeline whose tests fail is invalid. They are
+	// not part of the configs the workloads run.
+	// +optional
+	Tests []PipelineTest `json:"tests,omitempty"`
 }
 
 // VectorPipelineStatus defines the observed state of VectorPipeline
@@ -69,6 +168,30 @@ type VectorPipelineStatus struct {
 	// even though the pipeline spec itself did not, letting the reconciler detect that
 	// drift. Absent (nil) for pipelines whose config references no secrets.
 	RelatedSecretsHash *int64 `json:"relatedSecretsHash,omitempty"`
+	// Workloads lists the workloads whose published config includes the pipeline.
+	// Each workload adds or removes itself after publishing, so the list trails a
+	// change by one workload reconcile.
+	// +optional
+	Workloads []WorkloadReference `json:"workloads,omitempty"`
+	// ExcludedFrom lists the workloads whose configcheck fails with the pipeline and
+	// passes without it, though the pipeline passes its own. Each of them leaves the
+	// pipeline out of its config, and the others keep running it, until the pipeline is
+	// validated again: when it is edited or a Secret it references changes.
+	// +optional
+	ExcludedFrom []WorkloadReference `json:"excludedFrom,omitempty"`
+	// ObservedGeneration is the .metadata.generation the status was last written for.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Conditions break the pipeline's state down by stage: ConfigValid,
+	// SecretsResolved, Published and Ready.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// ConfigCheckReport breaks the last failed configcheck of the pipeline down by
+	// component. Cleared once the pipeline checks again.
+	// +optional
+	ConfigCheckReport *ConfigCheckReport `json:"configCheckReport,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -77,6 +200,7 @@ type VectorPipelineStatus struct {
 //+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 //+kubebuilder:printcolumn:name="Valid",type="boolean",JSONPath=".status.configCheckResult"
 //+kubebuilder:printcolumn:name="Role",type="string",JSONPath=".status.role"
+//+kubebuilder:printcolumn:name="Suspended",type="boolean",JSONPath=".spec.suspend",priority=1
 
 // VectorPipeline is the Schema for the vectorpipelines API
 type VectorPipeline struct {
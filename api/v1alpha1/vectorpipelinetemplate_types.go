@@ -0,0 +1,83 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+)
+
+// TemplateParameter declares one value a VectorPipelineTemplate is rendered with.
+type TemplateParameter struct {
+	// Name is what the template refers to the parameter by, as $(name).
+	// +kubebuilder:validation:Pattern=`^[A-Za-z_][A-Za-z0-9_]*$`
+	Name string `json:"name"`
+	// Type the value must parse as. A placeholder that makes up a whole string in the
+	// template is replaced by a value of this type, so an integer parameter renders
+	// as a JSON number there; inside a longer string it is replaced as text.
+	// +kubebuilder:validation:Enum=string;integer;boolean
+	// +kubebuilder:default=string
+	// +optional
+	Type string `json:"type,omitempty"`
+	// Default is used when a pipeline sets no value. A parameter without one must be
+	// set by every pipeline using the template.
+	// +optional
+	Default *string `json:"default,omitempty"`
+	// +optional
+	Description string `json:"description,omitempty"`
+}
+
+// VectorPipelineTemplateSpec is the part of a pipeline spec shared by every pipeline
+// rendered from the template, with $(name) placeholders for what differs.
+type VectorPipelineTemplateSpec struct {
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Parameters []TemplateParameter `json:"parameters,omitempty"`
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Sources *runtime.RawExtension `json:"sources,omitempty"`
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Transforms *runtime.RawExtension `json:"transforms,omitempty"`
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Sinks *runtime.RawExtension `json:"sinks,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:scope=Cluster,shortName=vpt
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// VectorPipelineTemplate is a pipeline body shared by VectorPipelines and
+// ClusterVectorPipelines that reference it with spec.templateRef.
+type VectorPipelineTemplate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec VectorPipelineTemplateSpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// VectorPipelineTemplateList contains a list of VectorPipelineTemplate
+type VectorPipelineTemplateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []VectorPipelineTemplate `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&VectorPipelineTemplate{}, &VectorPipelineTemplateList{})
+}
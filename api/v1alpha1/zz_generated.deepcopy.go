@@ -23,12 +23,28 @@ package v1alpha1
 import (
 	appsv1 "k8s.io/api/apps/v1"
 	"k8s.io/api/autoscaling/v2"
-	"k8s.io/api/core/v1"
+	corev1 "k8s.io/api/core/v1"
 	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AggregatorReference) DeepCopyInto(out *AggregatorReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AggregatorReference.
+func (in *AggregatorReference) DeepCopy() *AggregatorReference {
+	if in == nil {
+		return nil
+	}
+	out := new(AggregatorReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ApiSpec) DeepCopyInto(out *ApiSpec) {
 	*out = *in
@@ -135,6 +151,64 @@ func (in *ClusterVectorAggregatorStatus) DeepCopy() *ClusterVectorAggregatorStat
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterVectorOutput) DeepCopyInto(out *ClusterVectorOutput) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterVectorOutput.
+func (in *ClusterVectorOutput) DeepCopy() *ClusterVectorOutput {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterVectorOutput)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterVectorOutput) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterVectorOutputList) DeepCopyInto(out *ClusterVectorOutputList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ClusterVectorOutput, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterVectorOutputList.
+func (in *ClusterVectorOutputList) DeepCopy() *ClusterVectorOutputList {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterVectorOutputList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterVectorOutputList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterVectorPipeline) DeepCopyInto(out *ClusterVectorPipeline) {
 	*out = *in
@@ -194,6 +268,104 @@ func (in *ClusterVectorPipelineList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterVectorPipelinePolicy) DeepCopyInto(out *ClusterVectorPipelinePolicy) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterVectorPipelinePolicy.
+func (in *ClusterVectorPipelinePolicy) DeepCopy() *ClusterVectorPipelinePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterVectorPipelinePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterVectorPipelinePolicy) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterVectorPipelinePolicyList) DeepCopyInto(out *ClusterVectorPipelinePolicyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ClusterVectorPipelinePolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterVectorPipelinePolicyList.
+func (in *ClusterVectorPipelinePolicyList) DeepCopy() *ClusterVectorPipelinePolicyList {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterVectorPipelinePolicyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterVectorPipelinePolicyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterVectorPipelinePolicySpec) DeepCopyInto(out *ClusterVectorPipelinePolicySpec) {
+	*out = *in
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AllowedSinkTypes != nil {
+		in, out := &in.AllowedSinkTypes, &out.AllowedSinkTypes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowedTransformTypes != nil {
+		in, out := &in.AllowedTransformTypes, &out.AllowedTransformTypes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowedEndpointHosts != nil {
+		in, out := &in.AllowedEndpointHosts, &out.AllowedEndpointHosts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Quota != nil {
+		in, out := &in.Quota, &out.Quota
+		*out = new(PipelineQuota)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterVectorPipelinePolicySpec.
+func (in *ClusterVectorPipelinePolicySpec) DeepCopy() *ClusterVectorPipelinePolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterVectorPipelinePolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ConfigCheck) DeepCopyInto(out *ConfigCheck) {
 	*out = *in
@@ -204,20 +376,20 @@ func (in *ConfigCheck) DeepCopyInto(out *ConfigCheck) {
 	}
 	if in.Resources != nil {
 		in, out := &in.Resources, &out.Resources
-		*out = new(v1.ResourceRequirements)
+		*out = new(corev1.ResourceRequirements)
 		(*in).DeepCopyInto(*out)
 	}
 	if in.Affinity != nil {
 		in, out := &in.Affinity, &out.Affinity
-		*out = new(v1.Affinity)
+		*out = new(corev1.Affinity)
 		(*in).DeepCopyInto(*out)
 	}
 	if in.Tolerations != nil {
 		in, out := &in.Tolerations, &out.Tolerations
-		*out = new([]v1.Toleration)
+		*out = new([]corev1.Toleration)
 		if **in != nil {
 			in, out := *in, *out
-			*out = make([]v1.Toleration, len(*in))
+			*out = make([]corev1.Toleration, len(*in))
 			for i := range *in {
 				(*in)[i].DeepCopyInto(&(*out)[i])
 			}
@@ -249,9 +421,80 @@ func (in *ConfigCheck) DeepCopy() *ConfigCheck {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConfigCheckError) DeepCopyInto(out *ConfigCheckError) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigCheckError.
+func (in *ConfigCheckError) DeepCopy() *ConfigCheckError {
+	if in == nil {
+		return nil
+	}
+	out := new(ConfigCheckError)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConfigCheckOutputReference) DeepCopyInto(out *ConfigCheckOutputReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigCheckOutputReference.
+func (in *ConfigCheckOutputReference) DeepCopy() *ConfigCheckOutputReference {
+	if in == nil {
+		return nil
+	}
+	out := new(ConfigCheckOutputReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConfigCheckReport) DeepCopyInto(out *ConfigCheckReport) {
+	*out = *in
+	if in.Errors != nil {
+		in, out := &in.Errors, &out.Errors
+		*out = make([]ConfigCheckError, len(*in))
+		copy(*out, *in)
+	}
+	if in.Output != nil {
+		in, out := &in.Output, &out.Output
+		*out = new(ConfigCheckOutputReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigCheckReport.
+func (in *ConfigCheckReport) DeepCopy() *ConfigCheckReport {
+	if in == nil {
+		return nil
+	}
+	out := new(ConfigCheckReport)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EventCollector) DeepCopyInto(out *EventCollector) {
 	*out = *in
+	if in.MaxReplayWindow != nil {
+		in, out := &in.MaxReplayWindow, &out.MaxReplayWindow
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.Replicas != nil {
+		in, out := &in.Replicas, &out.Replicas
+		*out = new(int32)
+		**out = **in
+	}
+	in.PodDisruptionBudget.DeepCopyInto(&out.PodDisruptionBudget)
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(EventCollectorTLS)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EventCollector.
@@ -264,6 +507,61 @@ func (in *EventCollector) DeepCopy() *EventCollector {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EventCollectorTLS) DeepCopyInto(out *EventCollectorTLS) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EventCollectorTLS.
+func (in *EventCollectorTLS) DeepCopy() *EventCollectorTLS {
+	if in == nil {
+		return nil
+	}
+	out := new(EventCollectorTLS)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PipelineQuota) DeepCopyInto(out *PipelineQuota) {
+	*out = *in
+	if in.MaxPipelines != nil {
+		in, out := &in.MaxPipelines, &out.MaxPipelines
+		*out = new(int64)
+		**out = **in
+	}
+	if in.MaxSources != nil {
+		in, out := &in.MaxSources, &out.MaxSources
+		*out = new(int64)
+		**out = **in
+	}
+	if in.MaxTransforms != nil {
+		in, out := &in.MaxTransforms, &out.MaxTransforms
+		*out = new(int64)
+		**out = **in
+	}
+	if in.MaxSinks != nil {
+		in, out := &in.MaxSinks, &out.MaxSinks
+		*out = new(int64)
+		**out = **in
+	}
+	if in.MaxConfigBytes != nil {
+		in, out := &in.MaxConfigBytes, &out.MaxConfigBytes
+		*out = new(int64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PipelineQuota.
+func (in *PipelineQuota) DeepCopy() *PipelineQuota {
+	if in == nil {
+		return nil
+	}
+	out := new(PipelineQuota)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PipelineSecretBackend) DeepCopyInto(out *PipelineSecretBackend) {
 	*out = *in
@@ -279,6 +577,92 @@ func (in *PipelineSecretBackend) DeepCopy() *PipelineSecretBackend {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PipelineTest) DeepCopyInto(out *PipelineTest) {
+	*out = *in
+	if in.Inputs != nil {
+		in, out := &in.Inputs, &out.Inputs
+		*out = make([]PipelineTestInput, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Outputs != nil {
+		in, out := &in.Outputs, &out.Outputs
+		*out = make([]PipelineTestOutput, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.NoOutputsFrom != nil {
+		in, out := &in.NoOutputsFrom, &out.NoOutputsFrom
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PipelineTest.
+func (in *PipelineTest) DeepCopy() *PipelineTest {
+	if in == nil {
+		return nil
+	}
+	out := new(PipelineTest)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PipelineTestInput) DeepCopyInto(out *PipelineTestInput) {
+	*out = *in
+	if in.LogFields != nil {
+		in, out := &in.LogFields, &out.LogFields
+		*out = new(runtime.RawExtension)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Metric != nil {
+		in, out := &in.Metric, &out.Metric
+		*out = new(runtime.RawExtension)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PipelineTestInput.
+func (in *PipelineTestInput) DeepCopy() *PipelineTestInput {
+	if in == nil {
+		return nil
+	}
+	out := new(PipelineTestInput)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PipelineTestOutput) DeepCopyInto(out *PipelineTestOutput) {
+	*out = *in
+	if in.ExtractFrom != nil {
+		in, out := &in.ExtractFrom, &out.ExtractFrom
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]runtime.RawExtension, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PipelineTestOutput.
+func (in *PipelineTestOutput) DeepCopy() *PipelineTestOutput {
+	if in == nil {
+		return nil
+	}
+	out := new(PipelineTestOutput)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PodDisruptionBudget) DeepCopyInto(out *PodDisruptionBudget) {
 	*out = *in
@@ -309,6 +693,48 @@ func (in *PodDisruptionBudget) DeepCopy() *PodDisruptionBudget {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TemplateParameter) DeepCopyInto(out *TemplateParameter) {
+	*out = *in
+	if in.Default != nil {
+		in, out := &in.Default, &out.Default
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TemplateParameter.
+func (in *TemplateParameter) DeepCopy() *TemplateParameter {
+	if in == nil {
+		return nil
+	}
+	out := new(TemplateParameter)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TemplateReference) DeepCopyInto(out *TemplateReference) {
+	*out = *in
+	if in.Values != nil {
+		in, out := &in.Values, &out.Values
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TemplateReference.
+func (in *TemplateReference) DeepCopy() *TemplateReference {
+	if in == nil {
+		return nil
+	}
+	out := new(TemplateReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Vector) DeepCopyInto(out *Vector) {
 	*out = *in
@@ -425,11 +851,11 @@ func (in *VectorAggregatorCommon) DeepCopyInto(out *VectorAggregatorCommon) {
 		*out = new(VectorSelectorSpec)
 		(*in).DeepCopyInto(*out)
 	}
-	out.EventCollector = in.EventCollector
+	in.EventCollector.DeepCopyInto(&out.EventCollector)
 	in.Autoscaling.DeepCopyInto(&out.Autoscaling)
 	if in.TopologySpreadConstraints != nil {
 		in, out := &in.TopologySpreadConstraints, &out.TopologySpreadConstraints
-		*out = make([]v1.TopologySpreadConstraint, len(*in))
+		*out = make([]corev1.TopologySpreadConstraint, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -491,12 +917,12 @@ func (in *VectorAggregatorPersistence) DeepCopyInto(out *VectorAggregatorPersist
 	}
 	if in.AccessModes != nil {
 		in, out := &in.AccessModes, &out.AccessModes
-		*out = make([]v1.PersistentVolumeAccessMode, len(*in))
+		*out = make([]corev1.PersistentVolumeAccessMode, len(*in))
 		copy(*out, *in)
 	}
 	if in.VolumeClaimTemplates != nil {
 		in, out := &in.VolumeClaimTemplates, &out.VolumeClaimTemplates
-		*out = make([]v1.PersistentVolumeClaim, len(*in))
+		*out = make([]corev1.PersistentVolumeClaim, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -555,7 +981,7 @@ func (in *VectorCommon) DeepCopyInto(out *VectorCommon) {
 	*out = *in
 	if in.ImagePullSecrets != nil {
 		in, out := &in.ImagePullSecrets, &out.ImagePullSecrets
-		*out = make([]v1.LocalObjectReference, len(*in))
+		*out = make([]corev1.LocalObjectReference, len(*in))
 		copy(*out, *in)
 	}
 	if in.Annotations != nil {
@@ -575,24 +1001,24 @@ func (in *VectorCommon) DeepCopyInto(out *VectorCommon) {
 	in.Resources.DeepCopyInto(&out.Resources)
 	if in.Affinity != nil {
 		in, out := &in.Affinity, &out.Affinity
-		*out = new(v1.Affinity)
+		*out = new(corev1.Affinity)
 		(*in).DeepCopyInto(*out)
 	}
 	if in.Tolerations != nil {
 		in, out := &in.Tolerations, &out.Tolerations
-		*out = make([]v1.Toleration, len(*in))
+		*out = make([]corev1.Toleration, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 	if in.SecurityContext != nil {
 		in, out := &in.SecurityContext, &out.SecurityContext
-		*out = new(v1.PodSecurityContext)
+		*out = new(corev1.PodSecurityContext)
 		(*in).DeepCopyInto(*out)
 	}
 	if in.ContainerSecurityContext != nil {
 		in, out := &in.ContainerSecurityContext, &out.ContainerSecurityContext
-		*out = new(v1.SecurityContext)
+		*out = new(corev1.SecurityContext)
 		(*in).DeepCopyInto(*out)
 	}
 	if in.RuntimeClassName != nil {
@@ -602,21 +1028,21 @@ func (in *VectorCommon) DeepCopyInto(out *VectorCommon) {
 	}
 	if in.HostAliases != nil {
 		in, out := &in.HostAliases, &out.HostAliases
-		*out = make([]v1.HostAlias, len(*in))
+		*out = make([]corev1.HostAlias, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 	if in.Env != nil {
 		in, out := &in.Env, &out.Env
-		*out = make([]v1.EnvVar, len(*in))
+		*out = make([]corev1.EnvVar, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 	if in.EnvFrom != nil {
 		in, out := &in.EnvFrom, &out.EnvFrom
-		*out = make([]v1.EnvFromSource, len(*in))
+		*out = make([]corev1.EnvFromSource, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -629,24 +1055,24 @@ func (in *VectorCommon) DeepCopyInto(out *VectorCommon) {
 	out.Api = in.Api
 	if in.Volumes != nil {
 		in, out := &in.Volumes, &out.Volumes
-		*out = make([]v1.Volume, len(*in))
+		*out = make([]corev1.Volume, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 	if in.ReadinessProbe != nil {
 		in, out := &in.ReadinessProbe, &out.ReadinessProbe
-		*out = new(v1.Probe)
+		*out = new(corev1.Probe)
 		(*in).DeepCopyInto(*out)
 	}
 	if in.LivenessProbe != nil {
 		in, out := &in.LivenessProbe, &out.LivenessProbe
-		*out = new(v1.Probe)
+		*out = new(corev1.Probe)
 		(*in).DeepCopyInto(*out)
 	}
 	if in.VolumeMounts != nil {
 		in, out := &in.VolumeMounts, &out.VolumeMounts
-		*out = make([]v1.VolumeMount, len(*in))
+		*out = make([]corev1.VolumeMount, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -692,6 +1118,18 @@ func (in *VectorCommonStatus) DeepCopyInto(out *VectorCommonStatus) {
 		in, out := &in.LastConfigPublishedAt, &out.LastConfigPublishedAt
 		*out = (*in).DeepCopy()
 	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ConfigCheckReport != nil {
+		in, out := &in.ConfigCheckReport, &out.ConfigCheckReport
+		*out = new(ConfigCheckReport)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VectorCommonStatus.
@@ -736,6 +1174,91 @@ func (in *VectorList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VectorOutput) DeepCopyInto(out *VectorOutput) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VectorOutput.
+func (in *VectorOutput) DeepCopy() *VectorOutput {
+	if in == nil {
+		return nil
+	}
+	out := new(VectorOutput)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *VectorOutput) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VectorOutputList) DeepCopyInto(out *VectorOutputList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]VectorOutput, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VectorOutputList.
+func (in *VectorOutputList) DeepCopy() *VectorOutputList {
+	if in == nil {
+		return nil
+	}
+	out := new(VectorOutputList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *VectorOutputList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VectorOutputSpec) DeepCopyInto(out *VectorOutputSpec) {
+	*out = *in
+	if in.Sink != nil {
+		in, out := &in.Sink, &out.Sink
+		*out = new(runtime.RawExtension)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Secret != nil {
+		in, out := &in.Secret, &out.Secret
+		*out = make(map[string]PipelineSecretBackend, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VectorOutputSpec.
+func (in *VectorOutputSpec) DeepCopy() *VectorOutputSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(VectorOutputSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VectorPipeline) DeepCopyInto(out *VectorPipeline) {
 	*out = *in
@@ -820,6 +1343,23 @@ func (in *VectorPipelineSpec) DeepCopyInto(out *VectorPipelineSpec) {
 			(*out)[key] = val
 		}
 	}
+	if in.AggregatorRef != nil {
+		in, out := &in.AggregatorRef, &out.AggregatorRef
+		*out = new(AggregatorReference)
+		**out = **in
+	}
+	if in.TemplateRef != nil {
+		in, out := &in.TemplateRef, &out.TemplateRef
+		*out = new(TemplateReference)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Tests != nil {
+		in, out := &in.Tests, &out.Tests
+		*out = make([]PipelineTest, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VectorPipelineSpec.
@@ -860,6 +1400,28 @@ func (in *VectorPipelineStatus) DeepCopyInto(out *VectorPipelineStatus) {
 		*out = new(int64)
 		**out = **in
 	}
+	if in.Workloads != nil {
+		in, out := &in.Workloads, &out.Workloads
+		*out = make([]WorkloadReference, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExcludedFrom != nil {
+		in, out := &in.ExcludedFrom, &out.ExcludedFrom
+		*out = make([]WorkloadReference, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ConfigCheckReport != nil {
+		in, out := &in.ConfigCheckReport, &out.ConfigCheckReport
+		*out = new(ConfigCheckReport)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VectorPipelineStatus.
@@ -872,6 +1434,101 @@ func (in *VectorPipelineStatus) DeepCopy() *VectorPipelineStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VectorPipelineTemplate) DeepCopyInto(out *VectorPipelineTemplate) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VectorPipelineTemplate.
+func (in *VectorPipelineTemplate) DeepCopy() *VectorPipelineTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(VectorPipelineTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *VectorPipelineTemplate) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VectorPipelineTemplateList) DeepCopyInto(out *VectorPipelineTemplateList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]VectorPipelineTemplate, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VectorPipelineTemplateList.
+func (in *VectorPipelineTemplateList) DeepCopy() *VectorPipelineTemplateList {
+	if in == nil {
+		return nil
+	}
+	out := new(VectorPipelineTemplateList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *VectorPipelineTemplateList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VectorPipelineTemplateSpec) DeepCopyInto(out *VectorPipelineTemplateSpec) {
+	*out = *in
+	if in.Parameters != nil {
+		in, out := &in.Parameters, &out.Parameters
+		*out = make([]TemplateParameter, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Sources != nil {
+		in, out := &in.Sources, &out.Sources
+		*out = new(runtime.RawExtension)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Transforms != nil {
+		in, out := &in.Transforms, &out.Transforms
+		*out = new(runtime.RawExtension)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Sinks != nil {
+		in, out := &in.Sinks, &out.Sinks
+		*out = new(runtime.RawExtension)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VectorPipelineTemplateSpec.
+func (in *VectorPipelineTemplateSpec) DeepCopy() *VectorPipelineTemplateSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(VectorPipelineTemplateSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VectorSelectorSpec) DeepCopyInto(out *VectorSelectorSpec) {
 	*out = *in
@@ -882,6 +1539,18 @@ func (in *VectorSelectorSpec) DeepCopyInto(out *VectorSelectorSpec) {
 			(*out)[key] = val
 		}
 	}
+	if in.MatchExpressions != nil {
+		in, out := &in.MatchExpressions, &out.MatchExpressions
+		*out = make([]v1.LabelSelectorRequirement, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VectorSelectorSpec.
@@ -934,3 +1603,18 @@ func (in *VectorStatus) DeepCopy() *VectorStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkloadReference) DeepCopyInto(out *WorkloadReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkloadReference.
+func (in *WorkloadReference) DeepCopy() *WorkloadReference {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkloadReference)
+	in.DeepCopyInto(out)
+	return out
+}
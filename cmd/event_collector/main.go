@@ -10,6 +10,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"reflect"
 	"strings"
 	"syscall"
 
@@ -21,6 +22,7 @@ import (
 
 	"github.com/kaasops/vector-operator/internal/buildinfo"
 	"github.com/kaasops/vector-operator/internal/evcollector"
+	"github.com/kaasops/vector-operator/internal/evconfig"
 )
 
 func main() {
@@ -58,23 +60,60 @@ func main() {
 		os.Exit(1)
 	}
 
-	var cfg evcollector.Config
-	store := make(map[string]*evcollector.Collector)
+	// identity in the receivers' Leases; a pod's hostname is its name
+	identity, err := os.Hostname()
+	if err != nil {
+		log.Error("unable to get hostname", "error", err)
+		os.Exit(1)
+	}
+
+	type receiver struct {
+		addr      string
+		namespace string
+		filter    evconfig.EventFilter
+		batchSize int32
+		queue     evconfig.QueueParams
+		tls       evconfig.TLSParams
+		runner    evcollector.Runner
+	}
 
-	setupCollector := func(svcName, svcNamespace, port, watchedNamespace string) {
+	var cfg evconfig.Config
+	store := make(map[string]*receiver)
+
+	setupCollector := func(svcName, svcNamespace, port, watchedNamespace string, filter evconfig.EventFilter) {
 		host := fmt.Sprintf("%s.%s", svcName, svcNamespace)
 		addr := net.JoinHostPort(host, port)
 
-		if oldC, ok := store[host]; ok {
-			if oldC.Addr == addr && oldC.Namespace == watchedNamespace {
+		if old, ok := store[host]; ok {
+			if old.addr == addr && old.namespace == watchedNamespace && reflect.DeepEqual(old.filter, filter) &&
+				old.batchSize == cfg.MaxBatchSize && old.queue == cfg.Queue && old.tls == cfg.TLS {
 				return
 			}
-			oldC.Stop()
+			old.runner.Stop()
 		}
 
-		c := evcollector.New(addr, watchedNamespace, cfg.MaxBatchSize, log, clientset.CoreV1().RESTClient())
-		store[host] = c
-		c.Start()
+		var resume *evcollector.Resume
+		if cfg.CheckpointConfigMap != "" {
+			resume = &evcollector.Resume{
+				Receiver: host,
+				Checkpoints: &evcollector.ConfigMapCheckpoints{
+					ConfigMaps: clientset.CoreV1().ConfigMaps(cfg.CheckpointNamespace),
+					Name:       cfg.CheckpointConfigMap,
+				},
+				MaxReplayWindow: cfg.MaxReplayWindow,
+			}
+		}
+		newCollector := func() evcollector.Runner {
+			return evcollector.New(addr, watchedNamespace, filter, cfg.MaxBatchSize, cfg.Queue, cfg.TLS, log, clientset.CoreV1().RESTClient(), resume)
+		}
+		var runner evcollector.Runner
+		if cfg.LeaseNamespace != "" {
+			runner = evcollector.NewElected(clientset.CoordinationV1(), cfg.LeaseNamespace, cfg.LeasePrefix+"-"+host, identity, newCollector, log)
+		} else {
+			runner = newCollector()
+		}
+		store[host] = &receiver{addr: addr, namespace: watchedNamespace, filter: filter, batchSize: cfg.MaxBatchSize, queue: cfg.Queue, tls: cfg.TLS, runner: runner}
+		runner.Start()
 	}
 
 	applyConfig := func() {
@@ -89,12 +128,12 @@ func main() {
 		}
 
 		for _, r := range cfg.Receivers {
-			setupCollector(r.ServiceName, r.ServiceNamespace, r.Port, r.WatchedNamespace)
+			setupCollector(r.ServiceName, r.ServiceNamespace, r.Port, r.WatchedNamespace, r.Filter)
 			delete(notModified, fmt.Sprintf("%s.%s", r.ServiceName, r.ServiceNamespace))
 		}
 
 		for k := range notModified {
-			store[k].Stop()
+			store[k].runner.Stop()
 			delete(store, k)
 		}
 	}
@@ -125,7 +164,7 @@ func main() {
 	log.Info("shutting down")
 
 	for k := range store {
-		store[k].Stop()
+		store[k].runner.Stop()
 		delete(store, k)
 	}
 }
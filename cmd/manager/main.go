@@ -22,6 +22,7 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/kaasops/vector-operator/internal/buildinfo"
@@ -31,6 +32,7 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/kubernetes"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
@@ -52,12 +54,14 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/filters"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	"github.com/kaasops/vector-operator/api/v1alpha1"
 	"github.com/kaasops/vector-operator/internal/controller"
+	webhookv1alpha1 "github.com/kaasops/vector-operator/internal/webhook/v1alpha1"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -84,11 +88,19 @@ func main() {
 	var watchNamespace string
 	var watchLabel string
 	var configCheckTimeout time.Duration
+	var configCheckMode string
+	var configCheckVectorBinary string
+	var configCheckCacheSize int
+	var configCheckCacheSecret string
+	var configCheckAttributionMaxChecks int
+	var configCheckMaxConcurrent int
 	var enableReconciliationInvalidPipelines bool
 	var reconciliationRetryDelay time.Duration
 	var enableConfigOptimization bool
+	var enableMetadataEnrichment bool
 	var enableCheckpointMigration bool
 	var checkpointMergerImage string
+	var enableWebhooks bool
 
 	flag.StringVar(&metricsAddr, "metrics-bind-address", "0", "The address the metrics endpoint binds to. "+
 		"Use :8443 for HTTPS or :8080 for HTTP, or leave as 0 to disable the metrics service.")
@@ -103,12 +115,20 @@ func main() {
 	flag.StringVar(&watchNamespace, "watch-namespace", "", "Namespace to filter the list of watched objects")
 	flag.StringVar(&watchLabel, "watch-name", "", "Filter the list of watched objects by the value of their app.kubernetes.io/name label; they must also carry app.kubernetes.io/managed-by=vector-operator")
 	flag.DurationVar(&configCheckTimeout, "configcheck-timeout", 300*time.Second, "configcheck timeout")
+	flag.StringVar(&configCheckMode, "configcheck-mode", string(configcheck.ModePod), "How generated configs are validated: \"pod\" runs a configcheck pod with the workload's image, env and volumes; \"local\" runs vector validate --no-environment in the operator process, without creating anything on the cluster")
+	flag.StringVar(&configCheckVectorBinary, "configcheck-vector-binary", configcheck.DefaultVectorBinary, "The vector binary run by -configcheck-mode=local")
+	flag.IntVar(&configCheckCacheSize, "configcheck-cache-size", configcheck.DefaultCacheSize, "How many configcheck verdicts to keep, by config content, image and environment, so identical configs are validated once; 0 disables the cache")
+	flag.StringVar(&configCheckCacheSecret, "configcheck-cache-secret", "", "Persist configcheck verdicts across operator restarts in this Secret, as <namespace>/<name>")
+	flag.IntVar(&configCheckMaxConcurrent, "configcheck-max-concurrent", configcheck.DefaultMaxConcurrentChecks, "How many configchecks may run at once across the operator; the others wait in line, and a waiting check of a workload is replaced by a newer one of the same workload. 0 runs them all at once")
+	flag.IntVar(&configCheckAttributionMaxChecks, "configcheck-attribution-max-checks", controller.DefaultConfigCheckAttributionMaxChecks, "When a workload's configcheck fails, run up to this many more on subsets of its pipelines to find the ones it fails with, mark them invalid and publish the config without them; 0 fails the whole workload instead")
 	flag.BoolVar(&enableReconciliationInvalidPipelines, "enable-reconciliation-invalid-pipelines", false,
 		"Enable the reconciliation process for pipelines with invalid configurations")
 	flag.DurationVar(&reconciliationRetryDelay, "reconciliation-retry-delay", 30*time.Second, "Specify the delay before retrying the reconciliation process for pipelines")
 	flag.BoolVar(&enableConfigOptimization, "enable-config-optimization", false, "Collapse kubernetes_logs sources with identical settings into one source per group in generated agent configs. A Vector CR (whole agent) or an individual (Cluster)VectorPipeline (just its source) can opt out with the vector-operator.kaasops.io/config-optimization=disabled annotation")
+	flag.BoolVar(&enableMetadataEnrichment, "enable-metadata-enrichment", false, "Add a remap transform after every pipeline source stamping the pipeline's kind, name, namespace and labels into .vector_operator of its events. A (Cluster)VectorPipeline can opt out with the vector-operator.kaasops.io/metadata-enrichment=disabled annotation, or opt in with =enabled while the flag is off")
 	flag.BoolVar(&enableCheckpointMigration, "enable-checkpoint-migration", false, "Migrate vector file checkpoints when the config optimization renames kubernetes_logs sources: the agent config secret name is bound to the optimization mode (switching it rolls the DaemonSet) and a checkpoint-merger init container consolidates checkpoints before vector starts")
 	flag.StringVar(&checkpointMergerImage, "checkpoint-merger-image", "", "Override the checkpoint-merger init container image (default kaasops/checkpoint-merger:<operator version>)")
+	flag.BoolVar(&enableWebhooks, "enable-webhooks", false, "Serve the validating admission webhooks for VectorPipeline and ClusterVectorPipeline, rejecting specs the pipeline controller would mark invalid. Requires a serving certificate and a ValidatingWebhookConfiguration (see config/webhook)")
 
 	opts := zap.Options{
 		Development: true,
@@ -119,6 +139,33 @@ func main() {
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 	setupLog.Info("build info", "version", buildinfo.Version)
 
+	mode, err := configcheck.ParseMode(configCheckMode)
+	if err != nil {
+		setupLog.Error(err, "invalid -configcheck-mode")
+		os.Exit(1)
+	}
+	configCheckSettings := configcheck.Settings{Mode: mode, VectorBinary: configCheckVectorBinary}
+	if mode == configcheck.ModeLocal {
+		configCheckSettings.VectorVersion, err = configcheck.ProbeVectorVersion(context.Background(), configCheckVectorBinary)
+		if err != nil {
+			setupLog.Error(err, "unable to run the vector binary of -configcheck-mode=local")
+			os.Exit(1)
+		}
+		setupLog.Info("local configcheck", "vectorVersion", configCheckSettings.VectorVersion)
+	}
+	if configCheckMaxConcurrent > 0 {
+		configCheckSettings.Queue = configcheck.NewQueue(configCheckMaxConcurrent, configCheckTimeout)
+	}
+	var configCheckCacheRef types.NamespacedName
+	if configCheckCacheSecret != "" {
+		ns, name, ok := strings.Cut(configCheckCacheSecret, "/")
+		if !ok || ns == "" || name == "" {
+			setupLog.Error(fmt.Errorf("%q is not <namespace>/<name>", configCheckCacheSecret), "invalid -configcheck-cache-secret")
+			os.Exit(1)
+		}
+		configCheckCacheRef = types.NamespacedName{Namespace: ns, Name: name}
+	}
+
 	// if the enable-http2 flag is false (the default), http/2 should be disabled
 	// due to its vulnerabilities. More specifically, disabling http/2 will
 	// prevent from being vulnerable to the HTTP/2 Stream Cancellation and
@@ -207,6 +254,21 @@ func main() {
 		os.Exit(1)
 	}
 
+	if err := pipeline.IndexWorkloads(context.Background(), mgr.GetFieldIndexer()); err != nil {
+		setupLog.Error(err, "unable to index the pipelines by workload")
+		os.Exit(1)
+	}
+
+	// counted from the manager's cache at scrape time, see PipelineCollector
+	metrics.Registry.MustRegister(&controller.PipelineCollector{Reader: mgr.GetClient()})
+
+	if configCheckCacheSize > 0 {
+		configCheckSettings.Cache = configcheck.NewCache(configCheckCacheSize)
+		if configCheckCacheRef.Name != "" {
+			configCheckSettings.Cache.WithSecret(mgr.GetAPIReader(), mgr.GetClient(), configCheckCacheRef)
+		}
+	}
+
 	// Sweep configcheck Secrets orphaned by a dead operator process (crash between
 	// creating them and the process-local deferred cleanup): nothing owns the root
 	// configcheck Secret, and with pipeline secrets the orphan can include a plaintext
@@ -221,20 +283,28 @@ func main() {
 		os.Exit(1)
 	}
 
+	// one limiter for every reconciler: a pipeline gets events from the pipeline
+	// controller and from each workload that selects it, and they share its budget
+	eventRecorder := k8s.NewRateLimitedRecorder(mgr.GetEventRecorder("vector-operator"), k8s.DefaultEventBurst, k8s.DefaultEventInterval)
+
 	vectorAgentEventCh := make(chan event.GenericEvent, 400)
 	defer close(vectorAgentEventCh)
 
 	if err = (&controller.VectorReconciler{
-		Client:                    mgr.GetClient(),
-		Scheme:                    mgr.GetScheme(),
-		Clientset:                 clientset,
-		ConfigCheckTimeout:        configCheckTimeout,
-		EnableConfigOptimization:  enableConfigOptimization,
-		EnableCheckpointMigration: enableCheckpointMigration,
-		CheckpointMergerImage:     checkpointMergerImage,
-		DiscoveryClient:           dc,
-		EventChan:                 vectorAgentEventCh,
-		APIReader:                 mgr.GetAPIReader(),
+		Client:                          mgr.GetClient(),
+		Scheme:                          mgr.GetScheme(),
+		Clientset:                       clientset,
+		ConfigCheckTimeout:              configCheckTimeout,
+		ConfigCheckSettings:             configCheckSettings,
+		EnableConfigOptimization:        enableConfigOptimization,
+		EnableMetadataEnrichment:        enableMetadataEnrichment,
+		EnableCheckpointMigration:       enableCheckpointMigration,
+		CheckpointMergerImage:           checkpointMergerImage,
+		DiscoveryClient:                 dc,
+		EventChan:                       vectorAgentEventCh,
+		ConfigCheckAttributionMaxChecks: configCheckAttributionMaxChecks,
+		APIReader:                       mgr.GetAPIReader(),
+		Recorder:                        eventRecorder,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Vector")
 		os.Exit(1)
@@ -252,7 +322,9 @@ func main() {
 		Scheme:                                   mgr.GetScheme(),
 		Clientset:                                clientset,
 		ConfigCheckTimeout:                       configCheckTimeout,
+		ConfigCheckSettings:                      configCheckSettings,
 		EnableConfigOptimization:                 enableConfigOptimization,
+		EnableMetadataEnrichment:                 enableMetadataEnrichment,
 		VectorAgentEventCh:                       vectorAgentsPipelineEventCh,
 		VectorAggregatorsEventCh:                 vectorAggregatorsPipelineEventCh,
 		ClusterVectorAggregatorsEventCh:          clusterVectorAggregatorsPipelineEventCh,
@@ -266,6 +338,7 @@ func main() {
 		// actually reference Secrets get a periodic re-check instead. See
 		// PollSecretRotation and secretRotationPollInterval.
 		PollSecretRotation: watchNamespace != "" || watchLabel != "",
+		Recorder:           eventRecorder,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "VectorPipeline")
 		os.Exit(1)
@@ -275,12 +348,16 @@ func main() {
 	defer close(vectorAggregatorsEventCh)
 
 	if err = (&controller.VectorAggregatorReconciler{
-		Client:             mgr.GetClient(),
-		Clientset:          clientset,
-		Scheme:             mgr.GetScheme(),
-		ConfigCheckTimeout: configCheckTimeout,
-		EventChan:          vectorAggregatorsEventCh,
-		APIReader:          mgr.GetAPIReader(),
+		Client:                          mgr.GetClient(),
+		Clientset:                       clientset,
+		Scheme:                          mgr.GetScheme(),
+		ConfigCheckTimeout:              configCheckTimeout,
+		ConfigCheckSettings:             configCheckSettings,
+		EventChan:                       vectorAggregatorsEventCh,
+		EnableMetadataEnrichment:        enableMetadataEnrichment,
+		ConfigCheckAttributionMaxChecks: configCheckAttributionMaxChecks,
+		APIReader:                       mgr.GetAPIReader(),
+		Recorder:                        eventRecorder,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "VectorAggregator")
 		os.Exit(1)
@@ -290,16 +367,31 @@ func main() {
 	defer close(clusterVectorAggregatorsEventCh)
 
 	if err = (&controller.ClusterVectorAggregatorReconciler{
-		Client:             mgr.GetClient(),
-		Clientset:          clientset,
-		Scheme:             mgr.GetScheme(),
-		ConfigCheckTimeout: configCheckTimeout,
-		EventChan:          clusterVectorAggregatorsEventCh,
-		APIReader:          mgr.GetAPIReader(),
+		Client:                          mgr.GetClient(),
+		Clientset:                       clientset,
+		Scheme:                          mgr.GetScheme(),
+		ConfigCheckTimeout:              configCheckTimeout,
+		ConfigCheckSettings:             configCheckSettings,
+		EventChan:                       clusterVectorAggregatorsEventCh,
+		EnableMetadataEnrichment:        enableMetadataEnrichment,
+		ConfigCheckAttributionMaxChecks: configCheckAttributionMaxChecks,
+		APIReader:                       mgr.GetAPIReader(),
+		Recorder:                        eventRecorder,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "ClusterVectorAggregator")
 		os.Exit(1)
 	}
+
+	if enableWebhooks {
+		if err = webhookv1alpha1.SetupVectorPipelineWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "VectorPipeline")
+			os.Exit(1)
+		}
+		if err = webhookv1alpha1.SetupClusterVectorPipelineWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "ClusterVectorPipeline")
+			os.Exit(1)
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
 	go reconcileWithDelay(context.Background(), vectorAgentsPipelineEventCh, vectorAgentEventCh, time.Second*10)
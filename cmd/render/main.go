@@ -0,0 +1,192 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// render prints the vector configs the operator would publish for a set of
+// manifests, without a cluster: Vector, VectorAggregator, ClusterVectorAggregator,
+// the pipelines and what they reference are read from files, and every workload's
+// config is built by the code the reconcilers run. It exits 1 when a pipeline is
+// invalid or left out of a config, or a config does not build, so CI can both diff
+// the configs and fail on a change the operator would reject.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	"github.com/kaasops/vector-operator/internal/config"
+	"github.com/kaasops/vector-operator/internal/render"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// files collects the repeated -f flag.
+type files []string
+
+func (f *files) String() string { return strings.Join(*f, ",") }
+
+func (f *files) Set(v string) error {
+	*f = append(*f, v)
+	return nil
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("render", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	var paths files
+	flags.Var(&paths, "f", "A manifest file, or a directory read recursively for *.yaml, *.yml and *.json; - reads stdin. Repeatable")
+	namespace := flags.String("namespace", "default", "The namespace of namespaced objects that do not set one")
+	output := flags.String("output", "yaml", "How configs are printed: yaml, json as published in the config Secret, or the graph of their components as dot or mermaid")
+	outputDir := flags.String("output-dir", "", "Write each config to <kind>-<namespace>-<name>.<ext> in this directory instead of printing them")
+	optimize := flags.Bool("enable-config-optimization", false, "Build agent configs as the operator does with -enable-config-optimization")
+	enrich := flags.Bool("enable-metadata-enrichment", false, "Build configs as the operator does with -enable-metadata-enrichment")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	ext, ok := extensions[*output]
+	if len(paths) == 0 || !ok {
+		flags.Usage()
+		return 2
+	}
+
+	objects, err := readManifests(paths, stdin, *namespace)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	result, err := render.Render(context.Background(), objects, render.Options{OptimizeSources: *optimize, EnrichMetadata: *enrich})
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+
+	for _, p := range result.Problems {
+		fmt.Fprintln(stderr, p)
+	}
+	for _, s := range result.Placeholders {
+		fmt.Fprintf(stderr, "Secret %s is not among the manifests, its keys are rendered as empty values\n", s)
+	}
+	for _, cfg := range result.Configs {
+		if cfg.Err != nil {
+			fmt.Fprintf(stderr, "%s: build failed: %s\n", cfg.ID(), cfg.Err)
+			continue
+		}
+		data := cfg.JSON
+		switch *output {
+		case "yaml":
+			data, err = yaml.JSONToYAML(cfg.JSON)
+		case "dot", "mermaid":
+			data, err = cfg.VectorConfig.Graph(config.GraphFormat(*output), cfg.ID())
+		}
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 2
+		}
+		if *outputDir != "" {
+			name := strings.ToLower(cfg.Kind) + "-" + cfg.Name + "." + ext
+			if cfg.Namespace != "" {
+				name = strings.ToLower(cfg.Kind) + "-" + cfg.Namespace + "-" + cfg.Name + "." + ext
+			}
+			if err := os.WriteFile(filepath.Join(*outputDir, name), data, 0o644); err != nil {
+				fmt.Fprintln(stderr, err)
+				return 2
+			}
+			continue
+		}
+		if *output == "yaml" {
+			fmt.Fprintf(stdout, "---\n# %s\n# pipelines: %s\n", cfg.ID(), strings.Join(cfg.Pipelines, ", "))
+		}
+		_, _ = stdout.Write(data)
+		if *output == "json" {
+			fmt.Fprintln(stdout)
+		}
+	}
+	if result.Failed() {
+		return 1
+	}
+	return 0
+}
+
+// extensions are the file extensions of the -output formats.
+var extensions = map[string]string{"yaml": "yaml", "json": "json", "dot": "dot", "mermaid": "mmd"}
+
+// readManifests decodes every file of paths, walking directories in lexical order.
+func readManifests(paths []string, stdin io.Reader, namespace string) ([]client.Object, error) {
+	var objects []client.Object
+	read := func(name string, r io.Reader) error {
+		objs, err := render.Decode(r, namespace)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		objects = append(objects, objs...)
+		return nil
+	}
+	readFile := func(name string) error {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return read(name, f)
+	}
+
+	for _, path := range paths {
+		if path == "-" {
+			if err := read("stdin", stdin); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			if err := readFile(path); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		err = filepath.WalkDir(path, func(name string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			switch filepath.Ext(name) {
+			case ".yaml", ".yml", ".json":
+				return readFile(name)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(objects) == 0 {
+		return nil, errors.New("no manifests found")
+	}
+	return objects, nil
+}
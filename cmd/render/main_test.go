@@ -0,0 +1,111 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const agent = `
+apiVersion: observability.kaasops.io/v1alpha1
+kind: Vector
+metadata:
+  name: agent
+  namespace: vector
+spec:
+  agent: {}
+`
+
+const pipeline = `
+apiVersion: observability.kaasops.io/v1alpha1
+kind: VectorPipeline
+metadata:
+  name: web
+spec:
+  sources:
+    logs:
+      type: kubernetes_logs
+  sinks:
+    out:
+      type: blackhole
+      inputs: ["logs"]
+`
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "team-a"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "agent.yaml"), []byte(agent), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "team-a", "web.yml"), []byte(pipeline), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte("# not a manifest"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-f", dir, "-namespace", "team-a"}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	out := stdout.String()
+	if !strings.HasPrefix(out, "---\n# Vector vector/agent\n# pipelines: team-a/web\n") || !strings.Contains(out, "team-a-web-logs:") {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+
+	outDir := t.TempDir()
+	stdout.Reset()
+	if code := run([]string{"-f", "-", "-output", "json", "-output-dir", outDir}, strings.NewReader(agent+"---"+pipeline), &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	data, err := os.ReadFile(filepath.Join(outDir, "vector-vector-agent.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"default-web-logs"`) || stdout.Len() != 0 {
+		t.Fatalf("unexpected output: %s", data)
+	}
+
+	if code := run([]string{"-f", "-", "-output", "mermaid", "-output-dir", outDir}, strings.NewReader(agent+"---"+pipeline), &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	data, err = os.ReadFile(filepath.Join(outDir, "vector-vector-agent.mmd"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "subgraph p0[\"default/web\"]") {
+		t.Fatalf("unexpected graph: %s", data)
+	}
+
+	if code := run([]string{"-f", "-"}, strings.NewReader(agent+"---"+pipeline+"---"+agent), &stdout, &stderr); code != 2 {
+		t.Fatalf("an object defined twice: exit code %d", code)
+	}
+	stderr.Reset()
+	broken := strings.Replace(pipeline, "type: kubernetes_logs", "type: file", 1)
+	if code := run([]string{"-f", "-"}, strings.NewReader(agent+"---"+broken), &stdout, &stderr); code != 1 {
+		t.Fatalf("an invalid pipeline: exit code %d", code)
+	}
+	if !strings.HasPrefix(stderr.String(), "VectorPipeline default/web is invalid: ") {
+		t.Fatalf("unexpected error output: %s", stderr.String())
+	}
+}
@@ -10,7 +10,14 @@ const (
 	// pipeline's kubernetes_logs source standalone while the rest of the group still
 	// collapses.
 	AnnotationConfigOptimization = "vector-operator.kaasops.io/config-optimization"
+	// AnnotationMetadataEnrichment on a (Cluster)VectorPipeline set to
+	// AnnotationValueEnabled stamps the pipeline's metadata into its events, and set to
+	// AnnotationValueDisabled opts it out of --enable-metadata-enrichment.
+	AnnotationMetadataEnrichment = "vector-operator.kaasops.io/metadata-enrichment"
 
-	// AnnotationValueDisabled is the opt-out value for AnnotationConfigOptimization.
+	// AnnotationValueDisabled is the opt-out value for AnnotationConfigOptimization and
+	// AnnotationMetadataEnrichment.
 	AnnotationValueDisabled = "disabled"
+	// AnnotationValueEnabled is the opt-in value for AnnotationMetadataEnrichment.
+	AnnotationValueEnabled = "enabled"
 )
@@ -43,31 +43,23 @@ func buildAgentConfig(params VectorConfigParams, pipelines ...pipeline.Pipeline)
 	var pendingSecrets []pendingSecretRef
 
 	for _, pipeline := range pipelines {
+		if pipeline.GetSpec().Suspend {
+			continue
+		}
 		p := &PipelineConfig{}
 		if err := UnmarshalJson(pipeline.GetSpec(), p); err != nil {
 			return nil, fmt.Errorf("failed to unmarshal pipeline %s: %w", pipeline.GetName(), err)
 		}
+		if err := checkPipelinePolicies(params, pipeline, p); err != nil {
+			return nil, err
+		}
 		optedOut := pipeline.GetAnnotations()[common.AnnotationConfigOptimization] == common.AnnotationValueDisabled
 		var comps []map[string]any
 		for k, v := range p.Sources {
 			// Validate source
 			if _, ok := pipeline.(*vectorv1alpha1.VectorPipeline); ok {
-				if v.Type != KubernetesLogsType {
-					return nil, ErrNotAllowedSourceType
-				}
-				_, err := labels.Parse(v.ExtraLabelSelector)
-				if err != nil {
-					return nil, fmt.Errorf("invalid pod selector for source %s: %w", k, err)
-				}
-				_, err = labels.Parse(v.ExtraNamespaceLabelSelector)
-				if err != nil {
-					return nil, fmt.Errorf("invalid namespace selector for source %s: %w", k, err)
-				}
-				if v.ExtraNamespaceLabelSelector == "" {
-					v.ExtraNamespaceLabelSelector = k8s.NamespaceNameToLabel(pipeline.GetNamespace())
-				}
-				if v.ExtraNamespaceLabelSelector != k8s.NamespaceNameToLabel(pipeline.GetNamespace()) {
-					return nil, ErrClusterScopeNotAllowed
+				if err := validateNamespacedAgentSource(pipeline.GetNamespace(), k, v); err != nil {
+					return nil, err
 				}
 			}
 			if v.Type == KubernetesLogsType && params.UseApiServerCache {
@@ -83,25 +75,36 @@ func buildAgentConfig(params VectorConfigParams, pipelines ...pipeline.Pipeline)
 				optOutSources[v.Name] = struct{}{}
 			}
 		}
+		var enriched map[string]string
+		if enrichMetadata(params, pipeline) {
+			enriched = enrichPipelineSources(cfg, pipeline, p.Sources)
+		}
 		for k, v := range p.Transforms {
 			v.Name = addPrefix(pipeline.GetNamespace(), pipeline.GetName(), k)
 			for i, inputName := range v.Inputs {
 				v.Inputs[i] = addPrefix(pipeline.GetNamespace(), pipeline.GetName(), inputName)
 			}
+			v.Inputs = rewriteInputs(v.Inputs, enriched)
 			cfg.Transforms[v.Name] = v
 			comps = append(comps, v.Options)
 		}
+		if err := addPipelineTests(cfg, params, pipeline, p.Transforms); err != nil {
+			return nil, err
+		}
 		for k, v := range p.Sinks {
-			v.Name = addPrefix(pipeline.GetNamespace(), pipeline.GetName(), k)
 			for i, inputName := range v.Inputs {
 				v.Inputs[i] = addPrefix(pipeline.GetNamespace(), pipeline.GetName(), inputName)
 			}
-			cfg.Sinks[v.Name] = v
+			v.Inputs = rewriteInputs(v.Inputs, enriched)
+			if err := addSink(cfg, pipeline, k, v); err != nil {
+				return nil, err
+			}
 			comps = append(comps, v.Options)
 		}
 		if err := processPipelineSecrets(pipeline, params.PipelineSecretGetter, comps, &pendingSecrets); err != nil {
 			return nil, err
 		}
+		cfg.internal.addPipeline(params, pipeline)
 	}
 
 	if params.OptimizeSources {
@@ -124,3 +127,28 @@ func buildAgentConfig(params VectorConfigParams, pipelines ...pipeline.Pipeline)
 
 	return cfg, nil
 }
+
+// validateNamespacedAgentSource enforces the rules a namespaced VectorPipeline's agent
+// source has to satisfy: kubernetes_logs only, parseable pod and namespace selectors,
+// and no reach outside the pipeline's own namespace. An empty namespace selector is
+// defaulted to the pipeline's namespace in place, so the caller sees the source exactly
+// as it will be rendered. Shared by buildAgentConfig and ValidatePipeline, which must
+// reject the same specs.
+func validateNamespacedAgentSource(namespace, name string, v *Source) error {
+	if v.Type != KubernetesLogsType {
+		return ErrNotAllowedSourceType
+	}
+	if _, err := labels.Parse(v.ExtraLabelSelector); err != nil {
+		return fmt.Errorf("invalid pod selector for source %s: %w", name, err)
+	}
+	if _, err := labels.Parse(v.ExtraNamespaceLabelSelector); err != nil {
+		return fmt.Errorf("invalid namespace selector for source %s: %w", name, err)
+	}
+	if v.ExtraNamespaceLabelSelector == "" {
+		v.ExtraNamespaceLabelSelector = k8s.NamespaceNameToLabel(namespace)
+	}
+	if v.ExtraNamespaceLabelSelector != k8s.NamespaceNameToLabel(namespace) {
+		return ErrClusterScopeNotAllowed
+	}
+	return nil
+}
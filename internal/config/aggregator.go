@@ -28,11 +28,17 @@ func BuildAggregatorConfig(params VectorConfigParams, pipelines ...pipeline.Pipe
 	var pendingSecrets []pendingSecretRef
 
 	for _, pipeline := range pipelines {
+		if pipeline.GetSpec().Suspend {
+			continue
+		}
 		kubernetesEventsAlreadyExists := false
 		p := &PipelineConfig{}
 		if err := UnmarshalJson(pipeline.GetSpec(), p); err != nil {
 			return nil, fmt.Errorf("failed to unmarshal pipeline %s: %w", pipeline.GetName(), err)
 		}
+		if err := checkPipelinePolicies(params, pipeline, p); err != nil {
+			return nil, err
+		}
 		var comps []map[string]any
 		for k, v := range p.Sources {
 			settings := v
@@ -44,6 +50,10 @@ func BuildAggregatorConfig(params VectorConfigParams, pipelines ...pipeline.Pipe
 						return nil, fmt.Errorf("pipeline can only contain one source with the type kubernetes_events")
 					}
 					kubernetesEventsAlreadyExists = true
+					filter, err := kubernetesEventsFilter(v.Options)
+					if err != nil {
+						return nil, fmt.Errorf("source %s: %w", k, err)
+					}
 					address := net.JoinHostPort(net.IPv4zero.String(), strconv.Itoa(int(kubernetesEventsPort)))
 					settings = &Source{
 						Name: k,
@@ -52,8 +62,12 @@ func BuildAggregatorConfig(params VectorConfigParams, pipelines ...pipeline.Pipe
 							"address": address,
 						},
 					}
-					err := cfg.internal.addServicePort(&ServicePort{
+					if params.KubernetesEventsTLS != nil {
+						settings.Options["tls"] = kubernetesEventsTLS(params.KubernetesEventsTLS)
+					}
+					err = cfg.internal.addServicePort(&ServicePort{
 						IsKubernetesEvents: true,
+						EventFilter:        filter,
 						Port:               kubernetesEventsPort,
 						Protocol:           corev1.ProtocolTCP,
 						Namespace:          pipeline.GetNamespace(),
@@ -96,25 +110,36 @@ func BuildAggregatorConfig(params VectorConfigParams, pipelines ...pipeline.Pipe
 			cfg.Sources[v.Name] = settings
 			comps = append(comps, settings.Options)
 		}
+		var enriched map[string]string
+		if enrichMetadata(params, pipeline) {
+			enriched = enrichPipelineSources(cfg, pipeline, p.Sources)
+		}
 		for k, v := range p.Transforms {
 			v.Name = addPrefix(pipeline.GetNamespace(), pipeline.GetName(), k)
 			for i, inputName := range v.Inputs {
 				v.Inputs[i] = addPrefix(pipeline.GetNamespace(), pipeline.GetName(), inputName)
 			}
+			v.Inputs = rewriteInputs(v.Inputs, enriched)
 			cfg.Transforms[v.Name] = v
 			comps = append(comps, v.Options)
 		}
+		if err := addPipelineTests(cfg, params, pipeline, p.Transforms); err != nil {
+			return nil, err
+		}
 		for k, v := range p.Sinks {
-			v.Name = addPrefix(pipeline.GetNamespace(), pipeline.GetName(), k)
 			for i, inputName := range v.Inputs {
 				v.Inputs[i] = addPrefix(pipeline.GetNamespace(), pipeline.GetName(), inputName)
 			}
-			cfg.Sinks[v.Name] = v
+			v.Inputs = rewriteInputs(v.Inputs, enriched)
+			if err := addSink(cfg, pipeline, k, v); err != nil {
+				return nil, err
+			}
 			comps = append(comps, v.Options)
 		}
 		if err := processPipelineSecrets(pipeline, params.PipelineSecretGetter, comps, &pendingSecrets); err != nil {
 			return nil, err
 		}
+		cfg.internal.addPipeline(params, pipeline)
 	}
 
 	// Add exporter pipeline
@@ -0,0 +1,43 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/kaasops/vector-operator/internal/pipeline"
+)
+
+// PipelineComponents returns the names p's components get in a workload config built
+// with params, for mapping the component IDs in vector's output back to pipelines: the
+// prefixed sources, transforms and sinks, the metadata enrichment transforms, and the
+// shared sink of every VectorOutput p references. A shared sink is named by every
+// pipeline feeding it. The collapsed sources and routes of the sources optimization are
+// not: they belong to a group of pipelines, never to one. A suspended pipeline or a spec
+// that does not parse has no components.
+func PipelineComponents(params VectorConfigParams, p pipeline.Pipeline) []string {
+	if p.GetSpec().Suspend {
+		return nil
+	}
+	cfg := &PipelineConfig{}
+	if err := UnmarshalJson(p.GetSpec(), cfg); err != nil {
+		return nil
+	}
+	var names []string
+	for k, v := range cfg.Sources {
+		source := addPrefix(p.GetNamespace(), p.GetName(), k)
+		names = append(names, source)
+		if _, metric := metricSourceTypes[v.Type]; !metric && enrichMetadata(params, p) {
+			names = append(names, fmt.Sprintf("%s-%s", enrichTransformPrefix, source))
+		}
+	}
+	for k := range cfg.Transforms {
+		names = append(names, addPrefix(p.GetNamespace(), p.GetName(), k))
+	}
+	for k, v := range cfg.Sinks {
+		if ref, ok := v.Options[pipeline.OutputRefKey]; ok {
+			names = append(names, outputSinkName(p.GetNamespace(), fmt.Sprint(ref)))
+			continue
+		}
+		names = append(names, addPrefix(p.GetNamespace(), p.GetName(), k))
+	}
+	return names
+}
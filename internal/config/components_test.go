@@ -0,0 +1,40 @@
+package config
+
+import (
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	vectorv1alpha1 "github.com/kaasops/vector-operator/api/v1alpha1"
+)
+
+// PipelineComponents must name exactly what the build generates, or a component in
+// vector's output cannot be traced back to its pipeline.
+func TestPipelineComponentsMatchBuild(t *testing.T) {
+	shared := `{"out": {"type": "console", "outputRef": "central", "inputs": ["logs"]}}`
+	web := testPipeline("team-a", "web", `{"logs": {"type": "kubernetes_logs"}}`, shared).(*vectorv1alpha1.VectorPipeline)
+	web.Spec.Transforms = &runtime.RawExtension{Raw: []byte(`{"parse": {"type": "remap", "inputs": ["logs"], "source": "."}}`)}
+	api := testPipeline("team-a", "api", `{"logs": {"type": "kubernetes_logs"}}`, shared)
+	debug := testLogPipeline("team-b")
+	params := VectorConfigParams{EnrichMetadata: true}
+
+	cfg, _, err := BuildAgentConfig(params, web, api, debug)
+	require.NoError(t, err)
+
+	built := slices.Concat(slices.Collect(maps.Keys(cfg.Sources)), slices.Collect(maps.Keys(cfg.Transforms)), slices.Collect(maps.Keys(cfg.Sinks)))
+	var named []string
+	for _, p := range []vectorv1alpha1.VectorPipeline{*web, *api.(*vectorv1alpha1.VectorPipeline), *debug.(*vectorv1alpha1.VectorPipeline)} {
+		named = append(named, PipelineComponents(params, &p)...)
+	}
+	slices.Sort(named)
+	assert.ElementsMatch(t, built, slices.Compact(named))
+
+	assert.ElementsMatch(t, []string{
+		"team-a-web-logs", "enrichMetadata-team-a-web-logs", "team-a-web-parse", "output_team-a_central",
+	}, PipelineComponents(params, web))
+	assert.Contains(t, PipelineComponents(params, api), "output_team-a_central", "a shared sink is named by every pipeline feeding it")
+}
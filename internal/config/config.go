@@ -30,12 +30,14 @@ import (
 	goyaml "sigs.k8s.io/yaml"
 
 	vectorv1alpha1 "github.com/kaasops/vector-operator/api/v1alpha1"
-	"github.com/kaasops/vector-operator/internal/evcollector"
+	"github.com/kaasops/vector-operator/internal/evconfig"
+	"github.com/kaasops/vector-operator/internal/pipeline"
 )
 
 var (
 	ErrNotAllowedSourceType   = errors.New("type kubernetes_logs only allowed")
 	ErrClusterScopeNotAllowed = errors.New("logs from external namespace not allowed")
+	ErrInvalidAggregatorRef   = errors.New("invalid aggregatorRef")
 )
 
 type VectorConfigParams struct {
@@ -46,10 +48,39 @@ type VectorConfigParams struct {
 	InternalMetrics   bool
 	ExpireMetricsSecs *int
 	OptimizeSources   bool
+	// EnrichMetadata stamps the pipeline's identity into the events of every pipeline
+	// not opted out by annotation (enrichPipelineSources).
+	EnrichMetadata bool
 	// PipelineSecretGetter resolves a pipeline secret backend to the referenced
 	// Kubernetes Secret. nil means secrets are unsupported in this context: any
 	// pipeline that declares spec.secret fails config generation.
 	PipelineSecretGetter func(ctx context.Context, namespace, name string) (*corev1.Secret, error)
+	// PipelinePolicies returns the ClusterVectorPipelinePolicies governing a
+	// VectorPipeline's namespace, which its transforms and sinks are checked against
+	// (CheckPolicies). nil means no policy applies.
+	PipelinePolicies func(ctx context.Context, p pipeline.Pipeline) ([]vectorv1alpha1.ClusterVectorPipelinePolicy, error)
+	// Tests adds the spec.tests of the pipelines to the config, for the configcheck to
+	// run with vector test. The configs the workloads run go without them.
+	Tests bool
+	// KubernetesEventsTLS is the aggregator's EventCollector.TLS, which its
+	// kubernetes_events sources serve; nil serves them in plaintext.
+	KubernetesEventsTLS *vectorv1alpha1.EventCollectorTLS
+}
+
+// checkPipelinePolicies enforces params.PipelinePolicies on a VectorPipeline;
+// ClusterVectorPipelines are not governed by policies.
+func checkPipelinePolicies(params VectorConfigParams, p pipeline.Pipeline, cfg *PipelineConfig) error {
+	if _, ok := p.(*vectorv1alpha1.VectorPipeline); !ok || params.PipelinePolicies == nil {
+		return nil
+	}
+	policies, err := params.PipelinePolicies(context.Background(), p)
+	if err != nil {
+		return err
+	}
+	if err := CheckPolicies(policies, cfg); err != nil {
+		return fmt.Errorf("pipeline %s/%s: %w", p.GetNamespace(), p.GetName(), err)
+	}
+	return nil
 }
 
 func newVectorConfig(p VectorConfigParams) *VectorConfig {
@@ -166,14 +197,15 @@ func (c *VectorConfig) GetSourcesServicePorts() map[SPGroup][]*ServicePort {
 	return m
 }
 
-func (c *VectorConfig) GetEventCollectorConfig(namespace string) *evcollector.Config {
-	items := make([]*evcollector.ReceiverParams, 0)
+func (c *VectorConfig) GetEventCollectorConfig(namespace string) *evconfig.Config {
+	items := make([]*evconfig.ReceiverParams, 0)
 	for _, s := range c.internal.servicePort {
 		if s.IsKubernetesEvents {
-			items = append(items, &evcollector.ReceiverParams{
+			items = append(items, &evconfig.ReceiverParams{
 				ServiceNamespace: namespace,
 				ServiceName:      s.ServiceName,
 				WatchedNamespace: s.Namespace,
+				Filter:           s.EventFilter,
 				Port:             strconv.Itoa(int(s.Port)),
 			})
 		}
@@ -181,7 +213,7 @@ func (c *VectorConfig) GetEventCollectorConfig(namespace string) *evcollector.Co
 	if len(items) == 0 {
 		return nil
 	}
-	return &evcollector.Config{
+	return &evconfig.Config{
 		Receivers: items,
 	}
 }
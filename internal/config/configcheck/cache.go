@@ -0,0 +1,267 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package configcheck
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	api_errors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/kaasops/vector-operator/internal/utils/k8s"
+)
+
+const (
+	// DefaultCacheSize is how many verdicts a Cache keeps by default.
+	DefaultCacheSize = 1024
+	// maxPersistedBytes bounds what a Cache writes to its Secret, well below the 1MiB
+	// object limit: the newest verdicts that fit are kept.
+	maxPersistedBytes = 768 * 1024
+	// maxPersistedReason truncates the reason of a persisted failure. The reason is a
+	// pod log and can be long; the beginning says what is wrong.
+	maxPersistedReason = 2048
+)
+
+// Verdict is the outcome of a check that ran to the end: the config is valid, or it is
+// not and Reason says why. Checks that timed out, were skipped or failed to run have no
+// verdict and are never cached.
+type Verdict struct {
+	Valid   bool      `json:"valid"`
+	Reason  string    `json:"reason,omitempty"`
+	Checked time.Time `json:"checked"`
+	// TestsFailed tells a config whose unit tests failed (ErrTestsFailed) from one
+	// that does not validate.
+	TestsFailed bool `json:"testsFailed,omitempty"`
+}
+
+// Cache keeps verdicts by the content they were reached on (CacheKey): identical
+// configs checked by the same image in the same environment are validated once, however
+// many workloads render them, and - with a Secret - across operator restarts. A Secret
+// rather than a ConfigMap: the reason of a failure is vector's output, which can hold
+// the secret values the config interpolates.
+//
+// The cache is an optimization. A Secret that cannot be read or written is logged and
+// the check runs as if nothing was cached.
+type Cache struct {
+	mu         sync.Mutex
+	entries    map[string]Verdict
+	maxEntries int
+
+	// reader and writer persist the verdicts in secret when it is set. reader should be
+	// uncached (mgr.GetAPIReader()): under --watch-name the manager's cache only holds
+	// labeled objects.
+	reader client.Reader
+	writer client.Client
+	secret types.NamespacedName
+	loaded bool
+}
+
+// NewCache returns an in-memory cache of up to maxEntries verdicts, evicting the oldest.
+func NewCache(maxEntries int) *Cache {
+	return &Cache{entries: make(map[string]Verdict), maxEntries: maxEntries}
+}
+
+// WithSecret persists the verdicts in the Secret nn, loaded on first use.
+func (c *Cache) WithSecret(reader client.Reader, writer client.Client, nn types.NamespacedName) *Cache {
+	c.reader, c.writer, c.secret = reader, writer, nn
+	return c
+}
+
+// CacheKey identifies what a verdict depends on: the config and the secret assets it
+// reads, and the validator - its mode, the image or binary and its version, and the
+// environment the check runs in (env and volumes of the configcheck pod).
+func CacheKey(mode Mode, validator string, environment any, config []byte, secretAssets map[string][]byte) string {
+	h := sha256.New()
+	env, _ := json.Marshal(environment)
+	for _, part := range [][]byte{[]byte(mode), []byte(validator), env, config} {
+		writeFramed(h, part)
+	}
+	keys := make([]string, 0, len(secretAssets))
+	for k := range secretAssets {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		writeFramed(h, []byte(k))
+		writeFramed(h, secretAssets[k])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// writeFramed length-prefixes b so that no two different part lists hash the same.
+func writeFramed(h interface{ Write([]byte) (int, error) }, b []byte) {
+	var n [8]byte
+	for i, l := 0, uint64(len(b)); i < 8; i++ {
+		n[i] = byte(l >> (8 * i))
+	}
+	_, _ = h.Write(n[:])
+	_, _ = h.Write(b)
+}
+
+// Get returns the verdict reached on key, if any.
+func (c *Cache) Get(ctx context.Context, key string) (Verdict, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.load(ctx)
+	v, ok := c.entries[key]
+	return v, ok
+}
+
+// Put records the verdict reached on key. The Secret is written under the lock, so
+// concurrent Puts reach it in order; a write takes far less than the check it saves.
+func (c *Cache) Put(ctx context.Context, key string, v Verdict) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.load(ctx)
+	c.entries[key] = v
+	c.evict()
+	c.persist(ctx)
+}
+
+// evict drops the oldest verdicts over maxEntries.
+func (c *Cache) evict() {
+	for len(c.entries) > c.maxEntries {
+		var oldest string
+		for k, v := range c.entries {
+			if oldest == "" || v.Checked.Before(c.entries[oldest].Checked) {
+				oldest = k
+			}
+		}
+		delete(c.entries, oldest)
+	}
+}
+
+// load merges the persisted verdicts in once. A failed read is retried on the next use.
+func (c *Cache) load(ctx context.Context) {
+	if c.loaded || c.reader == nil {
+		return
+	}
+	secret := &corev1.Secret{}
+	if err := c.reader.Get(ctx, c.secret, secret); err != nil {
+		if !api_errors.IsNotFound(err) {
+			log.FromContext(ctx).Error(err, "failed to load configcheck cache", "secret", c.secret)
+			return
+		}
+	}
+	for k, raw := range secret.Data {
+		var v Verdict
+		if json.Unmarshal(raw, &v) != nil {
+			continue
+		}
+		if _, ok := c.entries[k]; !ok {
+			c.entries[k] = v
+		}
+	}
+	c.evict()
+	c.loaded = true
+}
+
+// persist writes the newest verdicts that fit maxPersistedBytes to the Secret. The
+// in-memory entries are the whole truth once loaded: another operator process writing
+// the same Secret - only during a rolling update - loses its latest verdicts, which
+// just costs a check.
+func (c *Cache) persist(ctx context.Context) {
+	if c.writer == nil {
+		return
+	}
+	keys := make([]string, 0, len(c.entries))
+	for k := range c.entries {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return c.entries[keys[i]].Checked.After(c.entries[keys[j]].Checked) })
+	data := make(map[string][]byte, len(keys))
+	size := 0
+	for _, k := range keys {
+		v := c.entries[k]
+		if len(v.Reason) > maxPersistedReason {
+			v.Reason = v.Reason[:maxPersistedReason]
+		}
+		raw, _ := json.Marshal(v)
+		if size += len(k) + len(raw); size > maxPersistedBytes {
+			break
+		}
+		data[k] = raw
+	}
+
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		secret := &corev1.Secret{}
+		err := c.reader.Get(ctx, c.secret, secret)
+		if api_errors.IsNotFound(err) {
+			secret = &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      c.secret.Name,
+					Namespace: c.secret.Namespace,
+					Labels: map[string]string{
+						k8s.ManagedByLabelKey: "vector-operator",
+						k8s.NameLabelKey:      "vector-configcheck-cache",
+					},
+				},
+				Data: data,
+			}
+			return c.writer.Create(ctx, secret)
+		}
+		if err != nil {
+			return err
+		}
+		secret.Data = data
+		return c.writer.Update(ctx, secret)
+	})
+	if err != nil {
+		log.FromContext(ctx).Error(err, "failed to persist configcheck cache", "secret", c.secret)
+	}
+}
+
+// cachedValidator answers from the cache when it has a verdict on key, and records the
+// verdict of next otherwise.
+type cachedValidator struct {
+	cache *Cache
+	key   string
+	next  Validator
+}
+
+func (cv *cachedValidator) Run(ctx context.Context) (string, error) {
+	if v, ok := cv.cache.Get(ctx, cv.key); ok {
+		log.FromContext(ctx).Info("ConfigCheck verdict from cache", "valid", v.Valid, "checked", v.Checked)
+		if v.Valid {
+			return "", nil
+		}
+		if v.TestsFailed {
+			return v.Reason, ErrTestsFailed
+		}
+		return v.Reason, ErrValidation
+	}
+	reason, err := cv.next.Run(ctx)
+	switch {
+	case err == nil:
+		cv.cache.Put(ctx, cv.key, Verdict{Valid: true, Checked: time.Now()})
+	case IsConfigInvalid(err):
+		cv.cache.Put(ctx, cv.key, Verdict{Reason: reason, TestsFailed: errors.Is(err, ErrTestsFailed), Checked: time.Now()})
+	}
+	return reason, err
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package configcheck
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	vectorv1alpha1 "github.com/kaasops/vector-operator/api/v1alpha1"
+)
+
+type countingValidator struct {
+	runs   int
+	reason string
+	err    error
+}
+
+func (v *countingValidator) Run(context.Context) (string, error) {
+	v.runs++
+	return v.reason, v.err
+}
+
+func TestCacheKey(t *testing.T) {
+	base := CacheKey(ModePod, "vector:1", nil, []byte("cfg"), map[string][]byte{"a": []byte("1")})
+	require.Equal(t, base, CacheKey(ModePod, "vector:1", nil, []byte("cfg"), map[string][]byte{"a": []byte("1")}))
+	for name, key := range map[string]string{
+		"mode":         CacheKey(ModeLocal, "vector:1", nil, []byte("cfg"), map[string][]byte{"a": []byte("1")}),
+		"image":        CacheKey(ModePod, "vector:2", nil, []byte("cfg"), map[string][]byte{"a": []byte("1")}),
+		"environment":  CacheKey(ModePod, "vector:1", []string{"X=1"}, []byte("cfg"), map[string][]byte{"a": []byte("1")}),
+		"config":       CacheKey(ModePod, "vector:1", nil, []byte("cfg2"), map[string][]byte{"a": []byte("1")}),
+		"secret value": CacheKey(ModePod, "vector:1", nil, []byte("cfg"), map[string][]byte{"a": []byte("2")}),
+		"framing":      CacheKey(ModePod, "vector:1", nil, []byte("cfg"), map[string][]byte{"a1": nil}),
+	} {
+		require.NotEqual(t, base, key, name)
+	}
+}
+
+func TestCachedValidator(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("verdicts are reused", func(t *testing.T) {
+		cache := NewCache(10)
+		valid := &countingValidator{}
+		for range 3 {
+			_, err := (&cachedValidator{cache: cache, key: "valid", next: valid}).Run(ctx)
+			require.NoError(t, err)
+		}
+		require.Equal(t, 1, valid.runs)
+
+		invalid := &countingValidator{reason: "bad sink", err: ErrValidation}
+		for range 2 {
+			reason, err := (&cachedValidator{cache: cache, key: "invalid", next: invalid}).Run(ctx)
+			require.ErrorIs(t, err, ErrValidation)
+			require.Equal(t, "bad sink", reason)
+		}
+		require.Equal(t, 1, invalid.runs)
+	})
+
+	t.Run("checks without a verdict are not cached", func(t *testing.T) {
+		for _, err := range []error{ErrConfigcheckTimeout, ErrConfigcheckSkipped, ErrPodUnstartable, ErrCheckAborted, errors.New("api down")} {
+			cache := NewCache(10)
+			v := &countingValidator{err: err}
+			for range 2 {
+				_, _ = (&cachedValidator{cache: cache, key: "k", next: v}).Run(ctx)
+			}
+			require.Equal(t, 2, v.runs, err.Error())
+		}
+	})
+
+	t.Run("oldest verdicts are evicted", func(t *testing.T) {
+		cache := NewCache(2)
+		now := time.Now()
+		cache.Put(ctx, "a", Verdict{Valid: true, Checked: now})
+		cache.Put(ctx, "b", Verdict{Valid: true, Checked: now.Add(time.Second)})
+		cache.Put(ctx, "c", Verdict{Valid: true, Checked: now.Add(2 * time.Second)})
+		_, ok := cache.Get(ctx, "a")
+		require.False(t, ok)
+		_, ok = cache.Get(ctx, "c")
+		require.True(t, ok)
+	})
+}
+
+func TestCacheSecret(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	c := fake.NewClientBuilder().WithScheme(scheme).Build()
+	nn := types.NamespacedName{Namespace: "vector-operator", Name: "configcheck-cache"}
+
+	first := NewCache(10).WithSecret(c, c, nn)
+	first.Put(ctx, "valid", Verdict{Valid: true, Checked: time.Now()})
+	first.Put(ctx, "invalid", Verdict{Reason: strings.Repeat("x", 3*maxPersistedReason), Checked: time.Now()})
+	// the reasons are vector's output, with whatever secret values it interpolated
+	require.NoError(t, c.Get(ctx, nn, &corev1.Secret{}))
+
+	// a restarted operator starts from the persisted verdicts
+	restarted := NewCache(10).WithSecret(c, c, nn)
+	v, ok := restarted.Get(ctx, "valid")
+	require.True(t, ok)
+	require.True(t, v.Valid)
+	v, ok = restarted.Get(ctx, "invalid")
+	require.True(t, ok)
+	require.False(t, v.Valid)
+	require.Len(t, v.Reason, maxPersistedReason)
+}
+
+func TestSettingsForceKey(t *testing.T) {
+	vc := &vectorv1alpha1.VectorCommon{Image: "vector:1"}
+	s := Settings{Cache: NewCache(10)}
+	plain := s.New([]byte("cfg"), nil, nil, vc, "v", "ns", time.Minute, ConfigCheckInitiatorPipieline, nil).(*cachedValidator)
+	s.ForceKey = "2024-01-01T00:00:00Z"
+	forced := s.New([]byte("cfg"), nil, nil, vc, "v", "ns", time.Minute, ConfigCheckInitiatorPipieline, nil).(*cachedValidator)
+	require.NotEqual(t, plain.key, forced.key)
+}
+
+// A persisted verdict must not outlive an upgrade of the local vector, whose binary keeps
+// its name.
+func TestSettingsLocalVersionKey(t *testing.T) {
+	vc := &vectorv1alpha1.VectorCommon{}
+	s := Settings{Mode: ModeLocal, Cache: NewCache(10), VectorVersion: "0.47.0"}
+	before := s.New([]byte("cfg"), nil, nil, vc, "v", "ns", time.Minute, ConfigCheckInitiatorPipieline, nil).(*cachedValidator)
+	s.VectorVersion = "0.48.0"
+	after := s.New([]byte("cfg"), nil, nil, vc, "v", "ns", time.Minute, ConfigCheckInitiatorPipieline, nil).(*cachedValidator)
+	require.NotEqual(t, before.key, after.key)
+}
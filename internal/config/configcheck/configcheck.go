@@ -290,6 +290,33 @@ func unstartableReason(pod *corev1.Pod) (string, bool) {
 	return "", false
 }
 
+// failedContainer returns the container of a failed configcheck pod that exited
+// non-zero, the init containers first: with tests, the validating container is one of
+// them. It is nil when none did, such as for an evicted pod.
+func failedContainer(pod *corev1.Pod) *corev1.ContainerStatus {
+	for _, statuses := range [][]corev1.ContainerStatus{pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses} {
+		for i, st := range statuses {
+			if t := st.State.Terminated; t != nil && t.ExitCode != 0 {
+				return &statuses[i]
+			}
+		}
+	}
+	return nil
+}
+
+// abortedReason describes a configcheck pod that failed without vector exiting on the
+// config: evicted, OOMKilled, or a container that could not run at all. Only a container
+// that exited with reason Error is a verdict.
+func abortedReason(pod *corev1.Pod, container *corev1.ContainerStatus) (string, bool) {
+	if container == nil {
+		return fmt.Sprintf("configcheck pod failed, %s: %s", pod.Status.Reason, pod.Status.Message), true
+	}
+	if t := container.State.Terminated; t.Reason != "Error" {
+		return fmt.Sprintf("configcheck container %s exited with %d, %s: %s", container.Name, t.ExitCode, t.Reason, t.Message), true
+	}
+	return "", false
+}
+
 func (cc *ConfigCheck) getCheckResult(ctx context.Context, pod *corev1.Pod, deadline time.Time) (reason string, err error) {
 	log := log.FromContext(ctx).WithValues("Vector ConfigCheck", pod.Name)
 	log.Info("Trying to get configcheck result")
@@ -347,10 +374,18 @@ func (cc *ConfigCheck) getCheckResult(ctx context.Context, pod *corev1.Pod, dead
 					return "", nil
 				case corev1.PodFailed:
 					log.Info("Config Check Failed")
-					reason, err := k8s.GetPodLogs(ctx, pod, cc.ClientSet)
+					container := failedContainer(pod)
+					if reason, aborted := abortedReason(pod, container); aborted {
+						log.Info("Config Check pod failed before a verdict", "reason", reason)
+						return reason, ErrCheckAborted
+					}
+					reason, err := k8s.GetContainerLogs(ctx, pod, container.Name, cc.ClientSet)
 					if err != nil {
 						return "", err
 					}
+					if container.Name == configTestContainerName {
+						return reason, ErrTestsFailed
+					}
 					return reason, ErrValidation
 				default:
 					// A pod that can never start (e.g. env from a missing secret →
@@ -358,7 +393,7 @@ func (cc *ConfigCheck) getCheckResult(ctx context.Context, pod *corev1.Pod, dead
 					// now instead of holding the reconcile worker until timeout.
 					if reason, unstartable := unstartableReason(pod); unstartable {
 						log.Info("Config Check pod cannot start", "reason", reason)
-						return reason, ErrValidation
+						return reason, ErrPodUnstartable
 					}
 				}
 			case watch.Deleted:
@@ -18,6 +18,7 @@ package configcheck
 
 import (
 	"errors"
+	"fmt"
 )
 
 var (
@@ -27,4 +28,23 @@ var (
 	// namespace is terminating (or gone). Callers should treat it as a no-op skip,
 	// not a validation failure — the config was never checked.
 	ErrConfigcheckSkipped = errors.New("configcheck skipped: namespace is terminating")
+	// ErrPodUnstartable is the ErrValidation of a configcheck pod that can never start,
+	// e.g. its env reads a Secret that does not exist. The verdict is on the workload's
+	// environment rather than on the config, so it is not cached: creating the Secret
+	// changes nothing the cache key covers.
+	ErrPodUnstartable = fmt.Errorf("%w: configcheck pod cannot start", ErrValidation)
+	// ErrTestsFailed is the ErrValidation of a config that validates but whose unit
+	// tests (spec.tests of the pipelines) fail under vector test.
+	ErrTestsFailed = fmt.Errorf("%w: pipeline tests failed", ErrValidation)
+	// ErrCheckAborted is the ErrValidation of a check that ended before vector reached
+	// a verdict: the configcheck pod was evicted or OOMKilled, or the local vector was
+	// killed. Like ErrPodUnstartable it says nothing about the config and is not cached:
+	// raising the configcheck resources changes nothing the cache key covers.
+	ErrCheckAborted = fmt.Errorf("%w: configcheck aborted", ErrValidation)
 )
+
+// IsConfigInvalid reports whether err is a verdict on the config itself: ErrValidation
+// of a check that ran to the end, not ErrPodUnstartable or ErrCheckAborted.
+func IsConfigInvalid(err error) bool {
+	return errors.Is(err, ErrValidation) && !errors.Is(err, ErrPodUnstartable) && !errors.Is(err, ErrCheckAborted)
+}
@@ -24,6 +24,14 @@ import (
 	"github.com/kaasops/vector-operator/internal/utils/k8s"
 )
 
+const (
+	// configCheckContainerName validates the config.
+	configCheckContainerName = "config-check"
+	// configTestContainerName runs the unit tests of a config that has them, once
+	// configCheckContainerName, then an init container, validated it.
+	configTestContainerName = "config-test"
+)
+
 func (cc *ConfigCheck) createVectorConfigCheckPod() *corev1.Pod {
 	labels := cc.labelsForVectorConfigCheck()
 	annotations := cc.annotationsForVectorConfigCheck()
@@ -34,7 +42,7 @@ func (cc *ConfigCheck) createVectorConfigCheckPod() *corev1.Pod {
 	}
 
 	container := corev1.Container{
-		Name:            "config-check",
+		Name:            configCheckContainerName,
 		Image:           cc.Image,
 		Resources:       cc.Resources,
 		Args:            []string{"--require-healthy=false", "validate", "/etc/vector/*.json"},
@@ -47,6 +55,15 @@ func (cc *ConfigCheck) createVectorConfigCheckPod() *corev1.Pod {
 		container.EnvFrom = cc.EnvFrom
 	}
 
+	containers := []corev1.Container{container}
+	if hasTests(cc.Config) {
+		test := container
+		test.Name = configTestContainerName
+		test.Args = []string{"test", "/etc/vector/*.json"}
+		initContainers = append(initContainers, container)
+		containers = []corev1.Container{test}
+	}
+
 	pod := &corev1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:        cc.getNameVectorConfigCheck(),
@@ -61,10 +78,8 @@ func (cc *ConfigCheck) createVectorConfigCheckPod() *corev1.Pod {
 			ImagePullSecrets:   cc.ImagePullSecrets,
 			Tolerations:        cc.Tolerations,
 			InitContainers:     initContainers,
-			Containers: []corev1.Container{
-				container,
-			},
-			RestartPolicy: "Never",
+			Containers:         containers,
+			RestartPolicy:      "Never",
 		},
 	}
 
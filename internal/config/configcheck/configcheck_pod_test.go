@@ -634,3 +634,30 @@ func TestConfigCheckSecretAssetsMountPathIsAuthoritative(t *testing.T) {
 		t.Fatalf("mount at %q must use the operator's secret-assets volume, got %q", config.SecretsMountPath, mounts[0].Name)
 	}
 }
+
+// A config with unit tests validates in an init container and runs its tests in the
+// pod's container, so they only run on a config that validates; one without keeps the
+// single validating container.
+func TestConfigCheckPodRunsTests(t *testing.T) {
+	cc := &ConfigCheck{Name: "test", Namespace: "default", Config: []byte(`{"sources":{}}`)}
+	pod := cc.createVectorConfigCheckPod()
+	if len(pod.Spec.InitContainers) != 0 || len(pod.Spec.Containers) != 1 || pod.Spec.Containers[0].Name != configCheckContainerName {
+		t.Fatalf("without tests: want the single %s container, got init %v containers %v", configCheckContainerName, pod.Spec.InitContainers, pod.Spec.Containers)
+	}
+
+	cc.Config = []byte(`{"tests":[{"name":"t","inputs":[{"insert_at":"ns-p-parse","value":"x"}]}]}`)
+	cc.CompressedConfig = true
+	pod = cc.createVectorConfigCheckPod()
+	if n := len(pod.Spec.InitContainers); n != 2 || pod.Spec.InitContainers[1].Name != configCheckContainerName {
+		t.Fatalf("want validation after the config reloader in the init containers, got %v", pod.Spec.InitContainers)
+	}
+	if args := pod.Spec.InitContainers[1].Args; fmt.Sprint(args) != "[--require-healthy=false validate /etc/vector/*.json]" {
+		t.Fatalf("unexpected validation args %v", args)
+	}
+	if len(pod.Spec.Containers) != 1 || pod.Spec.Containers[0].Name != configTestContainerName {
+		t.Fatalf("want the %s container, got %v", configTestContainerName, pod.Spec.Containers)
+	}
+	if args := pod.Spec.Containers[0].Args; fmt.Sprint(args) != "[test /etc/vector/*.json]" {
+		t.Fatalf("unexpected test args %v", args)
+	}
+}
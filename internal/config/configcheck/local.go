@@ -0,0 +1,264 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package configcheck
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/kaasops/vector-operator/internal/config"
+)
+
+// DefaultVectorBinary is the vector binary LocalCheck runs when Settings names none,
+// looked up in PATH. The operator image ships one.
+const DefaultVectorBinary = "vector"
+
+// envPlaceholder is the value of the variables LocalCheck cannot read.
+const envPlaceholder = "configcheck"
+
+// LocalCheck validates a config by running `vector validate --no-environment` in the
+// operator's own process, instead of scheduling a configcheck pod. Nothing is created
+// on the cluster, so a check takes as long as vector needs to parse the config rather
+// than as long as it takes to schedule and start a pod.
+//
+// --no-environment skips everything that depends on where the config runs - building
+// the components, health checks, data_dir - so LocalCheck catches what the config says,
+// not whether it works on the agent's nodes: a sink that cannot reach its endpoint or a
+// file source without access to its path passes here and fails only once deployed.
+//
+// It also validates with the operator's vector, whatever the version the workload runs:
+// a config using options the two versions disagree on passes here and fails in the
+// pods, or the other way round. Run logs when WorkloadImage names another version.
+type LocalCheck struct {
+	Config       []byte
+	SecretAssets map[string][]byte
+	// Env is the workload's env. Values read from Secrets, ConfigMaps or the downward
+	// API only exist in the pod: vector gets a placeholder for them.
+	Env          []corev1.EnvVar
+	VectorBinary string
+	// VectorVersion is the version of VectorBinary (ProbeVectorVersion), and
+	// WorkloadImage the image the workload runs the config with.
+	VectorVersion string
+	WorkloadImage string
+	Timeout       time.Duration
+	Initiator     string
+}
+
+// Run writes the config, and the secret assets its directory secret backend reads,
+// to a temporary directory and validates it. An invalid config returns vector's
+// output as the reason with ErrValidation, like a failed configcheck pod. A valid one
+// carrying unit tests then runs them with vector test, and returns its output with
+// ErrTestsFailed when one fails.
+func (lc *LocalCheck) Run(ctx context.Context) (reason string, err error) {
+	log := log.FromContext(ctx).WithValues("Vector ConfigCheck", lc.Initiator, "mode", ModeLocal)
+
+	dir, err := os.MkdirTemp("", "configcheck-")
+	if err != nil {
+		return "", err
+	}
+	// the secret assets are plaintext credentials: never leave them behind
+	defer func() {
+		if rmErr := os.RemoveAll(dir); rmErr != nil {
+			log.Error(rmErr, "failed to remove configcheck directory", "dir", dir)
+		}
+	}()
+
+	if v := imageVersion(lc.WorkloadImage); v != "" && lc.VectorVersion != "" && v != lc.VectorVersion {
+		log.Info("Warning: the workload runs another vector version than the local configcheck validates with",
+			"image", lc.WorkloadImage, "localVersion", lc.VectorVersion)
+	}
+
+	cfg, err := lc.localConfig(dir)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(path, cfg, 0o600); err != nil {
+		return "", err
+	}
+
+	// one budget for both commands, as a configcheck pod has for both containers
+	runCtx, cancel := context.WithTimeout(ctx, lc.Timeout)
+	defer cancel()
+	if out, err := lc.vector(ctx, runCtx, dir, "validate", "--no-environment", path); err != nil {
+		if errors.Is(err, ErrValidation) {
+			log.Info("Config Check Failed")
+			return out, err
+		}
+		return "", err
+	}
+	if hasTests(lc.Config) {
+		if out, err := lc.vector(ctx, runCtx, dir, "test", path); err != nil {
+			if errors.Is(err, ErrValidation) {
+				log.Info("Config Check tests failed")
+				return out, ErrTestsFailed
+			}
+			return "", err
+		}
+	}
+	log.Info("Config Check completed successfully")
+	return "", nil
+}
+
+// vector runs the vector binary with args in dir, bounded by runCtx, the caller's ctx
+// with the check's timeout. A non-zero exit returns vector's output with ErrValidation,
+// a vector killed by a signal ErrCheckAborted.
+func (lc *LocalCheck) vector(ctx, runCtx context.Context, dir string, args ...string) (string, error) {
+	binary := lc.VectorBinary
+	if binary == "" {
+		binary = DefaultVectorBinary
+	}
+	cmd := exec.CommandContext(runCtx, binary, args...)
+	cmd.Dir = dir
+	cmd.Env = lc.environ()
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	// a child vector leaves behind would hold the output pipes open past the kill
+	cmd.WaitDelay = time.Second
+
+	err := cmd.Run()
+	if runCtx.Err() != nil {
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("configcheck: %w while running %s", ctx.Err(), binary)
+		}
+		return "", ErrConfigcheckTimeout
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		if exitErr.ExitCode() < 0 {
+			// killed by a signal, the OOM killer say: vector never got to the config
+			return fmt.Sprintf("%s was killed: %s", binary, exitErr), ErrCheckAborted
+		}
+		return out.String(), ErrValidation
+	}
+	if err != nil {
+		// the binary is missing or cannot be started: the config was never checked
+		return "", fmt.Errorf("configcheck: run %s: %w", binary, err)
+	}
+	return "", nil
+}
+
+// ProbeVectorVersion returns the version of the vector binary, from the output of
+// vector --version, e.g. "vector 0.48.0 (x86_64-unknown-linux-gnu 2025-06-30)".
+func ProbeVectorVersion(ctx context.Context, binary string) (string, error) {
+	if binary == "" {
+		binary = DefaultVectorBinary
+	}
+	out, err := exec.CommandContext(ctx, binary, "--version").Output()
+	if err != nil {
+		return "", fmt.Errorf("run %s --version: %w", binary, err)
+	}
+	fields := strings.Fields(string(out))
+	if len(fields) < 2 || !versionPattern.MatchString(fields[1]) {
+		return "", fmt.Errorf("no version in the output of %s --version: %q", binary, out)
+	}
+	return fields[1], nil
+}
+
+var versionPattern = regexp.MustCompile(`^[0-9]+\.[0-9]+\.[0-9]+$`)
+
+// imageVersion returns the vector version in the tag of image, such as 0.48.0 in
+// timberio/vector:0.48.0-distroless-libc, and "" for a tag that is not a version
+// (latest, nightly) or no tag at all.
+func imageVersion(image string) string {
+	image, _, _ = strings.Cut(image, "@")
+	i := strings.LastIndex(image, ":")
+	if i < 0 || strings.Contains(image[i:], "/") {
+		return ""
+	}
+	version, _, _ := strings.Cut(image[i+1:], "-")
+	if !versionPattern.MatchString(version) {
+		return ""
+	}
+	return version
+}
+
+// hasTests reports whether config carries unit tests to run with vector test
+// (config.VectorConfigParams.Tests).
+func hasTests(config []byte) bool {
+	var doc struct {
+		Tests []json.RawMessage `json:"tests"`
+	}
+	return json.Unmarshal(config, &doc) == nil && len(doc.Tests) > 0
+}
+
+// localConfig points data_dir and the directory secret backend of the config into dir,
+// writing the secret assets there. The workloads mount them at config.SecretsMountPath,
+// which the operator's container does not have.
+func (lc *LocalCheck) localConfig(dir string) ([]byte, error) {
+	var doc map[string]any
+	if err := json.Unmarshal(lc.Config, &doc); err != nil {
+		return nil, fmt.Errorf("configcheck: config does not parse: %w", err)
+	}
+	dataDir := filepath.Join(dir, "data")
+	if err := os.Mkdir(dataDir, 0o700); err != nil {
+		return nil, err
+	}
+	doc["data_dir"] = dataDir
+
+	if len(lc.SecretAssets) > 0 {
+		secretsDir := filepath.Join(dir, "secrets")
+		if err := os.Mkdir(secretsDir, 0o700); err != nil {
+			return nil, err
+		}
+		for key, value := range lc.SecretAssets {
+			if err := os.WriteFile(filepath.Join(secretsDir, filepath.Base(key)), value, 0o600); err != nil {
+				return nil, err
+			}
+		}
+		if backends, ok := doc["secret"].(map[string]any); ok {
+			if backend, ok := backends[config.SecretsBackendName].(map[string]any); ok {
+				backend["path"] = secretsDir
+			}
+		}
+	}
+	return json.Marshal(doc)
+}
+
+// environ is the environment vector runs with: the literal env of the workload, and
+// placeholders for its variables from valueFrom and for those the configcheck pod gets
+// from the downward API, so a config interpolating them still parses.
+func (lc *LocalCheck) environ() []string {
+	env := []string{
+		"VECTOR_SELF_NODE_NAME=" + envPlaceholder,
+		"VECTOR_SELF_POD_NAME=" + envPlaceholder,
+		"VECTOR_SELF_POD_NAMESPACE=" + envPlaceholder,
+		"PROCFS_ROOT=/proc",
+		"SYSFS_ROOT=/sys",
+	}
+	for _, e := range lc.Env {
+		if e.ValueFrom != nil {
+			env = append(env, e.Name+"="+envPlaceholder)
+			continue
+		}
+		env = append(env, e.Name+"="+e.Value)
+	}
+	return env
+}
@@ -0,0 +1,197 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package configcheck
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+
+	vectorv1alpha1 "github.com/kaasops/vector-operator/api/v1alpha1"
+)
+
+// fakeVector writes a stand-in for the vector binary: it prints its arguments, the
+// config it is given and the secret asset "k8s_key", then exits with $FAKE_EXIT, or
+// $FAKE_TEST_EXIT when running tests, or is killed with $FAKE_KILL set.
+func fakeVector(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "vector")
+	script := `#!/bin/sh
+echo "args: $*"
+for last; do :; done
+cat "$last"
+echo
+dir=$(sed -n 's/.*"path":"\([^"]*\)".*/\1/p' "$last")
+[ -n "$dir" ] && cat "$dir/k8s_key"
+[ -n "$FAKE_SLEEP" ] && sleep "$FAKE_SLEEP"
+[ -n "$FAKE_KILL" ] && kill -9 $$
+[ "$1" = test ] && exit "${FAKE_TEST_EXIT:-0}"
+exit "${FAKE_EXIT:-0}"
+`
+	require.NoError(t, os.WriteFile(path, []byte(script), 0o755))
+	return path
+}
+
+func TestLocalCheck(t *testing.T) {
+	ctx := context.Background()
+	cfg := []byte(`{"data_dir":"/vector-data-dir","secret":{"k8s":{"path":"/etc/vector/secrets","type":"directory"}}}`)
+
+	t.Run("valid config", func(t *testing.T) {
+		lc := &LocalCheck{Config: cfg, VectorBinary: fakeVector(t), Timeout: time.Minute}
+		reason, err := lc.Run(ctx)
+		require.NoError(t, err)
+		require.Empty(t, reason)
+	})
+
+	t.Run("invalid config reports vector's output", func(t *testing.T) {
+		lc := &LocalCheck{
+			Config:       cfg,
+			SecretAssets: map[string][]byte{"k8s_key": []byte("s3cr3t")},
+			Env: []corev1.EnvVar{
+				{Name: "FAKE_EXIT", Value: "78"},
+				{Name: "FROM_SECRET", ValueFrom: &corev1.EnvVarSource{}},
+			},
+			VectorBinary: fakeVector(t),
+			Timeout:      time.Minute,
+		}
+		reason, err := lc.Run(ctx)
+		require.ErrorIs(t, err, ErrValidation)
+		require.Contains(t, reason, "args: validate --no-environment ")
+		// data_dir and the secret backend point into the check's own directory, where
+		// the secret assets are written
+		require.NotContains(t, reason, "/vector-data-dir")
+		require.NotContains(t, reason, "/etc/vector/secrets")
+		require.Contains(t, reason, "s3cr3t")
+		require.Contains(t, lc.environ(), "FROM_SECRET="+envPlaceholder)
+	})
+
+	t.Run("tests run once the config validates", func(t *testing.T) {
+		withTests := []byte(`{"data_dir":"/vector-data-dir","tests":[{"name":"t","inputs":[{"insert_at":"ns-p-parse","value":"x"}]}]}`)
+		lc := &LocalCheck{Config: withTests, VectorBinary: fakeVector(t), Timeout: time.Minute}
+		reason, err := lc.Run(ctx)
+		require.NoError(t, err)
+		require.Empty(t, reason)
+
+		lc.Env = []corev1.EnvVar{{Name: "FAKE_TEST_EXIT", Value: "78"}}
+		reason, err = lc.Run(ctx)
+		require.ErrorIs(t, err, ErrTestsFailed)
+		require.True(t, strings.HasPrefix(reason, "args: test "), reason)
+
+		// an invalid config never gets to its tests
+		lc.Env = append(lc.Env, corev1.EnvVar{Name: "FAKE_EXIT", Value: "78"})
+		reason, err = lc.Run(ctx)
+		require.ErrorIs(t, err, ErrValidation)
+		require.False(t, errors.Is(err, ErrTestsFailed))
+		require.Contains(t, reason, "validate")
+
+		// without tests, vector test is not run at all
+		lc = &LocalCheck{Config: cfg, Env: []corev1.EnvVar{{Name: "FAKE_TEST_EXIT", Value: "78"}}, VectorBinary: fakeVector(t), Timeout: time.Minute}
+		_, err = lc.Run(ctx)
+		require.NoError(t, err)
+	})
+
+	t.Run("the directory is removed", func(t *testing.T) {
+		lc := &LocalCheck{Config: cfg, SecretAssets: map[string][]byte{"k8s_key": []byte("s3cr3t")}, VectorBinary: fakeVector(t), Timeout: time.Minute}
+		lc.Env = []corev1.EnvVar{{Name: "FAKE_EXIT", Value: "1"}}
+		reason, err := lc.Run(ctx)
+		require.ErrorIs(t, err, ErrValidation)
+		line := strings.SplitN(strings.TrimPrefix(reason, "args: "), "\n", 2)[0]
+		path := line[strings.LastIndex(line, " ")+1:]
+		_, err = os.Stat(filepath.Dir(path))
+		require.True(t, os.IsNotExist(err), "configcheck directory %s left behind", filepath.Dir(path))
+	})
+
+	t.Run("missing binary is not a validation failure", func(t *testing.T) {
+		lc := &LocalCheck{Config: cfg, VectorBinary: filepath.Join(t.TempDir(), "missing"), Timeout: time.Minute}
+		_, err := lc.Run(ctx)
+		require.Error(t, err)
+		require.False(t, errors.Is(err, ErrValidation))
+	})
+
+	t.Run("a killed vector is no verdict on the config", func(t *testing.T) {
+		lc := &LocalCheck{Config: cfg, Env: []corev1.EnvVar{{Name: "FAKE_KILL", Value: "1"}}, VectorBinary: fakeVector(t), Timeout: time.Minute}
+		_, err := lc.Run(ctx)
+		require.ErrorIs(t, err, ErrCheckAborted)
+		require.False(t, IsConfigInvalid(err))
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		lc := &LocalCheck{
+			Config:       cfg,
+			Env:          []corev1.EnvVar{{Name: "FAKE_SLEEP", Value: "10"}},
+			VectorBinary: fakeVector(t),
+			Timeout:      100 * time.Millisecond,
+		}
+		_, err := lc.Run(ctx)
+		require.ErrorIs(t, err, ErrConfigcheckTimeout)
+	})
+}
+
+func TestProbeVectorVersion(t *testing.T) {
+	ctx := context.Background()
+	script := func(output string) string {
+		path := filepath.Join(t.TempDir(), "vector")
+		require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\necho '"+output+"'\n"), 0o755))
+		return path
+	}
+
+	version, err := ProbeVectorVersion(ctx, script("vector 0.48.0 (x86_64-unknown-linux-gnu a1b2c3d 2025-06-30 16:35:11.503416563)"))
+	require.NoError(t, err)
+	require.Equal(t, "0.48.0", version)
+
+	_, err = ProbeVectorVersion(ctx, script("usage: vector [OPTIONS]"))
+	require.ErrorContains(t, err, "no version")
+	_, err = ProbeVectorVersion(ctx, filepath.Join(t.TempDir(), "missing"))
+	require.Error(t, err)
+}
+
+func TestImageVersion(t *testing.T) {
+	for image, want := range map[string]string{
+		"timberio/vector:0.48.0-distroless-libc":             "0.48.0",
+		"registry:5000/timberio/vector:0.47.1":               "0.47.1",
+		"timberio/vector:0.48.0-debian@sha256:0123456789abc": "0.48.0",
+		"timberio/vector:latest":                             "",
+		"timberio/vector:nightly-2025-06-30-debian":          "",
+		"registry:5000/timberio/vector":                      "",
+		"":                                                   "",
+	} {
+		require.Equal(t, want, imageVersion(image), image)
+	}
+}
+
+func TestSettingsNew(t *testing.T) {
+	for mode, want := range map[Mode]Validator{"": &ConfigCheck{}, ModeLocal: &LocalCheck{}} {
+		v := Settings{Mode: mode}.New(nil, nil, nil, &vectorv1alpha1.VectorCommon{}, "v", "ns", time.Minute, ConfigCheckInitiatorVector, nil)
+		require.IsType(t, &meteredValidator{}, v)
+		require.IsType(t, want, v.(*meteredValidator).next)
+	}
+
+	// vector would miss the variables of envFrom locally
+	envFrom := &vectorv1alpha1.VectorCommon{EnvFrom: []corev1.EnvFromSource{{SecretRef: &corev1.SecretEnvSource{}}}}
+	v := Settings{Mode: ModeLocal}.New(nil, nil, nil, envFrom, "v", "ns", time.Minute, ConfigCheckInitiatorVector, nil)
+	require.IsType(t, &ConfigCheck{}, v.(*meteredValidator).next)
+
+	_, err := ParseMode("container")
+	require.Error(t, err)
+}
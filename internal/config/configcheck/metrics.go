@@ -0,0 +1,145 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package configcheck
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	vectorv1alpha1 "github.com/kaasops/vector-operator/api/v1alpha1"
+)
+
+// The queue metrics are served on the manager's metrics endpoint, next to the
+// controller-runtime ones.
+var (
+	queueLimit = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "vector_operator",
+		Subsystem: "configcheck",
+		Name:      "queue_limit",
+		Help:      "How many configchecks may run at once; 0 when unbounded",
+	})
+	queueDepth = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "vector_operator",
+		Subsystem: "configcheck",
+		Name:      "queue_depth",
+		Help:      "The number of configchecks waiting for a slot",
+	})
+	runningChecks = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "vector_operator",
+		Subsystem: "configcheck",
+		Name:      "running",
+		Help:      "The number of configchecks holding a slot",
+	})
+	queueWait = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: "vector_operator",
+		Subsystem: "configcheck",
+		Name:      "queue_wait_seconds",
+		Help:      "How long configchecks waited for a slot",
+		Buckets:   []float64{0.1, 0.5, 1, 5, 10, 30, 60, 120, 300, 600},
+	})
+	configChecksCoalesced = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "vector_operator",
+		Subsystem: "configcheck",
+		Name:      "coalesced_total",
+		Help:      "The number of workload configchecks that coalesced into a check of the same workload already queued",
+	})
+	configCheckRuns = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "vector_operator",
+		Subsystem: "configcheck",
+		Name:      "runs_total",
+		Help:      "The number of configchecks run, by workload, initiator and outcome",
+	}, []string{"kind", "namespace", "name", "initiator", "outcome"})
+	configCheckDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "vector_operator",
+		Subsystem: "configcheck",
+		Name:      "duration_seconds",
+		Help:      "How long configchecks ran, not counting the wait for a slot",
+		Buckets:   []float64{0.5, 1, 2, 5, 10, 30, 60, 120, 300, 600},
+	}, []string{"kind", "namespace", "name"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(queueLimit, queueDepth, runningChecks, queueWait, configChecksCoalesced,
+		configCheckRuns, configCheckDuration)
+}
+
+// Outcomes of a configcheck run, the outcome label of runs_total.
+const (
+	outcomeValid       = "valid"
+	outcomeInvalid     = "invalid"
+	outcomeTestsFailed = "tests_failed"
+	outcomeUnstartable = "unstartable"
+	outcomeAborted     = "aborted"
+	outcomeSkipped     = "skipped"
+	outcomeError       = "error"
+)
+
+func checkOutcome(err error) string {
+	switch {
+	case err == nil:
+		return outcomeValid
+	case errors.Is(err, ErrTestsFailed):
+		return outcomeTestsFailed
+	case errors.Is(err, ErrPodUnstartable):
+		return outcomeUnstartable
+	case errors.Is(err, ErrCheckAborted):
+		return outcomeAborted
+	case errors.Is(err, ErrValidation):
+		return outcomeInvalid
+	case errors.Is(err, ErrConfigcheckSkipped):
+		return outcomeSkipped
+	}
+	return outcomeError
+}
+
+// meteredValidator records the runs of next. It sits innermost, under the queue and the
+// cache: a verdict from the cache and a check queued for its workload are not runs,
+// and the time spent waiting for a slot is queue_wait_seconds, not part of the duration.
+type meteredValidator struct {
+	workload  vectorv1alpha1.WorkloadReference
+	initiator string
+	next      Validator
+}
+
+func (mv *meteredValidator) Run(ctx context.Context) (string, error) {
+	start := time.Now()
+	reason, err := mv.next.Run(ctx)
+	w := mv.workload
+	configCheckDuration.WithLabelValues(w.Kind, w.Namespace, w.Name).Observe(time.Since(start).Seconds())
+	configCheckRuns.WithLabelValues(w.Kind, w.Namespace, w.Name, initiatorLabel(mv.initiator), checkOutcome(err)).Inc()
+	return reason, err
+}
+
+// initiatorLabel tells the checks of a workload's own config from those a pipeline
+// runs against it.
+func initiatorLabel(initiator string) string {
+	if initiator == ConfigCheckInitiatorPipieline {
+		return "pipeline"
+	}
+	return "workload"
+}
+
+// ForgetWorkload drops the configcheck series of a deleted workload.
+func ForgetWorkload(workload vectorv1alpha1.WorkloadReference) {
+	labels := prometheus.Labels{"kind": workload.Kind, "namespace": workload.Namespace, "name": workload.Name}
+	configCheckRuns.DeletePartialMatch(labels)
+	configCheckDuration.DeletePartialMatch(labels)
+}
@@ -0,0 +1,57 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package configcheck
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	vectorv1alpha1 "github.com/kaasops/vector-operator/api/v1alpha1"
+)
+
+func TestMeteredValidator(t *testing.T) {
+	workload := vectorv1alpha1.WorkloadReference{Kind: "VectorAggregator", Namespace: "team-a", Name: "metered"}
+	for _, tc := range []struct {
+		initiator string
+		err       error
+		labels    []string
+	}{
+		{ConfigCheckInitiatorVector, nil, []string{"workload", outcomeValid}},
+		{ConfigCheckInitiatorPipieline, ErrValidation, []string{"pipeline", outcomeInvalid}},
+		{ConfigCheckInitiatorPipieline, ErrTestsFailed, []string{"pipeline", outcomeTestsFailed}},
+		{ConfigCheckInitiatorVector, ErrPodUnstartable, []string{"workload", outcomeUnstartable}},
+		{ConfigCheckInitiatorVector, ErrCheckAborted, []string{"workload", outcomeAborted}},
+		{ConfigCheckInitiatorVector, ErrConfigcheckSkipped, []string{"workload", outcomeSkipped}},
+		{ConfigCheckInitiatorVector, errors.New("api down"), []string{"workload", outcomeError}},
+	} {
+		next := &countingValidator{err: tc.err}
+		_, err := (&meteredValidator{workload: workload, initiator: tc.initiator, next: next}).Run(context.Background())
+		require.Equal(t, tc.err, err)
+		require.Equal(t, 1, next.runs)
+		labels := append([]string{workload.Kind, workload.Namespace, workload.Name}, tc.labels...)
+		require.Equal(t, float64(1), testutil.ToFloat64(configCheckRuns.WithLabelValues(labels...)), tc.labels)
+	}
+
+	runs, durations := testutil.CollectAndCount(configCheckRuns), testutil.CollectAndCount(configCheckDuration)
+	ForgetWorkload(workload)
+	require.Equal(t, runs-7, testutil.CollectAndCount(configCheckRuns))
+	require.Equal(t, durations-1, testutil.CollectAndCount(configCheckDuration))
+}
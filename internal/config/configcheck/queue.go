@@ -0,0 +1,224 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package configcheck
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// DefaultMaxConcurrentChecks is how many checks a Queue runs at once by default.
+const DefaultMaxConcurrentChecks = 8
+
+// ErrConfigcheckQueued signals that a workload's check did not get a slot and was
+// queued instead of run. The queue wakes the workload up once a slot is kept for it, and
+// its next reconcile checks the config it builds then; the caller should drop the
+// config it has rather than report anything.
+var ErrConfigcheckQueued = errors.New("configcheck queued until a slot is free")
+
+// Queue bounds how many checks run at once across the operator. A storm of pipeline
+// changes makes every workload and pipeline reconcile check a config; without a bound
+// each one starts a configcheck pod of its own, all at once.
+//
+// Checks wait for a slot in arrival order. A check with a key (Claim) does not wait:
+// it takes a place in line for its workload and returns ErrConfigcheckQueued. Later
+// checks of the same workload coalesce into that place, so of the many configs a
+// workload builds while the queue is full only the latest is checked: once the place
+// comes up the slot is kept for the workload, which is woken up to rebuild and claim it.
+// A workload reconciler runs one reconcile at a time, so a blocking wait would also
+// hold up every other workload of its kind.
+type Queue struct {
+	mu      sync.Mutex
+	limit   int
+	hold    time.Duration
+	running int
+	waiting []*queueWaiter
+	held    map[string]*heldSlot
+}
+
+// queueWaiter is a check waiting for a slot. A blocking one (Acquire) has ready, closed
+// once it is given a slot; a keyed one (Claim) has wake instead.
+type queueWaiter struct {
+	key   string
+	wake  func()
+	start time.Time
+	ready chan struct{}
+}
+
+// heldSlot is a slot kept for a woken workload; timer gives it back if the workload
+// does not claim it in time.
+type heldSlot struct {
+	start time.Time
+	timer *time.Timer
+}
+
+// NewQueue returns a queue running up to limit checks at once; limit must be positive.
+// hold is how long a slot is kept for a woken workload before it is handed on: the
+// workload's reconcile may first wait for others of its kind.
+func NewQueue(limit int, hold time.Duration) *Queue {
+	queueLimit.Set(float64(limit))
+	return &Queue{limit: limit, hold: hold, held: map[string]*heldSlot{}}
+}
+
+// Acquire waits for a slot and returns the func giving it back. It returns the
+// context's error when it is done first.
+func (q *Queue) Acquire(ctx context.Context) (release func(), err error) {
+	start := time.Now()
+	q.mu.Lock()
+	if q.running < q.limit && len(q.waiting) == 0 {
+		q.running++
+		q.mu.Unlock()
+		return q.releaser(start), nil
+	}
+	w := &queueWaiter{ready: make(chan struct{})}
+	q.enqueue(w)
+	q.mu.Unlock()
+
+	select {
+	case <-w.ready:
+	case <-ctx.Done():
+		q.mu.Lock()
+		select {
+		case <-w.ready:
+			// given a slot meanwhile: hand it on
+			q.release()
+		default:
+			q.remove(w)
+		}
+		q.mu.Unlock()
+		return nil, ctx.Err()
+	}
+	return q.releaser(start), nil
+}
+
+// Claim returns the func giving back a slot for the workload with key: the one kept for
+// it, or a free one. Without either it queues the workload, or coalesces into its place
+// in line, and returns ErrConfigcheckQueued; wake is called once a slot is kept for it.
+func (q *Queue) Claim(key string, wake func()) (release func(), err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if slot, ok := q.held[key]; ok {
+		slot.timer.Stop()
+		delete(q.held, key)
+		return q.releaser(slot.start), nil
+	}
+	if q.running < q.limit && len(q.waiting) == 0 {
+		q.running++
+		return q.releaser(time.Now()), nil
+	}
+	for _, w := range q.waiting {
+		if w.key == key {
+			w.wake = wake
+			configChecksCoalesced.Inc()
+			return nil, ErrConfigcheckQueued
+		}
+	}
+	q.enqueue(&queueWaiter{key: key, wake: wake, start: time.Now()})
+	return nil, ErrConfigcheckQueued
+}
+
+// enqueue appends w.
+func (q *Queue) enqueue(w *queueWaiter) {
+	q.waiting = append(q.waiting, w)
+	queueDepth.Set(float64(len(q.waiting)))
+}
+
+// remove drops w from the waiters.
+func (q *Queue) remove(w *queueWaiter) {
+	for i, old := range q.waiting {
+		if old == w {
+			q.waiting = append(q.waiting[:i], q.waiting[i+1:]...)
+			break
+		}
+	}
+	queueDepth.Set(float64(len(q.waiting)))
+}
+
+// releaser records the wait of a check that got a slot, and returns the func giving
+// the slot back once; later calls do nothing.
+func (q *Queue) releaser(start time.Time) func() {
+	queueWait.Observe(time.Since(start).Seconds())
+	runningChecks.Inc()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			runningChecks.Dec()
+			q.mu.Lock()
+			q.release()
+			q.mu.Unlock()
+		})
+	}
+}
+
+// release hands a slot to the first waiter, or frees it. A keyed waiter gets the slot
+// kept for it for the hold time, and is woken up.
+func (q *Queue) release() {
+	if len(q.waiting) == 0 {
+		q.running--
+		return
+	}
+	w := q.waiting[0]
+	q.waiting = q.waiting[1:]
+	queueDepth.Set(float64(len(q.waiting)))
+	if w.wake == nil {
+		close(w.ready)
+		return
+	}
+	slot := &heldSlot{start: w.start}
+	slot.timer = time.AfterFunc(q.hold, func() {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		if q.held[w.key] == slot {
+			// the workload is gone or no longer needs a check
+			delete(q.held, w.key)
+			q.release()
+		}
+	})
+	q.held[w.key] = slot
+	go w.wake()
+}
+
+// queuedValidator runs next once the queue gives it a slot: a claimed one when it has a
+// key and wake, or one it waits for.
+type queuedValidator struct {
+	queue *Queue
+	key   string
+	wake  func()
+	next  Validator
+}
+
+func (qv *queuedValidator) Run(ctx context.Context) (string, error) {
+	var release func()
+	var err error
+	if qv.key != "" && qv.wake != nil {
+		release, err = qv.queue.Claim(qv.key, qv.wake)
+	} else {
+		release, err = qv.queue.Acquire(ctx)
+	}
+	if err != nil {
+		if errors.Is(err, ErrConfigcheckQueued) {
+			log.FromContext(ctx).Info("ConfigCheck queued until a slot is free", "key", qv.key)
+		}
+		return "", err
+	}
+	defer release()
+	return qv.next.Run(ctx)
+}
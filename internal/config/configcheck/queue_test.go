@@ -0,0 +1,210 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package configcheck
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	vectorv1alpha1 "github.com/kaasops/vector-operator/api/v1alpha1"
+)
+
+// queued waits until q has n checks in line.
+func queued(t *testing.T, q *Queue, n int) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		return len(q.waiting) == n
+	}, time.Second, time.Millisecond)
+}
+
+type acquired struct {
+	name    string
+	release func()
+	err     error
+}
+
+func acquire(q *Queue, ctx context.Context, name string, out chan<- acquired) {
+	go func() {
+		release, err := q.Acquire(ctx)
+		out <- acquired{name: name, release: release, err: err}
+	}()
+}
+
+func TestQueueLimitsAndOrders(t *testing.T) {
+	q := NewQueue(1, time.Minute)
+	ctx := context.Background()
+	first, err := q.Acquire(ctx)
+	require.NoError(t, err)
+
+	out := make(chan acquired, 2)
+	acquire(q, ctx, "second", out)
+	queued(t, q, 1)
+	acquire(q, ctx, "third", out)
+	queued(t, q, 2)
+	select {
+	case got := <-out:
+		t.Fatalf("%s ran over the limit", got.name)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	first()
+	first() // releasing twice gives back one slot
+	got := <-out
+	require.Equal(t, "second", got.name)
+	require.NoError(t, got.err)
+	queued(t, q, 1)
+	got.release()
+	got = <-out
+	require.Equal(t, "third", got.name)
+	got.release()
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	require.Zero(t, q.running)
+}
+
+// A workload without a free slot takes a place in line instead of waiting; its later
+// checks coalesce into that place, and once it comes up the slot is kept for the
+// workload and it is woken up to claim it.
+func TestQueueClaim(t *testing.T) {
+	q := NewQueue(1, time.Minute)
+	ctx := context.Background()
+	release, err := q.Acquire(ctx)
+	require.NoError(t, err)
+
+	woken := make(chan string, 4)
+	wake := func(name string) func() { return func() { woken <- name } }
+	_, err = q.Claim("Vector vector/agent", wake("old"))
+	require.ErrorIs(t, err, ErrConfigcheckQueued)
+	out := make(chan acquired, 1)
+	acquire(q, ctx, "blocking", out)
+	queued(t, q, 2)
+	_, err = q.Claim("Vector vector/agent", wake("new"))
+	require.ErrorIs(t, err, ErrConfigcheckQueued)
+	queued(t, q, 2)
+
+	release()
+	require.Equal(t, "new", <-woken, "the latest check is woken up")
+	select {
+	case got := <-out:
+		t.Fatalf("%s took the slot kept for the workload", got.name)
+	case <-time.After(20 * time.Millisecond):
+	}
+	claimed, err := q.Claim("Vector vector/agent", wake("unused"))
+	require.NoError(t, err)
+	queued(t, q, 1)
+
+	claimed()
+	got := <-out
+	require.Equal(t, "blocking", got.name)
+	require.NoError(t, got.err)
+	// a free slot is claimed right away
+	_, err = q.Claim("Vector vector/other", wake("other"))
+	require.ErrorIs(t, err, ErrConfigcheckQueued)
+	got.release()
+	require.Equal(t, "other", <-woken)
+	require.Empty(t, woken)
+}
+
+// A slot kept for a workload that never claims it is handed on after the hold time.
+func TestQueueClaimHold(t *testing.T) {
+	q := NewQueue(1, 20*time.Millisecond)
+	release, err := q.Acquire(context.Background())
+	require.NoError(t, err)
+	_, err = q.Claim("Vector vector/agent", func() {})
+	require.ErrorIs(t, err, ErrConfigcheckQueued)
+	out := make(chan acquired, 1)
+	acquire(q, context.Background(), "next", out)
+	queued(t, q, 2)
+
+	release()
+	got := <-out
+	require.Equal(t, "next", got.name)
+	require.NoError(t, got.err)
+	got.release()
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	require.Zero(t, q.running)
+	require.Empty(t, q.held)
+}
+
+// A check that gives up while waiting leaves the line.
+func TestQueueCancel(t *testing.T) {
+	q := NewQueue(1, time.Minute)
+	release, err := q.Acquire(context.Background())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	out := make(chan acquired, 2)
+	acquire(q, ctx, "cancelled", out)
+	queued(t, q, 1)
+	cancel()
+	got := <-out
+	require.ErrorIs(t, got.err, context.Canceled)
+	queued(t, q, 0)
+
+	acquire(q, context.Background(), "next", out)
+	queued(t, q, 1)
+	release()
+	got = <-out
+	require.Equal(t, "next", got.name)
+	got.release()
+}
+
+func TestQueuedValidator(t *testing.T) {
+	q := NewQueue(1, time.Minute)
+	release, err := q.Acquire(context.Background())
+	require.NoError(t, err)
+
+	next := &countingValidator{reason: "bad", err: ErrValidation}
+	woken := make(chan struct{}, 1)
+	settings := Settings{Mode: ModeLocal, Queue: q, QueueKey: "Vector vector/agent", QueueWake: func() { woken <- struct{}{} }}
+	keyed := &queuedValidator{queue: q, key: settings.QueueKey, wake: settings.QueueWake, next: next}
+	_, err = keyed.Run(context.Background())
+	require.ErrorIs(t, err, ErrConfigcheckQueued)
+	require.Zero(t, next.runs, "a queued check does not run")
+
+	// without a wake the check waits for its slot
+	waitingNext := &countingValidator{reason: "bad", err: ErrValidation}
+	waiting := &queuedValidator{queue: q, key: settings.QueueKey, next: waitingNext}
+	result := make(chan acquired, 1)
+	go func() {
+		reason, err := waiting.Run(context.Background())
+		result <- acquired{name: reason, err: err}
+	}()
+	queued(t, q, 2)
+
+	release()
+	<-woken
+	reason, err := keyed.Run(context.Background())
+	require.Equal(t, "bad", reason)
+	require.True(t, errors.Is(err, ErrValidation))
+	require.Equal(t, 1, next.runs)
+	got := <-result
+	require.Equal(t, "bad", got.name)
+	require.Equal(t, 1, waitingNext.runs)
+
+	_, ok := settings.New([]byte("{}"), nil, nil, &vectorv1alpha1.VectorCommon{}, "agent", "vector", time.Second, ConfigCheckInitiatorVector, nil).(*queuedValidator)
+	require.True(t, ok)
+}
@@ -0,0 +1,333 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package configcheck
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	corev1 "k8s.io/api/core/v1"
+	api_errors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	vectorv1alpha1 "github.com/kaasops/vector-operator/api/v1alpha1"
+	"github.com/kaasops/vector-operator/internal/utils/k8s"
+)
+
+const (
+	// MaxReportErrors is how many errors a ConfigCheckReport lists. The rest are counted
+	// in OmittedErrors.
+	MaxReportErrors = 10
+	// maxReportMessage is the length, in characters, messages are cut to.
+	maxReportMessage = 256
+	// maxOutputBytes is how much of a check's output, from the end, its ConfigMap keeps:
+	// well under the 1MiB an object can take.
+	maxOutputBytes = 512 << 10
+	// OutputKey is the ConfigMap key the output is stored under.
+	OutputKey = "output"
+)
+
+// Error kinds of a ConfigCheckError.
+const (
+	ErrorKindUnknownInput       = "UnknownInput"
+	ErrorKindNoInputs           = "NoInputs"
+	ErrorKindDataTypeMismatch   = "DataTypeMismatch"
+	ErrorKindDuplicateComponent = "DuplicateComponent"
+	ErrorKindVRL                = "VRL"
+	ErrorKindInvalidOption      = "InvalidOption"
+	ErrorKindComponent          = "Component"
+	ErrorKindConfig             = "Config"
+	// ErrorKindTest is a failed unit test of a pipeline (ErrTestsFailed).
+	ErrorKindTest = "TestFailed"
+)
+
+var (
+	// vector validate prints every error on a line of its own starting with "x" (a
+	// cross when the terminal takes unicode), continued on the lines that follow it.
+	errorLinePattern = regexp.MustCompile(`^(?:x|✗|×)\s+(.*)$`)
+	// the headers and markers vector validate prints around the errors
+	noiseLinePattern = regexp.MustCompile(`^(?:[-=]+|~\s.*|√\s.*|✓\s.*|Failed to load .*|Component errors|Health check.*)$`)
+	ansiPattern      = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+	inputForPattern    = regexp.MustCompile(`for (?:sink|transform) "([^"]+)"`)
+	mismatchPattern    = regexp.MustCompile(`Data type mismatch between \S+ \(.*?\) and (\S+) \(`)
+	componentPattern   = regexp.MustCompile(`^(?:Source|Transform|Sink|Enrichment table|Secret) "([^"]+)"`)
+	keyPathPattern     = regexp.MustCompile("`(?:sources|transforms|sinks)\\.([^`.]+)")
+	quotedPattern      = regexp.MustCompile(`"([^"]+)"`)
+	vrlErrorPattern    = regexp.MustCompile(`error\[E\d+\]`)
+	invalidOptionWords = []string{"unknown field", "unknown variant", "missing field", "invalid type", "invalid value", "data did not match any variant"}
+
+	// vector test lists the failed tests after a "failures:" line, each under a "test
+	// <name>:" header, with one "check..." line per failed check followed by the
+	// conditions it failed and the events it saw.
+	testFailuresPattern  = regexp.MustCompile(`^failures:$`)
+	testHeaderPattern    = regexp.MustCompile(`^test (.+):$`)
+	testCheckPattern     = regexp.MustCompile(`^check\b`)
+	testPayloadPattern   = regexp.MustCompile(`^payloads\b`)
+	testTransformPattern = regexp.MustCompile(`transforms? \[?["']([^"']+)["']`)
+)
+
+// ParseOutput splits the output of a failed vector validate into its errors, in the
+// order vector reports them, with duplicates dropped. The output of a failed vector
+// test yields a TestFailed error per failed check. Output holding no error line at
+// all, such as a crash, yields a single Config error with its last line. Pipeline is
+// left empty: only the caller knows which pipelines generated which components.
+func ParseOutput(output string) []vectorv1alpha1.ConfigCheckError {
+	lines := strings.Split(ansiPattern.ReplaceAllString(output, ""), "\n")
+	if errs := parseTestFailures(lines); len(errs) > 0 {
+		return errs
+	}
+	var entries [][]string
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t\r")
+		trimmed := strings.TrimSpace(line)
+		if m := errorLinePattern.FindStringSubmatch(trimmed); m != nil {
+			entries = append(entries, []string{m[1]})
+			continue
+		}
+		if trimmed == "" || noiseLinePattern.MatchString(trimmed) {
+			continue
+		}
+		if n := len(entries); n > 0 {
+			entries[n-1] = append(entries[n-1], trimmed)
+		}
+	}
+	if len(entries) == 0 {
+		if last := lastLine(output); last != "" {
+			entries = [][]string{{last}}
+		}
+	}
+
+	var errs []vectorv1alpha1.ConfigCheckError
+	seen := make(map[vectorv1alpha1.ConfigCheckError]bool)
+	for _, lines := range entries {
+		e := parseError(lines)
+		if !seen[e] {
+			seen[e] = true
+			errs = append(errs, e)
+		}
+	}
+	return errs
+}
+
+// parseTestFailures parses the failures vector test lists, if lines are its output: a
+// TestFailed error per failed check, its message the test name, the check and the
+// conditions it failed, and its component the first transform the check names.
+func parseTestFailures(lines []string) []vectorv1alpha1.ConfigCheckError {
+	var test string
+	var entries [][]string
+	inFailures, inPayloads := false, false
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case !inFailures:
+			inFailures = testFailuresPattern.MatchString(trimmed)
+			continue
+		case trimmed == "":
+			continue
+		}
+		if m := testHeaderPattern.FindStringSubmatch(trimmed); m != nil {
+			test, inPayloads = m[1], false
+			entries = append(entries, []string{"test " + test + ":"})
+			continue
+		}
+		if test == "" {
+			continue
+		}
+		if testCheckPattern.MatchString(trimmed) {
+			inPayloads = false
+			if n := len(entries); len(entries[n-1]) > 1 {
+				entries = append(entries, []string{"test " + test + ":"})
+			}
+		}
+		if testPayloadPattern.MatchString(trimmed) {
+			inPayloads = true
+		}
+		if !inPayloads {
+			entries[len(entries)-1] = append(entries[len(entries)-1], trimmed)
+		}
+	}
+
+	var errs []vectorv1alpha1.ConfigCheckError
+	seen := make(map[vectorv1alpha1.ConfigCheckError]bool)
+	for _, lines := range entries {
+		text := strings.Join(strings.Fields(strings.Join(lines, " ")), " ")
+		e := vectorv1alpha1.ConfigCheckError{Kind: ErrorKindTest, Message: truncateMessage(text)}
+		if m := testTransformPattern.FindStringSubmatch(text); m != nil {
+			e.Component = m[1]
+		}
+		if !seen[e] {
+			seen[e] = true
+			errs = append(errs, e)
+		}
+	}
+	return errs
+}
+
+func parseError(lines []string) vectorv1alpha1.ConfigCheckError {
+	text := strings.Join(strings.Fields(strings.Join(lines, " ")), " ")
+	e := vectorv1alpha1.ConfigCheckError{Kind: ErrorKindConfig, Message: truncateMessage(text)}
+	switch {
+	case strings.Contains(text, "doesn't match any components"):
+		e.Kind = ErrorKindUnknownInput
+	case strings.Contains(text, "has no inputs"):
+		e.Kind = ErrorKindNoInputs
+	case strings.Contains(text, "Data type mismatch"):
+		e.Kind = ErrorKindDataTypeMismatch
+	case strings.Contains(text, "More than one component with name"):
+		e.Kind = ErrorKindDuplicateComponent
+	case vrlErrorPattern.MatchString(text):
+		e.Kind = ErrorKindVRL
+	case containsAny(text, invalidOptionWords):
+		e.Kind = ErrorKindInvalidOption
+	}
+
+	for _, p := range []*regexp.Regexp{inputForPattern, mismatchPattern, componentPattern, keyPathPattern, quotedPattern} {
+		if m := p.FindStringSubmatch(text); m != nil {
+			e.Component = m[1]
+			break
+		}
+	}
+	if e.Kind == ErrorKindConfig && componentPattern.MatchString(text) {
+		e.Kind = ErrorKindComponent
+	}
+	return e
+}
+
+// NewReport parses output into a ConfigCheckReport, attributing each component to a
+// pipeline with pipelineOf, which returns "" for a component of no single pipeline. The
+// errors beyond MaxReportErrors are counted, not listed.
+func NewReport(output string, pipelineOf func(component string) string) *vectorv1alpha1.ConfigCheckReport {
+	errs := ParseOutput(output)
+	report := &vectorv1alpha1.ConfigCheckReport{}
+	if len(errs) > MaxReportErrors {
+		report.OmittedErrors = int32(len(errs) - MaxReportErrors)
+		errs = errs[:MaxReportErrors]
+	}
+	for i := range errs {
+		if errs[i].Component != "" && pipelineOf != nil {
+			errs[i].Pipeline = pipelineOf(errs[i].Component)
+		}
+	}
+	report.Errors = errs
+	return report
+}
+
+// Summary renders report on one line for .status.reason, "configcheck failed:" followed
+// by the errors and where to find the whole output.
+func Summary(report *vectorv1alpha1.ConfigCheckReport) string {
+	parts := make([]string, 0, len(report.Errors))
+	for _, e := range report.Errors {
+		var b strings.Builder
+		if e.Component != "" {
+			b.WriteString(e.Component)
+			if e.Pipeline != "" {
+				fmt.Fprintf(&b, " (pipeline %s)", e.Pipeline)
+			}
+			b.WriteString(": ")
+		}
+		fmt.Fprintf(&b, "%s: %s", e.Kind, e.Message)
+		parts = append(parts, b.String())
+	}
+	summary := "configcheck failed: " + strings.Join(parts, "; ")
+	if report.OmittedErrors > 0 {
+		summary += fmt.Sprintf("; and %d more", report.OmittedErrors)
+	}
+	if o := report.Output; o != nil {
+		summary += fmt.Sprintf(" (full output in ConfigMap %s/%s, key %s)", o.Namespace, o.Name, o.Key)
+	}
+	return summary
+}
+
+// OutputConfigMapName is the name of the ConfigMap keeping the output of the last failed
+// configcheck of the object of kind and name.
+func OutputConfigMapName(kind, name string) string {
+	return fmt.Sprintf("%s-%s-configcheck", strings.ToLower(kind), name)
+}
+
+// StoreOutput writes the last maxOutputBytes of output to the ConfigMap nn, owned by
+// owner so that it goes with it. owner must be cluster-scoped or live in nn's namespace.
+// reader is read through instead of c's cache, which would otherwise start watching
+// every ConfigMap of the cluster.
+func StoreOutput(ctx context.Context, reader client.Reader, c client.Client, owner client.Object, nn types.NamespacedName, output string) (*vectorv1alpha1.ConfigCheckOutputReference, error) {
+	if len(output) > maxOutputBytes {
+		output = output[len(output)-maxOutputBytes:]
+	}
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cm := &corev1.ConfigMap{}
+		err := reader.Get(ctx, nn, cm)
+		if api_errors.IsNotFound(err) {
+			cm = &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      nn.Name,
+					Namespace: nn.Namespace,
+					Labels: map[string]string{
+						k8s.ManagedByLabelKey: "vector-operator",
+						k8s.NameLabelKey:      "vector-configcheck-output",
+					},
+				},
+				Data: map[string]string{OutputKey: output},
+			}
+			if err := controllerutil.SetOwnerReference(owner, cm, c.Scheme()); err != nil {
+				return err
+			}
+			return c.Create(ctx, cm)
+		}
+		if err != nil {
+			return err
+		}
+		cm.Data = map[string]string{OutputKey: output}
+		if err := controllerutil.SetOwnerReference(owner, cm, c.Scheme()); err != nil {
+			return err
+		}
+		return c.Update(ctx, cm)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &vectorv1alpha1.ConfigCheckOutputReference{Namespace: nn.Namespace, Name: nn.Name, Key: OutputKey}, nil
+}
+
+// truncateMessage cuts s to maxReportMessage characters, on a rune boundary.
+func truncateMessage(s string) string {
+	if utf8.RuneCountInString(s) <= maxReportMessage {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:maxReportMessage-3]) + "..."
+}
+
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(ansiPattern.ReplaceAllString(s, "")), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
+
+func containsAny(s string, words []string) bool {
+	for _, w := range words {
+		if strings.Contains(s, w) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package configcheck
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	vectorv1alpha1 "github.com/kaasops/vector-operator/api/v1alpha1"
+)
+
+// validateOutput is what vector validate prints for a config with several errors.
+const validateOutput = "\x1b[31mFailed to load [\"/etc/vector/agent.json\"]\x1b[0m\n" +
+	"----------------------------------------\n" +
+	"x Input \"team-a-web-logs\" for sink \"team-a-web-out\" doesn't match any components.\n" +
+	"x Transform \"team-a-api-parse\" has no inputs\n" +
+	"x Data type mismatch between team-a-db-metrics ([\"Metric\"]) and team-a-db-out ([\"Log\"])\n" +
+	"x More than one component with name \"team-a-web-logs\" (source, transform).\n" +
+	"x Transform \"team-b-app-parse\":\n" +
+	"error[E103]: unhandled fallible assignment\n" +
+	"  ┌─ :1:5\n" +
+	"  │\n" +
+	"1 │ . = parse_json(.message)\n" +
+	"\n" +
+	"x unknown field `bogus`, expected one of `endpoint`, `inputs` for key `sinks.team-b-app-out`\n" +
+	"x Sink \"output_team-a_central\": invalid endpoint\n" +
+	"x Configuration error.\n" +
+	"x Configuration error.\n" +
+	"~ Health check for \"team-a-web-out\" failed\n"
+
+func TestParseOutput(t *testing.T) {
+	require.Equal(t, []vectorv1alpha1.ConfigCheckError{
+		{Component: "team-a-web-out", Kind: ErrorKindUnknownInput, Message: `Input "team-a-web-logs" for sink "team-a-web-out" doesn't match any components.`},
+		{Component: "team-a-api-parse", Kind: ErrorKindNoInputs, Message: `Transform "team-a-api-parse" has no inputs`},
+		{Component: "team-a-db-out", Kind: ErrorKindDataTypeMismatch, Message: `Data type mismatch between team-a-db-metrics (["Metric"]) and team-a-db-out (["Log"])`},
+		{Component: "team-a-web-logs", Kind: ErrorKindDuplicateComponent, Message: `More than one component with name "team-a-web-logs" (source, transform).`},
+		{Component: "team-b-app-parse", Kind: ErrorKindVRL, Message: `Transform "team-b-app-parse": error[E103]: unhandled fallible assignment ┌─ :1:5 │ 1 │ . = parse_json(.message)`},
+		{Component: "team-b-app-out", Kind: ErrorKindInvalidOption, Message: "unknown field `bogus`, expected one of `endpoint`, `inputs` for key `sinks.team-b-app-out`"},
+		{Component: "output_team-a_central", Kind: ErrorKindComponent, Message: `Sink "output_team-a_central": invalid endpoint`},
+		{Kind: ErrorKindConfig, Message: "Configuration error."},
+	}, ParseOutput(validateOutput))
+
+	// no error line: the last line says the most
+	require.Equal(t, []vectorv1alpha1.ConfigCheckError{{Kind: ErrorKindConfig, Message: "thread 'main' panicked"}},
+		ParseOutput("2024-01-01T00:00:00Z INFO vector: starting\nthread 'main' panicked\n"))
+	require.Empty(t, ParseOutput(""))
+}
+
+// testOutput is what vector test prints when checks of two tests fail.
+const testOutput = "Running tests\n" +
+	"test team-a/web: parses json ... \x1b[31mfailed\x1b[0m\n" +
+	"test team-a/web: drops debug ... \x1b[31mfailed\x1b[0m\n" +
+	"test team-a/web: keeps errors ... \x1b[32mpassed\x1b[0m\n" +
+	"\n" +
+	"failures:\n" +
+	"\n" +
+	"test team-a/web: parses json:\n" +
+	"\n" +
+	"check[0] for transforms [\"team-a-web-parse\"] failed conditions:\n" +
+	"  condition[0]: source: .level == \"info\"\n" +
+	"payloads (events encoded as JSON):\n" +
+	"  input: {\"message\":\"{\\\"level\\\":\\\"debug\\\"}\"}\n" +
+	"  output: {\"level\":\"debug\"}\n" +
+	"\n" +
+	"check[1] for transforms [\"team-a-web-route.errors\"] failed, no events received.\n" +
+	"\n" +
+	"test team-a/web: drops debug:\n" +
+	"\n" +
+	"check transform 'team-a-web-filter' failed, expected no outputs\n"
+
+func TestParseTestOutput(t *testing.T) {
+	require.Equal(t, []vectorv1alpha1.ConfigCheckError{
+		{Component: "team-a-web-parse", Kind: ErrorKindTest, Message: `test team-a/web: parses json: check[0] for transforms ["team-a-web-parse"] failed conditions: condition[0]: source: .level == "info"`},
+		{Component: "team-a-web-route.errors", Kind: ErrorKindTest, Message: `test team-a/web: parses json: check[1] for transforms ["team-a-web-route.errors"] failed, no events received.`},
+		{Component: "team-a-web-filter", Kind: ErrorKindTest, Message: `test team-a/web: drops debug: check transform 'team-a-web-filter' failed, expected no outputs`},
+	}, ParseOutput(testOutput))
+}
+
+func TestNewReport(t *testing.T) {
+	pipelineOf := func(component string) string {
+		if strings.HasPrefix(component, "team-a-web-") {
+			return "team-a/web"
+		}
+		return ""
+	}
+	report := NewReport(validateOutput, pipelineOf)
+	require.Len(t, report.Errors, 8)
+	require.Equal(t, "team-a/web", report.Errors[0].Pipeline)
+	require.Empty(t, report.Errors[1].Pipeline)
+
+	var many strings.Builder
+	for i := range MaxReportErrors + 5 {
+		many.WriteString("x Sink \"s" + strings.Repeat("x", i) + "\": " + strings.Repeat("long ", 100) + "\n")
+	}
+	report = NewReport(many.String(), nil)
+	require.Len(t, report.Errors, MaxReportErrors)
+	require.EqualValues(t, 5, report.OmittedErrors)
+	require.Equal(t, maxReportMessage, len([]rune(report.Errors[0].Message)))
+	require.Equal(t, report, NewReport(many.String(), nil), "the same output makes the same report")
+
+	report.Output = &vectorv1alpha1.ConfigCheckOutputReference{Namespace: "vector", Name: "vector-agent-configcheck", Key: OutputKey}
+	summary := Summary(report)
+	require.True(t, strings.HasPrefix(summary, `configcheck failed: s: Component: Sink "s": long`))
+	require.True(t, strings.HasSuffix(summary, "; and 5 more (full output in ConfigMap vector/vector-agent-configcheck, key output)"))
+}
+
+func TestStoreOutput(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	require.NoError(t, vectorv1alpha1.AddToScheme(scheme))
+	c := fake.NewClientBuilder().WithScheme(scheme).Build()
+	owner := &vectorv1alpha1.Vector{ObjectMeta: metav1.ObjectMeta{Name: "agent", Namespace: "vector", UID: "uid"}}
+	nn := types.NamespacedName{Namespace: "vector", Name: OutputConfigMapName("Vector", "agent")}
+
+	ref, err := StoreOutput(ctx, c, c, owner, nn, "first")
+	require.NoError(t, err)
+	require.Equal(t, &vectorv1alpha1.ConfigCheckOutputReference{Namespace: "vector", Name: "vector-agent-configcheck", Key: OutputKey}, ref)
+
+	long := strings.Repeat("a", maxOutputBytes) + "tail"
+	_, err = StoreOutput(ctx, c, c, owner, nn, long)
+	require.NoError(t, err)
+
+	cm := &corev1.ConfigMap{}
+	require.NoError(t, c.Get(ctx, nn, cm))
+	require.Len(t, cm.Data[OutputKey], maxOutputBytes)
+	require.True(t, strings.HasSuffix(cm.Data[OutputKey], "tail"), "the end of the output is kept")
+	require.Len(t, cm.OwnerReferences, 1)
+	require.Equal(t, "Vector", cm.OwnerReferences[0].Kind)
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package configcheck
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	vectorv1alpha1 "github.com/kaasops/vector-operator/api/v1alpha1"
+)
+
+// Validator checks a generated Vector config. Run returns a non-empty reason with
+// ErrValidation for an invalid config, and ErrConfigcheckSkipped or any other error when
+// the config could not be checked.
+type Validator interface {
+	Run(ctx context.Context) (reason string, err error)
+}
+
+var (
+	_ Validator = &ConfigCheck{}
+	_ Validator = &LocalCheck{}
+)
+
+// Mode selects the Validator the reconcilers run.
+type Mode string
+
+const (
+	// ModePod runs every check in a configcheck pod, with the workload's image, env and
+	// volumes (ConfigCheck).
+	ModePod Mode = "pod"
+	// ModeLocal runs every check in the operator's process (LocalCheck), but those of
+	// a workload with envFrom: the names of its variables are only known in the pod.
+	ModeLocal Mode = "local"
+)
+
+// ParseMode parses the --configcheck-mode flag.
+func ParseMode(s string) (Mode, error) {
+	switch m := Mode(s); m {
+	case ModePod, ModeLocal:
+		return m, nil
+	}
+	return "", fmt.Errorf("unknown configcheck mode %q: must be %s or %s", s, ModePod, ModeLocal)
+}
+
+// Settings is the operator-wide validator configuration. The zero value runs pods.
+type Settings struct {
+	Mode Mode
+	// VectorBinary is the binary ModeLocal runs; empty means DefaultVectorBinary.
+	VectorBinary string
+	// VectorVersion is the version of VectorBinary, probed once at startup.
+	VectorVersion string
+	// Cache, when set, answers checks it already has a verdict for (CacheKey).
+	Cache *Cache
+	// ForceKey is mixed into the cache key, so a new value always runs a check.
+	ForceKey string
+	// Queue, when set, bounds how many checks run at once. Cached verdicts do not wait.
+	Queue *Queue
+	// QueueKey identifies the workload whose config is checked and QueueWake wakes it
+	// up: with both set a check without a free slot returns ErrConfigcheckQueued and
+	// coalesces with the workload's later checks (Queue.Claim). Without them a check
+	// waits for its slot, for checks of configs that do not replace one another.
+	QueueKey  string
+	QueueWake func()
+	// Workload is the workload whose config is checked, labelling the check in the
+	// configcheck metrics.
+	Workload vectorv1alpha1.WorkloadReference
+}
+
+// New returns the Validator for config, taking the same arguments as New does for the
+// pod-based check.
+func (s Settings) New(
+	config []byte,
+	c client.Client,
+	cs kubernetes.Interface,
+	vc *vectorv1alpha1.VectorCommon,
+	name, namespace string,
+	timeout time.Duration,
+	initiator string,
+	secretAssets map[string][]byte,
+) Validator {
+	var v Validator
+	var key string
+	if s.Mode == ModeLocal && len(vc.EnvFrom) == 0 {
+		lc := &LocalCheck{
+			Config:        config,
+			SecretAssets:  secretAssets,
+			Env:           vc.Env,
+			VectorBinary:  s.VectorBinary,
+			VectorVersion: s.VectorVersion,
+			WorkloadImage: vc.Image,
+			Timeout:       timeout,
+			Initiator:     initiator,
+		}
+		v = lc
+		if s.Cache != nil {
+			// the version, not just the binary's name: an upgraded vector may judge the
+			// persisted verdicts differently
+			key = CacheKey(ModeLocal, s.validatorKey(lc.VectorBinary+"\x00"+lc.VectorVersion), lc.environ(), config, secretAssets)
+		}
+	} else {
+		cc := New(config, c, cs, vc, name, namespace, timeout, initiator, secretAssets)
+		v = cc
+		if s.Cache != nil {
+			// what the pod validates with besides the config; placement (tolerations,
+			// resources) and metadata do not change the verdict
+			key = CacheKey(ModePod, s.validatorKey(cc.Image), struct {
+				Env          []corev1.EnvVar
+				EnvFrom      []corev1.EnvFromSource
+				Volumes      []corev1.Volume
+				VolumeMounts []corev1.VolumeMount
+			}{cc.Envs, cc.EnvFrom, cc.Volumes, cc.VolumeMounts}, config, secretAssets)
+		}
+	}
+	v = &meteredValidator{workload: s.Workload, initiator: initiator, next: v}
+	if s.Queue != nil {
+		v = &queuedValidator{queue: s.Queue, key: s.QueueKey, wake: s.QueueWake, next: v}
+	}
+	if s.Cache == nil {
+		return v
+	}
+	return &cachedValidator{cache: s.Cache, key: key, next: v}
+}
+
+// validatorKey is the validator part of the cache key: the image or binary, and
+// ForceKey when set. Keys without one stay what they were.
+func (s Settings) validatorKey(validator string) string {
+	if s.ForceKey == "" {
+		return validator
+	}
+	return validator + "\x00" + s.ForceKey
+}
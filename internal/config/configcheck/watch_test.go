@@ -110,6 +110,49 @@ func TestGetCheckResultHandlesInitialAddedEvent(t *testing.T) {
 	}
 }
 
+// A failed pod is a failed validation, unless it is the tests that failed after the
+// config validated, or the pod failed before vector got to the config.
+func TestGetCheckResultTellsFailedTestsApart(t *testing.T) {
+	exited := func(name string, code int32) corev1.ContainerStatus {
+		return corev1.ContainerStatus{Name: name, State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: code, Reason: "Error"}}}
+	}
+	oomKilled := exited(configCheckContainerName, 137)
+	oomKilled.State.Terminated.Reason = "OOMKilled"
+	for name, tc := range map[string]struct {
+		init, containers []corev1.ContainerStatus
+		podReason        string
+		want             error
+	}{
+		"validation":              {containers: []corev1.ContainerStatus{exited(configCheckContainerName, 78)}, want: ErrValidation},
+		"validation before tests": {init: []corev1.ContainerStatus{exited(configCheckContainerName, 78)}, want: ErrValidation},
+		"tests":                   {init: []corev1.ContainerStatus{exited(configCheckContainerName, 0)}, containers: []corev1.ContainerStatus{exited(configTestContainerName, 78)}, want: ErrTestsFailed},
+		"oom killed":              {containers: []corev1.ContainerStatus{oomKilled}, want: ErrCheckAborted},
+		"evicted":                 {podReason: "Evicted", want: ErrCheckAborted},
+	} {
+		t.Run(name, func(t *testing.T) {
+			fw, pod, res := startGetCheckResult(t, 5*time.Second)
+
+			failed := pod.DeepCopy()
+			failed.Status.Phase = corev1.PodFailed
+			failed.Status.Reason = tc.podReason
+			failed.Status.InitContainerStatuses = tc.init
+			failed.Status.ContainerStatuses = tc.containers
+			fw.Modify(failed)
+
+			r := waitResult(t, res, 2*time.Second)
+			if !errors.Is(r.err, tc.want) {
+				t.Fatalf("want %v, got err=%v reason=%q", tc.want, r.err, r.reason)
+			}
+			if verdict := tc.want != ErrCheckAborted; IsConfigInvalid(r.err) != verdict {
+				t.Fatalf("want a verdict on the config %v, got err=%v", verdict, r.err)
+			}
+			if errors.Is(r.err, ErrTestsFailed) != (tc.want == ErrTestsFailed) {
+				t.Fatalf("want tests failed %v, got err=%v", tc.want == ErrTestsFailed, r.err)
+			}
+		})
+	}
+}
+
 // If the configcheck pod is deleted before completing (namespace teardown, manual
 // cleanup), the check can never produce a result — bail out instead of waiting.
 func TestGetCheckResultFailsWhenPodDeleted(t *testing.T) {
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	vectorv1alpha1 "github.com/kaasops/vector-operator/api/v1alpha1"
+	"github.com/kaasops/vector-operator/internal/common"
+	"github.com/kaasops/vector-operator/internal/pipeline"
+)
+
+// enrichTransformPrefix names the remap transforms stamping pipeline metadata. Generated
+// component names are "<namespace>-<pipeline>-<component>" of lowercase Kubernetes names,
+// so the upper case letter keeps them clear of any name a pipeline can produce.
+const enrichTransformPrefix = "enrichMetadata"
+
+// metricSourceTypes are the sources that only emit metric events. A metric has no
+// arbitrary fields to stamp (remap can only set its tags), so they are not enriched.
+var metricSourceTypes = map[string]struct{}{
+	ApacheMetricsType:         {},
+	EventStoreDBMetricsType:   {},
+	HostMetricsType:           {},
+	InternalMetricsType:       {},
+	MongoDBMetricsType:        {},
+	NginxMetricsType:          {},
+	PostgreSQLMetricsType:     {},
+	PrometheusPushgatewayType: {},
+	PrometheusRemoteWriteType: {},
+	PrometheusScrapeType:      {},
+	StatsDType:                {},
+}
+
+// enrichMetadata reports whether the sources of p get a metadata enrichment transform:
+// the operator-wide flag (params.EnrichMetadata) unless p opts out with the
+// metadata-enrichment=disabled annotation, or p opting in with =enabled.
+func enrichMetadata(params VectorConfigParams, p pipeline.Pipeline) bool {
+	return EnrichesMetadata(params.EnrichMetadata, p)
+}
+
+// EnrichesMetadata reports whether p gets a metadata enrichment transform, with
+// enabled the operator-wide flag. The transform stamps p's labels into the config, so
+// a label change of such a pipeline changes the config too.
+func EnrichesMetadata(enabled bool, p pipeline.Pipeline) bool {
+	switch p.GetAnnotations()[common.AnnotationMetadataEnrichment] {
+	case common.AnnotationValueEnabled:
+		return true
+	case common.AnnotationValueDisabled:
+		return false
+	}
+	return enabled
+}
+
+// enrichPipelineSources adds a remap transform after every source of p stamping the
+// pipeline's identity into .vector_operator, and returns the generated source name to
+// transform name mapping for rewriteInputs: the pipeline's own transforms and sinks read
+// the enriched stream instead of the source. Must run before optimizeAgentSources, which
+// then rewrites the transform's input like any other consumer of a collapsed source.
+func enrichPipelineSources(cfg *VectorConfig, p pipeline.Pipeline, sources map[string]*Source) map[string]string {
+	program := enrichProgram(p)
+	enriched := make(map[string]string, len(sources))
+	for k, v := range sources {
+		if _, ok := metricSourceTypes[v.Type]; ok {
+			continue
+		}
+		source := addPrefix(p.GetNamespace(), p.GetName(), k)
+		name := fmt.Sprintf("%s-%s", enrichTransformPrefix, source)
+		cfg.Transforms[name] = &Transform{
+			Name:    name,
+			Type:    RemapTransformType,
+			Inputs:  []string{source},
+			Options: map[string]any{"source": program},
+		}
+		enriched[source] = name
+	}
+	return enriched
+}
+
+// enrichProgram is the VRL stamping p's kind, name, namespace and labels. Names and
+// label keys and values are restricted to characters that need no escaping beyond
+// quoting, so strconv.Quote yields valid VRL string literals.
+func enrichProgram(p pipeline.Pipeline) string {
+	kind := "ClusterVectorPipeline"
+	if _, ok := p.(*vectorv1alpha1.VectorPipeline); ok {
+		kind = "VectorPipeline"
+	}
+	lines := []string{
+		fmt.Sprintf(".vector_operator.kind = %s", strconv.Quote(kind)),
+		fmt.Sprintf(".vector_operator.pipeline = %s", strconv.Quote(p.GetName())),
+	}
+	if ns := p.GetNamespace(); ns != "" {
+		lines = append(lines, fmt.Sprintf(".vector_operator.namespace = %s", strconv.Quote(ns)))
+	}
+	labels := p.GetLabels()
+	fields := make([]string, 0, len(labels))
+	for _, k := range slices.Sorted(maps.Keys(labels)) {
+		fields = append(fields, fmt.Sprintf("%s: %s", strconv.Quote(k), strconv.Quote(labels[k])))
+	}
+	lines = append(lines, fmt.Sprintf(".vector_operator.labels = {%s}", strings.Join(fields, ", ")))
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,99 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	vectorv1alpha1 "github.com/kaasops/vector-operator/api/v1alpha1"
+	"github.com/kaasops/vector-operator/internal/common"
+)
+
+func TestEnrichMetadataAgent(t *testing.T) {
+	p := testPipeline("team-a", "pipe",
+		`{"logs": {"type": "kubernetes_logs"}}`,
+		`{"out": {"type": "blackhole", "inputs": ["logs", "parse"]}}`).(*vectorv1alpha1.VectorPipeline)
+	p.Labels = map[string]string{"team": "a", "app.kubernetes.io/name": "web"}
+	p.Spec.Transforms = &runtime.RawExtension{Raw: []byte(`{"parse": {"type": "remap", "inputs": ["logs"], "source": "."}}`)}
+
+	cfg, _, err := BuildAgentConfig(VectorConfigParams{EnrichMetadata: true}, p)
+	require.NoError(t, err)
+
+	enrich := cfg.Transforms["enrichMetadata-team-a-pipe-logs"]
+	require.NotNil(t, enrich)
+	assert.Equal(t, RemapTransformType, enrich.Type)
+	assert.Equal(t, []string{"team-a-pipe-logs"}, enrich.Inputs)
+	assert.Equal(t, `.vector_operator.kind = "VectorPipeline"
+.vector_operator.pipeline = "pipe"
+.vector_operator.namespace = "team-a"
+.vector_operator.labels = {"app.kubernetes.io/name": "web", "team": "a"}`, enrich.Options["source"])
+
+	// the pipeline's own components read the enriched stream, transforms stay as they are
+	assert.Equal(t, []string{enrich.Name}, cfg.Transforms["team-a-pipe-parse"].Inputs)
+	assert.Equal(t, []string{enrich.Name, "team-a-pipe-parse"}, cfg.Sinks["team-a-pipe-out"].Inputs)
+}
+
+func TestEnrichMetadataAnnotation(t *testing.T) {
+	optIn := testLogPipeline("ns-a").(*vectorv1alpha1.VectorPipeline)
+	optIn.Annotations = map[string]string{common.AnnotationMetadataEnrichment: common.AnnotationValueEnabled}
+	optOut := testLogPipeline("ns-b").(*vectorv1alpha1.VectorPipeline)
+	optOut.Annotations = map[string]string{common.AnnotationMetadataEnrichment: common.AnnotationValueDisabled}
+	plain := testLogPipeline("ns-c")
+
+	cfg, _, err := BuildAgentConfig(VectorConfigParams{}, optIn, optOut, plain)
+	require.NoError(t, err)
+	assert.Contains(t, cfg.Transforms, "enrichMetadata-ns-a-pipe-logs")
+	assert.Len(t, cfg.Transforms, 1)
+
+	cfg, _, err = BuildAgentConfig(VectorConfigParams{EnrichMetadata: true}, optIn, optOut, plain)
+	require.NoError(t, err)
+	assert.Contains(t, cfg.Transforms, "enrichMetadata-ns-a-pipe-logs")
+	assert.Contains(t, cfg.Transforms, "enrichMetadata-ns-c-pipe-logs")
+	assert.Len(t, cfg.Transforms, 2)
+	assert.Equal(t, []string{"ns-b-pipe-logs"}, cfg.Sinks["ns-b-pipe-out"].Inputs)
+}
+
+// With the sources optimization the enrichment transforms consume the route outputs,
+// and the sinks keep reading the enrichment transforms.
+func TestEnrichMetadataOptimizedSources(t *testing.T) {
+	cfg, _, err := BuildAgentConfig(VectorConfigParams{EnrichMetadata: true, OptimizeSources: true},
+		testLogPipeline("ns-a"), testLogPipeline("ns-b"))
+	require.NoError(t, err)
+
+	require.Len(t, cfg.Sources, 1)
+	for _, ns := range []string{"ns-a", "ns-b"} {
+		enrich := cfg.Transforms["enrichMetadata-"+ns+"-pipe-logs"]
+		require.NotNil(t, enrich, ns)
+		require.Len(t, enrich.Inputs, 1)
+		router := cfg.Transforms[strings.TrimSuffix(enrich.Inputs[0], "."+ns)]
+		require.NotNil(t, router, enrich.Inputs[0])
+		assert.Equal(t, RouteTransformType, router.Type)
+		assert.Equal(t, []string{enrich.Name}, cfg.Sinks[ns+"-pipe-out"].Inputs)
+	}
+}
+
+func TestEnrichMetadataAggregator(t *testing.T) {
+	p := &vectorv1alpha1.ClusterVectorPipeline{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+		Spec: vectorv1alpha1.VectorPipelineSpec{
+			Sources: &runtime.RawExtension{Raw: []byte(`{"in": {"type": "vector", "address": "0.0.0.0:9000"}, "metrics": {"type": "internal_metrics"}}`)},
+			Sinks:   &runtime.RawExtension{Raw: []byte(`{"out": {"type": "blackhole", "inputs": ["in", "metrics"]}}`)},
+		},
+	}
+
+	cfg, err := BuildAggregatorConfig(VectorConfigParams{AggregatorName: "agg", EnrichMetadata: true}, p)
+	require.NoError(t, err)
+
+	enrich := cfg.Transforms["enrichMetadata-cluster-in"]
+	require.NotNil(t, enrich)
+	assert.Equal(t, `.vector_operator.kind = "ClusterVectorPipeline"
+.vector_operator.pipeline = "cluster"
+.vector_operator.labels = {}`, enrich.Options["source"])
+	// metric-only sources are left as they are
+	assert.NotContains(t, cfg.Transforms, "enrichMetadata-cluster-metrics")
+	assert.Equal(t, []string{enrich.Name, "cluster-metrics"}, cfg.Sinks["cluster-out"].Inputs)
+}
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/mitchellh/mapstructure"
+	corev1 "k8s.io/api/core/v1"
+
+	vectorv1alpha1 "github.com/kaasops/vector-operator/api/v1alpha1"
+	"github.com/kaasops/vector-operator/internal/evconfig"
+)
+
+const (
+	// KubernetesEventsTLSMountPath is where the aggregator mounts the Secret of
+	// EventCollector.TLS, for its kubernetes_events sources to serve.
+	KubernetesEventsTLSMountPath = "/etc/vector/event-collector-tls"
+	// TLSCAKey is the key of the CA in that Secret, next to corev1.TLSCertKey and
+	// corev1.TLSPrivateKeyKey, as cert-manager writes them.
+	TLSCAKey = "ca.crt"
+)
+
+// kubernetesEventsOptions are the options of a kubernetes_events source. The source is
+// not vector's own: the event collector watches the events and pushes them to a vector
+// source in the aggregator, so these options filter what the collector sends rather
+// than go into the vector config.
+type kubernetesEventsOptions struct {
+	Include       *kubernetesEventsMatch `mapstructure:"include"`
+	Exclude       *kubernetesEventsMatch `mapstructure:"exclude"`
+	FieldSelector string                 `mapstructure:"field_selector"`
+}
+
+type kubernetesEventsMatch struct {
+	Types               []string `mapstructure:"types"`
+	Reasons             []string `mapstructure:"reasons"`
+	InvolvedObjectKinds []string `mapstructure:"involved_object_kinds"`
+}
+
+func (m *kubernetesEventsMatch) toMatch() evconfig.EventMatch {
+	if m == nil {
+		return evconfig.EventMatch{}
+	}
+	return evconfig.EventMatch{Types: m.Types, Reasons: m.Reasons, InvolvedObjectKinds: m.InvolvedObjectKinds}
+}
+
+// kubernetesEventsFilter reads the filter of a kubernetes_events source from its
+// options. Other options are ignored, as they always were; a typo inside include or
+// exclude is an error, since it would silently widen or narrow the filter.
+func kubernetesEventsFilter(options map[string]any) (evconfig.EventFilter, error) {
+	opts := kubernetesEventsOptions{}
+	known := map[string]any{}
+	for _, k := range []string{"include", "exclude", "field_selector"} {
+		if v, ok := options[k]; ok {
+			known[k] = v
+		}
+	}
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		Result:      &opts,
+		ErrorUnused: true,
+	})
+	if err != nil {
+		return evconfig.EventFilter{}, err
+	}
+	if err := decoder.Decode(known); err != nil {
+		return evconfig.EventFilter{}, fmt.Errorf("invalid kubernetes_events options: %w", err)
+	}
+	filter := evconfig.EventFilter{
+		Include:       opts.Include.toMatch(),
+		Exclude:       opts.Exclude.toMatch(),
+		FieldSelector: opts.FieldSelector,
+	}
+	if err := filter.Validate(); err != nil {
+		return evconfig.EventFilter{}, err
+	}
+	return filter, nil
+}
+
+// kubernetesEventsTLS is the tls block of the vector source behind a kubernetes_events
+// source: it serves the certificate of the Secret and, for mTLS, requires the
+// collector's to be signed by its CA.
+func kubernetesEventsTLS(t *vectorv1alpha1.EventCollectorTLS) map[string]any {
+	return map[string]any{
+		"enabled":            true,
+		"crt_file":           path.Join(KubernetesEventsTLSMountPath, corev1.TLSCertKey),
+		"key_file":           path.Join(KubernetesEventsTLSMountPath, corev1.TLSPrivateKeyKey),
+		"ca_file":            path.Join(KubernetesEventsTLSMountPath, TLSCAKey),
+		"verify_certificate": t.Mutual,
+	}
+}
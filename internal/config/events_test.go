@@ -0,0 +1,83 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	vectorv1alpha1 "github.com/kaasops/vector-operator/api/v1alpha1"
+	"github.com/kaasops/vector-operator/internal/evconfig"
+)
+
+func TestBuildAggregatorConfigKubernetesEventsFilter(t *testing.T) {
+	events := func(source string) *VectorConfig {
+		t.Helper()
+		cfg, err := BuildAggregatorConfig(VectorConfigParams{AggregatorName: "agg"},
+			testPipeline("team-a", "events", source, `{"out": {"type": "blackhole", "inputs": ["ev"]}}`))
+		require.NoError(t, err)
+		return cfg
+	}
+
+	cfg := events(`{"ev": {"type": "kubernetes_events", "include": {"types": ["Warning"], "involved_object_kinds": ["Pod", "Node"]}, "exclude": {"reasons": ["BackOff"]}, "field_selector": "involvedObject.name!=noisy"}}`)
+	collector := cfg.GetEventCollectorConfig("vector")
+	require.NotNil(t, collector)
+	require.Len(t, collector.Receivers, 1)
+	require.Equal(t, evconfig.EventFilter{
+		Include:       evconfig.EventMatch{Types: []string{"Warning"}, InvolvedObjectKinds: []string{"Pod", "Node"}},
+		Exclude:       evconfig.EventMatch{Reasons: []string{"BackOff"}},
+		FieldSelector: "involvedObject.name!=noisy",
+	}, collector.Receivers[0].Filter)
+
+	// the filter is the collector's: the vector source only listens for its pushes
+	for _, s := range cfg.Sources {
+		if s.Type == VectorType {
+			require.Equal(t, map[string]any{"address": "0.0.0.0:42000"}, s.Options)
+		}
+	}
+
+	require.Equal(t, evconfig.EventFilter{}, events(`{"ev": {"type": "kubernetes_events"}}`).GetEventCollectorConfig("vector").Receivers[0].Filter)
+}
+
+func TestBuildAggregatorConfigKubernetesEventsFilterInvalid(t *testing.T) {
+	for name, source := range map[string]string{
+		"typo in include":        `{"ev": {"type": "kubernetes_events", "include": {"reason": ["BackOff"]}}}`,
+		"unparsable selector":    `{"ev": {"type": "kubernetes_events", "field_selector": "type"}}`,
+		"unsupported selector":   `{"ev": {"type": "kubernetes_events", "field_selector": "message=x"}}`,
+		"include is not a match": `{"ev": {"type": "kubernetes_events", "include": "Warning"}}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := BuildAggregatorConfig(VectorConfigParams{AggregatorName: "agg"},
+				testPipeline("team-a", "events", source, `{"out": {"type": "blackhole", "inputs": ["ev"]}}`))
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestBuildAggregatorConfigKubernetesEventsTLS(t *testing.T) {
+	source := func(tls *vectorv1alpha1.EventCollectorTLS) *Source {
+		t.Helper()
+		cfg, err := BuildAggregatorConfig(VectorConfigParams{AggregatorName: "agg", KubernetesEventsTLS: tls},
+			testPipeline("team-a", "events", `{"ev": {"type": "kubernetes_events"}}`, `{"out": {"type": "blackhole", "inputs": ["ev"]}}`))
+		require.NoError(t, err)
+		for _, s := range cfg.Sources {
+			if s.Type == VectorType {
+				return s
+			}
+		}
+		t.Fatal("no vector source")
+		return nil
+	}
+
+	require.NotContains(t, source(nil).Options, "tls")
+
+	require.Equal(t, map[string]any{
+		"enabled":            true,
+		"crt_file":           "/etc/vector/event-collector-tls/tls.crt",
+		"key_file":           "/etc/vector/event-collector-tls/tls.key",
+		"ca_file":            "/etc/vector/event-collector-tls/ca.crt",
+		"verify_certificate": false,
+	}, source(&vectorv1alpha1.EventCollectorTLS{SecretName: "events-tls"}).Options["tls"])
+
+	tls := source(&vectorv1alpha1.EventCollectorTLS{SecretName: "events-tls", Mutual: true}).Options["tls"]
+	require.Equal(t, true, tls.(map[string]any)["verify_certificate"], "mTLS requires the collector's certificate")
+}
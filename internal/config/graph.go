@@ -0,0 +1,252 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// GraphFormat is a text format Graph writes the topology of a config in.
+type GraphFormat string
+
+const (
+	GraphDOT     GraphFormat = "dot"
+	GraphMermaid GraphFormat = "mermaid"
+)
+
+var ErrUnknownGraphFormat = errors.New("unknown graph format")
+
+// Component classes set apart in a graph from what the pipelines define.
+const (
+	// graphOptimized components are generated by the sources optimization
+	// (optimizeAgentSources) in place of the pipelines' own sources.
+	graphOptimized = "optimized"
+	// graphOperator components are added by the operator: the metadata enrichment
+	// transforms, the internal metrics exporter and the placeholder of an empty config.
+	graphOperator = "operator"
+	// graphMissing is an input no component of the config provides.
+	graphMissing = "missing"
+)
+
+type graphNode struct {
+	name string
+	// kind is source, transform or sink, and empty for a missing input.
+	kind  string
+	typ   string
+	class string
+	// pipeline groups the node; empty for one that belongs to no single pipeline.
+	pipeline string
+}
+
+type graphEdge struct {
+	from, to string
+	// output is the named output of from the edge reads, such as a route.
+	output string
+}
+
+// Graph writes the topology of c, every source, transform and sink and the inputs
+// connecting them, as a DOT digraph or a Mermaid flowchart titled title. Components
+// are grouped by the pipeline that generated them; a sink several pipelines share,
+// such as the sink of a VectorOutput, and the components of the sources optimization
+// belong to none. Optimized and operator-added components are drawn dashed, and an
+// input nothing provides as a node of its own. The output only depends on c, so the
+// graphs of two builds can be diffed.
+func (c *VectorConfig) Graph(format GraphFormat, title string) ([]byte, error) {
+	nodes, edges := c.graph()
+	switch format {
+	case GraphDOT:
+		return dotGraph(title, nodes, edges), nil
+	case GraphMermaid:
+		return mermaidGraph(title, nodes, edges), nil
+	}
+	return nil, fmt.Errorf("%w %q, want %s or %s", ErrUnknownGraphFormat, format, GraphDOT, GraphMermaid)
+}
+
+func (c *VectorConfig) graph() ([]*graphNode, []graphEdge) {
+	byName := make(map[string]*graphNode, len(c.Sources)+len(c.Transforms)+len(c.Sinks))
+	pipelines := c.internal.componentPipelines()
+	add := func(name, kind, typ string) {
+		n := &graphNode{name: name, kind: kind, typ: typ}
+		if owners := pipelines[name]; len(owners) == 1 {
+			n.pipeline = owners[0]
+		}
+		switch {
+		case strings.HasPrefix(name, optimizedSourcePrefix+"-"),
+			strings.HasPrefix(name, optimizedBucketerPrefix+"-"),
+			strings.HasPrefix(name, optimizedRouterPrefix+"-"):
+			n.class = graphOptimized
+		case strings.HasPrefix(name, enrichTransformPrefix+"-"),
+			name == DefaultInternalMetricsSourceName, name == DefaultInternalMetricsSinkName,
+			name == DefaultSourceName, name == DefaultSinkName:
+			n.class = graphOperator
+		}
+		byName[name] = n
+	}
+	for name, v := range c.Sources {
+		add(name, "source", v.Type)
+	}
+	for name, v := range c.Transforms {
+		add(name, "transform", v.Type)
+	}
+	for name, v := range c.Sinks {
+		add(name, "sink", v.Type)
+	}
+
+	var edges []graphEdge
+	connect := func(to string, inputs []string) {
+		for _, input := range inputs {
+			e := graphEdge{from: input, to: to}
+			if _, ok := byName[input]; !ok {
+				// a named output, such as a route of a route transform
+				if i := strings.LastIndex(input, "."); i > 0 && byName[input[:i]] != nil {
+					e.from, e.output = input[:i], input[i+1:]
+				} else {
+					byName[input] = &graphNode{name: input, class: graphMissing}
+				}
+			}
+			edges = append(edges, e)
+		}
+	}
+	for name, v := range c.Transforms {
+		connect(name, v.Inputs)
+	}
+	for name, v := range c.Sinks {
+		connect(name, v.Inputs)
+	}
+
+	nodes := slices.Collect(maps.Values(byName))
+	slices.SortFunc(nodes, func(a, b *graphNode) int { return strings.Compare(a.name, b.name) })
+	slices.SortFunc(edges, func(a, b graphEdge) int {
+		return strings.Compare(a.from+"\x00"+a.output+"\x00"+a.to, b.from+"\x00"+b.output+"\x00"+b.to)
+	})
+	return nodes, edges
+}
+
+// graphGroups returns the pipelines of nodes in order, and the nodes of each; the
+// nodes of no pipeline are under "".
+func graphGroups(nodes []*graphNode) ([]string, map[string][]*graphNode) {
+	groups := make(map[string][]*graphNode)
+	for _, n := range nodes {
+		groups[n.pipeline] = append(groups[n.pipeline], n)
+	}
+	return slices.Sorted(maps.Keys(groups)), groups
+}
+
+// graphLabel is the text of a node: its name, then its type and class.
+func graphLabel(n *graphNode) []string {
+	lines := []string{n.name}
+	if n.typ != "" {
+		lines = append(lines, n.kind+": "+n.typ)
+	}
+	switch n.class {
+	case graphOptimized:
+		lines = append(lines, "(config optimization)")
+	case graphOperator:
+		lines = append(lines, "(added by the operator)")
+	case graphMissing:
+		lines = append(lines, "(not in the config)")
+	}
+	return lines
+}
+
+func dotGraph(title string, nodes []*graphNode, edges []graphEdge) []byte {
+	escape := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace
+	quote := func(s string) string { return `"` + escape(s) + `"` }
+	shapes := map[string]string{"source": "ellipse", "transform": "box", "sink": "hexagon", "": "plaintext"}
+	styles := map[string]string{graphOptimized: "dashed", graphOperator: "dashed", graphMissing: "dotted"}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "digraph %s {\n", quote(title))
+	fmt.Fprintf(&b, "  label=%s;\n  labelloc=t;\n  rankdir=LR;\n", quote(title))
+	writeNode := func(indent string, n *graphNode) {
+		lines := graphLabel(n)
+		for i := range lines {
+			lines[i] = escape(lines[i])
+		}
+		fmt.Fprintf(&b, "%s%s [shape=%s, label=\"%s\"", indent, quote(n.name), shapes[n.kind], strings.Join(lines, `\n`))
+		if style, ok := styles[n.class]; ok {
+			fmt.Fprintf(&b, ", style=%s", style)
+		}
+		b.WriteString("];\n")
+	}
+	pipelines, groups := graphGroups(nodes)
+	for i, p := range pipelines {
+		if p == "" {
+			continue
+		}
+		fmt.Fprintf(&b, "  subgraph cluster_%d {\n    label=%s;\n", i, quote(p))
+		for _, n := range groups[p] {
+			writeNode("    ", n)
+		}
+		b.WriteString("  }\n")
+	}
+	for _, n := range groups[""] {
+		writeNode("  ", n)
+	}
+	for _, e := range edges {
+		fmt.Fprintf(&b, "  %s -> %s", quote(e.from), quote(e.to))
+		if e.output != "" {
+			fmt.Fprintf(&b, " [label=%s]", quote(e.output))
+		}
+		b.WriteString(";\n")
+	}
+	b.WriteString("}\n")
+	return b.Bytes()
+}
+
+func mermaidGraph(title string, nodes []*graphNode, edges []graphEdge) []byte {
+	// Mermaid ids are restricted, so nodes are numbered in name order and named by
+	// their label; quotes in a label are written as an entity.
+	quote := func(lines ...string) string {
+		return `"` + strings.ReplaceAll(strings.Join(lines, "<br/>"), `"`, "#quot;") + `"`
+	}
+	ids := make(map[string]string, len(nodes))
+	for i, n := range nodes {
+		ids[n.name] = fmt.Sprintf("n%d", i)
+	}
+	shapes := map[string][2]string{"source": {"([", "])"}, "transform": {"[", "]"}, "sink": {"[(", ")]"}, "": {"[", "]"}}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "---\ntitle: %s\n---\nflowchart LR\n", quote(title))
+	writeNode := func(indent string, n *graphNode) {
+		shape := shapes[n.kind]
+		fmt.Fprintf(&b, "%s%s%s%s%s\n", indent, ids[n.name], shape[0], quote(graphLabel(n)...), shape[1])
+	}
+	pipelines, groups := graphGroups(nodes)
+	for i, p := range pipelines {
+		if p == "" {
+			continue
+		}
+		fmt.Fprintf(&b, "  subgraph p%d[%s]\n", i, quote(p))
+		for _, n := range groups[p] {
+			writeNode("    ", n)
+		}
+		b.WriteString("  end\n")
+	}
+	for _, n := range groups[""] {
+		writeNode("  ", n)
+	}
+	for _, e := range edges {
+		if e.output != "" {
+			fmt.Fprintf(&b, "  %s -->|%s| %s\n", ids[e.from], quote(e.output), ids[e.to])
+			continue
+		}
+		fmt.Fprintf(&b, "  %s --> %s\n", ids[e.from], ids[e.to])
+	}
+	classes := map[string][]string{}
+	for _, n := range nodes {
+		if n.class != "" {
+			classes[n.class] = append(classes[n.class], ids[n.name])
+		}
+	}
+	b.WriteString("  classDef optimized stroke-dasharray: 5 5\n")
+	b.WriteString("  classDef operator stroke-dasharray: 5 5,fill:#eee\n")
+	b.WriteString("  classDef missing stroke-dasharray: 2 2,color:#b00\n")
+	for _, class := range slices.Sorted(maps.Keys(classes)) {
+		fmt.Fprintf(&b, "  class %s %s\n", strings.Join(classes[class], ","), class)
+	}
+	return b.Bytes()
+}
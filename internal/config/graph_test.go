@@ -0,0 +1,58 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	vectorv1alpha1 "github.com/kaasops/vector-operator/api/v1alpha1"
+)
+
+func TestGraph(t *testing.T) {
+	shared := `{"out": {"type": "console", "outputRef": "central", "inputs": ["logs"]}}`
+	web := testPipeline("team-a", "web", `{"logs": {"type": "kubernetes_logs"}}`, shared).(*vectorv1alpha1.VectorPipeline)
+	web.Spec.Transforms = &runtime.RawExtension{Raw: []byte(`{"parse": {"type": "remap", "inputs": ["logs"], "source": "."}}`)}
+	web.Spec.Sinks = &runtime.RawExtension{Raw: []byte(`{"out": {"type": "console", "outputRef": "central", "inputs": ["parse"]}, "debug": {"type": "blackhole", "inputs": ["missing"]}}`)}
+	api := testPipeline("team-a", "api", `{"logs": {"type": "kubernetes_logs"}}`, shared)
+
+	cfg, _, err := BuildAgentConfig(VectorConfigParams{OptimizeSources: true, EnrichMetadata: true, InternalMetrics: true}, web, api)
+	require.NoError(t, err)
+	router, _, _ := strings.Cut(cfg.Transforms["enrichMetadata-team-a-web-logs"].Inputs[0], ".")
+	source := strings.Replace(router, "optimizedRouter", "optimizedSource", 1)
+
+	dot, err := cfg.Graph(GraphDOT, "Vector vector/agent")
+	require.NoError(t, err)
+	out := string(dot)
+	assert.True(t, strings.HasPrefix(out, "digraph \"Vector vector/agent\" {\n"), out)
+	// the pipeline's own components and its enrichment transform are grouped under it
+	assert.Contains(t, out, "  subgraph cluster_2 {\n    label=\"team-a/web\";\n"+
+		"    \"enrichMetadata-team-a-web-logs\" [shape=box, label=\"enrichMetadata-team-a-web-logs\\ntransform: remap\\n(added by the operator)\", style=dashed];\n"+
+		"    \"team-a-web-debug\" [shape=hexagon, label=\"team-a-web-debug\\nsink: blackhole\"];\n"+
+		"    \"team-a-web-parse\" [shape=box, label=\"team-a-web-parse\\ntransform: remap\"];\n  }\n")
+	// the shared output sink and the optimized source belong to no pipeline
+	assert.Contains(t, out, "\n  \"output_team-a_central\" [shape=hexagon, label=\"output_team-a_central\\nsink: console\"];\n")
+	assert.Contains(t, out, "\n  \""+source+"\" [shape=ellipse, label=\""+source+"\\nsource: kubernetes_logs\\n(config optimization)\", style=dashed];\n")
+	assert.Contains(t, out, "\n  \"internalMetricsSink\" [shape=hexagon, label=\"internalMetricsSink\\nsink: prometheus_exporter\\n(added by the operator)\", style=dashed];\n")
+	assert.Contains(t, out, "\n  \"team-a-web-missing\" [shape=plaintext, label=\"team-a-web-missing\\n(not in the config)\", style=dotted];\n")
+	// a route output is an edge from the router, labelled with the route
+	assert.Contains(t, out, "\n  \""+router+"\" -> \"enrichMetadata-team-a-web-logs\" [label=\"team-a\"];\n")
+	assert.Contains(t, out, "\n  \"team-a-web-parse\" -> \"output_team-a_central\";\n")
+
+	mermaid, err := cfg.Graph(GraphMermaid, "Vector vector/agent")
+	require.NoError(t, err)
+	out = string(mermaid)
+	assert.True(t, strings.HasPrefix(out, "---\ntitle: \"Vector vector/agent\"\n---\nflowchart LR\n"), out)
+	assert.Contains(t, out, "[\"team-a/web\"]\n")
+	assert.Contains(t, out, "-->|\"team-a\"|")
+	assert.Contains(t, out, " optimized\n")
+
+	again, err := cfg.Graph(GraphMermaid, "Vector vector/agent")
+	require.NoError(t, err)
+	assert.Equal(t, mermaid, again)
+
+	_, err = cfg.Graph("svg", "")
+	require.ErrorIs(t, err, ErrUnknownGraphFormat)
+}
@@ -0,0 +1,47 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/kaasops/vector-operator/internal/pipeline"
+)
+
+// outputSinkName is the component name of the one sink an output contributes to a
+// config. Pipeline components are named namespace-name-component, and neither a
+// namespace nor a name can contain an underscore, so this can never collide with one.
+func outputSinkName(namespace, name string) string {
+	if namespace != "" {
+		return "output_" + namespace + "_" + name
+	}
+	return "output_" + name
+}
+
+// addSink adds sink k of pipeline p to cfg, its inputs already prefixed. A sink
+// expanded from an output (pipeline.ExpandOutputs) is merged into the output's single
+// sink instead, so however many pipelines reference an output the config connects to its
+// destination once. The first pipeline to reference the output supplies the definition;
+// the others only add their inputs. Its SECRET[] references are rewritten with that
+// pipeline's flat keys, and the references of the others resolve to the same values.
+func addSink(cfg *VectorConfig, p pipeline.Pipeline, k string, v *Sink) error {
+	ref, ok := v.Options[pipeline.OutputRefKey]
+	if !ok {
+		v.Name = addPrefix(p.GetNamespace(), p.GetName(), k)
+		cfg.Sinks[v.Name] = v
+		return nil
+	}
+	if v.Type == "" {
+		return fmt.Errorf("pipeline %s: sink %s: %w: output %v is not expanded", p.GetName(), k, pipeline.ErrInvalidOutputRef, ref)
+	}
+	delete(v.Options, pipeline.OutputRefKey)
+	v.Name = outputSinkName(p.GetNamespace(), fmt.Sprint(ref))
+	if shared, ok := cfg.Sinks[v.Name]; ok {
+		shared.Inputs = append(shared.Inputs, v.Inputs...)
+		slices.Sort(shared.Inputs)
+		shared.Inputs = slices.Compact(shared.Inputs)
+		return nil
+	}
+	slices.Sort(v.Inputs)
+	cfg.Sinks[v.Name] = v
+	return nil
+}
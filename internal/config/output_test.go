@@ -0,0 +1,61 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+
+	vectorv1alpha1 "github.com/kaasops/vector-operator/api/v1alpha1"
+	"github.com/kaasops/vector-operator/internal/pipeline"
+)
+
+// expandedESSink is a sink as pipeline.ExpandOutputs leaves it for VectorOutput
+// team-a/central.
+const expandedESSink = `{"es": {"type": "elasticsearch", "endpoints": ["https://es:9200"], "auth": {"password": "SECRET[es.password]"}, "outputRef": "central", "inputs": ["logs"]}}`
+
+func TestBuildAgentConfigMergesOutputSinks(t *testing.T) {
+	creds := map[string]vectorv1alpha1.PipelineSecretBackend{"es": {Type: "kubernetes_secret", Name: "es-creds"}}
+	getter := staticSecretGetter(map[string]*corev1.Secret{
+		"team-a/es-creds": {Data: map[string][]byte{"password": []byte("p1")}},
+	})
+
+	cfg, _, err := BuildAgentConfig(VectorConfigParams{PipelineSecretGetter: getter},
+		testVPWithSecret("team-a", "web", creds, `{"logs": {"type": "kubernetes_logs"}}`, expandedESSink),
+		testVPWithSecret("team-a", "api", creds, `{"logs": {"type": "kubernetes_logs"}}`, expandedESSink),
+		testPipeline("team-a", "debug", `{"logs": {"type": "kubernetes_logs"}}`, `{"out": {"type": "console", "inputs": ["logs"]}}`),
+	)
+	require.NoError(t, err)
+
+	require.Len(t, cfg.Sinks, 2)
+	require.Contains(t, cfg.Sinks, "team-a-debug-out")
+	shared := cfg.Sinks["output_team-a_central"]
+	require.NotNil(t, shared, "both pipelines feed the one sink of the output")
+	require.Equal(t, "elasticsearch", shared.Type)
+	require.Equal(t, []string{"team-a-api-logs", "team-a-web-logs"}, shared.Inputs)
+	require.NotContains(t, shared.Options, pipeline.OutputRefKey)
+
+	// the definition comes from the first pipeline, its secret from that pipeline's flat key
+	require.Equal(t, "SECRET[k8s.team_a_web_es_password]", shared.Options["auth"].(map[string]any)["password"])
+	require.Equal(t, []byte("p1"), cfg.SecretAssets()["team_a_web_es_password"])
+}
+
+func TestBuildAggregatorConfigMergesClusterOutputSinks(t *testing.T) {
+	sink := `{"out": {"type": "console", "outputRef": "debug", "inputs": ["in"]}}`
+	src := func(port string) string {
+		return `{"in": {"type": "http_server", "address": "0.0.0.0:` + port + `"}}`
+	}
+	cfg, err := BuildAggregatorConfig(VectorConfigParams{},
+		testCVPWithSecret("a", nil, src("8080"), sink),
+		testCVPWithSecret("b", nil, src("8081"), sink),
+	)
+	require.NoError(t, err)
+	require.Len(t, cfg.Sinks, 1)
+	require.Equal(t, []string{"a-in", "b-in"}, cfg.Sinks["output_debug"].Inputs)
+}
+
+func TestBuildAgentConfigRejectsUnexpandedOutput(t *testing.T) {
+	_, _, err := BuildAgentConfig(VectorConfigParams{},
+		testPipeline("team-a", "web", `{"logs": {"type": "kubernetes_logs"}}`, `{"es": {"outputRef": "central", "inputs": ["logs"]}}`))
+	require.ErrorIs(t, err, pipeline.ErrInvalidOutputRef)
+}
@@ -0,0 +1,116 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+
+	vectorv1alpha1 "github.com/kaasops/vector-operator/api/v1alpha1"
+)
+
+// ErrPolicyViolation is returned for a VectorPipeline that uses a sink or transform type,
+// or sends to a host, that a ClusterVectorPipelinePolicy governing its namespace does not
+// allow. Only editing the pipeline or the policy fixes it.
+var ErrPolicyViolation = errors.New("pipeline policy violation")
+
+// endpointOptions are the sink options that name where a sink connects to. Vector has
+// no one option for it: http sinks take uri, most others endpoint or endpoints, socket
+// and vector sinks address and kafka a comma-separated bootstrap_servers.
+var endpointOptions = []string{"endpoint", "endpoints", "uri", "address", "bootstrap_servers"}
+
+// localSinkTypes write inside the pod and connect nowhere, so AllowedEndpointHosts has
+// nothing to check for them. Every other sink needs an endpoint option while it applies:
+// many default to a vendor's endpoint when given none (datadog_logs, aws_s3, splunk and
+// so on), or derive it from options such as site or region.
+var localSinkTypes = []string{"blackhole", "console", "file"}
+
+// CheckPolicies checks the transforms and sinks of p against every policy in policies,
+// the ones governing the pipeline's namespace. The first violation is returned, with
+// the component and the policy that rejects it; components are checked in name order
+// so the same pipeline always reports the same one.
+func CheckPolicies(policies []vectorv1alpha1.ClusterVectorPipelinePolicy, p *PipelineConfig) error {
+	for _, policy := range policies {
+		spec := policy.Spec
+		for _, name := range slices.Sorted(maps.Keys(p.Transforms)) {
+			t := p.Transforms[name]
+			if spec.AllowedTransformTypes != nil && !slices.Contains(spec.AllowedTransformTypes, t.Type) {
+				return fmt.Errorf("%w: transform %s: type %s is not allowed by ClusterVectorPipelinePolicy %s", ErrPolicyViolation, name, t.Type, policy.Name)
+			}
+		}
+		for _, name := range slices.Sorted(maps.Keys(p.Sinks)) {
+			s := p.Sinks[name]
+			if spec.AllowedSinkTypes != nil && !slices.Contains(spec.AllowedSinkTypes, s.Type) {
+				return fmt.Errorf("%w: sink %s: type %s is not allowed by ClusterVectorPipelinePolicy %s", ErrPolicyViolation, name, s.Type, policy.Name)
+			}
+			if spec.AllowedEndpointHosts == nil {
+				continue
+			}
+			hosts := sinkHosts(s.Options)
+			if len(hosts) == 0 && !slices.Contains(localSinkTypes, s.Type) {
+				return fmt.Errorf("%w: sink %s: a %s sink without an endpoint, endpoints, uri, address or bootstrap_servers option sends to its default destination, which allowedEndpointHosts of ClusterVectorPipelinePolicy %s cannot check", ErrPolicyViolation, name, s.Type, policy.Name)
+			}
+			for _, host := range hosts {
+				if !hostAllowed(spec.AllowedEndpointHosts, host) {
+					return fmt.Errorf("%w: sink %s: host %s is not allowed by ClusterVectorPipelinePolicy %s", ErrPolicyViolation, name, host, policy.Name)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// sinkHosts returns the host of every endpoint the options of a sink name.
+func sinkHosts(options map[string]any) []string {
+	var hosts []string
+	add := func(v any) {
+		s, ok := v.(string)
+		if !ok {
+			return
+		}
+		for _, endpoint := range strings.Split(s, ",") {
+			if endpoint = strings.TrimSpace(endpoint); endpoint != "" {
+				hosts = append(hosts, endpointHost(endpoint))
+			}
+		}
+	}
+	for _, key := range endpointOptions {
+		switch v := options[key].(type) {
+		case []any:
+			for _, item := range v {
+				add(item)
+			}
+		default:
+			add(v)
+		}
+	}
+	return hosts
+}
+
+// endpointHost returns the host of a URL or a host:port pair, and the endpoint itself
+// when it is neither.
+func endpointHost(endpoint string) string {
+	if strings.Contains(endpoint, "://") {
+		if u, err := url.Parse(endpoint); err == nil && u.Hostname() != "" {
+			return strings.ToLower(u.Hostname())
+		}
+		return endpoint
+	}
+	if host, _, err := net.SplitHostPort(endpoint); err == nil {
+		return strings.ToLower(host)
+	}
+	return strings.ToLower(endpoint)
+}
+
+func hostAllowed(patterns []string, host string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(strings.ToLower(pattern), host); ok {
+			return true
+		}
+	}
+	return false
+}
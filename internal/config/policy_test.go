@@ -0,0 +1,135 @@
+package config
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	vectorv1alpha1 "github.com/kaasops/vector-operator/api/v1alpha1"
+	"github.com/kaasops/vector-operator/internal/pipeline"
+)
+
+func testPolicy(name string, spec vectorv1alpha1.ClusterVectorPipelinePolicySpec) vectorv1alpha1.ClusterVectorPipelinePolicy {
+	return vectorv1alpha1.ClusterVectorPipelinePolicy{ObjectMeta: metav1.ObjectMeta{Name: name}, Spec: spec}
+}
+
+func TestCheckPolicies(t *testing.T) {
+	restricted := testPolicy("tenants", vectorv1alpha1.ClusterVectorPipelinePolicySpec{
+		AllowedSinkTypes:      []string{"loki", "elasticsearch", "kafka", "console"},
+		AllowedTransformTypes: []string{"remap"},
+		AllowedEndpointHosts:  []string{"*.logging.svc", "es.example.com"},
+	})
+	tests := []struct {
+		name       string
+		transforms map[string]*Transform
+		sinks      map[string]*Sink
+		policies   []vectorv1alpha1.ClusterVectorPipelinePolicy
+		wantErr    string
+	}{
+		{
+			name:  "allowed sink with url endpoint",
+			sinks: map[string]*Sink{"out": {Type: "loki", Options: map[string]any{"endpoint": "http://loki.logging.svc:3100"}}},
+		},
+		{
+			name:  "allowed endpoints list",
+			sinks: map[string]*Sink{"out": {Type: "elasticsearch", Options: map[string]any{"endpoints": []any{"https://ES.example.com:9200", "https://es-2.logging.svc"}}}},
+		},
+		{
+			name:  "sink without endpoint",
+			sinks: map[string]*Sink{"out": {Type: "console"}},
+		},
+		{
+			name:     "defaulted endpoint",
+			sinks:    map[string]*Sink{"out": {Type: "datadog_logs", Options: map[string]any{"default_api_key": "key", "site": "datadoghq.eu"}}},
+			policies: []vectorv1alpha1.ClusterVectorPipelinePolicy{testPolicy("hosts", vectorv1alpha1.ClusterVectorPipelinePolicySpec{AllowedEndpointHosts: []string{"*"}})},
+			wantErr:  "sink out: a datadog_logs sink without an endpoint",
+		},
+		{
+			name:     "unknown sink type without endpoint",
+			sinks:    map[string]*Sink{"out": {Type: "shiny_new_sink"}},
+			policies: []vectorv1alpha1.ClusterVectorPipelinePolicy{testPolicy("hosts", vectorv1alpha1.ClusterVectorPipelinePolicySpec{AllowedEndpointHosts: []string{"*"}})},
+			wantErr:  "sends to its default destination, which allowedEndpointHosts of ClusterVectorPipelinePolicy hosts cannot check",
+		},
+		{
+			name:     "defaulted endpoint set explicitly",
+			sinks:    map[string]*Sink{"out": {Type: "datadog_logs", Options: map[string]any{"endpoint": "https://proxy.logging.svc"}}},
+			policies: []vectorv1alpha1.ClusterVectorPipelinePolicy{testPolicy("hosts", vectorv1alpha1.ClusterVectorPipelinePolicySpec{AllowedEndpointHosts: []string{"*.logging.svc"}})},
+		},
+		{
+			name:     "no allowlist",
+			sinks:    map[string]*Sink{"out": {Type: "datadog_logs", Options: map[string]any{"site": "datadoghq.eu"}}},
+			policies: []vectorv1alpha1.ClusterVectorPipelinePolicy{testPolicy("types", vectorv1alpha1.ClusterVectorPipelinePolicySpec{AllowedSinkTypes: []string{"datadog_logs"}})},
+		},
+		{
+			name:    "sink type not allowed",
+			sinks:   map[string]*Sink{"out": {Type: "http", Options: map[string]any{"uri": "http://loki.logging.svc"}}},
+			wantErr: "sink out: type http is not allowed by ClusterVectorPipelinePolicy tenants",
+		},
+		{
+			name:       "transform type not allowed",
+			transforms: map[string]*Transform{"run": {Type: "exec"}},
+			wantErr:    "transform run: type exec is not allowed",
+		},
+		{
+			name:    "host not allowed",
+			sinks:   map[string]*Sink{"out": {Type: "loki", Options: map[string]any{"endpoint": "https://attacker.example.org"}}},
+			wantErr: "sink out: host attacker.example.org is not allowed",
+		},
+		{
+			name:    "one bootstrap server not allowed",
+			sinks:   map[string]*Sink{"out": {Type: "kafka", Options: map[string]any{"bootstrap_servers": "kafka.logging.svc:9092, kafka.example.org:9092"}}},
+			wantErr: "host kafka.example.org is not allowed",
+		},
+		{
+			name:    "secret reference is not a host",
+			sinks:   map[string]*Sink{"out": {Type: "loki", Options: map[string]any{"endpoint": "SECRET[loki.url]"}}},
+			wantErr: "host secret[loki.url] is not allowed",
+		},
+		{
+			name:     "every policy applies",
+			sinks:    map[string]*Sink{"out": {Type: "loki", Options: map[string]any{"endpoint": "http://loki.logging.svc"}}},
+			policies: []vectorv1alpha1.ClusterVectorPipelinePolicy{restricted, testPolicy("no-loki", vectorv1alpha1.ClusterVectorPipelinePolicySpec{AllowedSinkTypes: []string{"console"}})},
+			wantErr:  "type loki is not allowed by ClusterVectorPipelinePolicy no-loki",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			policies := tc.policies
+			if policies == nil {
+				policies = []vectorv1alpha1.ClusterVectorPipelinePolicy{restricted}
+			}
+			err := CheckPolicies(policies, &PipelineConfig{Transforms: tc.transforms, Sinks: tc.sinks})
+			if tc.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, ErrPolicyViolation)
+			require.ErrorContains(t, err, tc.wantErr)
+		})
+	}
+}
+
+// The builders enforce the policies of VectorPipelines only: a ClusterVectorPipeline is
+// written by a cluster administrator.
+func TestBuildConfigEnforcesPolicies(t *testing.T) {
+	params := VectorConfigParams{
+		PipelinePolicies: func(_ context.Context, p pipeline.Pipeline) ([]vectorv1alpha1.ClusterVectorPipelinePolicy, error) {
+			return []vectorv1alpha1.ClusterVectorPipelinePolicy{
+				testPolicy("tenants", vectorv1alpha1.ClusterVectorPipelinePolicySpec{AllowedSinkTypes: []string{"loki"}}),
+			}, nil
+		},
+	}
+	sink := `{"out": {"type": "console", "inputs": ["logs"]}}`
+
+	_, _, err := BuildAgentConfig(params, testPipeline("team-a", "web", `{"logs": {"type": "kubernetes_logs"}}`, sink))
+	require.ErrorIs(t, err, ErrPolicyViolation)
+	require.ErrorContains(t, err, "pipeline team-a/web: ")
+
+	_, err = BuildAggregatorConfig(params, testPipeline("team-a", "web", `{"logs": {"type": "http_server", "address": "0.0.0.0:8080"}}`, sink))
+	require.ErrorIs(t, err, ErrPolicyViolation)
+
+	_, err = BuildAggregatorConfig(params, testCVPWithSecret("cluster", nil, `{"logs": {"type": "http_server", "address": "0.0.0.0:8080"}}`, sink))
+	require.NoError(t, err)
+}
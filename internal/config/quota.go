@@ -0,0 +1,136 @@
+package config
+
+import (
+	"context"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/runtime"
+
+	vectorv1alpha1 "github.com/kaasops/vector-operator/api/v1alpha1"
+	"github.com/kaasops/vector-operator/internal/pipeline"
+)
+
+// QuotaExclusion is a VectorPipeline DetectQuotaExclusions leaves out of a workload
+// build: admitting it would take its namespace over Limit of Policy's quota.
+type QuotaExclusion struct {
+	Victim pipeline.Pipeline
+	Policy string
+	// Limit is the quota field exceeded, e.g. maxSinks.
+	Limit string
+	Max   int64
+	// Used is what the older pipelines of the namespace already take of Limit, and
+	// Requested what Victim would add to it.
+	Used      int64
+	Requested int64
+}
+
+// quotaUsage is what one pipeline, or the pipelines admitted so far for one namespace,
+// take of each quota limit.
+type quotaUsage struct {
+	pipelines, sources, transforms, sinks, configBytes int64
+}
+
+func (u *quotaUsage) add(o quotaUsage) {
+	u.pipelines += o.pipelines
+	u.sources += o.sources
+	u.transforms += o.transforms
+	u.sinks += o.sinks
+	u.configBytes += o.configBytes
+}
+
+// pipelineQuotaUsage measures p. A spec that does not parse counts as one pipeline with
+// its bytes and no components: the build reports it right after.
+func pipelineQuotaUsage(p pipeline.Pipeline) quotaUsage {
+	u := quotaUsage{pipelines: 1}
+	spec := p.GetSpec()
+	for _, section := range []*runtime.RawExtension{spec.Sources, spec.Transforms, spec.Sinks} {
+		if section != nil {
+			u.configBytes += int64(len(section.Raw))
+		}
+	}
+	cfg := &PipelineConfig{}
+	if err := UnmarshalJson(spec, cfg); err == nil {
+		u.sources = int64(len(cfg.Sources))
+		u.transforms = int64(len(cfg.Transforms))
+		u.sinks = int64(len(cfg.Sinks))
+	}
+	return u
+}
+
+// DetectQuotaExclusions is a read-only pre-pass over the pipelines a workload build is
+// about to receive, attributing every namespace quota (ClusterVectorPipelinePolicy
+// spec.quota) the same way secret-assets budgets are attributed: pipelines are visited
+// oldest-to-youngest (CreationTimestamp, ties broken by ascending ID) and a pipeline that
+// would take its namespace over any limit of any policy governing it is excluded, without
+// taking any of the quota. A younger, smaller pipeline can still be admitted after it.
+//
+// policies is config.VectorConfigParams.PipelinePolicies; nil means no quota applies.
+// ClusterVectorPipelines are never counted.
+func DetectQuotaExclusions(ctx context.Context, policies func(ctx context.Context, p pipeline.Pipeline) ([]vectorv1alpha1.ClusterVectorPipelinePolicy, error), pipelines ...pipeline.Pipeline) ([]QuotaExclusion, error) {
+	if policies == nil {
+		return nil, nil
+	}
+	ordered := make([]pipeline.Pipeline, 0, len(pipelines))
+	for _, p := range pipelines {
+		if _, ok := p.(*vectorv1alpha1.VectorPipeline); ok {
+			ordered = append(ordered, p)
+		}
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		ti, tj := ordered[i].GetCreationTimestamp(), ordered[j].GetCreationTimestamp()
+		if !ti.Equal(&tj) {
+			return ti.Before(&tj)
+		}
+		return pipelineID(ordered[i]) < pipelineID(ordered[j])
+	})
+
+	used := make(map[string]*quotaUsage)
+	var exclusions []QuotaExclusion
+	for _, p := range ordered {
+		governing, err := policies(ctx, p)
+		if err != nil {
+			return nil, err
+		}
+		ns := used[p.GetNamespace()]
+		if ns == nil {
+			ns = &quotaUsage{}
+			used[p.GetNamespace()] = ns
+		}
+		req := pipelineQuotaUsage(p)
+		if ex, over := exceedsQuota(governing, *ns, req); over {
+			ex.Victim = p
+			exclusions = append(exclusions, ex)
+			continue
+		}
+		ns.add(req)
+	}
+	return exclusions, nil
+}
+
+// exceedsQuota reports the first limit, in policy then field order, that used plus req
+// goes over.
+func exceedsQuota(policies []vectorv1alpha1.ClusterVectorPipelinePolicy, used, req quotaUsage) (QuotaExclusion, bool) {
+	for _, policy := range policies {
+		q := policy.Spec.Quota
+		if q == nil {
+			continue
+		}
+		limits := []struct {
+			name      string
+			max       *int64
+			used, req int64
+		}{
+			{"maxPipelines", q.MaxPipelines, used.pipelines, req.pipelines},
+			{"maxSources", q.MaxSources, used.sources, req.sources},
+			{"maxTransforms", q.MaxTransforms, used.transforms, req.transforms},
+			{"maxSinks", q.MaxSinks, used.sinks, req.sinks},
+			{"maxConfigBytes", q.MaxConfigBytes, used.configBytes, req.configBytes},
+		}
+		for _, l := range limits {
+			if l.max != nil && l.used+l.req > *l.max {
+				return QuotaExclusion{Policy: policy.Name, Limit: l.name, Max: *l.max, Used: l.used, Requested: l.req}, true
+			}
+		}
+	}
+	return QuotaExclusion{}, false
+}
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	vectorv1alpha1 "github.com/kaasops/vector-operator/api/v1alpha1"
+	"github.com/kaasops/vector-operator/internal/pipeline"
+)
+
+func TestDetectQuotaExclusions(t *testing.T) {
+	t0 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	created := func(p pipeline.Pipeline, offset time.Duration) pipeline.Pipeline {
+		p.(*vectorv1alpha1.VectorPipeline).CreationTimestamp = metav1.NewTime(t0.Add(offset))
+		return p
+	}
+	logs := `{"logs": {"type": "kubernetes_logs"}}`
+	oneSink := `{"out": {"type": "console", "inputs": ["logs"]}}`
+	twoSinks := `{"a": {"type": "console", "inputs": ["logs"]}, "b": {"type": "console", "inputs": ["logs"]}}`
+
+	quota := testPolicy("tenants", vectorv1alpha1.ClusterVectorPipelinePolicySpec{
+		Quota: &vectorv1alpha1.PipelineQuota{MaxSinks: ptr.To[int64](2)},
+	})
+	policies := func(_ context.Context, p pipeline.Pipeline) ([]vectorv1alpha1.ClusterVectorPipelinePolicy, error) {
+		return []vectorv1alpha1.ClusterVectorPipelinePolicy{quota}, nil
+	}
+
+	oldest := created(testPipeline("team-a", "oldest", logs, oneSink), 0)
+	big := created(testPipeline("team-a", "big", logs, twoSinks), time.Hour)
+	small := created(testPipeline("team-a", "small", logs, oneSink), 2*time.Hour)
+	youngest := created(testPipeline("team-a", "youngest", logs, oneSink), 3*time.Hour)
+	other := created(testPipeline("team-b", "other", logs, twoSinks), 3*time.Hour)
+	cluster := testCVPWithSecret("cluster", nil, logs, twoSinks)
+
+	exclusions, err := DetectQuotaExclusions(context.Background(), policies, youngest, small, big, oldest, other, cluster)
+	require.NoError(t, err)
+	require.Len(t, exclusions, 2)
+
+	// big does not fit next to oldest and takes nothing; small, younger, still does
+	require.Equal(t, "big", exclusions[0].Victim.GetName())
+	require.Equal(t, QuotaExclusion{Victim: big, Policy: "tenants", Limit: "maxSinks", Max: 2, Used: 1, Requested: 2}, exclusions[0])
+	require.Equal(t, "youngest", exclusions[1].Victim.GetName())
+	require.Equal(t, int64(2), exclusions[1].Used)
+
+	exclusions, err = DetectQuotaExclusions(context.Background(), nil, youngest, small, big, oldest)
+	require.NoError(t, err)
+	require.Empty(t, exclusions)
+}
+
+func TestDetectQuotaExclusionsConfigBytes(t *testing.T) {
+	logs := `{"logs": {"type": "kubernetes_logs"}}`
+	sink := `{"out": {"type": "console", "inputs": ["logs"]}}`
+	policies := func(_ context.Context, p pipeline.Pipeline) ([]vectorv1alpha1.ClusterVectorPipelinePolicy, error) {
+		return []vectorv1alpha1.ClusterVectorPipelinePolicy{testPolicy("tenants", vectorv1alpha1.ClusterVectorPipelinePolicySpec{
+			Quota: &vectorv1alpha1.PipelineQuota{MaxConfigBytes: ptr.To(int64(len(logs) + len(sink)))},
+		})}, nil
+	}
+	exclusions, err := DetectQuotaExclusions(context.Background(), policies,
+		testPipeline("team-a", "a", logs, sink), testPipeline("team-a", "b", logs, sink))
+	require.NoError(t, err)
+	require.Len(t, exclusions, 1)
+	require.Equal(t, "b", exclusions[0].Victim.GetName())
+	require.Equal(t, "maxConfigBytes", exclusions[0].Limit)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"regexp"
+	"slices"
 	"sort"
 	"strings"
 	"unicode/utf8"
@@ -135,6 +136,21 @@ func flatKey(ns, name, alias, key string) string {
 // per-pipeline secret resolution and watch registration to backends that a reference
 // actually uses, so a declared-but-unused backend is never read, hashed, or watched.
 func UsedSecretBackends(p pipeline.Pipeline) (map[string]struct{}, error) {
+	keys, err := SecretKeys(p)
+	if err != nil || len(keys) == 0 {
+		return nil, err
+	}
+	used := make(map[string]struct{}, len(keys))
+	for alias := range keys {
+		used[alias] = struct{}{}
+	}
+	return used, nil
+}
+
+// SecretKeys returns the keys referenced by SECRET[alias.key] placeholders in the
+// pipeline's options, by declared spec.secret alias, in the same walk as
+// UsedSecretBackends. Nothing is validated: a malformed key is listed as written.
+func SecretKeys(p pipeline.Pipeline) (map[string][]string, error) {
 	declared := p.GetSpec().Secret
 	if len(declared) == 0 {
 		return nil, nil
@@ -154,14 +170,14 @@ func UsedSecretBackends(p pipeline.Pipeline) (map[string]struct{}, error) {
 		comps = append(comps, v.Options)
 	}
 
-	used := make(map[string]struct{})
+	keys := make(map[string][]string)
 	var walk func(v any)
 	walk = func(v any) {
 		switch val := v.(type) {
 		case string:
 			for _, sub := range secretRefRegex.FindAllStringSubmatch(val, -1) {
-				if _, ok := declared[sub[1]]; ok {
-					used[sub[1]] = struct{}{}
+				if _, ok := declared[sub[1]]; ok && !slices.Contains(keys[sub[1]], sub[2]) {
+					keys[sub[1]] = append(keys[sub[1]], sub[2])
 				}
 			}
 		case map[string]any:
@@ -177,10 +193,13 @@ func UsedSecretBackends(p pipeline.Pipeline) (map[string]struct{}, error) {
 	for _, c := range comps {
 		walk(c)
 	}
-	if len(used) == 0 {
+	if len(keys) == 0 {
 		return nil, nil
 	}
-	return used, nil
+	for _, k := range keys {
+		slices.Sort(k)
+	}
+	return keys, nil
 }
 
 // pipelineID is the identifier used to attribute a secret reference (and a collision)
@@ -290,13 +309,8 @@ func processPipelineSecrets(p pipeline.Pipeline, getter func(ctx context.Context
 	declared := p.GetSpec().Secret
 	_, isVP := p.(*v1alpha1.VectorPipeline)
 
-	for alias, backend := range declared {
-		if isVP && backend.Namespace != "" {
-			return fmt.Errorf("pipeline %s: secret backend %q: namespace is not allowed in VectorPipeline", p.GetName(), alias)
-		}
-		if !isVP && backend.Namespace == "" {
-			return fmt.Errorf("pipeline %s: secret backend %q: namespace is required", p.GetName(), alias)
-		}
+	if err := validateSecretBackends(p); err != nil {
+		return err
 	}
 	if len(declared) > 0 && getter == nil {
 		return fmt.Errorf("pipeline %s: secrets are not supported in this context", p.GetName())
@@ -330,6 +344,22 @@ func processPipelineSecrets(p pipeline.Pipeline, getter func(ctx context.Context
 	return nil
 }
 
+// validateSecretBackends checks the namespace rule of every declared spec.secret
+// backend: forbidden on a VectorPipeline (it may only read Secrets from its own
+// namespace), required on a ClusterVectorPipeline (which has no namespace to default to).
+func validateSecretBackends(p pipeline.Pipeline) error {
+	_, isVP := p.(*v1alpha1.VectorPipeline)
+	for alias, backend := range p.GetSpec().Secret {
+		if isVP && backend.Namespace != "" {
+			return fmt.Errorf("pipeline %s: secret backend %q: namespace is not allowed in VectorPipeline", p.GetName(), alias)
+		}
+		if !isVP && backend.Namespace == "" {
+			return fmt.Errorf("pipeline %s: secret backend %q: namespace is required", p.GetName(), alias)
+		}
+	}
+	return nil
+}
+
 // resolvePendingSecrets fetches every pending secret reference (memoized per
 // namespace/name so a Secret referenced multiple times is fetched once), and
 // materializes cfg.Secret + cfg.internal.secretAssets. A no-op when pending is empty,
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	vectorv1alpha1 "github.com/kaasops/vector-operator/api/v1alpha1"
+	"github.com/kaasops/vector-operator/internal/pipeline"
+)
+
+func suspended(p pipeline.Pipeline) pipeline.Pipeline {
+	p.(*vectorv1alpha1.VectorPipeline).Spec.Suspend = true
+	return p
+}
+
+// A suspended pipeline contributes nothing, not even an error: the config is byte for
+// byte the one built without it.
+func TestBuildAgentConfigSkipsSuspended(t *testing.T) {
+	_, want, err := BuildAgentConfig(VectorConfigParams{}, testLogPipeline("ns-a"))
+	require.NoError(t, err)
+
+	_, got, err := BuildAgentConfig(VectorConfigParams{},
+		testLogPipeline("ns-a"),
+		suspended(testPipeline("ns-b", "noisy", `{"logs": {"type": "kubernetes_logs"}}`, `{"out": {"type": "blackhole", "inputs": ["logs"]}}`)),
+		suspended(testPipeline("ns-c", "broken", `{"logs": {"type": "file"}}`, `{}`)))
+	require.NoError(t, err)
+	require.JSONEq(t, string(want), string(got))
+}
+
+func TestBuildAggregatorConfigSkipsSuspended(t *testing.T) {
+	agg := func(name string) pipeline.Pipeline {
+		return testPipeline("ns", name, `{"in": {"type": "http_server", "address": "0.0.0.0:8080"}}`, `{"out": {"type": "blackhole", "inputs": ["in"]}}`)
+	}
+	want, err := BuildAggregatorConfig(VectorConfigParams{}, agg("kept"))
+	require.NoError(t, err)
+	wantJSON, err := want.MarshalJSON()
+	require.NoError(t, err)
+
+	got, err := BuildAggregatorConfig(VectorConfigParams{}, agg("kept"), suspended(agg("noisy")))
+	require.NoError(t, err)
+	gotJSON, err := got.MarshalJSON()
+	require.NoError(t, err)
+	require.JSONEq(t, string(wantJSON), string(gotJSON))
+}
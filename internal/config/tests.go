@@ -0,0 +1,140 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	"k8s.io/apimachinery/pkg/runtime"
+
+	vectorv1alpha1 "github.com/kaasops/vector-operator/api/v1alpha1"
+	"github.com/kaasops/vector-operator/internal/pipeline"
+)
+
+var ErrInvalidPipelineTest = errors.New("invalid pipeline test")
+
+// Test is a vector unit test in the generated config, as vector test reads it.
+type Test struct {
+	Name          string        `yaml:"name"`
+	Inputs        []*TestInput  `yaml:"inputs"`
+	Outputs       []*TestOutput `yaml:"outputs,omitempty"`
+	NoOutputsFrom []string      `yaml:"no_outputs_from,omitempty"`
+}
+
+type TestInput struct {
+	InsertAt  string         `yaml:"insert_at"`
+	Type      string         `yaml:"type,omitempty"`
+	Value     string         `yaml:"value,omitempty"`
+	Source    string         `yaml:"source,omitempty"`
+	LogFields map[string]any `yaml:"log_fields,omitempty"`
+	Metric    map[string]any `yaml:"metric,omitempty"`
+}
+
+type TestOutput struct {
+	ExtractFrom []string         `yaml:"extract_from"`
+	Conditions  []map[string]any `yaml:"conditions,omitempty"`
+}
+
+// pipelineTests renders the spec.tests of p for a config built from it: the transforms
+// they name get the names the build gives them, and the test its pipeline's name, so
+// that vector's output says whose test failed. transforms are the pipeline's own, by
+// the names in its spec; a test naming anything else fails with ErrInvalidPipelineTest,
+// as vector test would fail on it only after a configcheck.
+func pipelineTests(p pipeline.Pipeline, transforms map[string]*Transform) ([]*Test, error) {
+	specs := p.GetSpec().Tests
+	if len(specs) == 0 {
+		return nil, nil
+	}
+	transform := func(test, field, name string) (string, error) {
+		if _, ok := transforms[name]; !ok {
+			return "", fmt.Errorf("%w: test %q of pipeline %s: %s %q is not a transform of the pipeline", ErrInvalidPipelineTest, test, p.GetName(), field, name)
+		}
+		return addPrefix(p.GetNamespace(), p.GetName(), name), nil
+	}
+
+	tests := make([]*Test, 0, len(specs))
+	names := make(map[string]bool, len(specs))
+	for _, spec := range specs {
+		if names[spec.Name] {
+			return nil, fmt.Errorf("%w: pipeline %s has more than one test named %q", ErrInvalidPipelineTest, p.GetName(), spec.Name)
+		}
+		names[spec.Name] = true
+		test := &Test{Name: testName(p, spec.Name)}
+		for i, in := range spec.Inputs {
+			at, err := transform(spec.Name, fmt.Sprintf("inputs[%d].insert_at", i), in.InsertAt)
+			if err != nil {
+				return nil, err
+			}
+			input := &TestInput{InsertAt: at, Type: in.Type, Value: in.Value, Source: in.Source}
+			if input.LogFields, err = testObject(in.LogFields); err != nil {
+				return nil, fmt.Errorf("%w: test %q of pipeline %s: inputs[%d].log_fields: %w", ErrInvalidPipelineTest, spec.Name, p.GetName(), i, err)
+			}
+			if input.Metric, err = testObject(in.Metric); err != nil {
+				return nil, fmt.Errorf("%w: test %q of pipeline %s: inputs[%d].metric: %w", ErrInvalidPipelineTest, spec.Name, p.GetName(), i, err)
+			}
+			test.Inputs = append(test.Inputs, input)
+		}
+		for i, out := range spec.Outputs {
+			output := &TestOutput{}
+			for _, name := range out.ExtractFrom {
+				from, err := transform(spec.Name, fmt.Sprintf("outputs[%d].extract_from", i), name)
+				if err != nil {
+					return nil, err
+				}
+				output.ExtractFrom = append(output.ExtractFrom, from)
+			}
+			for j := range out.Conditions {
+				condition, err := testObject(&out.Conditions[j])
+				if err != nil {
+					return nil, fmt.Errorf("%w: test %q of pipeline %s: outputs[%d].conditions[%d]: %w", ErrInvalidPipelineTest, spec.Name, p.GetName(), i, j, err)
+				}
+				output.Conditions = append(output.Conditions, condition)
+			}
+			test.Outputs = append(test.Outputs, output)
+		}
+		for _, name := range spec.NoOutputsFrom {
+			from, err := transform(spec.Name, "no_outputs_from", name)
+			if err != nil {
+				return nil, err
+			}
+			test.NoOutputsFrom = append(test.NoOutputsFrom, from)
+		}
+		tests = append(tests, test)
+	}
+	return tests, nil
+}
+
+// testName is the name of the test of p in the config: the names of two pipelines'
+// tests may clash, and the pipeline is what a failure has to point at.
+func testName(p pipeline.Pipeline, name string) string {
+	if _, ok := p.(*vectorv1alpha1.VectorPipeline); ok {
+		return fmt.Sprintf("%s/%s: %s", p.GetNamespace(), p.GetName(), name)
+	}
+	return fmt.Sprintf("%s: %s", p.GetName(), name)
+}
+
+// testObject decodes a JSON object of a test; nil stays nil.
+func testObject(raw *runtime.RawExtension) (map[string]any, error) {
+	if raw == nil || len(raw.Raw) == 0 {
+		return nil, nil
+	}
+	var obj map[string]any
+	if err := json.Unmarshal(raw.Raw, &obj); err != nil {
+		return nil, fmt.Errorf("not an object: %w", err)
+	}
+	return obj, nil
+}
+
+// addPipelineTests adds the tests of p to cfg when params asks for them.
+func addPipelineTests(cfg *VectorConfig, params VectorConfigParams, p pipeline.Pipeline, transforms map[string]*Transform) error {
+	if !params.Tests {
+		return nil
+	}
+	tests, err := pipelineTests(p, transforms)
+	if err != nil {
+		return err
+	}
+	cfg.Tests = slices.Concat(cfg.Tests, tests)
+	return nil
+}
@@ -0,0 +1,105 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	vectorv1alpha1 "github.com/kaasops/vector-operator/api/v1alpha1"
+)
+
+func testedPipeline(tests ...vectorv1alpha1.PipelineTest) *vectorv1alpha1.VectorPipeline {
+	p := testPipeline("team-a", "web",
+		`{"logs": {"type": "kubernetes_logs"}}`,
+		`{"out": {"type": "blackhole", "inputs": ["parse"]}}`).(*vectorv1alpha1.VectorPipeline)
+	p.Spec.Transforms = &runtime.RawExtension{Raw: []byte(`{"parse": {"type": "remap", "inputs": ["logs"], "source": ". = parse_json!(.message)"}}`)}
+	p.Spec.Tests = tests
+	return p
+}
+
+var parseTest = vectorv1alpha1.PipelineTest{
+	Name: "parses json",
+	Inputs: []vectorv1alpha1.PipelineTestInput{{
+		InsertAt:  "parse",
+		Type:      "log",
+		LogFields: &runtime.RawExtension{Raw: []byte(`{"message": "{\"level\": \"info\"}"}`)},
+	}},
+	Outputs: []vectorv1alpha1.PipelineTestOutput{{
+		ExtractFrom: []string{"parse"},
+		Conditions:  []runtime.RawExtension{{Raw: []byte(`{"type": "vrl", "source": ".level == \"info\""}`)}},
+	}},
+	NoOutputsFrom: []string{"parse"},
+}
+
+// The tests name the pipeline's transforms as its spec does, and run against the names
+// the build gives them; the configs the workloads run have no tests.
+func TestPipelineTestsRenamed(t *testing.T) {
+	p := testedPipeline(parseTest)
+
+	_, published, err := BuildAgentConfig(VectorConfigParams{}, p)
+	require.NoError(t, err)
+	assert.NotContains(t, string(published), "tests")
+
+	cfg, checked, err := BuildAgentConfig(VectorConfigParams{Tests: true, EnrichMetadata: true}, p)
+	require.NoError(t, err)
+	require.Len(t, cfg.Tests, 1)
+
+	var doc struct {
+		Tests []map[string]any `json:"tests"`
+	}
+	require.NoError(t, json.Unmarshal(checked, &doc))
+	assert.Equal(t, []map[string]any{{
+		"name": "team-a/web: parses json",
+		"inputs": []any{map[string]any{
+			"insert_at":  "team-a-web-parse",
+			"type":       "log",
+			"log_fields": map[string]any{"message": `{"level": "info"}`},
+		}},
+		"outputs": []any{map[string]any{
+			"extract_from": []any{"team-a-web-parse"},
+			"conditions":   []any{map[string]any{"type": "vrl", "source": `.level == "info"`}},
+		}},
+		"no_outputs_from": []any{"team-a-web-parse"},
+	}}, doc.Tests)
+
+	cluster := &vectorv1alpha1.ClusterVectorPipeline{Spec: p.Spec}
+	cluster.Name = "web"
+	aggCfg, err := BuildAggregatorConfig(VectorConfigParams{Tests: true}, cluster)
+	require.NoError(t, err)
+	require.Len(t, aggCfg.Tests, 1)
+	assert.Equal(t, "web: parses json", aggCfg.Tests[0].Name)
+	assert.Equal(t, "web-parse", aggCfg.Tests[0].Inputs[0].InsertAt)
+}
+
+func TestPipelineTestsInvalid(t *testing.T) {
+	for name, mutate := range map[string]func(*vectorv1alpha1.PipelineTest){
+		"insert_at a source": func(test *vectorv1alpha1.PipelineTest) { test.Inputs[0].InsertAt = "logs" },
+		"extract_from a sink": func(test *vectorv1alpha1.PipelineTest) {
+			test.Outputs = []vectorv1alpha1.PipelineTestOutput{{ExtractFrom: []string{"out"}}}
+		},
+		"no_outputs_from another pipeline": func(test *vectorv1alpha1.PipelineTest) { test.NoOutputsFrom = []string{"team-a-api-parse"} },
+		"log_fields not an object": func(test *vectorv1alpha1.PipelineTest) {
+			test.Inputs[0].LogFields = &runtime.RawExtension{Raw: []byte(`"message"`)}
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			test := parseTest
+			test.Inputs = []vectorv1alpha1.PipelineTestInput{parseTest.Inputs[0]}
+			test.Outputs = nil
+			test.NoOutputsFrom = nil
+			mutate(&test)
+			p := testedPipeline(test)
+
+			_, _, err := BuildAgentConfig(VectorConfigParams{Tests: true}, p)
+			require.ErrorIs(t, err, ErrInvalidPipelineTest)
+			_, err = ValidatePipeline(p)
+			require.ErrorIs(t, err, ErrInvalidPipelineTest)
+		})
+	}
+
+	_, err := ValidatePipeline(testedPipeline(parseTest, parseTest))
+	require.ErrorIs(t, err, ErrInvalidPipelineTest, "test names are unique in a pipeline")
+}
@@ -20,6 +20,8 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"github.com/kaasops/vector-operator/internal/evconfig"
+	"github.com/kaasops/vector-operator/internal/pipeline"
 	"github.com/kaasops/vector-operator/internal/utils/hash"
 
 	corev1 "k8s.io/api/core/v1"
@@ -37,7 +39,10 @@ type VectorConfig struct {
 	// Secret holds the secret backend section (e.g. the k8s directory backend), set
 	// only when at least one pipeline references a SECRET[] value. Absent otherwise,
 	// so configs without secrets stay byte-identical to pre-secrets behavior.
-	Secret   map[string]any `yaml:"secret,omitempty"`
+	Secret map[string]any `yaml:"secret,omitempty"`
+	// Tests are the unit tests of the pipelines, added only with
+	// VectorConfigParams.Tests.
+	Tests    []*Test        `yaml:"tests,omitempty"`
 	internal internalConfig `yaml:"-"`
 }
 
@@ -86,12 +91,14 @@ type pipelineConfig_ struct {
 
 type ServicePort struct {
 	IsKubernetesEvents bool
-	PipelineName       string
-	SourceName         string
-	Namespace          string
-	Port               int32
-	Protocol           corev1.Protocol
-	ServiceName        string
+	// EventFilter is the filter of a kubernetes_events source, for its receiver.
+	EventFilter  evconfig.EventFilter
+	PipelineName string
+	SourceName   string
+	Namespace    string
+	Port         int32
+	Protocol     corev1.Protocol
+	ServiceName  string
 }
 
 type internalConfig struct {
@@ -102,6 +109,10 @@ type internalConfig struct {
 	// into the aggregated Secret mounted at SecretsMountPath. Empty when no pipeline
 	// references a secret.
 	secretAssets map[string][]byte
+	// pipelines are the pipelines the config was built from with params, for Graph to
+	// group the components by (componentPipelines).
+	params    VectorConfigParams
+	pipelines []pipeline.Pipeline
 }
 
 // SecretAssets returns the resolved secret data (flatKey -> value) collected while
@@ -110,6 +121,24 @@ func (c *VectorConfig) SecretAssets() map[string][]byte {
 	return c.internal.secretAssets
 }
 
+// addPipeline records that the config was built from p.
+func (c *internalConfig) addPipeline(params VectorConfigParams, p pipeline.Pipeline) {
+	c.params = params
+	c.pipelines = append(c.pipelines, p)
+}
+
+// componentPipelines maps every component to the pipelines that generated it
+// (pipelineID). It parses each spec again (PipelineComponents), so only Graph builds it.
+func (c *internalConfig) componentPipelines() map[string][]string {
+	owners := make(map[string][]string)
+	for _, p := range c.pipelines {
+		for _, name := range PipelineComponents(c.params, p) {
+			owners[name] = append(owners[name], pipelineID(p))
+		}
+	}
+	return owners
+}
+
 func (c *internalConfig) addServicePort(port *ServicePort) error {
 	key := fmt.Sprintf("%d/%s", port.Port, port.Protocol)
 	if v, ok := c.servicePort[key]; !ok {
@@ -0,0 +1,116 @@
+package config
+
+import (
+	"fmt"
+
+	vectorv1alpha1 "github.com/kaasops/vector-operator/api/v1alpha1"
+	"github.com/kaasops/vector-operator/internal/pipeline"
+)
+
+// ValidatePipeline runs the spec checks the reconcilers apply to a single pipeline,
+// without touching the cluster: the spec must unmarshal (UnmarshalJson), its sources
+// must resolve to one role (VectorRole), a namespaced agent pipeline must only use
+// kubernetes_logs sources confined to its own namespace (ErrNotAllowedSourceType,
+// ErrClusterScopeNotAllowed), spec.aggregatorRef must name an aggregator that can run
+// the pipeline (ValidateAggregatorRef), a sink's outputRef must be well formed
+// (pipeline.OutputRefs), spec.tests must only name the pipeline's own transforms
+// (pipelineTests), the filter of a kubernetes_events source must be well formed
+// (kubernetesEventsFilter), and every SECRET[alias.key] reference must name a declared
+// backend with a well-formed key whose generated flat key fits a Secret key.
+//
+// It is what the admission webhook calls, so a spec rejected here is one the pipeline
+// controller would otherwise have marked invalid after the fact. Anything that needs
+// other objects - Secret contents, the outputs a sink references, collisions between
+// pipelines, the workload's configcheck - is deliberately left to reconciliation. So is
+// nearly all of a pipeline rendered from a template: without the template there is no
+// spec to check, and the role is returned nil. The pipeline is not modified: the
+// SECRET[] rewrite runs on a freshly unmarshaled copy of the spec.
+func ValidatePipeline(p pipeline.Pipeline) (*vectorv1alpha1.VectorPipelineRole, error) {
+	if p.GetSpec().TemplateRef != nil {
+		if err := pipeline.ValidateTemplateRef(p.GetSpec()); err != nil {
+			return nil, err
+		}
+		return nil, validateSecretBackends(p)
+	}
+	cfg := &PipelineConfig{}
+	if err := UnmarshalJson(p.GetSpec(), cfg); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal pipeline %s: %w", p.GetName(), err)
+	}
+	role, err := cfg.VectorRole()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := p.(*vectorv1alpha1.VectorPipeline); ok && *role == vectorv1alpha1.VectorPipelineRoleAgent {
+		for k, v := range cfg.Sources {
+			if err := validateNamespacedAgentSource(p.GetNamespace(), k, v); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for k, v := range cfg.Sources {
+		if v.Type != kubernetesEventsType {
+			continue
+		}
+		if _, err := kubernetesEventsFilter(v.Options); err != nil {
+			return nil, fmt.Errorf("source %s: %w", k, err)
+		}
+	}
+
+	if err := ValidateAggregatorRef(p, *role); err != nil {
+		return nil, err
+	}
+	if _, err := pipeline.OutputRefs(p.GetSpec()); err != nil {
+		return nil, err
+	}
+	if err := validateSecretBackends(p); err != nil {
+		return nil, err
+	}
+	if _, err := pipelineTests(p, cfg.Transforms); err != nil {
+		return nil, err
+	}
+	var comps []map[string]any
+	for _, v := range cfg.Sources {
+		comps = append(comps, v.Options)
+	}
+	for _, v := range cfg.Transforms {
+		comps = append(comps, v.Options)
+	}
+	for _, v := range cfg.Sinks {
+		comps = append(comps, v.Options)
+	}
+	declared := p.GetSpec().Secret
+	for _, opts := range comps {
+		if len(opts) == 0 {
+			continue
+		}
+		if _, err := scanAndRewriteSecretRefs(opts, p.GetNamespace(), p.GetName(), declared); err != nil {
+			return nil, fmt.Errorf("pipeline %s: %w", p.GetName(), err)
+		}
+	}
+	return role, nil
+}
+
+// ValidateAggregatorRef checks spec.aggregatorRef against the pipeline's kind and role.
+// A VectorAggregator only builds from VectorPipelines in its own namespace and a
+// ClusterVectorAggregator only from ClusterVectorPipelines, so any other pairing would
+// pin the pipeline to an aggregator that never runs it; and an agent pipeline runs on
+// every matching Vector, which a reference to an aggregator cannot change.
+func ValidateAggregatorRef(p pipeline.Pipeline, role vectorv1alpha1.VectorPipelineRole) error {
+	ref := p.GetSpec().AggregatorRef
+	if ref == nil {
+		return nil
+	}
+	if role != vectorv1alpha1.VectorPipelineRoleAggregator {
+		return fmt.Errorf("%w: only an aggregator pipeline can name an aggregator, pipeline %s has role %s", ErrInvalidAggregatorRef, p.GetName(), role)
+	}
+	want := "VectorAggregator"
+	if _, ok := p.(*vectorv1alpha1.ClusterVectorPipeline); ok {
+		want = "ClusterVectorAggregator"
+	}
+	if ref.Kind != want {
+		return fmt.Errorf("%w: pipeline %s can only name a %s, got %s", ErrInvalidAggregatorRef, p.GetName(), want, ref.Kind)
+	}
+	return nil
+}
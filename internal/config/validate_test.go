@@ -0,0 +1,161 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	vectorv1alpha1 "github.com/kaasops/vector-operator/api/v1alpha1"
+	"github.com/kaasops/vector-operator/internal/pipeline"
+)
+
+const validateTestSinks = `{"out":{"type":"blackhole","inputs":["logs"]}}`
+
+func TestValidatePipelineAcceptsAgentVP(t *testing.T) {
+	p := testVPWithSecret("team-a", "app", nil,
+		`{"logs":{"type":"kubernetes_logs","extra_label_selector":"app=web"}}`, validateTestSinks)
+	role, err := ValidatePipeline(p)
+	require.NoError(t, err)
+	require.Equal(t, vectorv1alpha1.VectorPipelineRoleAgent, *role)
+}
+
+func TestValidatePipelineRejectsNonKubernetesLogsAgentVP(t *testing.T) {
+	p := testVPWithSecret("team-a", "app", nil, `{"logs":{"type":"file","include":["/var/log/*.log"]}}`, validateTestSinks)
+	_, err := ValidatePipeline(p)
+	require.ErrorIs(t, err, ErrNotAllowedSourceType)
+}
+
+func TestValidatePipelineRejectsForeignNamespaceSelector(t *testing.T) {
+	p := testVPWithSecret("team-a", "app", nil,
+		`{"logs":{"type":"kubernetes_logs","extra_namespace_label_selector":"kubernetes.io/metadata.name=team-b"}}`, validateTestSinks)
+	_, err := ValidatePipeline(p)
+	require.ErrorIs(t, err, ErrClusterScopeNotAllowed)
+}
+
+func TestValidatePipelineRejectsBadSelector(t *testing.T) {
+	p := testVPWithSecret("team-a", "app", nil,
+		`{"logs":{"type":"kubernetes_logs","extra_label_selector":"app in (web"}}`, validateTestSinks)
+	_, err := ValidatePipeline(p)
+	require.ErrorContains(t, err, "invalid pod selector for source logs")
+}
+
+func TestValidatePipelineAllowsAggregatorVP(t *testing.T) {
+	// The kubernetes_logs-only rule is an agent rule: a namespaced aggregator pipeline
+	// is built by BuildAggregatorConfig, which never applied it.
+	p := testVPWithSecret("team-a", "app", nil, `{"logs":{"type":"http_server","address":"0.0.0.0:8080"}}`, validateTestSinks)
+	role, err := ValidatePipeline(p)
+	require.NoError(t, err)
+	require.Equal(t, vectorv1alpha1.VectorPipelineRoleAggregator, *role)
+}
+
+func TestValidatePipelineAllowsClusterScopeCVP(t *testing.T) {
+	p := testCVPWithSecret("cluster", nil,
+		`{"logs":{"type":"kubernetes_logs","extra_namespace_label_selector":"kubernetes.io/metadata.name=team-b"}}`, validateTestSinks)
+	_, err := ValidatePipeline(p)
+	require.NoError(t, err)
+}
+
+func TestValidatePipelineRejectsUnknownRole(t *testing.T) {
+	p := testVPWithSecret("team-a", "app", nil, `{"logs":{"type":"no_such_source"}}`, validateTestSinks)
+	_, err := ValidatePipeline(p)
+	require.ErrorContains(t, err, "unsupported source type: no_such_source")
+
+	p = testVPWithSecret("team-a", "app", nil, `{}`, validateTestSinks)
+	_, err = ValidatePipeline(p)
+	require.ErrorContains(t, err, "sources list is empty")
+}
+
+func TestValidatePipelineSecretRefs(t *testing.T) {
+	declared := map[string]vectorv1alpha1.PipelineSecretBackend{"es": {Type: "kubernetes_secret", Name: "creds"}}
+	sources := `{"logs":{"type":"kubernetes_logs"}}`
+
+	p := testVPWithSecret("team-a", "app", declared, sources,
+		`{"out":{"type":"elasticsearch","inputs":["logs"],"auth":{"user":"SECRET[es.username]"}}}`)
+	_, err := ValidatePipeline(p)
+	require.NoError(t, err)
+	// the rewrite ran on a copy: the stored spec still carries the user's placeholder
+	require.Contains(t, string(p.GetSpec().Sinks.Raw), "SECRET[es.username]")
+
+	p = testVPWithSecret("team-a", "app", declared, sources,
+		`{"out":{"type":"elasticsearch","inputs":["logs"],"auth":{"user":"SECRET[nope.username]"}}}`)
+	_, err = ValidatePipeline(p)
+	require.ErrorContains(t, err, `backend "nope" is not declared`)
+
+	p = testVPWithSecret("team-a", "app", declared, sources,
+		`{"out":{"type":"elasticsearch","inputs":["logs"],"auth":{"user":"SECRET[es.user/name]"}}}`)
+	_, err = ValidatePipeline(p)
+	require.ErrorContains(t, err, "must match")
+}
+
+func TestValidatePipelineSecretBackendNamespace(t *testing.T) {
+	sources := `{"logs":{"type":"kubernetes_logs"}}`
+
+	vp := testVPWithSecret("team-a", "app",
+		map[string]vectorv1alpha1.PipelineSecretBackend{"es": {Type: "kubernetes_secret", Name: "creds", Namespace: "other"}},
+		sources, validateTestSinks)
+	_, err := ValidatePipeline(vp)
+	require.ErrorContains(t, err, "namespace is not allowed in VectorPipeline")
+
+	cvp := testCVPWithSecret("cluster",
+		map[string]vectorv1alpha1.PipelineSecretBackend{"es": {Type: "kubernetes_secret", Name: "creds"}},
+		sources, validateTestSinks)
+	_, err = ValidatePipeline(cvp)
+	require.ErrorContains(t, err, "namespace is required")
+}
+
+func TestValidatePipelineAggregatorRef(t *testing.T) {
+	aggSources := `{"logs":{"type":"http_server","address":"0.0.0.0:8080"}}`
+	withRef := func(p pipeline.Pipeline, kind string) pipeline.Pipeline {
+		ref := &vectorv1alpha1.AggregatorReference{Kind: kind, Name: "agg"}
+		switch v := p.(type) {
+		case *vectorv1alpha1.VectorPipeline:
+			v.Spec.AggregatorRef = ref
+		case *vectorv1alpha1.ClusterVectorPipeline:
+			v.Spec.AggregatorRef = ref
+		}
+		return p
+	}
+
+	_, err := ValidatePipeline(withRef(testVPWithSecret("team-a", "app", nil, aggSources, validateTestSinks), "VectorAggregator"))
+	require.NoError(t, err)
+	_, err = ValidatePipeline(withRef(testCVPWithSecret("cluster", nil, aggSources, validateTestSinks), "ClusterVectorAggregator"))
+	require.NoError(t, err)
+
+	// each aggregator kind only ever builds from one pipeline kind
+	_, err = ValidatePipeline(withRef(testVPWithSecret("team-a", "app", nil, aggSources, validateTestSinks), "ClusterVectorAggregator"))
+	require.ErrorIs(t, err, ErrInvalidAggregatorRef)
+	_, err = ValidatePipeline(withRef(testCVPWithSecret("cluster", nil, aggSources, validateTestSinks), "VectorAggregator"))
+	require.ErrorIs(t, err, ErrInvalidAggregatorRef)
+
+	// an agent pipeline runs on Vectors, which a reference to an aggregator cannot pin
+	_, err = ValidatePipeline(withRef(testVPWithSecret("team-a", "app", nil, `{"logs":{"type":"kubernetes_logs"}}`, validateTestSinks), "VectorAggregator"))
+	require.ErrorIs(t, err, ErrInvalidAggregatorRef)
+	require.ErrorContains(t, err, "has role agent")
+}
+
+func TestValidatePipelineOutputRef(t *testing.T) {
+	sources := `{"logs":{"type":"kubernetes_logs"}}`
+
+	// the output itself is only looked up at reconcile time
+	_, err := ValidatePipeline(testVPWithSecret("team-a", "app", nil, sources, `{"es":{"outputRef":"central","inputs":["logs"]}}`))
+	require.NoError(t, err)
+
+	_, err = ValidatePipeline(testVPWithSecret("team-a", "app", nil, sources, `{"es":{"outputRef":"central","type":"console","inputs":["logs"]}}`))
+	require.ErrorIs(t, err, pipeline.ErrInvalidOutputRef)
+}
+
+func TestValidatePipelineTemplateRef(t *testing.T) {
+	vp := testVPWithSecret("team-a", "app", nil, `{}`, `{}`).(*vectorv1alpha1.VectorPipeline)
+	vp.Spec.TemplateRef = &vectorv1alpha1.TemplateReference{Name: "per-team"}
+
+	// the body comes from the template, which is only read at reconcile time
+	vp.Spec.Sources, vp.Spec.Sinks = nil, nil
+	role, err := ValidatePipeline(vp)
+	require.NoError(t, err)
+	require.Nil(t, role)
+
+	vp.Spec.Sinks = &runtime.RawExtension{Raw: []byte(validateTestSinks)}
+	_, err = ValidatePipeline(vp)
+	require.ErrorIs(t, err, pipeline.ErrInvalidTemplateRef)
+}
@@ -0,0 +1,323 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/kaasops/vector-operator/api/v1alpha1"
+	"github.com/kaasops/vector-operator/internal/config"
+	"github.com/kaasops/vector-operator/internal/config/configcheck"
+	"github.com/kaasops/vector-operator/internal/pipeline"
+	"github.com/kaasops/vector-operator/internal/utils/k8s"
+)
+
+// DefaultConfigCheckAttributionMaxChecks bounds the extra configchecks a workload runs to
+// find the pipelines its failed configcheck is down to. Locating one culprit among n
+// pipelines takes about log2(n) checks, one named in vector's output usually one.
+const DefaultConfigCheckAttributionMaxChecks = 16
+
+// configCheckAttributionRequeueDelay is how long a workload waits to rebuild without the
+// culprits it marked: long enough for the status writes to reach the cache the next
+// round lists pipelines from.
+const configCheckAttributionRequeueDelay = time.Second
+
+// errAttributionBudget ends an attribution that ran out of checks.
+var errAttributionBudget = errors.New("configcheck attribution: out of checks")
+
+// componentIDPattern matches the component IDs in vector's output: generated names are
+// Kubernetes names joined with '-' and '_', route outputs add ".<route>".
+var componentIDPattern = regexp.MustCompile(`[A-Za-z0-9_.\-]+`)
+
+// configChecker runs a workload's configcheck on the config built from pipelines alone,
+// returning what configcheck.Validator.Run returns.
+type configChecker func(ctx context.Context, pipelines []pipeline.Pipeline) (reason string, err error)
+
+// configCheckCulprit is a pipeline the workload's configcheck fails with, and Reason the
+// output of a failing check that had it in.
+type configCheckCulprit struct {
+	Pipeline pipeline.Pipeline
+	Reason   string
+}
+
+// attributeConfigCheckFailure finds the pipelines a workload's configcheck, which failed
+// on the config of all of pipelines with output failure, is down to: a set whose removal
+// makes the check pass, each member of which fails it when added back to what passes.
+//
+// vector names the components it rejects, so the pipelines owning a component in failure
+// (components maps a pipeline to its component names, config.PipelineComponents) are
+// tried first: when the check passes without them, only they are searched. Otherwise, or
+// when no name points at a single pipeline, all of them are, after a check of the
+// workload with no pipeline at all - a failure there is the workload's own, not any
+// pipeline's.
+//
+// It gives up, returning no culprits and an error, after maxChecks checks or on a check
+// that errs other than with a validation failure: a configcheck pod that cannot start or
+// a timeout says nothing about the config.
+func attributeConfigCheckFailure(ctx context.Context, pipelines []pipeline.Pipeline, failure string, components func(pipeline.Pipeline) []string, check configChecker, maxChecks int) ([]configCheckCulprit, error) {
+	if len(pipelines) == 0 {
+		return nil, nil
+	}
+	a := &attribution{check: check, checksLeft: maxChecks}
+
+	suspects := suspectPipelines(failure, pipelines, components)
+	if len(suspects) > 0 && len(suspects) < len(pipelines) {
+		rest := withoutPipelines(pipelines, suspects)
+		restFailure, err := a.run(ctx, rest)
+		if err != nil {
+			return nil, err
+		}
+		if restFailure == "" {
+			return a.search(ctx, rest, suspects, failure)
+		}
+	}
+	baseFailure, err := a.run(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	if baseFailure != "" {
+		return nil, nil
+	}
+	return a.search(ctx, nil, pipelines, failure)
+}
+
+type attribution struct {
+	check      configChecker
+	checksLeft int
+}
+
+// run checks pipelines, returning the check's output when it fails and "" when it passes.
+func (a *attribution) run(ctx context.Context, pipelines []pipeline.Pipeline) (string, error) {
+	if a.checksLeft <= 0 {
+		return "", errAttributionBudget
+	}
+	a.checksLeft--
+	reason, err := a.check(ctx, pipelines)
+	switch {
+	case err == nil:
+		return "", nil
+	case configcheck.IsConfigInvalid(err):
+		if reason == "" {
+			reason = err.Error()
+		}
+		return reason, nil
+	default:
+		return "", err
+	}
+}
+
+// search returns the culprits among suspects given base, a set the check passes on.
+// failure is the output of the check on base plus suspects when it is already known to
+// fail, "" when that is still to be checked. The halves are searched in turn, the first
+// with base alone and the second with base plus the innocent part of the first: a
+// culprit is a pipeline the check fails with once everything found innocent is in.
+func (a *attribution) search(ctx context.Context, base, suspects []pipeline.Pipeline, failure string) ([]configCheckCulprit, error) {
+	if failure == "" {
+		var err error
+		if failure, err = a.run(ctx, concatPipelines(base, suspects)); err != nil || failure == "" {
+			return nil, err
+		}
+	}
+	if len(suspects) == 1 {
+		return []configCheckCulprit{{Pipeline: suspects[0], Reason: failure}}, nil
+	}
+	first, second := suspects[:len(suspects)/2], suspects[len(suspects)/2:]
+	culprits, err := a.search(ctx, base, first, "")
+	if err != nil {
+		return nil, err
+	}
+	// with no culprit in the first half, base plus the first half passes and adding the
+	// second fails: that check is the one already run
+	secondFailure := ""
+	if len(culprits) == 0 {
+		secondFailure = failure
+	}
+	innocent := withoutPipelines(first, culpritPipelines(culprits))
+	more, err := a.search(ctx, concatPipelines(base, innocent), second, secondFailure)
+	if err != nil {
+		return nil, err
+	}
+	return append(culprits, more...), nil
+}
+
+// suspectPipelines returns the pipelines owning a component named in output, in the order
+// of pipelines. A name several pipelines share, the sink of a VectorOutput, points at
+// none of them.
+func suspectPipelines(output string, pipelines []pipeline.Pipeline, components func(pipeline.Pipeline) []string) []pipeline.Pipeline {
+	owners := make(map[string][]int)
+	for i, p := range pipelines {
+		for _, name := range components(p) {
+			owners[name] = append(owners[name], i)
+		}
+	}
+	named := make(map[int]bool)
+	for _, id := range componentIDPattern.FindAllString(output, -1) {
+		// a sentence can end right after a name, and a route output is named
+		// "<route transform>.<route>"
+		id = strings.TrimRight(id, ".")
+		candidates := []string{id}
+		if i := strings.LastIndexByte(id, '.'); i > 0 {
+			candidates = append(candidates, id[:i])
+		}
+		for _, name := range candidates {
+			if o := owners[name]; len(o) == 1 {
+				named[o[0]] = true
+				break
+			}
+		}
+	}
+	var suspects []pipeline.Pipeline
+	for i, p := range pipelines {
+		if named[i] {
+			suspects = append(suspects, p)
+		}
+	}
+	return suspects
+}
+
+// excludeConfigCheckCulprits attributes the configcheck failure of workload, whose
+// config built from pipelines with params failed, and has the workload leave the
+// culprits out, reporting whether it found any: the workload then rebuilds without them.
+// When it finds none, for whatever reason, the workload fails as before. maxChecks 0
+// disables it.
+func excludeConfigCheckCulprits(ctx context.Context, c client.Client, maxChecks int, params config.VectorConfigParams, pipelines []pipeline.Pipeline, failure string, check configChecker, workload v1alpha1.WorkloadReference) (bool, error) {
+	if maxChecks <= 0 {
+		return false, nil
+	}
+	log := log.FromContext(ctx)
+	components := func(p pipeline.Pipeline) []string { return config.PipelineComponents(params, p) }
+	culprits, err := attributeConfigCheckFailure(ctx, pipelines, failure, components, check, maxChecks)
+	if err != nil {
+		log.Error(err, "Configcheck failure not attributed to pipelines")
+		return false, nil
+	}
+	if len(culprits) == 0 {
+		return false, nil
+	}
+	pipelineOf := componentPipelineName(params, pipelines)
+	for _, culprit := range culprits {
+		log.Info("Pipeline fails the workload configcheck, leaving it out", "pipeline", client.ObjectKeyFromObject(culprit.Pipeline))
+		if err := markConfigCheckCulprit(ctx, c, culprit, pipelineOf, workload); err != nil {
+			return true, err
+		}
+	}
+	return true, nil
+}
+
+// markConfigCheckCulprit has workload leave culprit out of its config, with a Warning
+// event on the pipeline summing up the check that failed with it in. The pipeline stays
+// valid, and in the config of every other workload.
+func markConfigCheckCulprit(ctx context.Context, c client.Client, culprit configCheckCulprit, pipelineOf func(string) string, workload v1alpha1.WorkloadReference) error {
+	p := culprit.Pipeline
+	added, err := pipeline.ExcludeFromWorkload(ctx, c, p, workload)
+	if err != nil || !added {
+		return err
+	}
+	summary := configcheck.Summary(configcheck.NewReport(culprit.Reason, pipelineOf))
+	k8s.Eventf(ctx, p, corev1.EventTypeWarning, v1alpha1.ReasonWorkloadConfigCheckFailed, k8s.EventActionCheckConfig,
+		"The configcheck of %s fails with this pipeline and passes without it; the pipeline is left out of that config until it is edited: %s",
+		workloadName(workload), summary)
+	return nil
+}
+
+// configCheckAttributions runs the attributions of a reconciler's workloads in the
+// background. An attribution takes up to maxChecks checks, each waiting for its slot in
+// the configcheck queue, and a workload reconciler runs one reconcile at a time: run in
+// the reconcile, it would hold up every other workload of the kind for as long. The zero
+// value is ready to use.
+type configCheckAttributions struct {
+	mu   sync.Mutex
+	runs map[string]*attributionRun
+}
+
+// attributionRun is the attribution of the failed config with hash config.
+type attributionRun struct {
+	config   uint32
+	done     bool
+	excluded bool
+}
+
+// attribute returns the outcome of the attribution of the failed config with hash config
+// of the workload with key, starting it when there is none: done is false while it runs,
+// excluded tells whether it left culprits out. wake is called once it is done, to hand
+// the outcome to the workload's next reconcile; an outcome is handed out once. A run for
+// an older config of the workload is superseded, though not stopped.
+func (a *configCheckAttributions) attribute(ctx context.Context, key string, config uint32, exclude func(ctx context.Context) bool, wake func()) (done, excluded bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if run, ok := a.runs[key]; ok && run.config == config {
+		if run.done {
+			delete(a.runs, key)
+		}
+		return run.done, run.excluded
+	}
+	if a.runs == nil {
+		a.runs = make(map[string]*attributionRun)
+	}
+	run := &attributionRun{config: config}
+	a.runs[key] = run
+	// the run outlives the reconcile, but keeps its logger and event recorder
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		excluded := exclude(ctx)
+		a.mu.Lock()
+		run.done, run.excluded = true, excluded
+		a.mu.Unlock()
+		if wake != nil {
+			wake()
+		}
+	}()
+	return false, false
+}
+
+func culpritPipelines(culprits []configCheckCulprit) []pipeline.Pipeline {
+	ps := make([]pipeline.Pipeline, 0, len(culprits))
+	for _, culprit := range culprits {
+		ps = append(ps, culprit.Pipeline)
+	}
+	return ps
+}
+
+func concatPipelines(a, b []pipeline.Pipeline) []pipeline.Pipeline {
+	return append(append(make([]pipeline.Pipeline, 0, len(a)+len(b)), a...), b...)
+}
+
+// withoutPipelines returns ps less the pipelines of drop, the complement of
+// intersectPipelinesByKey.
+func withoutPipelines(ps, drop []pipeline.Pipeline) []pipeline.Pipeline {
+	dropped := make(map[types.NamespacedName]struct{}, len(drop))
+	for _, p := range drop {
+		dropped[client.ObjectKeyFromObject(p)] = struct{}{}
+	}
+	var rest []pipeline.Pipeline
+	for _, p := range ps {
+		if _, ok := dropped[client.ObjectKeyFromObject(p)]; !ok {
+			rest = append(rest, p)
+		}
+	}
+	return rest
+}
@@ -0,0 +1,335 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/events"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/kaasops/vector-operator/api/v1alpha1"
+	"github.com/kaasops/vector-operator/internal/config"
+	"github.com/kaasops/vector-operator/internal/config/configcheck"
+	"github.com/kaasops/vector-operator/internal/pipeline"
+	"github.com/kaasops/vector-operator/internal/utils/k8s"
+)
+
+func attributionPipeline(name string) *v1alpha1.VectorPipeline {
+	return &v1alpha1.VectorPipeline{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "team-a"},
+		Spec: v1alpha1.VectorPipelineSpec{
+			Sources: &runtime.RawExtension{Raw: []byte(`{"logs":{"type":"kubernetes_logs"}}`)},
+			Sinks:   &runtime.RawExtension{Raw: []byte(`{"out":{"inputs":["logs"],"type":"console"}}`)},
+		},
+		Status: v1alpha1.VectorPipelineStatus{
+			ConfigCheckResult: boolPtr(true),
+			Role:              rolePtr(v1alpha1.VectorPipelineRoleAgent),
+		},
+	}
+}
+
+func attributionPipelines(names ...string) []pipeline.Pipeline {
+	ps := make([]pipeline.Pipeline, 0, len(names))
+	for _, name := range names {
+		ps = append(ps, attributionPipeline(name))
+	}
+	return ps
+}
+
+// fakeWorkloadCheck fails the config of any set of pipelines fails holds, naming the sink
+// of the first bad pipeline when named is set, and counts the checks it ran.
+type fakeWorkloadCheck struct {
+	fails  func(names []string) bool
+	named  bool
+	checks int
+}
+
+func (f *fakeWorkloadCheck) run(_ context.Context, ps []pipeline.Pipeline) (string, error) {
+	f.checks++
+	names := make([]string, 0, len(ps))
+	for _, p := range ps {
+		names = append(names, p.GetName())
+	}
+	if !f.fails(names) {
+		return "", nil
+	}
+	output := "Configuration error."
+	if f.named && len(names) > 0 {
+		output = fmt.Sprintf("Sink \"team-a-%s-out\": unknown option.", names[len(names)-1])
+	}
+	return output, configcheck.ErrValidation
+}
+
+func failsWith(bad ...string) func([]string) bool {
+	return func(names []string) bool {
+		return slices.ContainsFunc(names, func(n string) bool { return slices.Contains(bad, n) })
+	}
+}
+
+func componentsOf(p pipeline.Pipeline) []string {
+	return config.PipelineComponents(config.VectorConfigParams{}, p)
+}
+
+func pipelineNames(ps []pipeline.Pipeline) []string {
+	var names []string
+	for _, p := range ps {
+		names = append(names, p.GetName())
+	}
+	return names
+}
+
+func culpritNames(culprits []configCheckCulprit) []string {
+	var names []string
+	for _, c := range culprits {
+		names = append(names, c.Pipeline.GetName())
+	}
+	return names
+}
+
+// A component vector names is traced to its pipeline, and one check without that
+// pipeline confirms it.
+func TestAttributeConfigCheckFailureNamedComponent(t *testing.T) {
+	ps := attributionPipelines("a", "b", "c", "d")
+	check := &fakeWorkloadCheck{fails: failsWith("c")}
+
+	culprits, err := attributeConfigCheckFailure(context.Background(), ps,
+		`Sink "team-a-c-out": unknown option "bogus".`, componentsOf, check.run, DefaultConfigCheckAttributionMaxChecks)
+	require.NoError(t, err)
+	require.Equal(t, []string{"c"}, culpritNames(culprits))
+	require.Equal(t, 1, check.checks)
+	require.Contains(t, culprits[0].Reason, "team-a-c-out")
+}
+
+// Without a usable name the pipelines are bisected, after making sure the workload
+// passes with none of them.
+func TestAttributeConfigCheckFailureBisects(t *testing.T) {
+	ps := attributionPipelines("a", "b", "c", "d", "e", "f", "g", "h")
+	for _, bad := range [][]string{{"a"}, {"f"}, {"b", "g"}} {
+		check := &fakeWorkloadCheck{fails: failsWith(bad...), named: true}
+		// a name several pipelines share points at none of them
+		output := "Sink \"output_team-a_central\": connection refused."
+
+		culprits, err := attributeConfigCheckFailure(context.Background(), ps, output, componentsOf, check.run, DefaultConfigCheckAttributionMaxChecks)
+		require.NoError(t, err, bad)
+		require.Equal(t, bad, culpritNames(culprits))
+		require.LessOrEqual(t, check.checks, 1+2*len(bad)*3, bad)
+		for _, c := range culprits {
+			require.Contains(t, c.Reason, "team-a-"+c.Pipeline.GetName()+"-out", "the reason comes from a check that failed with it")
+		}
+	}
+}
+
+// A misleading name does not blame the wrong pipeline: when the check still fails
+// without the named one, every pipeline is searched.
+func TestAttributeConfigCheckFailureMisleadingName(t *testing.T) {
+	ps := attributionPipelines("a", "b", "c", "d")
+	check := &fakeWorkloadCheck{fails: failsWith("d")}
+
+	culprits, err := attributeConfigCheckFailure(context.Background(), ps, `Input "team-a-b-out" is unused.`, componentsOf, check.run, DefaultConfigCheckAttributionMaxChecks)
+	require.NoError(t, err)
+	require.Equal(t, []string{"d"}, culpritNames(culprits))
+}
+
+// Two pipelines that only fail together: the one added last to what passes is left out,
+// the other one keeps its place.
+func TestAttributeConfigCheckFailureConflict(t *testing.T) {
+	ps := attributionPipelines("a", "b", "c", "d")
+	check := &fakeWorkloadCheck{fails: func(names []string) bool {
+		return slices.Contains(names, "a") && slices.Contains(names, "c")
+	}}
+
+	culprits, err := attributeConfigCheckFailure(context.Background(), ps, "Configuration error.", componentsOf, check.run, DefaultConfigCheckAttributionMaxChecks)
+	require.NoError(t, err)
+	require.Equal(t, []string{"c"}, culpritNames(culprits))
+}
+
+func TestAttributeConfigCheckFailureGivesUp(t *testing.T) {
+	ctx := context.Background()
+	ps := attributionPipelines("a", "b", "c", "d", "e", "f", "g", "h")
+
+	// the workload fails with no pipeline at all: none of them is to blame
+	check := &fakeWorkloadCheck{fails: func([]string) bool { return true }}
+	culprits, err := attributeConfigCheckFailure(ctx, ps, "Configuration error.", componentsOf, check.run, DefaultConfigCheckAttributionMaxChecks)
+	require.NoError(t, err)
+	require.Empty(t, culprits)
+	require.Equal(t, 1, check.checks)
+
+	// out of checks
+	check = &fakeWorkloadCheck{fails: failsWith("h")}
+	_, err = attributeConfigCheckFailure(ctx, ps, "Configuration error.", componentsOf, check.run, 2)
+	require.ErrorIs(t, err, errAttributionBudget)
+	require.Equal(t, 2, check.checks)
+
+	// a configcheck pod that cannot start says nothing about the config
+	unstartable := func(context.Context, []pipeline.Pipeline) (string, error) {
+		return "ImagePullBackOff", configcheck.ErrPodUnstartable
+	}
+	_, err = attributeConfigCheckFailure(ctx, ps, "Configuration error.", componentsOf, unstartable, DefaultConfigCheckAttributionMaxChecks)
+	require.ErrorIs(t, err, configcheck.ErrPodUnstartable)
+}
+
+func TestSuspectPipelines(t *testing.T) {
+	ps := attributionPipelines("web", "web-api")
+
+	require.Equal(t, []string{"web-api"}, pipelineNames(suspectPipelines(`Sink "team-a-web-api-out" failed.`, ps, componentsOf)))
+	require.Equal(t, []string{"web"}, pipelineNames(suspectPipelines("transform team-a-web-logs.route: bad.", ps, componentsOf)))
+	require.Empty(t, suspectPipelines("Configuration error: team-a-web-api.", ps, componentsOf))
+}
+
+// The culprits are left out of the workload whose check fails with them, and of no
+// other: they stay valid.
+func TestExcludeConfigCheckCulprits(t *testing.T) {
+	ctx := context.Background()
+	good, bad := attributionPipeline("good"), attributionPipeline("bad")
+	c := newFakeClient(good, bad)
+	check := &fakeWorkloadCheck{fails: failsWith("bad")}
+	output := `x Sink "team-a-bad-out": unknown field ` + "`bogus`" + `.`
+	workload := v1alpha1.WorkloadReference{Kind: "Vector", Namespace: "vector", Name: "agent"}
+
+	excluded, err := excludeConfigCheckCulprits(ctx, c, 0, config.VectorConfigParams{}, []pipeline.Pipeline{good, bad}, output, check.run, workload)
+	require.NoError(t, err)
+	require.False(t, excluded, "0 checks disables the attribution")
+	require.Zero(t, check.checks)
+
+	recorder := events.NewFakeRecorder(10)
+	excluded, err = excludeConfigCheckCulprits(k8s.WithEventRecorder(ctx, recorder), c, DefaultConfigCheckAttributionMaxChecks, config.VectorConfigParams{}, []pipeline.Pipeline{good, bad}, output, check.run, workload)
+	require.NoError(t, err)
+	require.True(t, excluded)
+
+	got := &v1alpha1.VectorPipeline{}
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(bad), got))
+	require.True(t, got.IsValid())
+	require.Equal(t, []v1alpha1.WorkloadReference{workload}, got.Status.ExcludedFrom)
+	require.True(t, pipeline.ExcludedFrom(got, workload))
+	require.False(t, pipeline.ExcludedFrom(got, v1alpha1.WorkloadReference{Kind: "Vector", Namespace: "vector", Name: "other"}))
+	require.Len(t, recorder.Events, 1)
+	event := <-recorder.Events
+	require.True(t, strings.HasPrefix(event, "Warning WorkloadConfigCheckFailed The configcheck of Vector vector/agent fails with this pipeline"), event)
+	require.Contains(t, event, "team-a-bad-out (pipeline team-a/bad): InvalidOption: Sink \"team-a-bad-out\": unknown field `bogus`.")
+
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(good), got))
+	require.True(t, got.IsValid())
+	require.Empty(t, got.Status.ExcludedFrom)
+
+	// found again by a later build: already listed, so no second event
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(bad), bad))
+	_, err = excludeConfigCheckCulprits(k8s.WithEventRecorder(ctx, recorder), c, DefaultConfigCheckAttributionMaxChecks, config.VectorConfigParams{}, []pipeline.Pipeline{good, bad}, output, check.run, workload)
+	require.NoError(t, err)
+	require.Empty(t, recorder.Events)
+}
+
+// A workload leaves out the pipelines its attribution excluded from it, and only those:
+// another workload keeps them.
+func TestResolveWorkloadPipelinesExcludedFrom(t *testing.T) {
+	ctx := context.Background()
+	good, bad := attributionPipeline("good"), attributionPipeline("bad")
+	agent := v1alpha1.WorkloadReference{Kind: "Vector", Namespace: "vector", Name: "agent"}
+	bad.Status.ExcludedFrom = []v1alpha1.WorkloadReference{agent}
+	c := newFakeClient(good, bad)
+
+	result, _, err := resolveWorkloadPipelines(ctx, c, nil, agentFilter(), "Vector", "vector", "agent", testAssetsPrototype())
+	require.NoError(t, err)
+	require.Equal(t, []string{"good"}, pipelineNames(result))
+
+	result, _, err = resolveWorkloadPipelines(ctx, c, nil, agentFilter(), "Vector", "vector", "other", testAssetsPrototype())
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"good", "bad"}, pipelineNames(result))
+}
+
+// The attribution runs in the background and wakes the workload up once it is done; the
+// workload's reconciles meanwhile do not start another one for the same config.
+func TestConfigCheckAttributions(t *testing.T) {
+	ctx := context.Background()
+	var a configCheckAttributions
+	var runs atomic.Int32
+	finish := make(chan struct{})
+	woken := make(chan struct{}, 1)
+	wake := func() { woken <- struct{}{} }
+	exclude := func(excluded bool) func(context.Context) bool {
+		return func(context.Context) bool {
+			runs.Add(1)
+			<-finish
+			return excluded
+		}
+	}
+
+	done, _ := a.attribute(ctx, "Vector vector/agent", 1, exclude(true), wake)
+	require.False(t, done)
+	done, _ = a.attribute(ctx, "Vector vector/agent", 1, exclude(true), wake)
+	require.False(t, done, "still running")
+
+	finish <- struct{}{}
+	<-woken
+	done, excluded := a.attribute(ctx, "Vector vector/agent", 1, exclude(true), wake)
+	require.True(t, done)
+	require.True(t, excluded)
+	require.EqualValues(t, 1, runs.Load())
+
+	// the outcome is handed out once: the same config failing again is attributed again
+	done, _ = a.attribute(ctx, "Vector vector/agent", 1, exclude(false), wake)
+	require.False(t, done)
+	finish <- struct{}{}
+	<-woken
+	done, excluded = a.attribute(ctx, "Vector vector/agent", 1, exclude(false), wake)
+	require.True(t, done)
+	require.False(t, excluded)
+	require.EqualValues(t, 2, runs.Load())
+}
+
+// The pipeline controller clears .status.excludedFrom when it validates the pipeline
+// again, so the workloads try it once more.
+func TestPipelineReconcileClearsExcludedFrom(t *testing.T) {
+	vp := attributionPipeline("app")
+	vp.Status = v1alpha1.VectorPipelineStatus{
+		ExcludedFrom: []v1alpha1.WorkloadReference{{Kind: "Vector", Namespace: "vector", Name: "agent"}},
+	}
+	vector := &v1alpha1.Vector{ObjectMeta: metav1.ObjectMeta{Name: "agent", Namespace: "vector"}}
+	cl := newFakeClient(vp, vector)
+	passing := filepath.Join(t.TempDir(), "vector")
+	require.NoError(t, os.WriteFile(passing, []byte("#!/bin/sh\nexit 0\n"), 0o755))
+	r := &PipelineReconciler{
+		Client:                          cl,
+		APIReader:                       cl,
+		ConfigCheckTimeout:              5 * time.Second,
+		ConfigCheckSettings:             configcheck.Settings{Mode: configcheck.ModeLocal, VectorBinary: passing},
+		VectorAgentEventCh:              make(chan event.GenericEvent, 10),
+		VectorAggregatorsEventCh:        make(chan event.GenericEvent, 10),
+		ClusterVectorAggregatorsEventCh: make(chan event.GenericEvent, 10),
+		SecretIndex:                     pipeline.NewSecretIndex(),
+	}
+	_, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: client.ObjectKeyFromObject(vp)})
+	require.NoError(t, err)
+
+	got := &v1alpha1.VectorPipeline{}
+	require.NoError(t, cl.Get(context.Background(), client.ObjectKeyFromObject(vp), got))
+	require.True(t, got.IsValid())
+	require.Empty(t, got.Status.ExcludedFrom)
+}
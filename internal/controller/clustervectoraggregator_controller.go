@@ -29,6 +29,7 @@ import (
 	api_errors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/events"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -55,7 +56,20 @@ type ClusterVectorAggregatorReconciler struct {
 
 	Clientset          *kubernetes.Clientset
 	ConfigCheckTimeout time.Duration
-	EventChan          chan event.GenericEvent
+	// ConfigCheckSettings selects how configs are validated: in a configcheck pod
+	// (the zero value) or in the operator process.
+	ConfigCheckSettings configcheck.Settings
+	EventChan           chan event.GenericEvent
+	// EnableMetadataEnrichment is the --enable-metadata-enrichment default for the
+	// pipelines of the aggregator.
+	EnableMetadataEnrichment bool
+
+	// ConfigCheckAttributionMaxChecks bounds the extra configchecks run to find the
+	// pipelines a failed configcheck is down to, and leave them out; 0 fails the whole
+	// workload instead.
+	ConfigCheckAttributionMaxChecks int
+	// attributions run the attributions of the reconciler's workloads off the reconcile.
+	attributions configCheckAttributions
 
 	// APIReader is an uncached read-only client (mgr.GetAPIReader()), used to resolve
 	// pipeline secrets: reads go through it for freshness and independence from cache
@@ -63,6 +77,9 @@ type ClusterVectorAggregatorReconciler struct {
 	// controller-runtime cache - Owns(&corev1.Secret{}) below already puts them there in
 	// default mode.
 	APIReader client.Reader
+	// Recorder emits the Kubernetes Events of status transitions, see
+	// k8s.WithEventRecorder. Nil emits none.
+	Recorder events.EventRecorder
 }
 
 // +kubebuilder:rbac:groups=observability.kaasops.io,resources=clustervectoraggregators,verbs=get;list;watch;create;update;patch;delete
@@ -84,6 +101,9 @@ type ClusterVectorAggregatorReconciler struct {
 // +kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="rbac.authorization.k8s.io",resources=clusterrolebindings,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="rbac.authorization.k8s.io",resources=clusterroles,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="rbac.authorization.k8s.io",resources=rolebindings,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="rbac.authorization.k8s.io",resources=roles,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=coordination.k8s.io,resources=leases,verbs=get;create;update
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -95,13 +115,17 @@ type ClusterVectorAggregatorReconciler struct {
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.19.0/pkg/reconcile
 func (r *ClusterVectorAggregatorReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	ctx = k8s.WithEventRecorder(ctx, r.Recorder)
 	_ = log.FromContext(ctx)
 
 	clusterAggregator := &v1alpha1.ClusterVectorAggregator{}
 	err := r.Get(ctx, req.NamespacedName, clusterAggregator)
 	if err != nil {
 		if api_errors.IsNotFound(err) {
-			return ctrl.Result{}, nil
+			// gone: drop it from the pipelines that still list it as a workload
+			workload := v1alpha1.WorkloadReference{Kind: "ClusterVectorAggregator", Name: req.Name}
+			forgetWorkload(workload)
+			return ctrl.Result{}, pipeline.SyncWorkload(ctx, r.Client, workload, nil)
 		}
 		return ctrl.Result{}, err
 	}
@@ -141,10 +165,19 @@ func (r *ClusterVectorAggregatorReconciler) createOrUpdateVectorAggregator(ctx c
 	// down, only once the build (and configcheck, if enabled) actually succeed - see
 	// reinstatePipelines' doc comment.
 	pipelines, reinstateCandidates, err := resolveWorkloadPipelines(ctx, vaCtrl.Client, secretGetter, pipeline.FilterPipelines{
-		Scope:    pipeline.ClusterPipelines,
-		Selector: v.Spec.Selector,
-		Role:     v1alpha1.VectorPipelineRoleAggregator,
+		Scope:      pipeline.ClusterPipelines,
+		Selector:   v.Spec.Selector,
+		Role:       v1alpha1.VectorPipelineRoleAggregator,
+		Aggregator: &v1alpha1.AggregatorReference{Kind: "ClusterVectorAggregator", Name: v.Name},
 	}, "ClusterVectorAggregator", "", v.Name, vaCtrl.SecretAssetsPrototype())
+	if errors.Is(err, pipeline.ErrInvalidSelector) {
+		// only editing the selector fixes it, and that edit triggers a reconcile
+		if err := vaCtrl.SetFailedStatus(ctx, err.Error()); err != nil {
+			return ctrl.Result{}, err
+		}
+		log.Error(err, "Invalid selector")
+		return ctrl.Result{}, nil
+	}
 	if err != nil {
 		return ctrl.Result{}, err
 	}
@@ -167,16 +200,23 @@ func (r *ClusterVectorAggregatorReconciler) createOrUpdateVectorAggregator(ctx c
 	}
 	reinstateCandidates = intersectPipelinesByKey(reinstateCandidates, bridgePipelines)
 
-	cfg, err := config.BuildAggregatorConfig(config.VectorConfigParams{
+	params := config.VectorConfigParams{
 		AggregatorName:       vaCtrl.Name,
 		ApiEnabled:           vaCtrl.Spec.Api.Enabled,
 		PlaygroundEnabled:    vaCtrl.Spec.Api.Playground,
 		InternalMetrics:      vaCtrl.Spec.InternalMetrics,
 		ExpireMetricsSecs:    vaCtrl.Spec.ExpireMetricsSecs,
+		EnrichMetadata:       r.EnableMetadataEnrichment,
 		PipelineSecretGetter: secretGetter,
-	}, bridgePipelines...)
+		KubernetesEventsTLS:  vaCtrl.Spec.EventCollector.TLS,
+	}
+	cfg, err := config.BuildAggregatorConfig(params, bridgePipelines...)
 	if err != nil {
-		if err := vaCtrl.SetFailedStatus(ctx, err.Error()); err != nil {
+		setFailedStatus := vaCtrl.SetFailedStatus
+		if isSecretBuildError(err) {
+			setFailedStatus = vaCtrl.SetSecretsFailedStatus
+		}
+		if err := setFailedStatus(ctx, err.Error()); err != nil {
 			return ctrl.Result{}, err
 		}
 		log.Error(err, "Build config failed")
@@ -200,31 +240,37 @@ func (r *ClusterVectorAggregatorReconciler) createOrUpdateVectorAggregator(ctx c
 
 	if !vaCtrl.Spec.ConfigCheck.Disabled {
 		if vaCtrl.Status.LastAppliedConfigHash == nil || *vaCtrl.Status.LastAppliedConfigHash != cfgHash {
-			reason, err := configcheck.New(
-				byteCfg,
-				vaCtrl.Client,
-				vaCtrl.ClientSet,
-				&vaCtrl.Spec.VectorCommon,
-				vaCtrl.Name,
-				vaCtrl.Namespace,
-				r.ConfigCheckTimeout,
-				configcheck.ConfigCheckInitiatorVector,
-				cfg.SecretAssets(),
-			).Run(ctx)
-			if err != nil {
-				if errors.Is(err, configcheck.ErrValidation) {
-					if err := vaCtrl.SetFailedStatus(ctx, reason); err != nil {
-						return ctrl.Result{}, err
+			check := &workloadConfigCheck{
+				settings:     r.ConfigCheckSettings,
+				workload:     v1alpha1.WorkloadReference{Kind: "ClusterVectorAggregator", Name: v.Name},
+				owner:        vaCtrl.VectorAggregator,
+				reader:       r.APIReader,
+				client:       vaCtrl.Client,
+				clientset:    vaCtrl.ClientSet,
+				common:       vaCtrl.ConfigCheckCommon(),
+				name:         vaCtrl.Name,
+				namespace:    vaCtrl.Namespace,
+				timeout:      r.ConfigCheckTimeout,
+				wake:         r.wake(v),
+				attributions: &r.attributions,
+				maxChecks:    r.ConfigCheckAttributionMaxChecks,
+				params:       params,
+				pipelines:    bridgePipelines,
+				build: func(pipelines []pipeline.Pipeline) ([]byte, map[string][]byte, error) {
+					cfg, err := config.BuildAggregatorConfig(params, pipelines...)
+					if err != nil {
+						return nil, nil, err
 					}
-					log.Error(err, "Invalid config")
-					return ctrl.Result{}, nil
-				}
-				if errors.Is(err, configcheck.ErrConfigcheckSkipped) {
-					// namespace is terminating; the aggregator is on its way out, nothing to do
-					log.Info("ConfigCheck skipped, namespace is terminating")
-					return ctrl.Result{}, nil
-				}
-				return ctrl.Result{}, err
+					byteCfg, err := cfg.MarshalJSON()
+					if err != nil {
+						return nil, nil, err
+					}
+					return byteCfg, cfg.SecretAssets(), nil
+				},
+				setFailed: vaCtrl.SetConfigCheckFailedStatus,
+			}
+			if passed, result, err := check.run(ctx, byteCfg, cfg.SecretAssets()); !passed {
+				return result, err
 			}
 		}
 	}
@@ -253,6 +299,7 @@ func (r *ClusterVectorAggregatorReconciler) createOrUpdateVectorAggregator(ctx c
 	if err := vaCtrl.EnsureVectorAggregator(ctx, !configUnchanged); err != nil {
 		return ctrl.Result{}, err
 	}
+	recordWorkloadConfig(ctx, v1alpha1.WorkloadReference{Kind: "ClusterVectorAggregator", Name: v.Name}, vaCtrl.Config, vaCtrl.ConfigBytes, vaCtrl.Spec.CompressConfigFile, vaCtrl.SecretAssets)
 
 	// Only now - after EnsureVectorAggregator has actually published the config and
 	// assets these candidates' references depend on - is it safe to mark them valid
@@ -265,11 +312,26 @@ func (r *ClusterVectorAggregatorReconciler) createOrUpdateVectorAggregator(ctx c
 		return ctrl.Result{}, err
 	}
 
+	// See vector_controller.go's identical call for why this runs last.
+	if err := pipeline.SyncWorkload(ctx, vaCtrl.Client, v1alpha1.WorkloadReference{Kind: "ClusterVectorAggregator", Name: v.Name}, bridgePipelines); err != nil {
+		return ctrl.Result{}, err
+	}
+
 	// See vector_controller.go's identical return for why a pending grace period must
 	// be requeued explicitly.
 	return ctrl.Result{RequeueAfter: requeueAfter}, nil
 }
 
+// wake returns the func the configcheck queue calls to reconcile v again once it keeps
+// a slot for it. Without an EventChan it is nil, and the checks of v wait for a slot.
+func (r *ClusterVectorAggregatorReconciler) wake(v *v1alpha1.ClusterVectorAggregator) func() {
+	if r.EventChan == nil {
+		return nil
+	}
+	obj := v.DeepCopy()
+	return func() { r.EventChan <- event.GenericEvent{Object: obj} }
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *ClusterVectorAggregatorReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	monitoringCRD, err := k8s.ResourceExists(r.Clientset.DiscoveryClient, monitorv1.SchemeGroupVersion.String(), monitorv1.PodMonitorsKind)
@@ -288,6 +350,8 @@ func (r *ClusterVectorAggregatorReconciler) SetupWithManager(mgr ctrl.Manager) e
 		Owns(&corev1.ServiceAccount{}).
 		Owns(&rbacv1.ClusterRole{}).
 		Owns(&rbacv1.ClusterRoleBinding{}).
+		Owns(&rbacv1.Role{}).
+		Owns(&rbacv1.RoleBinding{}).
 		Owns(&autoscalingv2.HorizontalPodAutoscaler{})
 
 	if monitoringCRD {
@@ -0,0 +1,105 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/kaasops/vector-operator/api/v1alpha1"
+	"github.com/kaasops/vector-operator/internal/config"
+	"github.com/kaasops/vector-operator/internal/config/configcheck"
+	"github.com/kaasops/vector-operator/internal/pipeline"
+)
+
+// configCheckFailedError is a pipeline's failed configcheck against one workload, as
+// returned from the pipeline controller's checks. It keeps vector's output apart from
+// the message so the status can carry a report of it instead.
+type configCheckFailedError struct {
+	// workload is how the message names the workload, e.g. "agent vector/agent".
+	workload string
+	// namespace is the namespace the check ran in.
+	namespace string
+	output    string
+	// tests is set when the config validated and the pipeline's tests failed.
+	tests bool
+}
+
+func (e *configCheckFailedError) Error() string {
+	return fmt.Sprintf("%s config check failed: %s", e.workload, e.output)
+}
+
+// configCheckReport turns the output of a failed configcheck of owner into the report
+// and the one-line reason of its status. The whole output is stored in the ConfigMap
+// configcheck.OutputConfigMapName(ownerKind, ...) in namespace, which has to be owner's
+// own for a namespaced owner. A ConfigMap that cannot be written is logged, and the
+// report goes without its reference: the status is what matters.
+func configCheckReport(ctx context.Context, reader client.Reader, c client.Client, owner client.Object, ownerKind, namespace, output string, pipelineOf func(component string) string) (*v1alpha1.ConfigCheckReport, string) {
+	report := configcheck.NewReport(output, pipelineOf)
+	if reader == nil {
+		reader = c
+	}
+	nn := types.NamespacedName{Namespace: namespace, Name: configcheck.OutputConfigMapName(ownerKind, owner.GetName())}
+	ref, err := configcheck.StoreOutput(ctx, reader, c, owner, nn, output)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "Failed to store configcheck output", "configMap", nn)
+	}
+	report.Output = ref
+	return report, configcheck.Summary(report)
+}
+
+// componentPipeline maps a component ID of a config built from pipelines with params
+// to the pipeline it comes from, nil for one that no single pipeline generated.
+func componentPipeline(params config.VectorConfigParams, pipelines []pipeline.Pipeline) func(component string) pipeline.Pipeline {
+	owners := make(map[string][]pipeline.Pipeline)
+	for _, p := range pipelines {
+		for _, name := range config.PipelineComponents(params, p) {
+			owners[name] = append(owners[name], p)
+		}
+	}
+	return func(component string) pipeline.Pipeline {
+		if o := owners[component]; len(o) == 1 {
+			return o[0]
+		}
+		return nil
+	}
+}
+
+// componentPipelineName is componentPipeline for a ConfigCheckError, naming the
+// pipeline with reportPipelineName.
+func componentPipelineName(params config.VectorConfigParams, pipelines []pipeline.Pipeline) func(component string) string {
+	of := componentPipeline(params, pipelines)
+	return func(component string) string {
+		if p := of(component); p != nil {
+			return reportPipelineName(p)
+		}
+		return ""
+	}
+}
+
+// reportPipelineName is p as ConfigCheckError.Pipeline names it: namespace/name, or
+// name for a ClusterVectorPipeline.
+func reportPipelineName(p pipeline.Pipeline) string {
+	if p.GetNamespace() == "" {
+		return p.GetName()
+	}
+	return client.ObjectKeyFromObject(p).String()
+}
@@ -0,0 +1,187 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"github.com/kaasops/vector-operator/api/v1alpha1"
+	"github.com/kaasops/vector-operator/internal/config"
+	"github.com/kaasops/vector-operator/internal/config/configcheck"
+	"github.com/kaasops/vector-operator/internal/utils/compression"
+)
+
+// workloadLabels identify a workload in the config metrics; a ClusterVectorAggregator
+// has no namespace.
+var workloadLabels = []string{"kind", "namespace", "name"}
+
+// The config metrics describe what a workload last published, so they are set after
+// its config and assets Secrets are written and dropped when the workload is deleted.
+var (
+	configBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "vector_operator",
+		Subsystem: "config",
+		Name:      "bytes",
+		Help:      "The size of the workload's published config",
+	}, workloadLabels)
+	configCompressedBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "vector_operator",
+		Subsystem: "config",
+		Name:      "compressed_bytes",
+		Help:      "The size of the workload's published config once gzipped, as compressConfigFile would store it",
+	}, workloadLabels)
+	configCollapsedSources = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "vector_operator",
+		Subsystem: "config",
+		Name:      "collapsed_sources",
+		Help:      "The number of kubernetes_logs sources the sources optimization collapsed",
+	}, workloadLabels)
+	configOptimizedSources = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "vector_operator",
+		Subsystem: "config",
+		Name:      "optimized_sources",
+		Help:      "The number of sources the collapsed kubernetes_logs sources were merged into",
+	}, workloadLabels)
+	configSecretHeadroom = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "vector_operator",
+		Subsystem: "config_secret",
+		Name:      "headroom_bytes",
+		Help:      "How many more bytes the workload's config Secret can hold before the Secret size limit, compressed when compressConfigFile is set",
+	}, workloadLabels)
+	secretAssetsHeadroom = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "vector_operator",
+		Subsystem: "secret_assets",
+		Name:      "headroom_bytes",
+		Help:      "How many more bytes of values the workload's secret-assets Secret can hold before the Secret size limit",
+	}, workloadLabels)
+	secretRotationPollHits = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "vector_operator",
+		Subsystem: "secret_rotation",
+		Name:      "poll_hits_total",
+		Help:      "The number of pipeline reconciles that found a referenced Secret changed, with the spec unchanged, that the Secret watch did not report, with scoped-mode polling on",
+	})
+)
+
+var pipelinesDesc = prometheus.NewDesc(
+	"vector_operator_pipelines",
+	"The number of pipelines by kind, role and validity",
+	[]string{"kind", "role", "valid"}, nil,
+)
+
+func init() {
+	metrics.Registry.MustRegister(configBytes, configCompressedBytes, configCollapsedSources, configOptimizedSources,
+		configSecretHeadroom, secretAssetsHeadroom, secretRotationPollHits)
+}
+
+// recordWorkloadConfig sets the config metrics of workload from the config it just
+// published, stored gzipped when compressed. assets are the variants of its
+// secret-assets Secret; the headroom is that of the fullest one, since each is a Secret
+// of its own with the same limit.
+func recordWorkloadConfig(ctx context.Context, workload v1alpha1.WorkloadReference, cfg *config.VectorConfig, byteConfig []byte, compressed bool, assets ...map[string][]byte) {
+	labels := prometheus.Labels{"kind": workload.Kind, "namespace": workload.Namespace, "name": workload.Name}
+	gzipped := len(compression.Compress(byteConfig, log.FromContext(ctx)))
+	configBytes.With(labels).Set(float64(len(byteConfig)))
+	configCompressedBytes.With(labels).Set(float64(gzipped))
+	stored := len(byteConfig)
+	if compressed {
+		stored = gzipped
+	}
+	configSecretHeadroom.With(labels).Set(float64(corev1.MaxSecretSize - stored))
+
+	collapsed, groups := cfg.OptimizationSummary()
+	configCollapsedSources.With(labels).Set(float64(collapsed))
+	configOptimizedSources.With(labels).Set(float64(groups))
+
+	// only values count against corev1.MaxSecretSize, as the API server measures it
+	fullest := 0
+	for _, data := range assets {
+		size := 0
+		for _, v := range data {
+			size += len(v)
+		}
+		fullest = max(fullest, size)
+	}
+	secretAssetsHeadroom.With(labels).Set(float64(corev1.MaxSecretSize - fullest))
+}
+
+// forgetWorkload drops the series of a deleted workload, configcheck ones included.
+func forgetWorkload(workload v1alpha1.WorkloadReference) {
+	labels := prometheus.Labels{"kind": workload.Kind, "namespace": workload.Namespace, "name": workload.Name}
+	for _, vec := range []*prometheus.GaugeVec{configBytes, configCompressedBytes, configCollapsedSources, configOptimizedSources, configSecretHeadroom, secretAssetsHeadroom} {
+		vec.DeletePartialMatch(labels)
+	}
+	configcheck.ForgetWorkload(workload)
+}
+
+// PipelineCollector counts pipelines by kind, role and validity at scrape time, from
+// the manager's cache: pipelines change under several controllers and by hand, and a
+// count kept up to date by each of them would drift where a list cannot.
+type PipelineCollector struct {
+	Reader client.Reader
+}
+
+var _ prometheus.Collector = &PipelineCollector{}
+
+func (c *PipelineCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- pipelinesDesc
+}
+
+// Collect leaves the metric out of a scrape it cannot list pipelines for, rather than
+// failing the scrape of every other metric with it.
+func (c *PipelineCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	type key struct{ kind, role, valid string }
+	counts := map[key]int{}
+	count := func(kind string, role v1alpha1.VectorPipelineRole, result *bool) {
+		valid := "unknown"
+		if result != nil {
+			valid = "false"
+			if *result {
+				valid = "true"
+			}
+		}
+		counts[key{kind, string(role), valid}]++
+	}
+
+	vps := &v1alpha1.VectorPipelineList{}
+	if err := c.Reader.List(ctx, vps); err != nil {
+		return
+	}
+	for _, p := range vps.Items {
+		count("VectorPipeline", p.GetRole(), p.Status.ConfigCheckResult)
+	}
+	cvps := &v1alpha1.ClusterVectorPipelineList{}
+	if err := c.Reader.List(ctx, cvps); err != nil {
+		return
+	}
+	for _, p := range cvps.Items {
+		count("ClusterVectorPipeline", p.GetRole(), p.Status.ConfigCheckResult)
+	}
+
+	for k, n := range counts {
+		ch <- prometheus.MustNewConstMetric(pipelinesDesc, prometheus.GaugeValue, float64(n), k.kind, k.role, k.valid)
+	}
+}
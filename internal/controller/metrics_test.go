@@ -0,0 +1,96 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/kaasops/vector-operator/api/v1alpha1"
+	"github.com/kaasops/vector-operator/internal/config"
+)
+
+func TestRecordWorkloadConfig(t *testing.T) {
+	logs := func(namespace string) *v1alpha1.VectorPipeline {
+		return &v1alpha1.VectorPipeline{
+			ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: namespace},
+			Spec: v1alpha1.VectorPipelineSpec{
+				Sources: &runtime.RawExtension{Raw: []byte(`{"logs":{"type":"kubernetes_logs"}}`)},
+				Sinks:   &runtime.RawExtension{Raw: []byte(`{"out":{"inputs":["logs"],"type":"console"}}`)},
+			},
+		}
+	}
+	cfg, byteConfig, err := config.BuildAgentConfig(config.VectorConfigParams{OptimizeSources: true}, logs("team-a"), logs("team-b"))
+	require.NoError(t, err)
+
+	workload := v1alpha1.WorkloadReference{Kind: "Vector", Namespace: "vector", Name: "agent"}
+	labels := prometheus.Labels{"kind": "Vector", "namespace": "vector", "name": "agent"}
+	primary := map[string][]byte{"a": make([]byte, 100)}
+	alt := map[string][]byte{"a": make([]byte, 100), "b": make([]byte, 50)}
+	recordWorkloadConfig(context.Background(), workload, cfg, byteConfig, true, primary, alt)
+
+	require.Equal(t, float64(len(byteConfig)), testutil.ToFloat64(configBytes.With(labels)))
+	compressed := testutil.ToFloat64(configCompressedBytes.With(labels))
+	require.Positive(t, compressed)
+	require.Less(t, compressed, float64(len(byteConfig)))
+	require.Equal(t, corev1.MaxSecretSize-compressed, testutil.ToFloat64(configSecretHeadroom.With(labels)),
+		"a compressed config is stored gzipped")
+	require.Equal(t, float64(2), testutil.ToFloat64(configCollapsedSources.With(labels)))
+	require.Equal(t, float64(1), testutil.ToFloat64(configOptimizedSources.With(labels)))
+	require.Equal(t, float64(corev1.MaxSecretSize-150), testutil.ToFloat64(secretAssetsHeadroom.With(labels)),
+		"the headroom is that of the fullest variant")
+
+	series := testutil.CollectAndCount(configBytes)
+	forgetWorkload(workload)
+	require.Equal(t, series-1, testutil.CollectAndCount(configBytes))
+}
+
+func TestPipelineCollector(t *testing.T) {
+	vp := func(name string, role v1alpha1.VectorPipelineRole, valid *bool) *v1alpha1.VectorPipeline {
+		return &v1alpha1.VectorPipeline{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "team-a"},
+			Status:     v1alpha1.VectorPipelineStatus{Role: rolePtr(role), ConfigCheckResult: valid},
+		}
+	}
+	c := newFakeClient(
+		vp("a", v1alpha1.VectorPipelineRoleAgent, boolPtr(true)),
+		vp("b", v1alpha1.VectorPipelineRoleAgent, boolPtr(true)),
+		vp("c", v1alpha1.VectorPipelineRoleAgent, boolPtr(false)),
+		vp("d", v1alpha1.VectorPipelineRoleAggregator, nil),
+		&v1alpha1.ClusterVectorPipeline{
+			ObjectMeta: metav1.ObjectMeta{Name: "e"},
+			Status:     v1alpha1.VectorPipelineStatus{ConfigCheckResult: boolPtr(false)},
+		},
+	)
+
+	require.NoError(t, testutil.CollectAndCompare(&PipelineCollector{Reader: c}, strings.NewReader(`
+# HELP vector_operator_pipelines The number of pipelines by kind, role and validity
+# TYPE vector_operator_pipelines gauge
+vector_operator_pipelines{kind="ClusterVectorPipeline",role="unknown",valid="false"} 1
+vector_operator_pipelines{kind="VectorPipeline",role="agent",valid="false"} 1
+vector_operator_pipelines{kind="VectorPipeline",role="agent",valid="true"} 2
+vector_operator_pipelines{kind="VectorPipeline",role="aggregator",valid="unknown"} 1
+`)))
+}
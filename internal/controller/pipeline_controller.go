@@ -22,13 +22,16 @@ import (
 	"fmt"
 	"hash/fnv"
 	"reflect"
+	"sync"
 	"time"
 
 	"golang.org/x/sync/errgroup"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/events"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
@@ -40,6 +43,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	"github.com/kaasops/vector-operator/api/v1alpha1"
+	"github.com/kaasops/vector-operator/internal/common"
 	"github.com/kaasops/vector-operator/internal/config"
 	"github.com/kaasops/vector-operator/internal/config/configcheck"
 	"github.com/kaasops/vector-operator/internal/pipeline"
@@ -53,14 +57,18 @@ type PipelineReconciler struct {
 	Scheme *runtime.Scheme
 
 	// Temp. Wait this issue - https://github.com/kubernetes-sigs/controller-runtime/issues/452
-	Clientset                                *kubernetes.Clientset
-	ConfigCheckTimeout                       time.Duration
+	Clientset          *kubernetes.Clientset
+	ConfigCheckTimeout time.Duration
+	// ConfigCheckSettings selects how configs are validated: in a configcheck pod
+	// (the zero value) or in the operator process.
+	ConfigCheckSettings                      configcheck.Settings
 	VectorAgentEventCh                       chan event.GenericEvent
 	VectorAggregatorsEventCh                 chan event.GenericEvent
 	ClusterVectorAggregatorsEventCh          chan event.GenericEvent
 	EnableReconciliationInvalidPipelines     bool
 	ReconciliationInvalidPipelinesRetryDelay time.Duration
 	EnableConfigOptimization                 bool
+	EnableMetadataEnrichment                 bool
 
 	// APIReader is an uncached read-only client (mgr.GetAPIReader()), used to resolve
 	// pipeline secrets: reads go through it for freshness and independence from cache
@@ -81,6 +89,12 @@ type PipelineReconciler struct {
 	// the Secret watch can miss a rotation outright. In default mode the watch already
 	// covers every referenced Secret, and polling would be API load buying nothing.
 	PollSecretRotation bool
+	// secretWatchRequeues holds the pipelines the Secret watch requeued since their
+	// last reconcile, to count only the rotations the watch did not report as poll hits.
+	secretWatchRequeues sync.Map
+	// Recorder emits the Kubernetes Events of status transitions, see
+	// k8s.WithEventRecorder. Nil emits none.
+	Recorder events.EventRecorder
 }
 
 var (
@@ -135,6 +149,7 @@ func secretRotationPollInterval(key types.NamespacedName) time.Duration {
 //+kubebuilder:rbac:groups=observability.kaasops.io,resources=vectorpipelines;clustervectorpipelines,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=observability.kaasops.io,resources=vectorpipelines/status;clustervectorpipelines/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=observability.kaasops.io,resources=vectorpipelines/finalizers;clustervectorpipelines/finalizers,verbs=update
+//+kubebuilder:rbac:groups=observability.kaasops.io,resources=vectoroutputs;clustervectoroutputs;vectorpipelinetemplates;clustervectorpipelinepolicies,verbs=get;list;watch
 
 // Reconcile wraps the reconcile body with the scoped-mode rotation poll, deliberately
 // as a wrapper rather than at each return: the body has ~20 exits (no-op, success,
@@ -146,6 +161,7 @@ func secretRotationPollInterval(key types.NamespacedName) time.Duration {
 // later. A returned error needs no arming: controller-runtime requeues it with
 // backoff and ignores RequeueAfter entirely.
 func (r *PipelineReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	ctx = k8s.WithEventRecorder(ctx, r.Recorder)
 	result, err := r.reconcile(ctx, req)
 	if err != nil {
 		return result, err
@@ -176,6 +192,9 @@ func (r *PipelineReconciler) armSecretRotationPoll(ctx context.Context, req ctrl
 		// failing the round over - the body already returned its own verdict.
 		return result
 	}
+	if err := pipeline.Expand(ctx, r.Client, p); err != nil {
+		return result
+	}
 	if len(p.GetSpec().Secret) == 0 {
 		return result
 	}
@@ -195,6 +214,7 @@ func (r *PipelineReconciler) reconcile(ctx context.Context, req ctrl.Request) (c
 	log := log.FromContext(ctx).WithValues("Pipeline", req.Name)
 
 	log.Info("start Reconcile Pipeline")
+	_, secretWatchRequeue := r.secretWatchRequeues.LoadAndDelete(req.NamespacedName)
 	pipelineCR, err := r.getPipeline(ctx, req)
 	if err != nil {
 		log.Error(err, "Failed to get Pipeline")
@@ -221,6 +241,38 @@ func (r *PipelineReconciler) reconcile(ctx context.Context, req ctrl.Request) (c
 		basePipeline = pipelineCR.DeepCopyObject().(pipeline.Pipeline)
 	}
 
+	if pipelineCR != nil && pipelineCR.GetSpec().Suspend {
+		return r.reconcileSuspended(ctx, pipelineCR, basePipeline)
+	}
+
+	// From here on the pipeline is handled with its template and outputs expanded, as
+	// the workloads list it, so the secret resolution and the hash below cover what they
+	// contribute too. The base is retaken from the expanded pipeline: the status patches
+	// are computed against it and must not carry a spec difference.
+	if pipelineCR != nil {
+		if err := pipeline.Expand(ctx, r.Client, pipelineCR); err != nil {
+			if !pipeline.IsInvalidReference(err) {
+				return ctrl.Result{}, err
+			}
+			if r.SecretIndex != nil {
+				r.SecretIndex.Set(client.ObjectKeyFromObject(pipelineCR), nil)
+			}
+			if err := pipeline.SetFailedStatus(ctx, r.Client, pipelineCR, err.Error(), basePipeline); err != nil {
+				return ctrl.Result{}, err
+			}
+			// Only an edit fixes it: of the pipeline, which triggers a reconcile by
+			// itself, or of the template or output, which their watches map back to it.
+			return ctrl.Result{}, nil
+		}
+		basePipeline = pipelineCR.DeepCopyObject().(pipeline.Pipeline)
+
+		// Policies are checked ahead of the unchanged-pipeline shortcut below: they are
+		// not part of the hash, so a tightened policy has to fail a pipeline nobody edited.
+		if violated, err := r.enforcePolicies(ctx, pipelineCR, basePipeline); err != nil || violated {
+			return ctrl.Result{}, err
+		}
+	}
+
 	var newRelatedSecretsHash *int64
 	if pipelineCR != nil {
 		pipelineKey := types.NamespacedName{Namespace: pipelineCR.GetNamespace(), Name: pipelineCR.GetName()}
@@ -246,7 +298,7 @@ func (r *PipelineReconciler) reconcile(ctx context.Context, req ctrl.Request) (c
 				// notChanged==true with a stale RelatedSecretsHash still matching and
 				// skip itself via the "Pipeline has no changes" branch below forever.
 				pipelineCR.SetRelatedSecretsHash(nil)
-				if err := pipeline.SetFailedStatus(ctx, r.Client, pipelineCR, err.Error(), basePipeline); err != nil {
+				if err := pipeline.SetSecretsFailedStatus(ctx, r.Client, pipelineCR, v1alpha1.ReasonSecretResolveFailed, err.Error(), basePipeline); err != nil {
 					return ctrl.Result{}, err
 				}
 				var shapeErr *invalidSecretShapeError
@@ -307,9 +359,17 @@ func (r *PipelineReconciler) reconcile(ctx context.Context, req ctrl.Request) (c
 			log.Info("Pipeline has no changes. Finish Reconcile Pipeline")
 			return ctrl.Result{}, nil
 		}
+		if notChanged && r.PollSecretRotation && !secretWatchRequeue {
+			// with the spec unchanged, a referenced Secret changed that the Secret
+			// watch did not report: what the poll is there to catch
+			secretRotationPollHits.Inc()
+		}
 	}
 
 	pipelineCR.SetRelatedSecretsHash(newRelatedSecretsHash)
+	// validated again from here on: the workloads whose configcheck failed with the
+	// pipeline take it back and find out whether it still does
+	pipelineCR.SetExcludedFrom(nil)
 
 	p := &config.PipelineConfig{}
 	if err := config.UnmarshalJson(pipelineCR.GetSpec(), p); err != nil {
@@ -329,20 +389,24 @@ func (r *PipelineReconciler) reconcile(ctx context.Context, req ctrl.Request) (c
 		return ctrl.Result{}, nil
 	}
 	pipelineCR.SetRole(pipelineVectorRole)
-	var pipelineLabels map[string]string
-	if pipelineCR.GetLabels() != nil {
-		pipelineLabels = pipelineCR.GetLabels()
+	if err := config.ValidateAggregatorRef(pipelineCR, *pipelineVectorRole); err != nil {
+		if err := pipeline.SetFailedStatus(ctx, r.Client, pipelineCR, err.Error(), basePipeline); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
 	}
+	// a new force-configcheck value must run a check, not find the verdict on the
+	// unchanged config in the cache
+	checks := r.ConfigCheckSettings
+	checks.ForceKey = pipelineCR.GetAnnotations()[common.AnnotationForceConfigCheck]
 	eg := errgroup.Group{}
 
 	if *pipelineVectorRole == v1alpha1.VectorPipelineRoleAgent {
 
 		for _, vector := range vectorAgents {
-			var selectorLabels map[string]string
-			if vector.Spec.Selector != nil {
-				selectorLabels = vector.Spec.Selector.MatchLabels
-			}
-			if !k8s.MatchLabels(selectorLabels, pipelineLabels) {
+			if selected, err := r.selects(ctx, pipelineCR, vector.Spec.Selector, nil); err != nil {
+				return ctrl.Result{}, err
+			} else if !selected {
 				continue
 			}
 			eg.Go(func() error {
@@ -354,7 +418,10 @@ func (r *PipelineReconciler) reconcile(ctx context.Context, req ctrl.Request) (c
 					InternalMetrics:      vaCtrl.Vector.Spec.Agent.InternalMetrics,
 					ExpireMetricsSecs:    vaCtrl.Vector.Spec.Agent.ExpireMetricsSecs,
 					OptimizeSources:      optimizeSources(r.EnableConfigOptimization, vaCtrl.Vector),
+					EnrichMetadata:       r.EnableMetadataEnrichment,
 					PipelineSecretGetter: pipelineSecretGetter(r.APIReader, ctx),
+					PipelinePolicies:     pipelinePolicies(r.Client, ctx),
+					Tests:                true,
 				}, pipelineCR)
 				if err != nil {
 					return fmt.Errorf("agent %s/%s build config failed: %w: %w", vector.Namespace, vector.Name, ErrBuildConfigFailed, err)
@@ -363,7 +430,9 @@ func (r *PipelineReconciler) reconcile(ctx context.Context, req ctrl.Request) (c
 				vaCtrl.Config = cfg
 				vaCtrl.ByteConfig = byteConfig
 
-				configCheck := configcheck.New(
+				checks := checks
+				checks.Workload = v1alpha1.WorkloadReference{Kind: "Vector", Namespace: vector.Namespace, Name: vector.Name}
+				configCheck := checks.New(
 					vaCtrl.ByteConfig,
 					vaCtrl.Client,
 					vaCtrl.ClientSet,
@@ -380,7 +449,7 @@ func (r *PipelineReconciler) reconcile(ctx context.Context, req ctrl.Request) (c
 					return nil
 				}
 				if reason != "" {
-					return fmt.Errorf("agent %s/%s config check failed: %s", vector.Namespace, vector.Name, reason)
+					return &configCheckFailedError{workload: fmt.Sprintf("agent %s/%s", vector.Namespace, vector.Name), namespace: vector.Namespace, output: reason, tests: errors.Is(err, configcheck.ErrTestsFailed)}
 				}
 				return err
 			})
@@ -394,11 +463,9 @@ func (r *PipelineReconciler) reconcile(ctx context.Context, req ctrl.Request) (c
 				if vector.Namespace != pipelineCR.GetNamespace() {
 					continue
 				}
-				var selectorLabels map[string]string
-				if vector.Spec.Selector != nil {
-					selectorLabels = vector.Spec.Selector.MatchLabels
-				}
-				if !k8s.MatchLabels(selectorLabels, pipelineLabels) {
+				if selected, err := r.selects(ctx, pipelineCR, vector.Spec.Selector, &v1alpha1.AggregatorReference{Kind: "VectorAggregator", Name: vector.Name}); err != nil {
+					return ctrl.Result{}, err
+				} else if !selected {
 					continue
 				}
 				eg.Go(func() error {
@@ -409,7 +476,11 @@ func (r *PipelineReconciler) reconcile(ctx context.Context, req ctrl.Request) (c
 						PlaygroundEnabled:    vaCtrl.Spec.Api.Playground,
 						InternalMetrics:      vaCtrl.Spec.InternalMetrics,
 						ExpireMetricsSecs:    vaCtrl.Spec.ExpireMetricsSecs,
+						EnrichMetadata:       r.EnableMetadataEnrichment,
 						PipelineSecretGetter: pipelineSecretGetter(r.APIReader, ctx),
+						PipelinePolicies:     pipelinePolicies(r.Client, ctx),
+						Tests:                true,
+						KubernetesEventsTLS:  vaCtrl.Spec.EventCollector.TLS,
 					}, pipelineCR)
 					if err != nil {
 						return fmt.Errorf("aggregator %s/%s build config failed: %w: %w", vector.Namespace, vector.Name, ErrBuildConfigFailed, err)
@@ -426,11 +497,13 @@ func (r *PipelineReconciler) reconcile(ctx context.Context, req ctrl.Request) (c
 					vaCtrl.ConfigBytes = byteConfig
 					vaCtrl.Config = cfg
 
-					configCheck := configcheck.New(
+					checks := checks
+					checks.Workload = v1alpha1.WorkloadReference{Kind: "VectorAggregator", Namespace: vector.Namespace, Name: vector.Name}
+					configCheck := checks.New(
 						vaCtrl.ConfigBytes,
 						vaCtrl.Client,
 						vaCtrl.ClientSet,
-						&vaCtrl.Spec.VectorCommon,
+						vaCtrl.ConfigCheckCommon(),
 						vaCtrl.Name,
 						vaCtrl.Namespace,
 						r.ConfigCheckTimeout,
@@ -443,7 +516,7 @@ func (r *PipelineReconciler) reconcile(ctx context.Context, req ctrl.Request) (c
 						return nil
 					}
 					if reason != "" {
-						return fmt.Errorf("aggregator %s/%s config check failed: %s", vector.Namespace, vector.Name, reason)
+						return &configCheckFailedError{workload: fmt.Sprintf("aggregator %s/%s", vector.Namespace, vector.Name), namespace: vaCtrl.Namespace, output: reason, tests: errors.Is(err, configcheck.ErrTestsFailed)}
 					}
 					return err
 				})
@@ -452,11 +525,9 @@ func (r *PipelineReconciler) reconcile(ctx context.Context, req ctrl.Request) (c
 		} else {
 
 			for _, vector := range clusterVectorAggregators {
-				var selectorLabels map[string]string
-				if vector.Spec.Selector != nil {
-					selectorLabels = vector.Spec.Selector.MatchLabels
-				}
-				if !k8s.MatchLabels(selectorLabels, pipelineLabels) {
+				if selected, err := r.selects(ctx, pipelineCR, vector.Spec.Selector, &v1alpha1.AggregatorReference{Kind: "ClusterVectorAggregator", Name: vector.Name}); err != nil {
+					return ctrl.Result{}, err
+				} else if !selected {
 					continue
 				}
 				eg.Go(func() error {
@@ -467,7 +538,10 @@ func (r *PipelineReconciler) reconcile(ctx context.Context, req ctrl.Request) (c
 						PlaygroundEnabled:    vaCtrl.Spec.Api.Playground,
 						InternalMetrics:      vaCtrl.Spec.InternalMetrics,
 						ExpireMetricsSecs:    vaCtrl.Spec.ExpireMetricsSecs,
+						EnrichMetadata:       r.EnableMetadataEnrichment,
 						PipelineSecretGetter: pipelineSecretGetter(r.APIReader, ctx),
+						Tests:                true,
+						KubernetesEventsTLS:  vaCtrl.Spec.EventCollector.TLS,
 					}, pipelineCR)
 					if err != nil {
 						return fmt.Errorf("cluster aggregator %s/%s build config failed: %w: %w", vector.Namespace, vector.Name, ErrBuildConfigFailed, err)
@@ -484,11 +558,13 @@ func (r *PipelineReconciler) reconcile(ctx context.Context, req ctrl.Request) (c
 					vaCtrl.ConfigBytes = byteConfig
 					vaCtrl.Config = cfg
 
-					configCheck := configcheck.New(
+					checks := checks
+					checks.Workload = v1alpha1.WorkloadReference{Kind: "ClusterVectorAggregator", Name: vector.Name}
+					configCheck := checks.New(
 						vaCtrl.ConfigBytes,
 						vaCtrl.Client,
 						vaCtrl.ClientSet,
-						&vaCtrl.Spec.VectorCommon,
+						vaCtrl.ConfigCheckCommon(),
 						vaCtrl.Name,
 						vaCtrl.Namespace,
 						r.ConfigCheckTimeout,
@@ -501,7 +577,7 @@ func (r *PipelineReconciler) reconcile(ctx context.Context, req ctrl.Request) (c
 						return nil
 					}
 					if reason != "" {
-						return fmt.Errorf("cluster aggregator %s/%s config check failed: %s", vector.Namespace, vector.Name, reason)
+						return &configCheckFailedError{workload: fmt.Sprintf("cluster aggregator %s/%s", vector.Namespace, vector.Name), namespace: vaCtrl.Namespace, output: reason, tests: errors.Is(err, configcheck.ErrTestsFailed)}
 					}
 					return err
 				})
@@ -512,6 +588,9 @@ func (r *PipelineReconciler) reconcile(ctx context.Context, req ctrl.Request) (c
 
 	if err = eg.Wait(); err != nil {
 		log.Error(err, "Configcheck error")
+		if errors.Is(err, configcheck.ErrConfigcheckTimeout) {
+			k8s.Eventf(ctx, pipelineCR, corev1.EventTypeWarning, v1alpha1.ReasonConfigCheckTimeout, k8s.EventActionCheckConfig, "ConfigCheck did not finish within %s", r.ConfigCheckTimeout)
+		}
 		var secretErr *config.SecretResolveError
 		if errors.As(err, &secretErr) {
 			// Symmetric with the resolveRelatedSecrets error path above (see the
@@ -527,8 +606,30 @@ func (r *PipelineReconciler) reconcile(ctx context.Context, req ctrl.Request) (c
 			// secret's data never actually changed.
 			pipelineCR.SetRelatedSecretsHash(nil)
 		}
-		if err := pipeline.SetFailedStatus(ctx, r.Client, pipelineCR, err.Error(), basePipeline); err != nil {
-			return ctrl.Result{}, err
+		var statusErr error
+		if isSecretBuildError(err) {
+			statusErr = pipeline.SetSecretsFailedStatus(ctx, r.Client, pipelineCR, v1alpha1.ReasonSecretResolveFailed, err.Error(), basePipeline)
+		} else if isPolicyError(err) {
+			statusErr = pipeline.SetPolicyViolationStatus(ctx, r.Client, pipelineCR, err.Error(), basePipeline)
+		} else if checkErr := (*configCheckFailedError)(nil); errors.As(err, &checkErr) {
+			kind, namespace := "ClusterVectorPipeline", checkErr.namespace
+			if pipelineCR.GetNamespace() != "" {
+				kind, namespace = "VectorPipeline", pipelineCR.GetNamespace()
+			}
+			// the config checked holds no other pipeline
+			pipelineOf := func(string) string { return reportPipelineName(pipelineCR) }
+			report, summary := configCheckReport(ctx, r.APIReader, r.Client, pipelineCR, kind, namespace, checkErr.output, pipelineOf)
+			reason := fmt.Sprintf("%s config check failed: %s", checkErr.workload, summary)
+			if checkErr.tests {
+				statusErr = pipeline.SetTestsFailedStatus(ctx, r.Client, pipelineCR, reason, report, basePipeline)
+			} else {
+				statusErr = pipeline.SetConfigCheckReportStatus(ctx, r.Client, pipelineCR, reason, report, basePipeline)
+			}
+		} else {
+			statusErr = pipeline.SetFailedStatus(ctx, r.Client, pipelineCR, err.Error(), basePipeline)
+		}
+		if statusErr != nil {
+			return ctrl.Result{}, statusErr
 		}
 		if secretErr != nil {
 			return ctrl.Result{RequeueAfter: relatedSecretsResolveRetryDelay}, nil
@@ -560,6 +661,78 @@ func (r *PipelineReconciler) reconcile(ctx context.Context, req ctrl.Request) (c
 	return ctrl.Result{}, nil
 }
 
+// reconcileSuspended takes a suspended pipeline out of circulation. It is not validated
+// and its Secrets are not indexed - a rotation has nothing to re-validate - and the
+// workloads are woken to rebuild without it. The pipeline hash covers spec.suspend, so
+// the round that resumes the pipeline is a change like any other and runs in full.
+func (r *PipelineReconciler) reconcileSuspended(ctx context.Context, p pipeline.Pipeline, base pipeline.Pipeline) (ctrl.Result, error) {
+	log := log.FromContext(ctx).WithValues("Pipeline", p.GetName())
+
+	if r.SecretIndex != nil {
+		r.SecretIndex.Set(client.ObjectKeyFromObject(p), nil)
+	}
+	unchanged, err := pipeline.IsPipelineChanged(p)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if unchanged {
+		log.Info("Pipeline is suspended and has no changes. Finish Reconcile Pipeline")
+		return ctrl.Result{}, nil
+	}
+	if err := pipeline.SetSuspendedStatus(ctx, r.Client, p, base); err != nil {
+		return ctrl.Result{}, err
+	}
+	if err := r.wakeWorkloads(ctx); err != nil {
+		return ctrl.Result{}, err
+	}
+	log.Info("Pipeline suspended. Finish Reconcile Pipeline")
+	return ctrl.Result{}, nil
+}
+
+// wakeWorkloads queues a rebuild of every workload, for a pipeline that has just left
+// the configs they publish.
+func (r *PipelineReconciler) wakeWorkloads(ctx context.Context) error {
+	vectorAgents, err := listVectorAgents(ctx, r.Client)
+	if err != nil {
+		return err
+	}
+	vectorAggregators, err := listVectorAggregators(ctx, r.Client)
+	if err != nil {
+		return err
+	}
+	clusterVectorAggregators, err := listClusterVectorAggregators(ctx, r.Client)
+	if err != nil {
+		return err
+	}
+	for _, vector := range vectorAgents {
+		r.VectorAgentEventCh <- event.GenericEvent{Object: vector}
+	}
+	for _, vector := range vectorAggregators {
+		r.VectorAggregatorsEventCh <- event.GenericEvent{Object: vector}
+	}
+	for _, vector := range clusterVectorAggregators {
+		r.ClusterVectorAggregatorsEventCh <- event.GenericEvent{Object: vector}
+	}
+	return nil
+}
+
+// selects reports whether a workload with selector picks up p, the same way the
+// workload's own listing does (pipeline.Selector). A selector that does not parse selects
+// nothing here: the workload reconcile fails on it and reports it on the workload.
+func (r *PipelineReconciler) selects(ctx context.Context, p pipeline.Pipeline, selector *v1alpha1.VectorSelectorSpec, aggregator *v1alpha1.AggregatorReference) (bool, error) {
+	sel, err := pipeline.NewSelector(selector, aggregator)
+	if err != nil {
+		return false, nil
+	}
+	var nsLabels labels.Set
+	if sel.SelectsNamespaces() && p.GetNamespace() != "" {
+		if nsLabels, err = pipeline.NamespaceLabels(ctx, r.Client, p.GetNamespace()); err != nil {
+			return false, err
+		}
+	}
+	return sel.Matches(p, nsLabels), nil
+}
+
 func (r *PipelineReconciler) getPipeline(ctx context.Context, req ctrl.Request) (pipeline pipeline.Pipeline, err error) {
 	if req.Namespace != "" {
 		vp := &v1alpha1.VectorPipeline{}
@@ -583,7 +756,11 @@ func (r *PipelineReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		For(&v1alpha1.VectorPipeline{}).
 		WithOptions(controller.Options{MaxConcurrentReconciles: 20}).
 		Watches(&v1alpha1.ClusterVectorPipeline{}, &handler.EnqueueRequestForObject{}).
-		WithEventFilter(specAndAnnotationsPredicate).
+		Watches(&v1alpha1.VectorOutput{}, handler.EnqueueRequestsFromMapFunc(r.mapOutputToPipelines)).
+		Watches(&v1alpha1.ClusterVectorOutput{}, handler.EnqueueRequestsFromMapFunc(r.mapOutputToPipelines)).
+		Watches(&v1alpha1.VectorPipelineTemplate{}, handler.EnqueueRequestsFromMapFunc(r.mapTemplateToPipelines)).
+		Watches(&v1alpha1.ClusterVectorPipelinePolicy{}, handler.EnqueueRequestsFromMapFunc(r.mapPolicyToPipelines)).
+		WithEventFilter(r.eventFilter()).
 		// Watch on Secrets, reusing the manager's cache: the workload reconcilers already
 		// run Owns(&corev1.Secret{}), a full structural informer, so this adds no second
 		// watch stream - only another handler on the informer already there.
@@ -591,7 +768,7 @@ func (r *PipelineReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		// read separately through the uncached APIReader.
 		//
 		// Wired through WatchesRawSource rather than Watches because WithEventFilter above
-		// applies specAndAnnotationsPredicate to every watch on this builder (ANDed with
+		// applies specAndAnnotationsPredicate (see eventFilter) to every watch on this builder (ANDed with
 		// any per-watch predicate), and that predicate fires only on a generation or
 		// annotations change. Secrets have no status subresource, so the API server never
 		// bumps metadata.generation on a data-only update - it would silently swallow every
@@ -605,6 +782,23 @@ func (r *PipelineReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		Complete(r)
 }
 
+// mapPolicyToPipelines resolves a changed ClusterVectorPipelinePolicy to every
+// VectorPipeline: the namespaces it governed before the change are no longer known, and
+// a relaxed policy has to revalidate the pipelines it used to reject as much as a
+// tightened one the pipelines it now rejects.
+func (r *PipelineReconciler) mapPolicyToPipelines(ctx context.Context, obj client.Object) []reconcile.Request {
+	vps, err := pipeline.GetVectorPipelines(ctx, r.Client)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list VectorPipelines for policy", "policy", obj.GetName())
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(vps))
+	for i := range vps {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&vps[i])})
+	}
+	return requests
+}
+
 // mapSecretToPipelines resolves a changed Secret to the pipelines that declared it via
 // spec.secret, so a Secret rotation gets requeued as a normal pipeline reconcile.
 // Returns nil for secrets no pipeline references, which is the overwhelming common case.
@@ -621,10 +815,91 @@ func (r *PipelineReconciler) mapSecretToPipelines(_ context.Context, obj client.
 	requests := make([]reconcile.Request, len(pipelines))
 	for i, p := range pipelines {
 		requests[i] = reconcile.Request{NamespacedName: p}
+		r.secretWatchRequeues.Store(p, struct{}{})
 	}
 	return requests
 }
 
+// mapOutputToPipelines resolves a changed VectorOutput to the VectorPipelines of its
+// namespace that reference it, and a ClusterVectorOutput to the ClusterVectorPipelines
+// that do. The expanded output is part of their hash, so each of them is revalidated
+// and, once valid, pushed to its workloads like any other pipeline change.
+func (r *PipelineReconciler) mapOutputToPipelines(ctx context.Context, obj client.Object) []reconcile.Request {
+	var pipelines []pipeline.Pipeline
+	if obj.GetNamespace() != "" {
+		vps := &v1alpha1.VectorPipelineList{}
+		if err := r.List(ctx, vps, client.InNamespace(obj.GetNamespace())); err != nil {
+			log.FromContext(ctx).Error(err, "Failed to list VectorPipelines for output", "output", obj.GetName())
+			return nil
+		}
+		for i := range vps.Items {
+			pipelines = append(pipelines, &vps.Items[i])
+		}
+	} else {
+		cvps := &v1alpha1.ClusterVectorPipelineList{}
+		if err := r.List(ctx, cvps); err != nil {
+			log.FromContext(ctx).Error(err, "Failed to list ClusterVectorPipelines for output", "output", obj.GetName())
+			return nil
+		}
+		for i := range cvps.Items {
+			pipelines = append(pipelines, &cvps.Items[i])
+		}
+	}
+
+	var requests []reconcile.Request
+	for _, p := range pipelines {
+		// the reference can be in the pipeline's template rather than its own spec
+		if err := pipeline.ExpandTemplate(ctx, r.Client, p); err != nil {
+			continue
+		}
+		if pipeline.ReferencesOutput(p, obj.GetName()) {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(p)})
+		}
+	}
+	return requests
+}
+
+// mapTemplateToPipelines resolves a changed VectorPipelineTemplate to every pipeline,
+// of either kind, rendered from it. The rendered spec is part of their hash, so each of
+// them is re-rendered, revalidated and pushed to its workloads.
+func (r *PipelineReconciler) mapTemplateToPipelines(ctx context.Context, obj client.Object) []reconcile.Request {
+	vps, err := pipeline.GetVectorPipelines(ctx, r.Client)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list VectorPipelines for template", "template", obj.GetName())
+		return nil
+	}
+	cvps, err := pipeline.GetClusterVectorPipelines(ctx, r.Client)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list ClusterVectorPipelines for template", "template", obj.GetName())
+		return nil
+	}
+
+	var requests []reconcile.Request
+	rendersFrom := func(p pipeline.Pipeline) {
+		if ref := p.GetSpec().TemplateRef; ref != nil && ref.Name == obj.GetName() {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(p)})
+		}
+	}
+	for i := range vps {
+		rendersFrom(&vps[i])
+	}
+	for i := range cvps {
+		rendersFrom(&cvps[i])
+	}
+	return requests
+}
+
+// eventFilter passes what specAndAnnotationsPredicate does, and label changes of the
+// pipelines metadata enrichment applies to: it stamps their labels into the config, and
+// GetPipelineHash covers them, but a label change bumps no generation.
+func (r *PipelineReconciler) eventFilter() predicate.Predicate {
+	enriched := predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		p, ok := obj.(pipeline.Pipeline)
+		return ok && config.EnrichesMetadata(r.EnableMetadataEnrichment, p)
+	})
+	return predicate.Or(specAndAnnotationsPredicate, predicate.And(predicate.LabelChangedPredicate{}, enriched))
+}
+
 var specAndAnnotationsPredicate = predicate.Funcs{
 	UpdateFunc: func(e event.UpdateEvent) bool {
 		if e.ObjectOld.GetGeneration() != e.ObjectNew.GetGeneration() {
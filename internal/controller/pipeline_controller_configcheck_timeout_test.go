@@ -0,0 +1,71 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/events"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/kaasops/vector-operator/api/v1alpha1"
+	"github.com/kaasops/vector-operator/internal/config/configcheck"
+	"github.com/kaasops/vector-operator/internal/pipeline"
+)
+
+// A pipeline whose check against a workload times out gets the same event a workload
+// does, next to the failed status.
+func TestPipelineReconcileConfigCheckTimeout(t *testing.T) {
+	vp := &v1alpha1.VectorPipeline{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "team-a"},
+		Spec: v1alpha1.VectorPipelineSpec{
+			Sources: &runtime.RawExtension{Raw: []byte(`{"logs":{"type":"kubernetes_logs"}}`)},
+			Sinks:   &runtime.RawExtension{Raw: []byte(`{"out":{"inputs":["logs"],"type":"console"}}`)},
+		},
+	}
+	vector := &v1alpha1.Vector{ObjectMeta: metav1.ObjectMeta{Name: "agent", Namespace: "vector"}}
+	cl := newFakeClient(vp, vector)
+	recorder := events.NewFakeRecorder(10)
+	r := &PipelineReconciler{
+		Client:                          cl,
+		APIReader:                       cl,
+		ConfigCheckTimeout:              100 * time.Millisecond,
+		ConfigCheckSettings:             configcheck.Settings{Mode: configcheck.ModeLocal, VectorBinary: slowVector(t, filepath.Join(t.TempDir(), "runs"))},
+		VectorAgentEventCh:              make(chan event.GenericEvent, 10),
+		VectorAggregatorsEventCh:        make(chan event.GenericEvent, 10),
+		ClusterVectorAggregatorsEventCh: make(chan event.GenericEvent, 10),
+		SecretIndex:                     pipeline.NewSecretIndex(),
+		Recorder:                        recorder,
+	}
+	_, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: client.ObjectKeyFromObject(vp)})
+	require.NoError(t, err)
+
+	close(recorder.Events)
+	var emitted []string
+	for e := range recorder.Events {
+		emitted = append(emitted, e)
+	}
+	require.Contains(t, emitted, "Warning ConfigCheckTimeout ConfigCheck did not finish within 100ms")
+}
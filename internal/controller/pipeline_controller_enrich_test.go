@@ -0,0 +1,99 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/kaasops/vector-operator/api/v1alpha1"
+	"github.com/kaasops/vector-operator/internal/common"
+	"github.com/kaasops/vector-operator/internal/pipeline"
+)
+
+// Metadata enrichment stamps the pipeline's labels into the config, so a label-only
+// edit, which bumps no generation, has to get through the event filter and rebuild the
+// workloads.
+func TestPipelineReconcileEnrichedLabels(t *testing.T) {
+	ctx := context.Background()
+	vp := &v1alpha1.VectorPipeline{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "team-a", Generation: 1, Labels: map[string]string{"team": "a"}},
+		Spec: v1alpha1.VectorPipelineSpec{
+			Sources: &runtime.RawExtension{Raw: []byte(`{"logs":{"type":"kubernetes_logs"}}`)},
+			Sinks:   &runtime.RawExtension{Raw: []byte(`{"out":{"inputs":["logs"],"type":"console"}}`)},
+		},
+	}
+	// selects nothing, so the pipeline is not configchecked
+	vector := &v1alpha1.Vector{
+		ObjectMeta: metav1.ObjectMeta{Name: "agent", Namespace: "vector"},
+		Spec:       v1alpha1.VectorSpec{Selector: &v1alpha1.VectorSelectorSpec{MatchLabels: map[string]string{"selects": "nothing"}}},
+	}
+	cl := newFakeClient(vp, vector)
+
+	agentEvents := make(chan event.GenericEvent, 10)
+	r := &PipelineReconciler{
+		Client:                          cl,
+		APIReader:                       cl,
+		VectorAgentEventCh:              agentEvents,
+		VectorAggregatorsEventCh:        make(chan event.GenericEvent, 10),
+		ClusterVectorAggregatorsEventCh: make(chan event.GenericEvent, 10),
+		SecretIndex:                     pipeline.NewSecretIndex(),
+		EnableMetadataEnrichment:        true,
+	}
+	req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(vp)}
+	_, err := r.reconcile(ctx, req)
+	require.NoError(t, err)
+	require.Len(t, agentEvents, 1)
+	<-agentEvents
+
+	require.NoError(t, cl.Get(ctx, req.NamespacedName, vp))
+	relabeled := vp.DeepCopy()
+	relabeled.Labels["team"] = "b"
+	update := event.UpdateEvent{ObjectOld: vp, ObjectNew: relabeled}
+	require.True(t, r.eventFilter().Update(update), "the label change reaches the reconcile")
+	require.False(t, specAndAnnotationsPredicate.Update(update))
+
+	require.NoError(t, cl.Update(ctx, relabeled))
+	_, err = r.reconcile(ctx, req)
+	require.NoError(t, err)
+	require.Len(t, agentEvents, 1, "the changed hash makes the agents rebuild with the new labels")
+	<-agentEvents
+
+	// without enrichment the labels are not in the config, and the edit is filtered out
+	r.EnableMetadataEnrichment = false
+	require.False(t, r.eventFilter().Update(update))
+	optedIn := update
+	optedIn.ObjectNew = relabeled.DeepCopy()
+	optedIn.ObjectNew.SetAnnotations(map[string]string{common.AnnotationMetadataEnrichment: common.AnnotationValueEnabled})
+	optedIn.ObjectOld = vp.DeepCopy()
+	optedIn.ObjectOld.SetAnnotations(optedIn.ObjectNew.GetAnnotations())
+	require.True(t, r.eventFilter().Update(optedIn), "a pipeline opting in by annotation is enriched all the same")
+
+	// other watched kinds are not enriched
+	output := &v1alpha1.VectorOutput{ObjectMeta: metav1.ObjectMeta{Name: "out", Labels: map[string]string{"team": "a"}}}
+	relabeledOutput := output.DeepCopy()
+	relabeledOutput.Labels["team"] = "b"
+	r.EnableMetadataEnrichment = true
+	require.False(t, r.eventFilter().Update(event.UpdateEvent{ObjectOld: output, ObjectNew: relabeledOutput}))
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/kaasops/vector-operator/api/v1alpha1"
+	"github.com/kaasops/vector-operator/internal/pipeline"
+)
+
+// A pipeline whose output does not exist fails like any other invalid pipeline, without
+// a configcheck round, and an output appearing later maps back to it.
+func TestPipelineReconcileMissingOutput(t *testing.T) {
+	ctx := context.Background()
+	vp := &v1alpha1.VectorPipeline{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "team-a", Generation: 2},
+		Spec: v1alpha1.VectorPipelineSpec{
+			Sources: &runtime.RawExtension{Raw: []byte(`{"logs":{"type":"kubernetes_logs"}}`)},
+			Sinks:   &runtime.RawExtension{Raw: []byte(`{"es":{"inputs":["logs"],"outputRef":"central"}}`)},
+		},
+	}
+	other := &v1alpha1.VectorPipeline{
+		ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "team-a"},
+		Spec: v1alpha1.VectorPipelineSpec{
+			Sinks: &runtime.RawExtension{Raw: []byte(`{"out":{"inputs":["logs"],"type":"console"}}`)},
+		},
+	}
+	vector := &v1alpha1.Vector{ObjectMeta: metav1.ObjectMeta{Name: "agent", Namespace: "vector"}}
+	cl := newFakeClient(vp, other, vector)
+
+	agentEvents := make(chan event.GenericEvent, 10)
+	r := &PipelineReconciler{
+		Client:                          cl,
+		APIReader:                       cl,
+		VectorAgentEventCh:              agentEvents,
+		VectorAggregatorsEventCh:        make(chan event.GenericEvent, 10),
+		ClusterVectorAggregatorsEventCh: make(chan event.GenericEvent, 10),
+		SecretIndex:                     pipeline.NewSecretIndex(),
+	}
+	req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(vp)}
+
+	_, err := r.reconcile(ctx, req)
+	require.NoError(t, err)
+	require.Empty(t, agentEvents)
+
+	result := &v1alpha1.VectorPipeline{}
+	require.NoError(t, cl.Get(ctx, req.NamespacedName, result))
+	require.False(t, result.IsValid())
+	require.Contains(t, *result.Status.Reason, `VectorOutput "central" not found`)
+	require.True(t, meta.IsStatusConditionFalse(result.Status.Conditions, v1alpha1.ConditionConfigValid))
+
+	output := &v1alpha1.VectorOutput{ObjectMeta: metav1.ObjectMeta{Name: "central", Namespace: "team-a"}}
+	require.Equal(t, []reconcile.Request{req}, r.mapOutputToPipelines(ctx, output))
+	output.Namespace = "team-b"
+	require.Empty(t, r.mapOutputToPipelines(ctx, output))
+}
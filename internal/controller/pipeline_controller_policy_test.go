@@ -0,0 +1,95 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/kaasops/vector-operator/api/v1alpha1"
+	"github.com/kaasops/vector-operator/internal/pipeline"
+)
+
+// A policy is not part of the pipeline hash: tightening one fails a pipeline nobody
+// edited and wakes the workloads that may publish it, and relaxing it again brings the
+// unchanged pipeline back.
+func TestPipelineReconcilePolicy(t *testing.T) {
+	ctx := context.Background()
+	vp := &v1alpha1.VectorPipeline{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "team-a"},
+		Spec: v1alpha1.VectorPipelineSpec{
+			Sources: &runtime.RawExtension{Raw: []byte(`{"logs":{"type":"kubernetes_logs"}}`)},
+			Sinks:   &runtime.RawExtension{Raw: []byte(`{"out":{"inputs":["logs"],"type":"console"}}`)},
+		},
+	}
+	// selects nothing, so a valid pipeline is not configchecked
+	vector := &v1alpha1.Vector{
+		ObjectMeta: metav1.ObjectMeta{Name: "agent", Namespace: "vector"},
+		Spec:       v1alpha1.VectorSpec{Selector: &v1alpha1.VectorSelectorSpec{MatchLabels: map[string]string{"selects": "nothing"}}},
+	}
+	policy := &v1alpha1.ClusterVectorPipelinePolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "tenants"},
+		Spec:       v1alpha1.ClusterVectorPipelinePolicySpec{AllowedSinkTypes: []string{"loki"}},
+	}
+	cl := newFakeClient(vp, vector)
+
+	agentEvents := make(chan event.GenericEvent, 10)
+	r := &PipelineReconciler{
+		Client:                          cl,
+		APIReader:                       cl,
+		VectorAgentEventCh:              agentEvents,
+		VectorAggregatorsEventCh:        make(chan event.GenericEvent, 10),
+		ClusterVectorAggregatorsEventCh: make(chan event.GenericEvent, 10),
+		SecretIndex:                     pipeline.NewSecretIndex(),
+	}
+	req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(vp)}
+	result := &v1alpha1.VectorPipeline{}
+	reconcileOnce := func() {
+		t.Helper()
+		_, err := r.reconcile(ctx, req)
+		require.NoError(t, err)
+		require.NoError(t, cl.Get(ctx, req.NamespacedName, result))
+	}
+
+	reconcileOnce()
+	require.True(t, result.IsValid())
+	<-agentEvents
+
+	require.NoError(t, cl.Create(ctx, policy))
+	require.Equal(t, []reconcile.Request{req}, r.mapPolicyToPipelines(ctx, policy))
+	reconcileOnce()
+	require.False(t, result.IsValid())
+	require.Contains(t, *result.Status.Reason, "sink out: type console is not allowed by ClusterVectorPipelinePolicy tenants")
+	cond := meta.FindStatusCondition(result.Status.Conditions, v1alpha1.ConditionConfigValid)
+	require.NotNil(t, cond)
+	require.Equal(t, v1alpha1.ReasonPolicyViolation, cond.Reason)
+	require.Nil(t, result.Status.LastAppliedPipelineHash)
+	require.Len(t, agentEvents, 1, "the workloads rebuild without the pipeline")
+	<-agentEvents
+
+	require.NoError(t, cl.Delete(ctx, policy))
+	reconcileOnce()
+	require.True(t, result.IsValid())
+}
@@ -25,6 +25,7 @@ import (
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -87,6 +88,72 @@ func TestSecretRotationPollInterval(t *testing.T) {
 // informer), pipelines that actually reference Secrets re-check themselves on a timer.
 // The specs pin who gets a poll, who does not, and that the poll survives the paths
 // where it matters.
+// Only a rotation the Secret watch did not report is a poll hit: the watch requeues the
+// pipelines of the Secret itself, and counting those would credit the poll with them.
+func TestSecretRotationPollHits(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "creds", Namespace: "team-a"},
+		Data:       map[string][]byte{"key": []byte("v1")},
+	}
+	vp := &v1alpha1.VectorPipeline{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "team-a"},
+		Spec: v1alpha1.VectorPipelineSpec{
+			Secret: map[string]v1alpha1.PipelineSecretBackend{
+				"es": {Type: "kubernetes_secret", Name: secret.Name},
+			},
+			Sources: &runtime.RawExtension{Raw: []byte(`{"logs": {"type": "kubernetes_logs"}}`)},
+			Sinks: &runtime.RawExtension{Raw: []byte(
+				`{"out": {"type": "elasticsearch", "inputs": ["logs"], "auth": {"user": "SECRET[es.key]"}}}`,
+			)},
+		},
+	}
+	// selects nothing, so the pipeline is not configchecked
+	vector := &v1alpha1.Vector{
+		ObjectMeta: metav1.ObjectMeta{Name: "agent", Namespace: "vector"},
+		Spec:       v1alpha1.VectorSpec{Selector: &v1alpha1.VectorSelectorSpec{MatchLabels: map[string]string{"selects": "nothing"}}},
+	}
+	cl := newFakeClient(secret, vp, vector)
+	r := &PipelineReconciler{
+		Client:                          cl,
+		APIReader:                       cl,
+		VectorAgentEventCh:              make(chan event.GenericEvent, 10),
+		VectorAggregatorsEventCh:        make(chan event.GenericEvent, 10),
+		ClusterVectorAggregatorsEventCh: make(chan event.GenericEvent, 10),
+		SecretIndex:                     pipeline.NewSecretIndex(),
+		PollSecretRotation:              true,
+	}
+	req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(vp)}
+	// the first round stamps the status the second one finds unchanged
+	for range 2 {
+		_, err := r.Reconcile(ctx, req)
+		g.Expect(err).NotTo(HaveOccurred())
+	}
+	hits := testutil.ToFloat64(secretRotationPollHits)
+
+	rotate := func(value string) {
+		g.Expect(cl.Get(ctx, client.ObjectKeyFromObject(secret), secret)).To(Succeed())
+		secret.Data["key"] = []byte(value)
+		g.Expect(cl.Update(ctx, secret)).To(Succeed())
+	}
+	rotate("v2")
+	g.Expect(r.mapSecretToPipelines(ctx, secret)).To(ConsistOf(req))
+	_, err := r.Reconcile(ctx, req)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(testutil.ToFloat64(secretRotationPollHits)).To(Equal(hits), "the Secret watch reported this rotation")
+
+	rotate("v3")
+	_, err = r.Reconcile(ctx, req)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(testutil.ToFloat64(secretRotationPollHits)).To(Equal(hits+1), "only the poll caught this one")
+
+	_, err = r.Reconcile(ctx, req)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(testutil.ToFloat64(secretRotationPollHits)).To(Equal(hits+1), "nothing changed")
+}
+
 var _ = Describe("PipelineReconciler scoped-mode rotation poll", func() {
 	makeSecret := func(name, ns string) *corev1.Secret {
 		s := &corev1.Secret{
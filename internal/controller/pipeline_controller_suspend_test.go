@@ -0,0 +1,106 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/kaasops/vector-operator/api/v1alpha1"
+	"github.com/kaasops/vector-operator/internal/pipeline"
+)
+
+// A suspended pipeline skips validation entirely - no configcheck pod, which a fake
+// client could not run anyway - keeps the verdict of its last validating round, and
+// wakes the workloads exactly once so they rebuild without it.
+func TestPipelineReconcileSuspended(t *testing.T) {
+	ctx := context.Background()
+	valid := true
+	reason := "kept from the last round"
+	vp := &v1alpha1.VectorPipeline{
+		ObjectMeta: metav1.ObjectMeta{Name: "noisy", Namespace: "team-a", Generation: 4},
+		Spec: v1alpha1.VectorPipelineSpec{
+			Suspend: true,
+			Sources: &runtime.RawExtension{Raw: []byte(`{"logs":{"type":"kubernetes_logs"}}`)},
+			// keys sorted: the fake client re-encodes the spec on its first write, and the
+			// pipeline hash is taken over the encoding
+			Sinks: &runtime.RawExtension{Raw: []byte(`{"out":{"inputs":["logs"],"type":"blackhole"}}`)},
+		},
+		Status: v1alpha1.VectorPipelineStatus{ConfigCheckResult: &valid, Reason: &reason},
+	}
+	vector := &v1alpha1.Vector{ObjectMeta: metav1.ObjectMeta{Name: "agent", Namespace: "vector"}}
+	cl := newFakeClient(vp, vector)
+
+	agentEvents := make(chan event.GenericEvent, 10)
+	r := &PipelineReconciler{
+		Client:                          cl,
+		APIReader:                       cl,
+		VectorAgentEventCh:              agentEvents,
+		VectorAggregatorsEventCh:        make(chan event.GenericEvent, 10),
+		ClusterVectorAggregatorsEventCh: make(chan event.GenericEvent, 10),
+		SecretIndex:                     pipeline.NewSecretIndex(),
+	}
+	req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(vp)}
+
+	_, err := r.reconcile(ctx, req)
+	require.NoError(t, err)
+	require.Len(t, agentEvents, 1)
+	<-agentEvents
+
+	result := &v1alpha1.VectorPipeline{}
+	require.NoError(t, cl.Get(ctx, req.NamespacedName, result))
+	require.True(t, result.IsValid(), "the last verdict is kept")
+	require.Equal(t, &reason, result.Status.Reason)
+	require.True(t, meta.IsStatusConditionTrue(result.Status.Conditions, v1alpha1.ConditionSuspended))
+	require.True(t, meta.IsStatusConditionFalse(result.Status.Conditions, v1alpha1.ConditionPublished))
+	require.True(t, meta.IsStatusConditionFalse(result.Status.Conditions, v1alpha1.ConditionReady))
+	require.Equal(t, result.Generation, result.Status.ObservedGeneration)
+	hash, err := pipeline.GetPipelineHash(result)
+	require.NoError(t, err)
+	require.NotNil(t, result.Status.LastAppliedPipelineHash)
+	require.Equal(t, *hash, *result.Status.LastAppliedPipelineHash)
+
+	// nothing changed since: no status write, no rebuild
+	_, err = r.reconcile(ctx, req)
+	require.NoError(t, err)
+	require.Empty(t, agentEvents)
+
+	// an edit while suspended leaves the last verdict on a spec that no longer exists
+	result.Spec.Sinks = &runtime.RawExtension{Raw: []byte(`{"out":{"inputs":["logs"],"type":"console"}}`)}
+	result.Generation++
+	require.NoError(t, cl.Update(ctx, result))
+	_, err = r.reconcile(ctx, req)
+	require.NoError(t, err)
+	require.NoError(t, cl.Get(ctx, req.NamespacedName, result))
+	require.Nil(t, result.Status.ConfigCheckResult)
+	require.Nil(t, result.Status.Reason)
+	for _, cond := range []string{v1alpha1.ConditionConfigValid, v1alpha1.ConditionSecretsResolved} {
+		c := meta.FindStatusCondition(result.Status.Conditions, cond)
+		require.NotNil(t, c, cond)
+		require.Equal(t, metav1.ConditionUnknown, c.Status, cond)
+	}
+	require.True(t, meta.IsStatusConditionTrue(result.Status.Conditions, v1alpha1.ConditionSuspended))
+	require.Equal(t, result.Generation, result.Status.ObservedGeneration)
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/kaasops/vector-operator/api/v1alpha1"
+	"github.com/kaasops/vector-operator/internal/pipeline"
+)
+
+// A pipeline that cannot be rendered fails with the reason, and editing the template
+// maps back to every pipeline of either kind rendered from it.
+func TestPipelineReconcileTemplate(t *testing.T) {
+	ctx := context.Background()
+	tpl := &v1alpha1.VectorPipelineTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "per-team"},
+		Spec: v1alpha1.VectorPipelineTemplateSpec{
+			Parameters: []v1alpha1.TemplateParameter{{Name: "team", Type: "string"}},
+		},
+	}
+	ref := &v1alpha1.TemplateReference{Name: "per-team"}
+	vp := &v1alpha1.VectorPipeline{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "team-a"},
+		Spec:       v1alpha1.VectorPipelineSpec{TemplateRef: ref},
+	}
+	cvp := &v1alpha1.ClusterVectorPipeline{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+		Spec:       v1alpha1.VectorPipelineSpec{TemplateRef: ref},
+	}
+	untemplated := &v1alpha1.VectorPipeline{ObjectMeta: metav1.ObjectMeta{Name: "plain", Namespace: "team-a"}}
+	vector := &v1alpha1.Vector{ObjectMeta: metav1.ObjectMeta{Name: "agent", Namespace: "vector"}}
+	cl := newFakeClient(tpl, vp, cvp, untemplated, vector)
+
+	r := &PipelineReconciler{
+		Client:                          cl,
+		APIReader:                       cl,
+		VectorAgentEventCh:              make(chan event.GenericEvent, 10),
+		VectorAggregatorsEventCh:        make(chan event.GenericEvent, 10),
+		ClusterVectorAggregatorsEventCh: make(chan event.GenericEvent, 10),
+		SecretIndex:                     pipeline.NewSecretIndex(),
+	}
+	req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(vp)}
+	_, err := r.reconcile(ctx, req)
+	require.NoError(t, err)
+
+	result := &v1alpha1.VectorPipeline{}
+	require.NoError(t, cl.Get(ctx, req.NamespacedName, result))
+	require.False(t, result.IsValid())
+	require.Contains(t, *result.Status.Reason, `parameter "team" is not bound`)
+
+	require.ElementsMatch(t, []reconcile.Request{req, {NamespacedName: client.ObjectKeyFromObject(cvp)}},
+		r.mapTemplateToPipelines(ctx, tpl))
+}
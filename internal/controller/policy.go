@@ -0,0 +1,79 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/kaasops/vector-operator/api/v1alpha1"
+	"github.com/kaasops/vector-operator/internal/config"
+	"github.com/kaasops/vector-operator/internal/pipeline"
+)
+
+// pipelinePolicies builds a config.VectorConfigParams.PipelinePolicies over reader.
+// Like pipelineSecretGetter it closes over the reconcile's own ctx: Build*Config invokes
+// it with context.Background().
+func pipelinePolicies(reader client.Reader, ctx context.Context) func(context.Context, pipeline.Pipeline) ([]v1alpha1.ClusterVectorPipelinePolicy, error) {
+	return func(_ context.Context, p pipeline.Pipeline) ([]v1alpha1.ClusterVectorPipelinePolicy, error) {
+		return pipeline.Policies(ctx, reader, p)
+	}
+}
+
+// isPolicyError reports whether err is a pipeline rejected by a
+// ClusterVectorPipelinePolicy, or governed by one that does not parse.
+func isPolicyError(err error) bool {
+	return errors.Is(err, config.ErrPolicyViolation) || errors.Is(err, pipeline.ErrInvalidPolicy)
+}
+
+// enforcePolicies checks p, expanded, against the ClusterVectorPipelinePolicies governing
+// its namespace and reports true once a violation is recorded on it. A pipeline that
+// was valid until now may be part of a published config, so its workloads are woken to
+// rebuild without it; the config builders enforce the same policies meanwhile. A spec
+// that does not parse is left to the rest of the round to report.
+func (r *PipelineReconciler) enforcePolicies(ctx context.Context, p pipeline.Pipeline, base pipeline.Pipeline) (bool, error) {
+	policies, err := pipeline.Policies(ctx, r.Client, p)
+	if err == nil && len(policies) > 0 {
+		cfg := &config.PipelineConfig{}
+		if config.UnmarshalJson(p.GetSpec(), cfg) == nil {
+			err = config.CheckPolicies(policies, cfg)
+		}
+	}
+	if err == nil {
+		return false, nil
+	}
+	if !isPolicyError(err) {
+		return false, err
+	}
+
+	log.FromContext(ctx).Info("Pipeline rejected by policy", "reason", err.Error())
+	if r.SecretIndex != nil {
+		r.SecretIndex.Set(client.ObjectKeyFromObject(p), nil)
+	}
+	if err := pipeline.SetPolicyViolationStatus(ctx, r.Client, p, err.Error(), base); err != nil {
+		return false, err
+	}
+	if base.IsValid() {
+		if err := r.wakeWorkloads(ctx); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kaasops/vector-operator/api/v1alpha1"
+	"github.com/kaasops/vector-operator/internal/config"
+	"github.com/kaasops/vector-operator/internal/pipeline"
+)
+
+// quotaExceededReasonPrefix marks a pipeline status Reason as coming from
+// resolveWorkloadPipelines' quota attribution (config.DetectQuotaExclusions). Like the
+// secret attribution classes it resolves itself - an older pipeline of the namespace is
+// deleted or the quota is raised - so a pipeline carrying it stays in the retry pool.
+//
+// Frozen on the same terms as secretAssetsWaitingReasonPrefix.
+const quotaExceededReasonPrefix = "namespace quota exceeded: "
+
+// isQuotaExceededReason reports whether reason was written by the quota attribution.
+func isQuotaExceededReason(reason string) bool {
+	return strings.HasPrefix(reason, quotaExceededReasonPrefix)
+}
+
+// quotaExclusionReason renders a config.QuotaExclusion into the pipeline status Reason,
+// naming the workload whose build excluded it: a quota is counted per workload.
+func quotaExclusionReason(e config.QuotaExclusion, workloadKind, workloadNamespace, workloadName string) string {
+	workloadRef := workloadKind + " " + workloadName
+	if workloadNamespace != "" {
+		workloadRef = fmt.Sprintf("%s %s/%s", workloadKind, workloadNamespace, workloadName)
+	}
+	return fmt.Sprintf(
+		"%snamespace %s is over %s of ClusterVectorPipelinePolicy %s on %s: this pipeline adds %d to the %d its older pipelines use, and the quota is %d; "+
+			"older pipelines (by creation time) keep their place and this one is left out until the namespace fits",
+		quotaExceededReasonPrefix, e.Victim.GetNamespace(), e.Limit, e.Policy, workloadRef, e.Requested, e.Used, e.Max,
+	)
+}
+
+// writeQuotaReasonIfChanged is writeAttributionReasonIfChanged for a quota exclusion,
+// which is reported on the Published condition rather than SecretsResolved.
+func writeQuotaReasonIfChanged(ctx context.Context, c client.Client, p pipeline.Pipeline, reason string) error {
+	if r := p.GetReason(); r != nil && *r == reason {
+		if cond := meta.FindStatusCondition(p.GetConditions(), v1alpha1.ConditionPublished); cond != nil && cond.Reason == v1alpha1.ReasonQuotaExceeded {
+			return nil
+		}
+	}
+	base := p.DeepCopyObject().(pipeline.Pipeline)
+	p.SetRelatedSecretsHash(nil)
+	return pipeline.SetQuotaExceededStatus(ctx, c, p, reason, base)
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kaasops/vector-operator/api/v1alpha1"
+)
+
+// A namespace over its quota loses its youngest pipeline, reported on Published; once
+// the quota is raised the pipeline is handed back as a reinstate candidate.
+func TestResolveWorkloadPipelinesQuota(t *testing.T) {
+	ctx := context.Background()
+	t0 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	newVP := func(name string, created time.Time) *v1alpha1.VectorPipeline {
+		return &v1alpha1.VectorPipeline{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "team-a", CreationTimestamp: metav1.NewTime(created)},
+			Spec: v1alpha1.VectorPipelineSpec{
+				Sources: &runtime.RawExtension{Raw: []byte(`{"logs":{"type":"kubernetes_logs"}}`)},
+				Sinks:   &runtime.RawExtension{Raw: []byte(`{"out":{"inputs":["logs"],"type":"console"}}`)},
+			},
+			Status: v1alpha1.VectorPipelineStatus{
+				ConfigCheckResult: boolPtr(true),
+				Role:              rolePtr(v1alpha1.VectorPipelineRoleAgent),
+			},
+		}
+	}
+	older, younger := newVP("older", t0), newVP("younger", t0.Add(time.Hour))
+	policy := &v1alpha1.ClusterVectorPipelinePolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "tenants"},
+		Spec: v1alpha1.ClusterVectorPipelinePolicySpec{
+			Quota: &v1alpha1.PipelineQuota{MaxPipelines: ptr.To[int64](1)},
+		},
+	}
+	c := newFakeClient(older, younger, policy)
+
+	result, _, err := resolveWorkloadPipelines(ctx, c, nil, agentFilter(), "Vector", "vector", "agent", testAssetsPrototype())
+	require.NoError(t, err)
+	require.Len(t, result, 1)
+	require.Equal(t, "older", result[0].GetName())
+
+	got := &v1alpha1.VectorPipeline{}
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(younger), got))
+	require.False(t, got.IsValid())
+	require.Contains(t, *got.Status.Reason, quotaExceededReasonPrefix+"namespace team-a is over maxPipelines of ClusterVectorPipelinePolicy tenants on Vector vector/agent")
+	cond := meta.FindStatusCondition(got.Status.Conditions, v1alpha1.ConditionPublished)
+	require.NotNil(t, cond)
+	require.Equal(t, v1alpha1.ReasonQuotaExceeded, cond.Reason)
+
+	policy.Spec.Quota.MaxPipelines = ptr.To[int64](2)
+	require.NoError(t, c.Update(ctx, policy))
+
+	result, reinstate, err := resolveWorkloadPipelines(ctx, c, nil, agentFilter(), "Vector", "vector", "agent", testAssetsPrototype())
+	require.NoError(t, err)
+	require.Len(t, result, 2)
+	require.Len(t, reinstate, 1)
+	require.Equal(t, "younger", reinstate[0].GetName())
+}
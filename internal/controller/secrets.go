@@ -27,6 +27,7 @@ import (
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -238,6 +239,16 @@ func relatedSecretsHashEqual(a, b *int64) bool {
 	return *a == *b
 }
 
+// isSecretBuildError reports whether a Build*Config failure came from resolving a
+// pipeline's SECRET[] references - the Secret could not be read, or its value cannot be
+// substituted safely - rather than from the config itself. Such a failure is reported
+// on the SecretsResolved condition, not ConfigValid.
+func isSecretBuildError(err error) bool {
+	var resolveErr *config.SecretResolveError
+	var unsafeErr *config.SecretValueUnsafeError
+	return errors.As(err, &resolveErr) || errors.As(err, &unsafeErr)
+}
+
 // secretCollisionReasonPrefix marks a pipeline status Reason as coming from
 // resolveWorkloadPipelines' collision attribution, distinguishing it from any other
 // reason a pipeline can be invalid for. resolveWorkloadPipelines looks for this prefix
@@ -526,7 +537,7 @@ func markWaitingPipelines(ctx context.Context, c client.Client, waitingPipelines
 	}
 	reason := secretAssetsWaitingReason(workloadKind, workloadNamespace, workloadName)
 	for _, p := range waitingPipelines {
-		if err := writeAttributionReasonIfChanged(ctx, c, p, reason); err != nil {
+		if err := writeAttributionReasonIfChanged(ctx, c, p, v1alpha1.ReasonSecretAssetsWaiting, reason); err != nil {
 			return err
 		}
 	}
@@ -546,15 +557,21 @@ func markWaitingPipelines(ctx context.Context, c client.Client, waitingPipelines
 // candidate whose reason for failing changed shape kept displaying the OLD reason
 // indefinitely, since nothing about its own spec ever changes to trigger a fresh
 // per-pipeline reconcile that would overwrite it.
-func writeAttributionReasonIfChanged(ctx context.Context, c client.Client, p pipeline.Pipeline, reason string) error {
+//
+// conditionReason is the SecretsResolved condition reason for reason's class. The
+// condition is part of the "already stored" check, so a pipeline marked by a version
+// that predates conditions gets them on the next round instead of never.
+func writeAttributionReasonIfChanged(ctx context.Context, c client.Client, p pipeline.Pipeline, conditionReason, reason string) error {
 	if r := p.GetReason(); r != nil && *r == reason {
-		return nil
+		if cond := meta.FindStatusCondition(p.GetConditions(), v1alpha1.ConditionSecretsResolved); cond != nil && cond.Reason == conditionReason {
+			return nil
+		}
 	}
 	// The patch base is this pipeline as it stands before the mutations below, so the
 	// write carries exactly what this call changes.
 	base := p.DeepCopyObject().(pipeline.Pipeline)
 	p.SetRelatedSecretsHash(nil)
-	return pipeline.SetFailedStatus(ctx, c, p, reason, base)
+	return pipeline.SetSecretsFailedStatus(ctx, c, p, conditionReason, reason, base)
 }
 
 // intersectPipelinesByKey returns the subset of pipelines whose ObjectKey also
@@ -590,7 +607,8 @@ func intersectPipelinesByKey(pipelines []pipeline.Pipeline, include []pipeline.P
 // (config.DetectSecretSizeOverflow) - see either function's doc comment for its own
 // attribution policy. Size is checked only among collision survivors: a collision
 // victim never reaches Build*Config either, so it must not consume any of the size
-// budget.
+// budget. Namespace quotas (config.DetectQuotaExclusions) are attributed the same way,
+// ahead of both.
 //
 // It also reconsiders pipelines this SAME filter previously excluded: it lists through
 // pipeline.GetAllPipelines rather than GetValidPipelines, because by IsValid()==false
@@ -619,7 +637,8 @@ func intersectPipelinesByKey(pipelines []pipeline.Pipeline, include []pipeline.P
 // (clears a collision, now loses on size) can keep last round's reason text, since the
 // status of a still-excluded candidate is not rewritten. The exclusion itself is always
 // correct; only the displayed reason can lag a round. Marking a pipeline failed is also
-// global across workloads - see docs/secrets.md.
+// global across workloads - see docs/secrets.md. A pipeline the workload's configcheck
+// attribution left out (pipeline.ExcludeFromWorkload) is dropped for this workload only.
 func resolveWorkloadPipelines(
 	ctx context.Context,
 	c client.Client,
@@ -638,31 +657,55 @@ func resolveWorkloadPipelines(
 		return nil, nil, err
 	}
 
+	workload := v1alpha1.WorkloadReference{Kind: workloadKind, Namespace: workloadNamespace, Name: workloadName}
 	pool := make([]pipeline.Pipeline, 0, len(all))
 	retryCandidates := make(map[types.NamespacedName]struct{})
 	for _, p := range all {
+		if pipeline.ExcludedFrom(p, workload) {
+			// the workload's configcheck fails with it: left out of this workload alone
+			continue
+		}
 		if p.IsValid() {
 			pool = append(pool, p)
 			continue
 		}
-		if reason := p.GetReason(); reason != nil && isSecretAttributionReason(*reason) {
+		if reason := p.GetReason(); reason != nil && (isSecretAttributionReason(*reason) || isQuotaExceededReason(*reason)) {
 			pool = append(pool, p)
 			retryCandidates[client.ObjectKeyFromObject(p)] = struct{}{}
 		}
 	}
 
+	// Namespace quotas are attributed before the secret detectors: a pipeline its namespace
+	// has no room for never reaches Build*Config, so it must not claim a flat key or any of
+	// the size budget.
+	quotaExclusions, err := config.DetectQuotaExclusions(ctx, pipelinePolicies(c, ctx), pool...)
+	if err != nil {
+		return nil, nil, err
+	}
+	quotaVictims := make(map[types.NamespacedName]config.QuotaExclusion, len(quotaExclusions))
+	for _, ex := range quotaExclusions {
+		quotaVictims[client.ObjectKeyFromObject(ex.Victim)] = ex
+	}
+	admitted := make([]pipeline.Pipeline, 0, len(pool))
+	for _, p := range pool {
+		if _, isVictim := quotaVictims[client.ObjectKeyFromObject(p)]; !isVictim {
+			admitted = append(admitted, p)
+		}
+	}
+
 	// individuallyValid is the fallback for a structural detection failure (a
 	// pipeline-level spec/shape problem while scanning, before either detector could
-	// say anything about the pool at all): the individually-valid subset of pool,
-	// exactly what GetValidPipelines would have returned before attribution existed.
-	// Nothing is marked a victim and no retry candidate is reinstated on evidence
-	// this shaky; Build*Config hits (and reports) the same underlying problem on its
-	// own right after this returns. DetectSecretSizeOverflow's other error class - a
-	// per-pipeline data-read failure, see its doc comment - is handled separately
-	// and does NOT use this fallback, since it says nothing about the rest of pool.
+	// say anything about the pool at all): the individually-valid subset of the
+	// pipelines the quotas admit - what GetValidPipelines would have returned before
+	// secret attribution existed. Nothing is marked a victim and no retry candidate is
+	// reinstated on evidence this shaky; Build*Config hits (and reports) the same
+	// underlying problem on its own right after this returns. DetectSecretSizeOverflow's
+	// other error class - a per-pipeline data-read failure, see its doc comment - is
+	// handled separately and does NOT use this fallback, since it says nothing about the
+	// rest of pool.
 	individuallyValid := func() []pipeline.Pipeline {
-		validOnly := make([]pipeline.Pipeline, 0, len(pool))
-		for _, p := range pool {
+		validOnly := make([]pipeline.Pipeline, 0, len(admitted))
+		for _, p := range admitted {
 			if p.IsValid() {
 				validOnly = append(validOnly, p)
 			}
@@ -670,7 +713,7 @@ func resolveWorkloadPipelines(
 		return validOnly
 	}
 
-	collisions, err := config.DetectSecretCollisions(getter, pool...)
+	collisions, err := config.DetectSecretCollisions(getter, admitted...)
 	if err != nil {
 		return individuallyValid(), nil, nil
 	}
@@ -682,8 +725,8 @@ func resolveWorkloadPipelines(
 	// Size is checked only among collision survivors - a collision victim never
 	// reaches Build*Config either, so it must not consume any of the size budget (see
 	// this function's doc comment).
-	survivors := make([]pipeline.Pipeline, 0, len(pool))
-	for _, p := range pool {
+	survivors := make([]pipeline.Pipeline, 0, len(admitted))
+	for _, p := range admitted {
 		if _, isVictim := collisionVictims[client.ObjectKeyFromObject(p)]; !isVictim {
 			survivors = append(survivors, p)
 		}
@@ -717,9 +760,17 @@ func resolveWorkloadPipelines(
 		key := client.ObjectKeyFromObject(p)
 		_, wasRetryCandidate := retryCandidates[key]
 
+		if ex, isVictim := quotaVictims[key]; isVictim {
+			reason := quotaExclusionReason(ex, workloadKind, workloadNamespace, workloadName)
+			if err := writeQuotaReasonIfChanged(ctx, c, p, reason); err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+
 		if col, isVictim := collisionVictims[key]; isVictim {
 			reason := secretCollisionReason(col, workloadKind, workloadNamespace, workloadName)
-			if err := writeAttributionReasonIfChanged(ctx, c, p, reason); err != nil {
+			if err := writeAttributionReasonIfChanged(ctx, c, p, v1alpha1.ReasonSecretKeyCollision, reason); err != nil {
 				return nil, nil, err
 			}
 			continue
@@ -727,7 +778,7 @@ func resolveWorkloadPipelines(
 
 		if ex, isVictim := sizeVictims[key]; isVictim {
 			reason := secretSizeExclusionReason(ex, workloadKind, workloadNamespace, workloadName)
-			if err := writeAttributionReasonIfChanged(ctx, c, p, reason); err != nil {
+			if err := writeAttributionReasonIfChanged(ctx, c, p, v1alpha1.ReasonSecretAssetsTooLarge, reason); err != nil {
 				return nil, nil, err
 			}
 			continue
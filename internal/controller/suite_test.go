@@ -32,12 +32,14 @@ import (
 
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/envtest"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	"github.com/kaasops/vector-operator/api/v1alpha1"
+	"github.com/kaasops/vector-operator/internal/pipeline"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -89,9 +91,16 @@ var _ = BeforeSuite(func() {
 
 	// +kubebuilder:scaffold:scheme
 
-	k8sClient, err = client.New(cfg, client.Options{Scheme: scheme.Scheme})
+	apiClient, err := client.New(cfg, client.Options{Scheme: scheme.Scheme})
 	Expect(err).NotTo(HaveOccurred())
-	Expect(k8sClient).NotTo(BeNil())
+	informers, err := cache.New(cfg, cache.Options{Scheme: scheme.Scheme})
+	Expect(err).NotTo(HaveOccurred())
+	Expect(pipeline.IndexWorkloads(ctx, informers)).To(Succeed())
+	go func() {
+		defer GinkgoRecover()
+		Expect(informers.Start(ctx)).To(Succeed())
+	}()
+	k8sClient = &indexedClient{Client: apiClient, informers: informers}
 
 	configCheckTimeout = time.Second * 60
 
@@ -106,3 +115,21 @@ var _ = AfterSuite(func() {
 	err := testEnv.Stop()
 	Expect(err).NotTo(HaveOccurred())
 })
+
+// indexedClient lists the pipelines by pipeline.WorkloadsField from informers, as the
+// manager's cached client does: the API server knows no such field. Everything else
+// goes to the API server.
+type indexedClient struct {
+	client.Client
+	informers cache.Cache
+}
+
+func (c *indexedClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	lo := (&client.ListOptions{}).ApplyOptions(opts)
+	if lo.FieldSelector != nil {
+		if _, ok := lo.FieldSelector.RequiresExactMatch(pipeline.WorkloadsField); ok {
+			return c.informers.List(ctx, list, opts...)
+		}
+	}
+	return c.Client.List(ctx, list, opts...)
+}
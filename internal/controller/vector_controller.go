@@ -42,11 +42,13 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/events"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	"github.com/kaasops/vector-operator/api/v1alpha1"
 	"github.com/kaasops/vector-operator/internal/common"
@@ -58,20 +60,34 @@ type VectorReconciler struct {
 	Scheme *runtime.Scheme
 
 	// Temp. Wait this issue - https://github.com/kubernetes-sigs/controller-runtime/issues/452
-	Clientset                 *kubernetes.Clientset
-	ConfigCheckTimeout        time.Duration
+	Clientset          *kubernetes.Clientset
+	ConfigCheckTimeout time.Duration
+	// ConfigCheckSettings selects how configs are validated: in a configcheck pod
+	// (the zero value) or in the operator process.
+	ConfigCheckSettings       configcheck.Settings
 	DiscoveryClient           *discovery.DiscoveryClient
 	EventChan                 chan event.GenericEvent
 	EnableConfigOptimization  bool
+	EnableMetadataEnrichment  bool
 	EnableCheckpointMigration bool
 	CheckpointMergerImage     string
 
+	// ConfigCheckAttributionMaxChecks bounds the extra configchecks run to find the
+	// pipelines a failed configcheck is down to, and leave them out; 0 fails the whole
+	// workload instead.
+	ConfigCheckAttributionMaxChecks int
+	// attributions run the attributions of the reconciler's workloads off the reconcile.
+	attributions configCheckAttributions
+
 	// APIReader is an uncached read-only client (mgr.GetAPIReader()), used to resolve
 	// pipeline secrets: reads go through it for freshness and independence from cache
 	// scoping (namespace/label filters), not to keep secret payloads out of the
 	// controller-runtime cache - Owns(&corev1.Secret{}) below already puts them there in
 	// default mode.
 	APIReader client.Reader
+	// Recorder emits the Kubernetes Events of status transitions, see
+	// k8s.WithEventRecorder. Nil emits none.
+	Recorder events.EventRecorder
 }
 
 // optimizeSources reports whether the agent config of the given Vector should be
@@ -93,11 +109,13 @@ func optimizeSources(enabled bool, v *v1alpha1.Vector) bool {
 // +kubebuilder:rbac:groups="",resources=namespaces,verbs=list;watch
 // +kubebuilder:rbac:groups="",resources=nodes,verbs=list;watch
 // +kubebuilder:rbac:groups="",resources=events,verbs=list;watch
+// +kubebuilder:rbac:groups=events.k8s.io,resources=events,verbs=create;patch
 // +kubebuilder:rbac:groups=apps,resources=daemonsets,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="rbac.authorization.k8s.io",resources=clusterrolebindings,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="rbac.authorization.k8s.io",resources=clusterroles,verbs=get;list;watch;create;update;patch;delete
 
 func (r *VectorReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	ctx = k8s.WithEventRecorder(ctx, r.Recorder)
 	log := log.FromContext(ctx).WithValues("Vector", req.NamespacedName)
 	log.Info("Start Reconcile Vector")
 	if req.Namespace == "" {
@@ -116,11 +134,24 @@ func (r *VectorReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 	}
 	if vectorCR == nil {
 		log.Info("Vector CR not found. Ignoring since object must be deleted")
-		return ctrl.Result{}, nil
+		workload := v1alpha1.WorkloadReference{Kind: "Vector", Namespace: req.Namespace, Name: req.Name}
+		forgetWorkload(workload)
+		// drop it from the pipelines that still list it as a workload
+		return ctrl.Result{}, pipeline.SyncWorkload(ctx, r.Client, workload, nil)
 	}
 	return r.createOrUpdateVector(ctx, r.Client, r.Clientset, vectorCR)
 }
 
+// wake returns the func the configcheck queue calls to reconcile v again once it keeps
+// a slot for it. Without an EventChan it is nil, and the checks of v wait for a slot.
+func (r *VectorReconciler) wake(v *v1alpha1.Vector) func() {
+	if r.EventChan == nil {
+		return nil
+	}
+	obj := v.DeepCopy()
+	return func() { r.EventChan <- event.GenericEvent{Object: obj} }
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *VectorReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	monitoringCRD, err := k8s.ResourceExists(r.DiscoveryClient, monitorv1.SchemeGroupVersion.String(), monitorv1.PodMonitorsKind)
@@ -130,6 +161,8 @@ func (r *VectorReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	builder := ctrl.NewControllerManagedBy(mgr).
 		For(&v1alpha1.Vector{}, builder.WithPredicates(predicate.Or(predicate.GenerationChangedPredicate{}, predicate.AnnotationChangedPredicate{}))).
 		WatchesRawSource(source.Channel(r.EventChan, &handler.EnqueueRequestForObject{})).
+		Watches(&corev1.Namespace{}, handler.EnqueueRequestsFromMapFunc(r.mapNamespaceToVectors), builder.WithPredicates(predicate.LabelChangedPredicate{})).
+		Watches(&v1alpha1.ClusterVectorPipelinePolicy{}, handler.EnqueueRequestsFromMapFunc(r.mapPolicyToVectors), builder.WithPredicates(predicate.GenerationChangedPredicate{})).
 		Owns(&appsv1.DaemonSet{}).
 		Owns(&corev1.Service{}).
 		Owns(&corev1.Secret{}).
@@ -147,6 +180,40 @@ func (r *VectorReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return nil
 }
 
+// mapNamespaceToVectors requeues the Vectors with a namespaceSelector when a namespace's
+// labels change: which VectorPipelines they select can change without any pipeline or
+// Vector being touched.
+func (r *VectorReconciler) mapNamespaceToVectors(ctx context.Context, _ client.Object) []reconcile.Request {
+	vectors, err := listVectorAgents(ctx, r.Client)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list vector instances")
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, v := range vectors {
+		if v.Spec.Selector != nil && v.Spec.Selector.NamespaceSelector != nil {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(v)})
+		}
+	}
+	return requests
+}
+
+// mapPolicyToVectors requeues every Vector when a ClusterVectorPipelinePolicy changes:
+// a raised quota has room for pipelines resolveWorkloadPipelines excluded, and nothing
+// else would wake it to reconsider them.
+func (r *VectorReconciler) mapPolicyToVectors(ctx context.Context, _ client.Object) []reconcile.Request {
+	vectors, err := listVectorAgents(ctx, r.Client)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list vector instances")
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(vectors))
+	for _, v := range vectors {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(v)})
+	}
+	return requests
+}
+
 func listVectorAgents(ctx context.Context, client client.Client) (vectors []*v1alpha1.Vector, err error) {
 	vectorList := v1alpha1.VectorList{}
 	err = client.List(ctx, &vectorList)
@@ -238,6 +305,14 @@ func (r *VectorReconciler) createOrUpdateVector(ctx context.Context, client clie
 		Selector: vaCtrl.Vector.Spec.Selector,
 		Role:     v1alpha1.VectorPipelineRoleAgent,
 	}, "Vector", v.Namespace, v.Name, vaCtrl.SecretAssetsPrototype())
+	if errors.Is(err, pipeline.ErrInvalidSelector) {
+		// only editing the selector fixes it, and that edit triggers a reconcile
+		if err := vaCtrl.SetFailedStatus(ctx, err.Error()); err != nil {
+			return ctrl.Result{}, err
+		}
+		log.Error(err, "Invalid selector")
+		return ctrl.Result{}, nil
+	}
 	if err != nil {
 		return ctrl.Result{}, err
 	}
@@ -275,11 +350,17 @@ func (r *VectorReconciler) createOrUpdateVector(ctx context.Context, client clie
 		InternalMetrics:      vaCtrl.Vector.Spec.Agent.InternalMetrics,
 		ExpireMetricsSecs:    vaCtrl.Vector.Spec.Agent.ExpireMetricsSecs,
 		OptimizeSources:      optimize,
+		EnrichMetadata:       r.EnableMetadataEnrichment,
 		PipelineSecretGetter: secretGetter,
+		PipelinePolicies:     pipelinePolicies(r.Client, ctx),
 	}
 	cfg, byteConfig, err := config.BuildAgentConfig(params, bridgePipelines...)
 	if err != nil {
-		if err := vaCtrl.SetFailedStatus(ctx, err.Error()); err != nil {
+		setFailedStatus := vaCtrl.SetFailedStatus
+		if isSecretBuildError(err) {
+			setFailedStatus = vaCtrl.SetSecretsFailedStatus
+		}
+		if err := setFailedStatus(ctx, err.Error()); err != nil {
 			return ctrl.Result{}, err
 		}
 		log.Error(err, "Build config failed")
@@ -306,32 +387,33 @@ func (r *VectorReconciler) createOrUpdateVector(ctx context.Context, client clie
 
 	if !vaCtrl.Vector.Spec.Agent.ConfigCheck.Disabled {
 		if vaCtrl.Vector.Status.LastAppliedConfigHash == nil || *vaCtrl.Vector.Status.LastAppliedConfigHash != cfgHash {
-			configCheck := configcheck.New(
-				byteConfig,
-				vaCtrl.Client,
-				vaCtrl.ClientSet,
-				&vaCtrl.Vector.Spec.Agent.VectorCommon,
-				vaCtrl.Vector.Name,
-				vaCtrl.Vector.Namespace,
-				r.ConfigCheckTimeout,
-				configcheck.ConfigCheckInitiatorVector,
-				cfg.SecretAssets(),
-			)
-			reason, err := configCheck.Run(ctx)
-			if err != nil {
-				if errors.Is(err, configcheck.ErrValidation) {
-					if err := vaCtrl.SetFailedStatus(ctx, reason); err != nil {
-						return ctrl.Result{}, err
+			check := &workloadConfigCheck{
+				settings:     r.ConfigCheckSettings,
+				workload:     v1alpha1.WorkloadReference{Kind: "Vector", Namespace: v.Namespace, Name: v.Name},
+				owner:        vaCtrl.Vector,
+				reader:       r.APIReader,
+				client:       vaCtrl.Client,
+				clientset:    vaCtrl.ClientSet,
+				common:       &vaCtrl.Vector.Spec.Agent.VectorCommon,
+				name:         vaCtrl.Vector.Name,
+				namespace:    vaCtrl.Vector.Namespace,
+				timeout:      r.ConfigCheckTimeout,
+				wake:         r.wake(v),
+				attributions: &r.attributions,
+				maxChecks:    r.ConfigCheckAttributionMaxChecks,
+				params:       params,
+				pipelines:    bridgePipelines,
+				build: func(pipelines []pipeline.Pipeline) ([]byte, map[string][]byte, error) {
+					cfg, byteConfig, err := config.BuildAgentConfig(params, pipelines...)
+					if err != nil {
+						return nil, nil, err
 					}
-					log.Error(err, "Invalid config")
-					return ctrl.Result{}, nil
-				}
-				if errors.Is(err, configcheck.ErrConfigcheckSkipped) {
-					// namespace is terminating; the Vector CR is on its way out, nothing to do
-					log.Info("ConfigCheck skipped, namespace is terminating")
-					return ctrl.Result{}, nil
-				}
-				return ctrl.Result{}, err
+					return byteConfig, cfg.SecretAssets(), nil
+				},
+				setFailed: vaCtrl.SetConfigCheckFailedStatus,
+			}
+			if passed, result, err := check.run(ctx, byteConfig, cfg.SecretAssets()); !passed {
+				return result, err
 			}
 		}
 	}
@@ -444,6 +526,11 @@ func (r *VectorReconciler) createOrUpdateVector(ctx context.Context, client clie
 	if err := vaCtrl.EnsureVectorAgent(ctx, !allConfigsUnchanged); err != nil {
 		return ctrl.Result{}, err
 	}
+	publishedAssets := []map[string][]byte{vaCtrl.SecretAssets}
+	if vaCtrl.CheckpointMigration {
+		publishedAssets = append(publishedAssets, vaCtrl.AltSecretAssets)
+	}
+	recordWorkloadConfig(ctx, v1alpha1.WorkloadReference{Kind: "Vector", Namespace: v.Namespace, Name: v.Name}, vaCtrl.Config, vaCtrl.ByteConfig, vaCtrl.Vector.Spec.Agent.CompressConfigFile, publishedAssets...)
 
 	// Only now - after EnsureVectorAgent has actually published the config and
 	// assets these candidates' references depend on - is it safe to mark them valid
@@ -463,6 +550,13 @@ func (r *VectorReconciler) createOrUpdateVector(ctx context.Context, client clie
 		return ctrl.Result{}, err
 	}
 
+	// The published config now holds exactly bridgePipelines, so this is the point
+	// at which each pipeline's .status.workloads can say so - and drop this Vector
+	// from pipelines that were held back, excluded or no longer selected.
+	if err := pipeline.SyncWorkload(ctx, vaCtrl.Client, v1alpha1.WorkloadReference{Kind: "Vector", Namespace: v.Namespace, Name: v.Name}, bridgePipelines); err != nil {
+		return ctrl.Result{}, err
+	}
+
 	// requeueAfter is non-zero exactly when this round held off pruning solely to
 	// wait out SecretAssetsPruneGracePeriod - nothing else changed that would trigger
 	// a fresh reconcile on its own (no Secret write, no pipeline status change), so
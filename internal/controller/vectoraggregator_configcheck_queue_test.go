@@ -0,0 +1,101 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/kaasops/vector-operator/api/v1alpha1"
+	"github.com/kaasops/vector-operator/internal/config/configcheck"
+)
+
+// slowVector writes a stand-in for the vector binary of local configchecks that
+// records each run in runs and outlasts any check's timeout.
+func slowVector(t *testing.T, runs string) string {
+	t.Helper()
+	vector := filepath.Join(t.TempDir(), "vector")
+	script := "#!/bin/sh\necho run >> " + runs + "\nexec sleep 5\n"
+	require.NoError(t, os.WriteFile(vector, []byte(script), 0o755))
+	return vector
+}
+
+// With the queue full, a reconcile of a workload does not hold the (serial) worker
+// waiting for a slot: it queues its check and ends. The reconciles after it coalesce
+// into that place, and once a slot comes up the workload is woken up and checks the
+// config it builds then, once.
+func TestVectorAggregatorReconcileConfigCheckQueued(t *testing.T) {
+	ctx := context.Background()
+	runs := filepath.Join(t.TempDir(), "runs")
+	// the timeout ends the reconcile before it publishes
+	vector := slowVector(t, runs)
+
+	agg := &v1alpha1.VectorAggregator{
+		ObjectMeta: metav1.ObjectMeta{Name: "agg", Namespace: "vector", Finalizers: []string{aggregatorFinalizerName}},
+	}
+	cl := newFakeClient(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "vector"}}, agg)
+
+	queue := configcheck.NewQueue(1, time.Minute)
+	release, err := queue.Acquire(ctx)
+	require.NoError(t, err)
+	events := make(chan event.GenericEvent, 1)
+	r := &VectorAggregatorReconciler{
+		Client:             cl,
+		APIReader:          cl,
+		EventChan:          events,
+		ConfigCheckTimeout: 100 * time.Millisecond,
+		ConfigCheckSettings: configcheck.Settings{
+			Mode:         configcheck.ModeLocal,
+			VectorBinary: vector,
+			Queue:        queue,
+		},
+	}
+	req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(agg)}
+
+	for range 2 {
+		result, err := r.Reconcile(ctx, req)
+		require.NoError(t, err)
+		require.Zero(t, result)
+	}
+	require.NoFileExists(t, runs, "a queued check does not run")
+	require.Empty(t, events)
+
+	release()
+	select {
+	case e := <-events:
+		require.Equal(t, req.NamespacedName, client.ObjectKeyFromObject(e.Object))
+	case <-time.After(time.Second):
+		t.Fatal("the aggregator was not woken up")
+	}
+	_, err = r.Reconcile(ctx, req)
+	require.ErrorIs(t, err, configcheck.ErrConfigcheckTimeout)
+	out, err := os.ReadFile(runs)
+	require.NoError(t, err)
+	require.Equal(t, 1, strings.Count(string(out), "run"), "the woken reconcile checks in the slot kept for it")
+	require.Empty(t, events)
+}
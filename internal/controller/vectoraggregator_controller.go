@@ -28,11 +28,13 @@ import (
 	rbacv1 "k8s.io/api/rbac/v1"
 	api_errors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/events"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	"github.com/kaasops/vector-operator/internal/config"
@@ -59,7 +61,20 @@ type VectorAggregatorReconciler struct {
 
 	Clientset          *kubernetes.Clientset
 	ConfigCheckTimeout time.Duration
-	EventChan          chan event.GenericEvent
+	// ConfigCheckSettings selects how configs are validated: in a configcheck pod
+	// (the zero value) or in the operator process.
+	ConfigCheckSettings configcheck.Settings
+	EventChan           chan event.GenericEvent
+	// EnableMetadataEnrichment is the --enable-metadata-enrichment default for the
+	// pipelines of the aggregator.
+	EnableMetadataEnrichment bool
+
+	// ConfigCheckAttributionMaxChecks bounds the extra configchecks run to find the
+	// pipelines a failed configcheck is down to, and leave them out; 0 fails the whole
+	// workload instead.
+	ConfigCheckAttributionMaxChecks int
+	// attributions run the attributions of the reconciler's workloads off the reconcile.
+	attributions configCheckAttributions
 
 	// APIReader is an uncached read-only client (mgr.GetAPIReader()), used to resolve
 	// pipeline secrets: reads go through it for freshness and independence from cache
@@ -67,6 +82,9 @@ type VectorAggregatorReconciler struct {
 	// controller-runtime cache - Owns(&corev1.Secret{}) below already puts them there in
 	// default mode.
 	APIReader client.Reader
+	// Recorder emits the Kubernetes Events of status transitions, see
+	// k8s.WithEventRecorder. Nil emits none.
+	Recorder events.EventRecorder
 }
 
 // +kubebuilder:rbac:groups=observability.kaasops.io,resources=vectoraggregators,verbs=get;list;watch;create;update;patch;delete
@@ -87,6 +105,9 @@ type VectorAggregatorReconciler struct {
 // +kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="rbac.authorization.k8s.io",resources=clusterrolebindings,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="rbac.authorization.k8s.io",resources=clusterroles,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="rbac.authorization.k8s.io",resources=rolebindings,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="rbac.authorization.k8s.io",resources=roles,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=coordination.k8s.io,resources=leases,verbs=get;create;update
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -98,6 +119,7 @@ type VectorAggregatorReconciler struct {
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.19.0/pkg/reconcile
 func (r *VectorAggregatorReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	ctx = k8s.WithEventRecorder(ctx, r.Recorder)
 	log := log.FromContext(ctx)
 	log.Info("Start Reconcile VectorAggregator")
 
@@ -130,6 +152,11 @@ func (r *VectorAggregatorReconciler) Reconcile(ctx context.Context, req ctrl.Req
 					return ctrl.Result{}, err
 				}
 			}
+			workload := v1alpha1.WorkloadReference{Kind: "VectorAggregator", Namespace: vectorCR.Namespace, Name: vectorCR.Name}
+			forgetWorkload(workload)
+			if err := pipeline.SyncWorkload(ctx, r.Client, workload, nil); err != nil {
+				return ctrl.Result{}, err
+			}
 			controllerutil.RemoveFinalizer(vectorCR, aggregatorFinalizerName)
 			if err := r.Update(ctx, vectorCR); err != nil {
 				return ctrl.Result{}, err
@@ -149,6 +176,16 @@ func (r *VectorAggregatorReconciler) Reconcile(ctx context.Context, req ctrl.Req
 	return r.createOrUpdateVectorAggregator(ctx, r.Client, r.Clientset, vectorCR)
 }
 
+// wake returns the func the configcheck queue calls to reconcile v again once it keeps
+// a slot for it. Without an EventChan it is nil, and the checks of v wait for a slot.
+func (r *VectorAggregatorReconciler) wake(v *v1alpha1.VectorAggregator) func() {
+	if r.EventChan == nil {
+		return nil
+	}
+	obj := v.DeepCopy()
+	return func() { r.EventChan <- event.GenericEvent{Object: obj} }
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *VectorAggregatorReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	monitoringCRD, err := k8s.ResourceExists(r.Clientset.DiscoveryClient, monitorv1.SchemeGroupVersion.String(), monitorv1.PodMonitorsKind)
@@ -159,6 +196,8 @@ func (r *VectorAggregatorReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	builder := ctrl.NewControllerManagedBy(mgr).
 		For(&v1alpha1.VectorAggregator{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
 		WatchesRawSource(source.Channel(r.EventChan, &handler.EnqueueRequestForObject{})).
+		Watches(&corev1.Namespace{}, handler.EnqueueRequestsFromMapFunc(r.mapNamespaceToVectorAggregators), builder.WithPredicates(predicate.LabelChangedPredicate{})).
+		Watches(&v1alpha1.ClusterVectorPipelinePolicy{}, handler.EnqueueRequestsFromMapFunc(r.mapPolicyToVectorAggregators), builder.WithPredicates(predicate.GenerationChangedPredicate{})).
 		Owns(&appsv1.Deployment{}).
 		Owns(&appsv1.StatefulSet{}).
 		Owns(&corev1.Service{}).
@@ -167,6 +206,8 @@ func (r *VectorAggregatorReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		Owns(&corev1.ServiceAccount{}).
 		Owns(&rbacv1.ClusterRole{}).
 		Owns(&rbacv1.ClusterRoleBinding{}).
+		Owns(&rbacv1.Role{}).
+		Owns(&rbacv1.RoleBinding{}).
 		Owns(&autoscalingv2.HorizontalPodAutoscaler{})
 
 	if monitoringCRD {
@@ -179,6 +220,38 @@ func (r *VectorAggregatorReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return nil
 }
 
+// mapNamespaceToVectorAggregators requeues the aggregators in a namespace whose labels
+// changed if they have a namespaceSelector, which is evaluated against them.
+func (r *VectorAggregatorReconciler) mapNamespaceToVectorAggregators(ctx context.Context, obj client.Object) []reconcile.Request {
+	list := v1alpha1.VectorAggregatorList{}
+	if err := r.List(ctx, &list, client.InNamespace(obj.GetName())); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list vector aggregators instances")
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, v := range list.Items {
+		if v.Spec.Selector != nil && v.Spec.Selector.NamespaceSelector != nil {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&v)})
+		}
+	}
+	return requests
+}
+
+// mapPolicyToVectorAggregators requeues every aggregator when a
+// ClusterVectorPipelinePolicy changes, for the reason given on mapPolicyToVectors.
+func (r *VectorAggregatorReconciler) mapPolicyToVectorAggregators(ctx context.Context, _ client.Object) []reconcile.Request {
+	list := v1alpha1.VectorAggregatorList{}
+	if err := r.List(ctx, &list); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list vector aggregators instances")
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(list.Items))
+	for i := range list.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&list.Items[i])})
+	}
+	return requests
+}
+
 func listVectorAggregators(ctx context.Context, client client.Client) (vectors []*v1alpha1.VectorAggregator, err error) {
 	vectorList := v1alpha1.VectorAggregatorList{}
 	err = client.List(ctx, &vectorList)
@@ -217,11 +290,20 @@ func (r *VectorAggregatorReconciler) createOrUpdateVectorAggregator(ctx context.
 	// down, only once the build (and configcheck, if enabled) actually succeed - see
 	// reinstatePipelines' doc comment.
 	pipelines, reinstateCandidates, err := resolveWorkloadPipelines(ctx, vaCtrl.Client, secretGetter, pipeline.FilterPipelines{
-		Scope:     pipeline.NamespacedPipeline,
-		Selector:  v.Spec.Selector,
-		Role:      v1alpha1.VectorPipelineRoleAggregator,
-		Namespace: vaCtrl.Namespace,
+		Scope:      pipeline.NamespacedPipeline,
+		Selector:   v.Spec.Selector,
+		Role:       v1alpha1.VectorPipelineRoleAggregator,
+		Namespace:  vaCtrl.Namespace,
+		Aggregator: &v1alpha1.AggregatorReference{Kind: "VectorAggregator", Name: v.Name},
 	}, "VectorAggregator", v.Namespace, v.Name, vaCtrl.SecretAssetsPrototype())
+	if errors.Is(err, pipeline.ErrInvalidSelector) {
+		// only editing the selector fixes it, and that edit triggers a reconcile
+		if err := vaCtrl.SetFailedStatus(ctx, err.Error()); err != nil {
+			return ctrl.Result{}, err
+		}
+		log.Error(err, "Invalid selector")
+		return ctrl.Result{}, nil
+	}
 	if err != nil {
 		return ctrl.Result{}, err
 	}
@@ -244,16 +326,24 @@ func (r *VectorAggregatorReconciler) createOrUpdateVectorAggregator(ctx context.
 	}
 	reinstateCandidates = intersectPipelinesByKey(reinstateCandidates, bridgePipelines)
 
-	cfg, err := config.BuildAggregatorConfig(config.VectorConfigParams{
+	params := config.VectorConfigParams{
 		AggregatorName:       vaCtrl.Name,
 		ApiEnabled:           vaCtrl.Spec.Api.Enabled,
 		PlaygroundEnabled:    vaCtrl.Spec.Api.Playground,
 		InternalMetrics:      vaCtrl.Spec.InternalMetrics,
 		ExpireMetricsSecs:    vaCtrl.Spec.ExpireMetricsSecs,
+		EnrichMetadata:       r.EnableMetadataEnrichment,
 		PipelineSecretGetter: secretGetter,
-	}, bridgePipelines...)
+		PipelinePolicies:     pipelinePolicies(r.Client, ctx),
+		KubernetesEventsTLS:  vaCtrl.Spec.EventCollector.TLS,
+	}
+	cfg, err := config.BuildAggregatorConfig(params, bridgePipelines...)
 	if err != nil {
-		if err := vaCtrl.SetFailedStatus(ctx, err.Error()); err != nil {
+		setFailedStatus := vaCtrl.SetFailedStatus
+		if isSecretBuildError(err) {
+			setFailedStatus = vaCtrl.SetSecretsFailedStatus
+		}
+		if err := setFailedStatus(ctx, err.Error()); err != nil {
 			return ctrl.Result{}, err
 		}
 		log.Error(err, "Build config failed")
@@ -277,31 +367,37 @@ func (r *VectorAggregatorReconciler) createOrUpdateVectorAggregator(ctx context.
 
 	if !vaCtrl.Spec.ConfigCheck.Disabled {
 		if vaCtrl.Status.LastAppliedConfigHash == nil || *vaCtrl.Status.LastAppliedConfigHash != cfgHash {
-			reason, err := configcheck.New(
-				byteCfg,
-				vaCtrl.Client,
-				vaCtrl.ClientSet,
-				&vaCtrl.Spec.VectorCommon,
-				vaCtrl.Name,
-				vaCtrl.Namespace,
-				r.ConfigCheckTimeout,
-				configcheck.ConfigCheckInitiatorVector,
-				cfg.SecretAssets(),
-			).Run(ctx)
-			if err != nil {
-				if errors.Is(err, configcheck.ErrValidation) {
-					if err := vaCtrl.SetFailedStatus(ctx, reason); err != nil {
-						return ctrl.Result{}, err
+			check := &workloadConfigCheck{
+				settings:     r.ConfigCheckSettings,
+				workload:     v1alpha1.WorkloadReference{Kind: "VectorAggregator", Namespace: v.Namespace, Name: v.Name},
+				owner:        vaCtrl.VectorAggregator,
+				reader:       r.APIReader,
+				client:       vaCtrl.Client,
+				clientset:    vaCtrl.ClientSet,
+				common:       vaCtrl.ConfigCheckCommon(),
+				name:         vaCtrl.Name,
+				namespace:    vaCtrl.Namespace,
+				timeout:      r.ConfigCheckTimeout,
+				wake:         r.wake(v),
+				attributions: &r.attributions,
+				maxChecks:    r.ConfigCheckAttributionMaxChecks,
+				params:       params,
+				pipelines:    bridgePipelines,
+				build: func(pipelines []pipeline.Pipeline) ([]byte, map[string][]byte, error) {
+					cfg, err := config.BuildAggregatorConfig(params, pipelines...)
+					if err != nil {
+						return nil, nil, err
 					}
-					log.Error(err, "Invalid config")
-					return ctrl.Result{}, nil
-				}
-				if errors.Is(err, configcheck.ErrConfigcheckSkipped) {
-					// namespace is terminating; the aggregator is on its way out, nothing to do
-					log.Info("ConfigCheck skipped, namespace is terminating")
-					return ctrl.Result{}, nil
-				}
-				return ctrl.Result{}, err
+					byteCfg, err := cfg.MarshalJSON()
+					if err != nil {
+						return nil, nil, err
+					}
+					return byteCfg, cfg.SecretAssets(), nil
+				},
+				setFailed: vaCtrl.SetConfigCheckFailedStatus,
+			}
+			if passed, result, err := check.run(ctx, byteCfg, cfg.SecretAssets()); !passed {
+				return result, err
 			}
 		}
 	}
@@ -330,6 +426,7 @@ func (r *VectorAggregatorReconciler) createOrUpdateVectorAggregator(ctx context.
 	if err := vaCtrl.EnsureVectorAggregator(ctx, !configUnchanged); err != nil {
 		return ctrl.Result{}, err
 	}
+	recordWorkloadConfig(ctx, v1alpha1.WorkloadReference{Kind: "VectorAggregator", Namespace: v.Namespace, Name: v.Name}, vaCtrl.Config, vaCtrl.ConfigBytes, vaCtrl.Spec.CompressConfigFile, vaCtrl.SecretAssets)
 
 	// Only now - after EnsureVectorAggregator has actually published the config and
 	// assets these candidates' references depend on - is it safe to mark them valid
@@ -342,6 +439,11 @@ func (r *VectorAggregatorReconciler) createOrUpdateVectorAggregator(ctx context.
 		return ctrl.Result{}, err
 	}
 
+	// See vector_controller.go's identical call for why this runs last.
+	if err := pipeline.SyncWorkload(ctx, vaCtrl.Client, v1alpha1.WorkloadReference{Kind: "VectorAggregator", Namespace: v.Namespace, Name: v.Name}, bridgePipelines); err != nil {
+		return ctrl.Result{}, err
+	}
+
 	// See vector_controller.go's identical return for why a pending grace period must
 	// be requeued explicitly.
 	return ctrl.Result{RequeueAfter: requeueAfter}, nil
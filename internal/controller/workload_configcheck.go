@@ -0,0 +1,159 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/kaasops/vector-operator/api/v1alpha1"
+	"github.com/kaasops/vector-operator/internal/config"
+	"github.com/kaasops/vector-operator/internal/config/configcheck"
+	"github.com/kaasops/vector-operator/internal/pipeline"
+	"github.com/kaasops/vector-operator/internal/utils/hash"
+	"github.com/kaasops/vector-operator/internal/utils/k8s"
+)
+
+// workloadConfigCheck runs the configcheck of the config a workload is about to publish.
+// A config that fails is attributed to the pipelines it is down to, which are left out,
+// and the workload fails only when there are none. The three workload reconcilers
+// differ in the fields alone.
+type workloadConfigCheck struct {
+	// settings are the reconciler's ConfigCheckSettings.
+	settings configcheck.Settings
+	workload v1alpha1.WorkloadReference
+	// owner is the workload's object: the report is read for it and the timeout event
+	// goes to it.
+	owner     client.Object
+	reader    client.Reader
+	client    client.Client
+	clientset kubernetes.Interface
+	common    *v1alpha1.VectorCommon
+	// name and namespace are those the configcheck runs as and in.
+	name      string
+	namespace string
+	timeout   time.Duration
+	wake      func()
+	// attributions are the reconciler's, see configCheckAttributions.
+	attributions *configCheckAttributions
+	maxChecks    int
+	params       config.VectorConfigParams
+	pipelines    []pipeline.Pipeline
+	// build builds the workload's config from pipelines alone, for the attribution.
+	build     func(pipelines []pipeline.Pipeline) (byteConfig []byte, secretAssets map[string][]byte, err error)
+	setFailed func(ctx context.Context, reason string, report *v1alpha1.ConfigCheckReport) error
+}
+
+// run checks byteConfig, reporting whether the reconcile goes on to publish it. When it
+// does not, the reconcile returns result and err.
+func (w *workloadConfigCheck) run(ctx context.Context, byteConfig []byte, secretAssets map[string][]byte) (passed bool, result ctrl.Result, err error) {
+	log := log.FromContext(ctx)
+
+	// only the config of the workload itself coalesces in the queue: the attribution
+	// checks below leave QueueKey empty and wait for their slot, off the reconcile
+	checks := w.settings
+	checks.Workload = w.workload
+	attributionChecks := checks
+	checks.QueueKey = workloadName(w.workload)
+	checks.QueueWake = w.wake
+	reason, err := w.validator(checks, byteConfig, secretAssets).Run(ctx)
+	if err == nil {
+		return true, ctrl.Result{}, nil
+	}
+	if errors.Is(err, configcheck.ErrValidation) {
+		if w.maxChecks > 0 {
+			// a pipeline can pass its own check and fail this one, next to the others:
+			// find it, leave it out and publish the rest
+			exclude := func(ctx context.Context) bool {
+				check := func(ctx context.Context, pipelines []pipeline.Pipeline) (string, error) {
+					byteConfig, secretAssets, err := w.build(pipelines)
+					if err != nil {
+						return "", err
+					}
+					return w.validator(attributionChecks, byteConfig, secretAssets).Run(ctx)
+				}
+				excluded, err := excludeConfigCheckCulprits(ctx, w.client, w.maxChecks, w.params, w.pipelines, reason, check, w.workload)
+				if err != nil {
+					log.Error(err, "Failed to leave the configcheck culprits out")
+				}
+				return excluded
+			}
+			done, excluded := w.attributions.attribute(ctx, checks.QueueKey, hash.Get(byteConfig), exclude, w.wake)
+			switch {
+			case !done && w.wake == nil:
+				return false, ctrl.Result{RequeueAfter: configCheckAttributionRequeueDelay}, nil
+			case !done:
+				// the attribution wakes the workload up once it is done
+				log.Info("ConfigCheck failed, attributing the failure to pipelines")
+				return false, ctrl.Result{}, nil
+			case excluded:
+				return false, ctrl.Result{RequeueAfter: configCheckAttributionRequeueDelay}, nil
+			}
+		}
+		report, summary := configCheckReport(ctx, w.reader, w.client, w.owner, w.workload.Kind, w.namespace, reason, componentPipelineName(w.params, w.pipelines))
+		if err := w.setFailed(ctx, summary, report); err != nil {
+			return false, ctrl.Result{}, err
+		}
+		log.Error(err, "Invalid config")
+		return false, ctrl.Result{}, nil
+	}
+	if errors.Is(err, configcheck.ErrConfigcheckSkipped) {
+		// namespace is terminating; the workload is on its way out, nothing to do
+		log.Info("ConfigCheck skipped, namespace is terminating")
+		return false, ctrl.Result{}, nil
+	}
+	if errors.Is(err, configcheck.ErrConfigcheckQueued) {
+		// the queue wakes the workload up once a slot is free, to check its latest config
+		log.Info("ConfigCheck queued until a slot is free")
+		return false, ctrl.Result{}, nil
+	}
+	if errors.Is(err, configcheck.ErrConfigcheckTimeout) {
+		k8s.Eventf(ctx, w.owner, corev1.EventTypeWarning, v1alpha1.ReasonConfigCheckTimeout, k8s.EventActionCheckConfig, "ConfigCheck did not finish within %s", w.timeout)
+	}
+	return false, ctrl.Result{}, err
+}
+
+func (w *workloadConfigCheck) validator(checks configcheck.Settings, byteConfig []byte, secretAssets map[string][]byte) configcheck.Validator {
+	return checks.New(
+		byteConfig,
+		w.client,
+		w.clientset,
+		w.common,
+		w.name,
+		w.namespace,
+		w.timeout,
+		configcheck.ConfigCheckInitiatorVector,
+		secretAssets,
+	)
+}
+
+// workloadName is how logs, reasons and the configcheck queue name workload:
+// "<kind> <namespace>/<name>", or "<kind> <name>" for a cluster-scoped one.
+func workloadName(workload v1alpha1.WorkloadReference) string {
+	if workload.Namespace == "" {
+		return workload.Kind + " " + workload.Name
+	}
+	return fmt.Sprintf("%s %s/%s", workload.Kind, workload.Namespace, workload.Name)
+}
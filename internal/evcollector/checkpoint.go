@@ -0,0 +1,92 @@
+package evcollector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+// Checkpoints stores, per receiver, the timestamp of the newest event delivered to it,
+// for a restarted collector to resume from.
+//
+// A timestamp rather than a resourceVersion: a watch can only resume from a
+// resourceVersion the API server still has, and after a longer outage - the case this is
+// for - it answers 410 Gone and the informer lists everything anyway. The list holds
+// every event not yet expired, so skipping those older than the checkpoint resumes just
+// as well and survives any outage.
+type Checkpoints interface {
+	// Load returns the checkpoint of receiver, and false when there is none.
+	Load(ctx context.Context, receiver string) (time.Time, bool, error)
+	Save(ctx context.Context, receiver string, ts time.Time) error
+}
+
+// ConfigMapCheckpoints keeps the checkpoints in the data of a ConfigMap the operator
+// creates next to the collector, one key per receiver. Each save merge-patches its own
+// key only, so the collectors of one process do not overwrite each other.
+type ConfigMapCheckpoints struct {
+	ConfigMaps corev1client.ConfigMapInterface
+	Name       string
+}
+
+var _ Checkpoints = &ConfigMapCheckpoints{}
+
+func (s *ConfigMapCheckpoints) Load(ctx context.Context, receiver string) (time.Time, bool, error) {
+	cm, err := s.ConfigMaps.Get(ctx, s.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	v, ok := cm.Data[receiver]
+	if !ok {
+		return time.Time{}, false, nil
+	}
+	ts, err := time.Parse(time.RFC3339Nano, v)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("checkpoint of %s: %w", receiver, err)
+	}
+	return ts, true, nil
+}
+
+func (s *ConfigMapCheckpoints) Save(ctx context.Context, receiver string, ts time.Time) error {
+	patch, err := json.Marshal(map[string]any{
+		"data": map[string]string{receiver: ts.UTC().Format(time.RFC3339Nano)},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = s.ConfigMaps.Patch(ctx, s.Name, types.MergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
+
+// Resume makes a collector resume after the newest event it delivered before a restart
+// instead of at its start.
+type Resume struct {
+	// Receiver is the key of the collector's checkpoint.
+	Receiver    string
+	Checkpoints Checkpoints
+	// MaxReplayWindow bounds the replay: events from earlier than this long before the
+	// start are skipped even when the checkpoint is older. 0 turns resuming off.
+	MaxReplayWindow time.Duration
+}
+
+// resumePoint returns the timestamp before which events are skipped at start: the
+// checkpoint, but no earlier than maxReplayWindow before the start. Without a
+// checkpoint, as on a first start, it is the start itself, so a new collector does not
+// replay every event the API server still holds.
+func resumePoint(start, checkpoint time.Time, found bool, maxReplayWindow time.Duration) time.Time {
+	if !found || maxReplayWindow <= 0 {
+		return start
+	}
+	if oldest := start.Add(-maxReplayWindow); checkpoint.Before(oldest) {
+		return oldest
+	}
+	return checkpoint
+}
@@ -0,0 +1,135 @@
+package evcollector
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/kaasops/vector-operator/internal/evconfig"
+)
+
+func TestResumePoint(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		checkpoint time.Time
+		found      bool
+		window     time.Duration
+		want       time.Time
+	}{
+		{name: "first start", window: time.Hour, want: start},
+		{name: "within window", checkpoint: start.Add(-10 * time.Minute), found: true, window: time.Hour, want: start.Add(-10 * time.Minute)},
+		{name: "beyond window", checkpoint: start.Add(-3 * time.Hour), found: true, window: time.Hour, want: start.Add(-time.Hour)},
+		{name: "resume off", checkpoint: start.Add(-10 * time.Minute), found: true, want: start},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, resumePoint(start, tt.checkpoint, tt.found, tt.window))
+		})
+	}
+}
+
+func TestConfigMapCheckpoints(t *testing.T) {
+	ctx := context.Background()
+	cs := k8sfake.NewSimpleClientset(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "state", Namespace: "vector"},
+	})
+	store := &ConfigMapCheckpoints{ConfigMaps: cs.CoreV1().ConfigMaps("vector"), Name: "state"}
+
+	_, found, err := store.Load(ctx, "a.vector")
+	require.NoError(t, err)
+	require.False(t, found)
+
+	ts := time.Date(2026, 1, 1, 12, 0, 0, 123000, time.UTC)
+	require.NoError(t, store.Save(ctx, "a.vector", ts))
+	require.NoError(t, store.Save(ctx, "b.vector", ts.Add(time.Minute)))
+
+	got, found, err := store.Load(ctx, "a.vector")
+	require.NoError(t, err)
+	require.True(t, found)
+	require.True(t, ts.Equal(got), "microseconds of an eventTime survive the round trip")
+
+	got, _, err = store.Load(ctx, "b.vector")
+	require.NoError(t, err)
+	require.True(t, ts.Add(time.Minute).Equal(got), "each receiver keeps its own key")
+
+	missing := &ConfigMapCheckpoints{ConfigMaps: cs.CoreV1().ConfigMaps("vector"), Name: "missing"}
+	_, found, err = missing.Load(ctx, "a.vector")
+	require.NoError(t, err)
+	require.False(t, found, "no ConfigMap is a first start, not an error")
+}
+
+type memCheckpoints map[string]time.Time
+
+func (m memCheckpoints) Load(_ context.Context, receiver string) (time.Time, bool, error) {
+	ts, ok := m[receiver]
+	return ts, ok, nil
+}
+
+func (m memCheckpoints) Save(_ context.Context, receiver string, ts time.Time) error {
+	m[receiver] = ts
+	return nil
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Info(string, ...any)  {}
+func (nopLogger) Error(string, ...any) {}
+func (nopLogger) Debug(string, ...any) {}
+
+func TestCollectorCheckpoint(t *testing.T) {
+	checkpoint := time.Now().Add(-5 * time.Minute).Truncate(time.Second)
+	store := memCheckpoints{"a.vector": checkpoint}
+	c := New("a.vector:9000", "", evconfig.EventFilter{}, 10, evconfig.QueueParams{}, evconfig.TLSParams{}, nopLogger{}, nil, &Resume{Receiver: "a.vector", Checkpoints: store, MaxReplayWindow: time.Hour})
+	c.loadCheckpoint()
+	require.Equal(t, checkpoint, c.resumeFrom)
+
+	event := func(ts time.Time) *corev1.Event {
+		return &corev1.Event{LastTimestamp: metav1.NewTime(ts)}
+	}
+	newer := checkpoint.Add(time.Minute)
+
+	c.markDelivered([]*corev1.Event{event(newer)}, false)
+	c.saveCheckpoint()
+	require.Equal(t, checkpoint, store["a.vector"], "no checkpoint before the listed events are through")
+
+	c.markDelivered([]*corev1.Event{event(newer), event(checkpoint.Add(time.Second))}, true)
+	c.saveCheckpoint()
+	require.Equal(t, newer, store["a.vector"])
+}
+
+// The operator writes the config as JSON and the collector reads it with viper: the
+// replay window has to survive the trip as a number of nanoseconds.
+func TestConfigReplayWindowRoundTrip(t *testing.T) {
+	data, err := json.Marshal(&evconfig.Config{
+		MaxBatchSize:        250,
+		MaxReplayWindow:     90 * time.Minute,
+		CheckpointConfigMap: "state",
+		CheckpointNamespace: "vector",
+		TLS:                 evconfig.TLSParams{CAFile: "/etc/event-collector/tls/ca.crt", ServerName: "events.vector"},
+		Receivers:           []*evconfig.ReceiverParams{{ServiceName: "svc", ServiceNamespace: "vector", Port: "9000"}},
+	})
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "config.json")
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+
+	v := viper.New()
+	v.SetConfigFile(path)
+	require.NoError(t, v.ReadInConfig())
+	var cfg evconfig.Config
+	require.NoError(t, v.Unmarshal(&cfg))
+	require.Equal(t, 90*time.Minute, cfg.MaxReplayWindow)
+	require.Equal(t, "state", cfg.CheckpointConfigMap)
+	require.Equal(t, "vector", cfg.CheckpointNamespace)
+	require.Equal(t, evconfig.TLSParams{CAFile: "/etc/event-collector/tls/ca.crt", ServerName: "events.vector"}, cfg.TLS)
+}
@@ -2,15 +2,16 @@ package evcollector
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
 
+	"github.com/kaasops/vector-operator/internal/evconfig"
 	"github.com/kaasops/vector-operator/internal/vector/gen"
 )
 
@@ -20,17 +21,48 @@ type Logger interface {
 	Debug(msg string, keysAndValues ...any)
 }
 
+// checkpointInterval is how often a collector saves its checkpoint. A restart replays at
+// most the events delivered since the last save.
+const checkpointInterval = 10 * time.Second
+
+// retryDelay is how long a collector waits before it connects or sends again after a
+// failure.
+const retryDelay = 5 * time.Second
+
 type Collector struct {
-	Addr         string
-	Namespace    string
-	createdAt    time.Time
-	stopCh       chan struct{}
+	Addr      string
+	Namespace string
+	createdAt time.Time
+	stopCh    chan struct{}
+	// stopOnce makes Stop safe to call again: the elected collector of a lost Lease is
+	// stopped both by the lost term and by the process shutting down.
+	stopOnce sync.Once
+	// cancel cancels the context of the pushes in flight, on Stop.
+	cancel       context.CancelFunc
 	logger       Logger
 	client       rest.Interface
 	maxBatchSize int
+	filter       evconfig.EventFilter
+	queue        *eventQueue
+	tls          evconfig.TLSParams
+
+	resume *Resume
+	// resumeFrom is the timestamp before which events are skipped, see resumePoint.
+	resumeFrom time.Time
+
+	mu sync.Mutex
+	// delivered is the checkpoint to save: the newest timestamp delivered at a moment
+	// when every event listed at start had been delivered too.
+	delivered time.Time
+	// saveMu serializes saves, so a slow one does not hold up delivery on mu.
+	saveMu sync.Mutex
+	saved  time.Time
 }
 
-func New(addr, namespace string, maxBatchSize int32, logger Logger, client rest.Interface) *Collector {
+// New returns a collector pushing the events of namespace that pass filter to addr,
+// over tls, buffering them in a queue bounded by queue. With resume nil it delivers
+// only the events from after its start.
+func New(addr, namespace string, filter evconfig.EventFilter, maxBatchSize int32, queue evconfig.QueueParams, tls evconfig.TLSParams, logger Logger, client rest.Interface, resume *Resume) *Collector {
 	c := Collector{
 		Addr:         addr,
 		createdAt:    time.Now(),
@@ -38,7 +70,12 @@ func New(addr, namespace string, maxBatchSize int32, logger Logger, client rest.
 		Namespace:    namespace,
 		client:       client,
 		maxBatchSize: int(maxBatchSize),
+		filter:       filter,
+		queue:        newEventQueue(queue, queueDepth.WithLabelValues(addr, namespace)),
+		tls:          tls,
+		resume:       resume,
 	}
+	c.resumeFrom = c.createdAt
 	return &c
 }
 
@@ -48,21 +85,28 @@ func (c *Collector) Start() {
 	}
 
 	c.stopCh = make(chan struct{})
-	eventsCh := make(chan *corev1.Event)
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+	c.loadCheckpoint()
 
-	watchList := cache.NewListWatchFromClient(c.client, "events", c.Namespace, fields.Everything())
+	selector, err := c.filter.Selector()
+	if err != nil {
+		// the operator validates the selector; should one get through, the handler
+		// still applies the includes and excludes
+		c.logger.Error("field selector", "address", c.Addr, "error", err)
+		selector = fields.Everything()
+	}
+	watchList := cache.NewListWatchFromClient(c.client, "events", c.Namespace, selector)
 	_, ctrl := cache.NewInformerWithOptions(cache.InformerOptions{
 		ListerWatcher: watchList,
 		ObjectType:    &corev1.Event{},
 		ResyncPeriod:  0,
 		Handler: cache.ResourceEventHandlerFuncs{
 			AddFunc: func(obj any) {
-				event := obj.(*corev1.Event)
-				eventsCh <- event
+				c.enqueue(obj.(*corev1.Event))
 			},
 			UpdateFunc: func(_, obj interface{}) {
-				event := obj.(*corev1.Event)
-				eventsCh <- event
+				c.enqueue(obj.(*corev1.Event))
 			},
 		},
 	})
@@ -72,6 +116,11 @@ func (c *Collector) Start() {
 		var err error
 		var sending bool
 		var sentBatchCount int
+		// listDelivered is set once every event the informer listed at start has been
+		// delivered, see markDelivered; syncedAt is the queue's push count when the
+		// informer had handed them all over.
+		var listDelivered, synced bool
+		var syncedAt uint64
 
 		batch := make([]*corev1.Event, 0, c.maxBatchSize)
 
@@ -89,13 +138,15 @@ func (c *Collector) Start() {
 			default:
 				if !sending {
 					select {
-					case event := <-eventsCh:
-						if eventTimestamp(event).Before(c.createdAt) || event == nil {
-							eventsSkipped.WithLabelValues(c.Addr, c.Namespace).Inc()
-							continue
+					case <-c.queue.ready:
+						for _, event := range c.queue.pop(c.maxBatchSize - len(batch)) {
+							if event == nil || eventTimestamp(event).Before(c.resumeFrom) {
+								eventsSkipped.WithLabelValues(c.Addr, c.Namespace).Inc()
+								continue
+							}
+							eventsHandled.WithLabelValues(c.Addr, c.Namespace).Inc()
+							batch = append(batch, event)
 						}
-						eventsHandled.WithLabelValues(c.Addr, c.Namespace).Inc()
-						batch = append(batch, event)
 						if len(batch) == c.maxBatchSize {
 							sending = true
 						} else {
@@ -116,29 +167,41 @@ func (c *Collector) Start() {
 				}
 
 				if conn == nil {
-					for {
-						conn, err = grpc.NewClient(c.Addr,
-							grpc.WithTransportCredentials(insecure.NewCredentials()),
-						)
-						if err != nil {
-							c.logger.Error("connect to address", "address", c.Addr, "error", err)
-							time.Sleep(5 * time.Second)
-							continue
-						}
-						vectorClient = gen.NewVectorClient(conn)
-						break
+					if conn = c.connect(); conn == nil {
+						return
 					}
+					vectorClient = gen.NewVectorClient(conn)
 				}
 
-				_, err = vectorClient.PushEvents(context.Background(), k8sEventsToVectorEvents(batch))
+				_, err = vectorClient.PushEvents(ctx, k8sEventsToVectorEvents(batch))
 				if err != nil {
-					c.logger.Error("send event", "address", c.Addr, "error", err)
 					_ = conn.Close()
 					conn = nil
-					time.Sleep(5 * time.Second)
+					if ctx.Err() != nil {
+						return
+					}
+					c.logger.Error("send event", "address", c.Addr, "error", err)
+					select {
+					case <-c.stopCh:
+						return
+					case <-time.After(retryDelay):
+					}
 					continue
 				}
 				sentBatchCount++
+				// The informer hands over the events it listed at start in no particular
+				// order, so until they are all delivered a newer event can be delivered
+				// before an older one; the checkpoint only moves once the list is through.
+				// Everything popped off the queue so far has been delivered by now.
+				if !listDelivered {
+					if !synced && ctrl.HasSynced() {
+						synced = true
+						syncedAt, _ = c.queue.counts()
+					}
+					_, left := c.queue.counts()
+					listDelivered = synced && left >= syncedAt
+				}
+				c.markDelivered(batch, listDelivered)
 				eventsProcessed.WithLabelValues(c.Addr, c.Namespace).Add(float64(len(batch)))
 				c.logger.Debug("batch sent",
 					"address", c.Addr,
@@ -153,10 +216,120 @@ func (c *Collector) Start() {
 
 	}()
 	go ctrl.Run(c.stopCh)
+	if c.resume != nil {
+		go c.runCheckpoints()
+	}
+}
+
+// connect dials the receiver until it succeeds, and returns nil once the collector is
+// stopped.
+func (c *Collector) connect() *grpc.ClientConn {
+	for {
+		creds, err := transportCredentials(c.tls)
+		if err != nil {
+			c.logger.Error("load tls credentials", "address", c.Addr, "error", err)
+		} else {
+			conn, err := grpc.NewClient(c.Addr, grpc.WithTransportCredentials(creds))
+			if err == nil {
+				return conn
+			}
+			c.logger.Error("connect to address", "address", c.Addr, "error", err)
+		}
+		select {
+		case <-c.stopCh:
+			return nil
+		case <-time.After(retryDelay):
+		}
+	}
 }
 
+// Stop stops the collector, cancelling the push in flight, and saves its checkpoint.
 func (c *Collector) Stop() {
-	close(c.stopCh)
+	c.stopOnce.Do(func() {
+		close(c.stopCh)
+		c.cancel()
+		c.queue.close()
+		c.saveCheckpoint()
+		queueDepth.DeleteLabelValues(c.Addr, c.Namespace)
+	})
+}
+
+// enqueue hands an event from the informer to the sender, if it passes the filter.
+func (c *Collector) enqueue(event *corev1.Event) {
+	if !c.filter.Match(event) {
+		eventsFiltered.WithLabelValues(c.Addr, c.Namespace).Inc()
+		return
+	}
+	if dropped := c.queue.push(event); dropped > 0 {
+		eventsDropped.WithLabelValues(c.Addr, c.Namespace).Add(float64(dropped))
+	}
+}
+
+// loadCheckpoint sets resumeFrom from the saved checkpoint. A checkpoint that cannot be
+// read replays the whole window: a duplicate is cheaper than a lost event.
+func (c *Collector) loadCheckpoint() {
+	if c.resume == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	checkpoint, found, err := c.resume.Checkpoints.Load(ctx, c.resume.Receiver)
+	if err != nil {
+		c.logger.Error("load checkpoint", "receiver", c.resume.Receiver, "error", err)
+		checkpoint, found = time.Time{}, true
+	}
+	c.resumeFrom = resumePoint(c.createdAt, checkpoint, found, c.resume.MaxReplayWindow)
+	c.delivered = c.resumeFrom
+	c.saved = c.resumeFrom
+	c.logger.Info("resuming", "receiver", c.resume.Receiver, "from", c.resumeFrom)
+}
+
+func (c *Collector) markDelivered(batch []*corev1.Event, listDelivered bool) {
+	if c.resume == nil || !listDelivered {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, ev := range batch {
+		if ts := eventTimestamp(ev); ts.After(c.delivered) {
+			c.delivered = ts
+		}
+	}
+}
+
+func (c *Collector) runCheckpoints() {
+	ticker := time.NewTicker(checkpointInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.stopCh:
+			return
+		case <-ticker.C:
+			c.saveCheckpoint()
+		}
+	}
+}
+
+// saveCheckpoint saves the delivered checkpoint if it moved since the last save.
+func (c *Collector) saveCheckpoint() {
+	if c.resume == nil {
+		return
+	}
+	c.saveMu.Lock()
+	defer c.saveMu.Unlock()
+	c.mu.Lock()
+	delivered := c.delivered
+	c.mu.Unlock()
+	if !delivered.After(c.saved) {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.resume.Checkpoints.Save(ctx, c.resume.Receiver, delivered); err != nil {
+		c.logger.Error("save checkpoint", "receiver", c.resume.Receiver, "error", err)
+		return
+	}
+	c.saved = delivered
 }
 
 func eventTimestamp(ev *corev1.Event) time.Time {
@@ -0,0 +1,40 @@
+package evcollector
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"github.com/kaasops/vector-operator/internal/evconfig"
+)
+
+// A collector that cannot connect stops without waiting out its retry delay.
+func TestCollectorConnectStops(t *testing.T) {
+	tls := evconfig.TLSParams{CAFile: filepath.Join(t.TempDir(), "missing.crt")}
+	c := New("a.vector:9000", "", evconfig.EventFilter{}, 10, evconfig.QueueParams{}, tls, nopLogger{}, nil, nil)
+	c.stopCh = make(chan struct{})
+
+	done := make(chan *grpc.ClientConn, 1)
+	go func() { done <- c.connect() }()
+	close(c.stopCh)
+	select {
+	case conn := <-done:
+		require.Nil(t, conn)
+	case <-time.After(retryDelay / 2):
+		t.Fatal("connect did not return on stop")
+	}
+}
+
+// Stopping a stopped collector is a no-op rather than a close of a closed channel.
+func TestCollectorStopTwice(t *testing.T) {
+	c := New("a.vector:9000", "", evconfig.EventFilter{}, 10, evconfig.QueueParams{}, evconfig.TLSParams{}, nopLogger{}, nil, nil)
+	c.stopCh = make(chan struct{})
+	_, c.cancel = context.WithCancel(context.Background())
+
+	c.Stop()
+	require.NotPanics(t, c.Stop)
+}
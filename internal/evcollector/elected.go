@@ -0,0 +1,142 @@
+package evcollector
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	coordinationv1client "k8s.io/client-go/kubernetes/typed/coordination/v1"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+// Lease timings of the receivers: a replica that dies without releasing its Leases
+// hands them over within leaseDuration, and the checkpoints cover the gap.
+const (
+	leaseDuration = 15 * time.Second
+	renewDeadline = 10 * time.Second
+	retryPeriod   = 2 * time.Second
+)
+
+// Runner is what the event collector process keeps per receiver: a Collector, or an
+// ElectedCollector running one.
+type Runner interface {
+	Start()
+	Stop()
+}
+
+var (
+	_ Runner = &Collector{}
+	_ Runner = &ElectedCollector{}
+)
+
+// ElectedCollector runs a Collector only while its replica holds the receiver's Lease.
+// Every replica of the event collector contends for the Lease of every receiver, so a
+// receiver is pushed to by one replica at a time, and another one takes over when that
+// replica is drained or dies. Receivers are elected one by one rather than the whole
+// process, which spreads them across the replicas as they race for the Leases.
+type ElectedCollector struct {
+	lock         resourcelock.Interface
+	newCollector func() Runner
+	logger       Logger
+
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu      sync.Mutex
+	current Runner
+	stopped bool
+}
+
+// NewElected returns an ElectedCollector contending as identity for the Lease name in
+// namespace, running the collectors newCollector returns while it holds it.
+func NewElected(leases coordinationv1client.LeasesGetter, namespace, name, identity string, newCollector func() Runner, logger Logger) *ElectedCollector {
+	return &ElectedCollector{
+		lock: &resourcelock.LeaseLock{
+			LeaseMeta:  metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Client:     leases,
+			LockConfig: resourcelock.ResourceLockConfig{Identity: identity},
+		},
+		newCollector: newCollector,
+		logger:       logger,
+	}
+}
+
+func (e *ElectedCollector) Start() {
+	if e.done != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	e.cancel = cancel
+	e.done = make(chan struct{})
+
+	go func() {
+		defer close(e.done)
+		// Run returns when the Lease is lost; contend again until stopped.
+		for ctx.Err() == nil {
+			elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+				Lock:            e.lock,
+				LeaseDuration:   leaseDuration,
+				RenewDeadline:   renewDeadline,
+				RetryPeriod:     retryPeriod,
+				ReleaseOnCancel: true,
+				Name:            e.lock.Describe(),
+				Callbacks: leaderelection.LeaderCallbacks{
+					OnStartedLeading: e.lead,
+					OnStoppedLeading: func() {},
+				},
+			})
+			if err != nil {
+				e.logger.Error("leader election", "lease", e.lock.Describe(), "error", err)
+				return
+			}
+			elector.Run(ctx)
+		}
+	}()
+}
+
+// lead runs a collector until the Lease is lost.
+func (e *ElectedCollector) lead(ctx context.Context) {
+	e.mu.Lock()
+	if e.stopped {
+		e.mu.Unlock()
+		return
+	}
+	e.logger.Info("leading", "lease", e.lock.Describe())
+	c := e.newCollector()
+	e.current = c
+	c.Start()
+	e.mu.Unlock()
+
+	<-ctx.Done()
+	e.stopCollector(c)
+}
+
+// stopCollector stops c even when a newer term already replaced it as current: a Lease
+// lost and won again in quick succession must not leave the old collector pushing.
+func (e *ElectedCollector) stopCollector(c Runner) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.current == c {
+		e.current = nil
+	}
+	c.Stop()
+	e.logger.Info("stopped leading", "lease", e.lock.Describe())
+}
+
+// Stop stops the collector, saving its checkpoint, before it releases the Lease: the
+// replica taking over then resumes from where this one stopped.
+func (e *ElectedCollector) Stop() {
+	e.mu.Lock()
+	e.stopped = true
+	c := e.current
+	e.mu.Unlock()
+	if c != nil {
+		e.stopCollector(c)
+	}
+	if e.cancel != nil {
+		e.cancel()
+		<-e.done
+	}
+}
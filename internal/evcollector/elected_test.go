@@ -0,0 +1,83 @@
+package evcollector
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+)
+
+type fakeRunner struct {
+	mu      sync.Mutex
+	running bool
+}
+
+func (r *fakeRunner) Start() { r.mu.Lock(); r.running = true; r.mu.Unlock() }
+func (r *fakeRunner) Stop()  { r.mu.Lock(); r.running = false; r.mu.Unlock() }
+
+func (r *fakeRunner) isRunning() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.running
+}
+
+// One replica at a time pushes a receiver's events, and a stopped replica hands the
+// receiver over without waiting for its Lease to expire.
+func TestElectedCollectorHandover(t *testing.T) {
+	cs := k8sfake.NewSimpleClientset()
+	a, b := &fakeRunner{}, &fakeRunner{}
+	electedA := NewElected(cs.CoordinationV1(), "vector", "agg-event-collector-svc", "a", func() Runner { return a }, nopLogger{})
+	electedB := NewElected(cs.CoordinationV1(), "vector", "agg-event-collector-svc", "b", func() Runner { return b }, nopLogger{})
+
+	electedA.Start()
+	require.Eventually(t, a.isRunning, 5*time.Second, 50*time.Millisecond)
+	electedB.Start()
+	defer electedB.Stop()
+
+	require.Never(t, b.isRunning, 3*time.Second, 100*time.Millisecond, "the Lease is held by a")
+
+	electedA.Stop()
+	require.False(t, a.isRunning())
+	require.Eventually(t, b.isRunning, 2*leaseDuration, 100*time.Millisecond, "b takes over once a released the Lease")
+}
+
+// A Lease won again before the lost term wound down stops the lost term's collector
+// anyway, rather than leaving two collectors pushing the same events.
+func TestElectedCollectorQuickReelection(t *testing.T) {
+	cs := k8sfake.NewSimpleClientset()
+	var runners []*fakeRunner
+	var mu sync.Mutex
+	e := NewElected(cs.CoordinationV1(), "vector", "agg-event-collector-vector-svc", "a", func() Runner {
+		mu.Lock()
+		defer mu.Unlock()
+		r := &fakeRunner{}
+		runners = append(runners, r)
+		return r
+	}, nopLogger{})
+	runner := func(i int) *fakeRunner {
+		mu.Lock()
+		defer mu.Unlock()
+		if i >= len(runners) {
+			return &fakeRunner{}
+		}
+		return runners[i]
+	}
+
+	lost, loseLease := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() { e.lead(lost); close(done) }()
+	require.Eventually(t, func() bool { return runner(0).isRunning() }, time.Second, 10*time.Millisecond)
+
+	won, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go e.lead(won)
+	require.Eventually(t, func() bool { return runner(1).isRunning() }, time.Second, 10*time.Millisecond)
+
+	loseLease()
+	<-done
+	require.False(t, runner(0).isRunning(), "the lost term's collector is stopped")
+	require.True(t, runner(1).isRunning())
+}
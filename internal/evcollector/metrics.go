@@ -16,6 +16,21 @@ var (
 		Name:      "skipped_events_total",
 		Help:      "The total number of skipped events",
 	}, []string{"service", "namespace"})
+	eventsFiltered = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "event_collector",
+		Name:      "filtered_events_total",
+		Help:      "The total number of events dropped by the receiver's filter",
+	}, []string{"service", "namespace"})
+	eventsDropped = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "event_collector",
+		Name:      "dropped_events_total",
+		Help:      "The total number of events dropped by the overflow policy of a full queue",
+	}, []string{"service", "namespace"})
+	queueDepth = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "event_collector",
+		Name:      "queue_depth",
+		Help:      "The number of events queued for the receiver",
+	}, []string{"service", "namespace"})
 	eventsProcessed = promauto.NewCounterVec(prometheus.CounterOpts{
 		Namespace: "event_collector",
 		Name:      "processed_events_total",
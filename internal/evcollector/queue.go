@@ -0,0 +1,120 @@
+package evcollector
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/kaasops/vector-operator/internal/evconfig"
+)
+
+// eventQueue is the bounded queue between the informer's handlers and the sender. The
+// handlers must not wait on the sender: it stalls for as long as the aggregator is
+// unreachable, and with it, without a bound, the informer and its memory.
+type eventQueue struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	items  []*corev1.Event
+	head   int
+	len    int
+	policy evconfig.OverflowPolicy
+	closed bool
+	// ready holds a token while the queue has events, for the sender to select on.
+	ready chan struct{}
+	depth prometheus.Gauge
+
+	// pushed counts the events queued, left those that left the queue, popped or dropped.
+	pushed uint64
+	left   uint64
+}
+
+func newEventQueue(params evconfig.QueueParams, depth prometheus.Gauge) *eventQueue {
+	size := int(params.Size)
+	if size <= 0 {
+		size = evconfig.DefaultQueueSize
+	}
+	q := &eventQueue{
+		items:  make([]*corev1.Event, size),
+		policy: params.OverflowPolicy,
+		ready:  make(chan struct{}, 1),
+		depth:  depth,
+	}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+// push queues ev and returns the number of events the overflow policy dropped for it.
+func (q *eventQueue) push(ev *corev1.Event) (dropped int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for q.len == len(q.items) && !q.closed {
+		switch q.policy {
+		case evconfig.OverflowDropNewest:
+			return 1
+		case evconfig.OverflowBlock:
+			q.cond.Wait()
+		default:
+			q.items[q.head] = nil
+			q.head = (q.head + 1) % len(q.items)
+			q.len--
+			q.left++
+			dropped = 1
+		}
+	}
+	if q.closed {
+		return 1
+	}
+	q.items[(q.head+q.len)%len(q.items)] = ev
+	q.len++
+	q.pushed++
+	q.depth.Set(float64(q.len))
+	q.signal()
+	return dropped
+}
+
+// pop takes up to max events off the queue.
+func (q *eventQueue) pop(max int) []*corev1.Event {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	n := min(max, q.len)
+	out := make([]*corev1.Event, n)
+	for i := range n {
+		out[i] = q.items[q.head]
+		q.items[q.head] = nil
+		q.head = (q.head + 1) % len(q.items)
+	}
+	q.len -= n
+	q.left += uint64(n)
+	q.depth.Set(float64(q.len))
+	if n > 0 {
+		q.cond.Broadcast()
+	}
+	if q.len > 0 {
+		// what is left was signalled already, but that token was taken for this pop
+		q.signal()
+	}
+	return out
+}
+
+// counts returns how many events were queued and how many left the queue so far.
+func (q *eventQueue) counts() (pushed, left uint64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.pushed, q.left
+}
+
+// close releases the handlers blocked in push; what they push from then on is dropped.
+func (q *eventQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+	q.cond.Broadcast()
+}
+
+func (q *eventQueue) signal() {
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,107 @@
+package evcollector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kaasops/vector-operator/internal/evconfig"
+)
+
+func named(names ...string) []*corev1.Event {
+	events := make([]*corev1.Event, 0, len(names))
+	for _, n := range names {
+		events = append(events, &corev1.Event{ObjectMeta: metav1.ObjectMeta{Name: n}})
+	}
+	return events
+}
+
+func names(events []*corev1.Event) []string {
+	out := make([]string, 0, len(events))
+	for _, ev := range events {
+		out = append(out, ev.Name)
+	}
+	return out
+}
+
+func testGauge() prometheus.Gauge {
+	return prometheus.NewGauge(prometheus.GaugeOpts{Name: "test_queue_depth"})
+}
+
+func TestEventQueueOverflow(t *testing.T) {
+	tests := []struct {
+		policy evconfig.OverflowPolicy
+		want   []string
+	}{
+		{policy: evconfig.OverflowDropOldest, want: []string{"c", "d"}},
+		{policy: evconfig.OverflowDropNewest, want: []string{"a", "b"}},
+		{policy: "", want: []string{"c", "d"}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			depth := testGauge()
+			q := newEventQueue(evconfig.QueueParams{Size: 2, OverflowPolicy: tt.policy}, depth)
+			var dropped int
+			for _, ev := range named("a", "b", "c", "d") {
+				dropped += q.push(ev)
+			}
+			require.Equal(t, 2, dropped)
+			require.InDelta(t, 2, testutil.ToFloat64(depth), 0)
+
+			require.Equal(t, tt.want, names(q.pop(10)))
+			require.InDelta(t, 0, testutil.ToFloat64(depth), 0)
+			pushed, left := q.counts()
+			require.Equal(t, pushed, left, "every queued event has left the queue")
+		})
+	}
+}
+
+func TestEventQueueBlock(t *testing.T) {
+	q := newEventQueue(evconfig.QueueParams{Size: 1, OverflowPolicy: evconfig.OverflowBlock}, testGauge())
+	require.Zero(t, q.push(named("a")[0]))
+
+	pushed := make(chan int)
+	go func() { pushed <- q.push(named("b")[0]) }()
+	select {
+	case <-pushed:
+		t.Fatal("push into a full queue must wait with the Block policy")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	require.Equal(t, []string{"a"}, names(q.pop(1)))
+	require.Zero(t, <-pushed)
+	require.Equal(t, []string{"b"}, names(q.pop(1)))
+
+	// a stopping collector releases the blocked handlers
+	require.Zero(t, q.push(named("c")[0]))
+	go func() { pushed <- q.push(named("d")[0]) }()
+	q.close()
+	require.Equal(t, 1, <-pushed)
+}
+
+// The sender selects on ready and takes at most a batch at a time: what it leaves in
+// the queue has to be signalled again, or it would wait for the next event.
+func TestEventQueueReadyAfterPartialPop(t *testing.T) {
+	q := newEventQueue(evconfig.QueueParams{Size: 10}, testGauge())
+	for _, ev := range named("a", "b", "c") {
+		q.push(ev)
+	}
+	<-q.ready
+	require.Equal(t, []string{"a", "b"}, names(q.pop(2)))
+	select {
+	case <-q.ready:
+	default:
+		t.Fatal("events left in the queue must be signalled")
+	}
+	require.Equal(t, []string{"c"}, names(q.pop(2)))
+	select {
+	case <-q.ready:
+		t.Fatal("an empty queue must not be signalled")
+	default:
+	}
+}
@@ -0,0 +1,49 @@
+package evcollector
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/kaasops/vector-operator/internal/evconfig"
+)
+
+// transportCredentials reads the files of p afresh, so a collector picks up a rotated
+// CA when it reconnects. The client certificate is read on every handshake, as the
+// aggregator checks it on every one.
+func transportCredentials(p evconfig.TLSParams) (credentials.TransportCredentials, error) {
+	if p.CAFile == "" {
+		return insecure.NewCredentials(), nil
+	}
+	ca, err := os.ReadFile(p.CAFile)
+	if err != nil {
+		return nil, err
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(ca) {
+		return nil, fmt.Errorf("no certificates in %s", p.CAFile)
+	}
+	cfg := &tls.Config{
+		RootCAs:    roots,
+		ServerName: p.ServerName,
+		MinVersion: tls.VersionTLS12,
+	}
+	if p.CertFile != "" {
+		// fail the dial rather than every handshake on a broken pair
+		if _, err := tls.LoadX509KeyPair(p.CertFile, p.KeyFile); err != nil {
+			return nil, err
+		}
+		cfg.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			cert, err := tls.LoadX509KeyPair(p.CertFile, p.KeyFile)
+			if err != nil {
+				return nil, err
+			}
+			return &cert, nil
+		}
+	}
+	return credentials.NewTLS(cfg), nil
+}
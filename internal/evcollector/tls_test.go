@@ -0,0 +1,159 @@
+package evcollector
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/kaasops/vector-operator/internal/evconfig"
+	"github.com/kaasops/vector-operator/internal/vector/gen"
+)
+
+type healthServer struct {
+	gen.UnimplementedVectorServer
+}
+
+func (healthServer) HealthCheck(context.Context, *gen.HealthCheckRequest) (*gen.HealthCheckResponse, error) {
+	return &gen.HealthCheckResponse{}, nil
+}
+
+// writeCertificates writes a CA and a certificate it signed for name, with both the
+// server and the client auth usage, the way one Secret serves both ends.
+func writeCertificates(t *testing.T, dir, name string) (ca *x509.Certificate, cert tls.Certificate) {
+	t.Helper()
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+	ca, err = x509.ParseCertificate(caDER)
+	require.NoError(t, err)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	write := func(file, typ string, der []byte) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, file), pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}), 0o600))
+	}
+	write("ca.crt", "CERTIFICATE", caDER)
+	write("tls.crt", "CERTIFICATE", der)
+	write("tls.key", "EC PRIVATE KEY", keyDER)
+
+	cert, err = tls.LoadX509KeyPair(filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key"))
+	require.NoError(t, err)
+	return ca, cert
+}
+
+// serveMutualTLS serves the Vector API the way the aggregator's vector source does with
+// verify_certificate, requiring a client certificate signed by ca.
+func serveMutualTLS(t *testing.T, ca *x509.Certificate, cert tls.Certificate) string {
+	t.Helper()
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+	srv := grpc.NewServer(grpc.Creds(credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	})))
+	gen.RegisterVectorServer(srv, healthServer{})
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+	return lis.Addr().String()
+}
+
+func healthCheck(t *testing.T, addr string, p evconfig.TLSParams) error {
+	t.Helper()
+	creds, err := transportCredentials(p)
+	require.NoError(t, err)
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(creds))
+	require.NoError(t, err)
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err = gen.NewVectorClient(conn).HealthCheck(ctx, &gen.HealthCheckRequest{})
+	return err
+}
+
+func TestTransportCredentials(t *testing.T) {
+	dir := t.TempDir()
+	ca, cert := writeCertificates(t, dir, "events.vector")
+	addr := serveMutualTLS(t, ca, cert)
+
+	mutual := evconfig.TLSParams{
+		CAFile:     filepath.Join(dir, "ca.crt"),
+		CertFile:   filepath.Join(dir, "tls.crt"),
+		KeyFile:    filepath.Join(dir, "tls.key"),
+		ServerName: "events.vector",
+	}
+	require.NoError(t, healthCheck(t, addr, mutual))
+
+	t.Run("no client certificate", func(t *testing.T) {
+		p := mutual
+		p.CertFile, p.KeyFile = "", ""
+		require.Error(t, healthCheck(t, addr, p))
+	})
+
+	t.Run("server name not in the certificate", func(t *testing.T) {
+		p := mutual
+		p.ServerName = "other.vector"
+		require.Error(t, healthCheck(t, addr, p))
+	})
+
+	t.Run("plaintext", func(t *testing.T) {
+		creds, err := transportCredentials(evconfig.TLSParams{})
+		require.NoError(t, err)
+		require.Equal(t, "insecure", creds.Info().SecurityProtocol)
+		require.Error(t, healthCheck(t, addr, evconfig.TLSParams{}))
+	})
+
+	t.Run("missing files", func(t *testing.T) {
+		_, err := transportCredentials(evconfig.TLSParams{CAFile: filepath.Join(dir, "missing.crt")})
+		require.Error(t, err)
+
+		_, err = transportCredentials(evconfig.TLSParams{CAFile: filepath.Join(dir, "tls.key")})
+		require.ErrorContains(t, err, "no certificates")
+
+		p := mutual
+		p.KeyFile = filepath.Join(dir, "missing.key")
+		_, err = transportCredentials(p)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,69 @@
+package evconfig
+
+import "time"
+
+type ReceiverParams struct {
+	ServiceName      string
+	ServiceNamespace string
+	Port             string
+	WatchedNamespace string
+	// Filter selects the events of WatchedNamespace the receiver gets.
+	Filter EventFilter
+}
+
+type Config struct {
+	MaxBatchSize int32
+	// Queue bounds the events each receiver buffers while its aggregator is slow or
+	// unreachable.
+	Queue QueueParams
+	// MaxReplayWindow bounds the replay of a restarted collector.
+	MaxReplayWindow time.Duration
+	// CheckpointConfigMap is the ConfigMap in CheckpointNamespace the collectors keep
+	// their checkpoints in. Empty turns resuming off.
+	CheckpointConfigMap string
+	CheckpointNamespace string
+	// LeaseNamespace, when set, makes the replicas elect one of them per receiver
+	// through the Lease LeasePrefix-<ServiceName>.<ServiceNamespace> there: neither name
+	// holds a dot, so receivers of the same Service name in two namespaces do not share
+	// one.
+	LeaseNamespace string
+	LeasePrefix    string
+	// TLS secures the push to the aggregators; the zero value pushes in plaintext.
+	TLS       TLSParams
+	Receivers []*ReceiverParams
+}
+
+// OverflowPolicy is what a full queue does with another event.
+type OverflowPolicy string
+
+const (
+	// OverflowDropOldest drops the oldest queued event to make room: the newest events
+	// are the ones still worth reading when the aggregator comes back.
+	OverflowDropOldest OverflowPolicy = "DropOldest"
+	// OverflowDropNewest drops the event that does not fit.
+	OverflowDropNewest OverflowPolicy = "DropNewest"
+	// OverflowBlock holds up the informer until there is room. Nothing is dropped, but
+	// the events the informer cannot hand over pile up in client-go's queue, unbounded.
+	OverflowBlock OverflowPolicy = "Block"
+)
+
+// DefaultQueueSize is the queue size of a collector whose config sets none.
+const DefaultQueueSize = 10000
+
+// QueueParams bound the events a collector buffers for its receiver.
+type QueueParams struct {
+	Size           int32
+	OverflowPolicy OverflowPolicy
+}
+
+// TLSParams has a collector push over TLS: it verifies the aggregator against CAFile
+// and, with CertFile and KeyFile set, presents that certificate for mTLS. Without a
+// CAFile it pushes in plaintext.
+type TLSParams struct {
+	CAFile   string
+	CertFile string
+	KeyFile  string
+	// ServerName is verified in the aggregator's certificate; empty means the host of
+	// the collector's address.
+	ServerName string
+}
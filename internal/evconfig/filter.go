@@ -0,0 +1,107 @@
+// Package evconfig holds what the operator configures the event collector with. The
+// config builder and the collector share it without the builder depending on the
+// collector's runtime.
+package evconfig
+
+import (
+	"fmt"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/fields"
+)
+
+// EventFilter selects the events a receiver gets. Whatever can be expressed as a field
+// selector is left to the API server, so unwanted events are not even sent to the
+// collector; the rest is matched by the collector before batching.
+type EventFilter struct {
+	// Include keeps only the events matching it, Exclude drops those matching it.
+	Include EventMatch
+	Exclude EventMatch
+	// FieldSelector is a field selector on events, ANDed with the matches.
+	FieldSelector string
+}
+
+// EventMatch matches an event whose type, reason and involved object kind are each in
+// the corresponding list. An empty list does not constrain its field.
+type EventMatch struct {
+	Types               []string
+	Reasons             []string
+	InvolvedObjectKinds []string
+}
+
+// eventSelectorFields are the fields the API server can select events by.
+var eventSelectorFields = map[string]struct{}{
+	"metadata.name":                  {},
+	"metadata.namespace":             {},
+	"involvedObject.kind":            {},
+	"involvedObject.namespace":       {},
+	"involvedObject.name":            {},
+	"involvedObject.uid":             {},
+	"involvedObject.apiVersion":      {},
+	"involvedObject.resourceVersion": {},
+	"involvedObject.fieldPath":       {},
+	"reason":                         {},
+	"reportingComponent":             {},
+	"source":                         {},
+	"type":                           {},
+}
+
+// Validate rejects a field selector that does not parse or selects by a field the API
+// server does not support for events: the collector's list would fail on every retry.
+func (f *EventFilter) Validate() error {
+	if f.FieldSelector == "" {
+		return nil
+	}
+	selector, err := fields.ParseSelector(f.FieldSelector)
+	if err != nil {
+		return fmt.Errorf("invalid field selector %q: %w", f.FieldSelector, err)
+	}
+	for _, r := range selector.Requirements() {
+		if _, ok := eventSelectorFields[r.Field]; !ok {
+			return fmt.Errorf("invalid field selector %q: events cannot be selected by %s", f.FieldSelector, r.Field)
+		}
+	}
+	return nil
+}
+
+// Selector is the field selector of the collector's ListWatch. A field selector has no
+// set-based operators, so an include list goes to the API server only when it has a
+// single value; every exclusion does, as one != per value.
+func (f *EventFilter) Selector() (fields.Selector, error) {
+	selectors := make([]fields.Selector, 0)
+	if f.FieldSelector != "" {
+		s, err := fields.ParseSelector(f.FieldSelector)
+		if err != nil {
+			return nil, err
+		}
+		selectors = append(selectors, s)
+	}
+	add := func(field string, include, exclude []string) {
+		if len(include) == 1 {
+			selectors = append(selectors, fields.OneTermEqualSelector(field, include[0]))
+		}
+		for _, v := range exclude {
+			selectors = append(selectors, fields.OneTermNotEqualSelector(field, v))
+		}
+	}
+	add("type", f.Include.Types, f.Exclude.Types)
+	add("reason", f.Include.Reasons, f.Exclude.Reasons)
+	add("involvedObject.kind", f.Include.InvolvedObjectKinds, f.Exclude.InvolvedObjectKinds)
+	if len(selectors) == 0 {
+		return fields.Everything(), nil
+	}
+	return fields.AndSelectors(selectors...), nil
+}
+
+// Match reports whether ev passes the includes and excludes. The field selector is not
+// checked again: the API server already applied it.
+func (f *EventFilter) Match(ev *corev1.Event) bool {
+	in := func(values []string, v string) bool { return len(values) == 0 || slices.Contains(values, v) }
+	if !in(f.Include.Types, ev.Type) || !in(f.Include.Reasons, ev.Reason) || !in(f.Include.InvolvedObjectKinds, ev.InvolvedObject.Kind) {
+		return false
+	}
+	return !slices.Contains(f.Exclude.Types, ev.Type) &&
+		!slices.Contains(f.Exclude.Reasons, ev.Reason) &&
+		!slices.Contains(f.Exclude.InvolvedObjectKinds, ev.InvolvedObject.Kind)
+}
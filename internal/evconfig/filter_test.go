@@ -0,0 +1,75 @@
+package evconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/fields"
+)
+
+func TestEventFilterSelector(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter EventFilter
+		want   string
+	}{
+		{name: "empty", want: ""},
+		{
+			name:   "single include values go to the API server",
+			filter: EventFilter{Include: EventMatch{Types: []string{"Warning"}, Reasons: []string{"BackOff"}}},
+			want:   "type=Warning,reason=BackOff",
+		},
+		{
+			name:   "several include values do not",
+			filter: EventFilter{Include: EventMatch{InvolvedObjectKinds: []string{"Pod", "Node"}}},
+			want:   "",
+		},
+		{
+			name:   "every exclusion does",
+			filter: EventFilter{Exclude: EventMatch{Reasons: []string{"Pulled", "Created"}}, FieldSelector: "involvedObject.namespace=a"},
+			want:   "involvedObject.namespace=a,reason!=Pulled,reason!=Created",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selector, err := tt.filter.Selector()
+			require.NoError(t, err)
+			require.Equal(t, tt.want, selector.String())
+		})
+	}
+}
+
+func TestEventFilterMatch(t *testing.T) {
+	event := func(eventType, reason, kind string) *corev1.Event {
+		return &corev1.Event{Type: eventType, Reason: reason, InvolvedObject: corev1.ObjectReference{Kind: kind}}
+	}
+	filter := EventFilter{
+		Include: EventMatch{Types: []string{"Warning"}, InvolvedObjectKinds: []string{"Pod", "Node"}},
+		Exclude: EventMatch{Reasons: []string{"BackOff"}},
+	}
+
+	require.True(t, filter.Match(event("Warning", "FailedScheduling", "Pod")))
+	require.True(t, filter.Match(event("Warning", "NodeNotReady", "Node")))
+	require.False(t, filter.Match(event("Normal", "Scheduled", "Pod")), "type not included")
+	require.False(t, filter.Match(event("Warning", "FailedCreate", "ReplicaSet")), "kind not included")
+	require.False(t, filter.Match(event("Warning", "BackOff", "Pod")), "reason excluded")
+	require.True(t, (&EventFilter{}).Match(event("Normal", "Scheduled", "Pod")), "an empty filter matches everything")
+}
+
+func TestEventFilterValidate(t *testing.T) {
+	require.NoError(t, (&EventFilter{}).Validate())
+	require.NoError(t, (&EventFilter{FieldSelector: "involvedObject.kind=Pod,type!=Normal"}).Validate())
+	require.Error(t, (&EventFilter{FieldSelector: "type"}).Validate())
+	require.Error(t, (&EventFilter{FieldSelector: "message=oops"}).Validate())
+}
+
+// The selector the collector sends must be one the API server can parse back.
+func TestEventFilterSelectorEscapes(t *testing.T) {
+	filter := EventFilter{Include: EventMatch{Reasons: []string{"a,b=c"}}}
+	selector, err := filter.Selector()
+	require.NoError(t, err)
+	parsed, err := fields.ParseSelector(selector.String())
+	require.NoError(t, err)
+	require.True(t, parsed.Matches(fields.Set{"reason": "a,b=c"}))
+}
@@ -0,0 +1,25 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Expand resolves everything a pipeline spec can reference instead of spelling it out:
+// first the template (ExpandTemplate), then the outputs its sinks name, whether written
+// in the pipeline or in the template (ExpandOutputs). Workloads list pipelines expanded
+// and the pipeline controller validates them expanded, so both see the same spec.
+func Expand(ctx context.Context, c client.Reader, p Pipeline) error {
+	if err := ExpandTemplate(ctx, c, p); err != nil {
+		return err
+	}
+	return ExpandOutputs(ctx, c, p)
+}
+
+// IsInvalidReference reports whether err from Expand is a problem with the pipeline
+// or the objects it references, as opposed to a failure to read them.
+func IsInvalidReference(err error) bool {
+	return errors.Is(err, ErrInvalidTemplateRef) || errors.Is(err, ErrInvalidOutputRef)
+}
@@ -31,6 +31,7 @@ type tmp struct {
 	// omitempty so pipelines without the annotation keep a stable hash (no re-hash on upgrade)
 	ConfigOptimization string `json:",omitempty"`
 	ForceConfigCheck   string `json:",omitempty"`
+	MetadataEnrichment string `json:",omitempty"`
 }
 
 func GetPipelineHash(pipeline Pipeline) (*int64, error) {
@@ -40,6 +41,7 @@ func GetPipelineHash(pipeline Pipeline) (*int64, error) {
 		ServiceName:        pipeline.GetAnnotations()[common.AnnotationServiceName],
 		ConfigOptimization: pipeline.GetAnnotations()[common.AnnotationConfigOptimization],
 		ForceConfigCheck:   pipeline.GetAnnotations()[common.AnnotationForceConfigCheck],
+		MetadataEnrichment: pipeline.GetAnnotations()[common.AnnotationMetadataEnrichment],
 	})
 	if err != nil {
 		return nil, err
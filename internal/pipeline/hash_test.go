@@ -213,3 +213,24 @@ func TestPipelineHashStableWithoutSecrets(t *testing.T) {
 	require.NotContains(t, string(b), "secret")
 	require.NotNil(t, h1)
 }
+
+// Suspending and resuming must both read as changes, so the pipeline reconcile runs
+// and the workloads rebuild; an unsuspended pipeline keeps its pre-field hash.
+func TestGetPipelineHashTracksSuspend(t *testing.T) {
+	vp := &v1alpha1.VectorPipeline{ObjectMeta: metav1.ObjectMeta{Name: "p", Namespace: "ns"}}
+	vp.Spec.Sources = &runtime.RawExtension{Raw: []byte(`{"s":{"type":"kubernetes_logs"}}`)}
+	active, err := GetPipelineHash(vp)
+	require.NoError(t, err)
+	b, _ := json.Marshal(tmp{Spec: vp.Spec})
+	require.NotContains(t, string(b), "suspend")
+
+	vp.Spec.Suspend = true
+	suspended, err := GetPipelineHash(vp)
+	require.NoError(t, err)
+	assert.NotEqual(t, *active, *suspended)
+
+	vp.SetLastAppliedPipeline(active)
+	unchanged, err := IsPipelineChanged(vp)
+	require.NoError(t, err)
+	assert.False(t, unchanged)
+}
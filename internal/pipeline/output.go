@@ -0,0 +1,179 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kaasops/vector-operator/api/v1alpha1"
+)
+
+// OutputRefKey is the key a pipeline sink names an output with, in place of the sink
+// definition. ExpandOutputs keeps it on the expanded sink, which is how the config
+// builders tell the sinks of one output apart from a pipeline's own.
+const OutputRefKey = "outputRef"
+
+// ErrInvalidOutputRef is returned for a sink whose outputRef is malformed or names an
+// output that does not exist or cannot be used by the pipeline. It is a problem with the
+// pipeline or the output, not with the cluster, and is reported on the pipeline.
+var ErrInvalidOutputRef = errors.New("invalid outputRef")
+
+// OutputRefs returns the name of the output each sink of spec references, keyed by
+// sink name. Only the shape is checked: outputRef must be a non-empty string, and the
+// only other key the sink may carry is inputs. Sinks that do not parse are left for
+// config.UnmarshalJson to report.
+func OutputRefs(spec v1alpha1.VectorPipelineSpec) (map[string]string, error) {
+	if spec.Sinks == nil {
+		return nil, nil
+	}
+	var sinks map[string]map[string]any
+	if err := json.Unmarshal(spec.Sinks.Raw, &sinks); err != nil {
+		return nil, nil
+	}
+	var refs map[string]string
+	for name, sink := range sinks {
+		v, ok := sink[OutputRefKey]
+		if !ok {
+			continue
+		}
+		ref, _ := v.(string)
+		if ref == "" {
+			return nil, fmt.Errorf("%w: sink %s: outputRef must be the name of an output", ErrInvalidOutputRef, name)
+		}
+		for k := range sink {
+			if k != OutputRefKey && k != "inputs" {
+				return nil, fmt.Errorf("%w: sink %s: only inputs can be set next to outputRef, got %s", ErrInvalidOutputRef, name, k)
+			}
+		}
+		if refs == nil {
+			refs = make(map[string]string)
+		}
+		refs[name] = ref
+	}
+	return refs, nil
+}
+
+// ExpandOutputs replaces every sink of p that references an output with the output's
+// sink definition, fed by the sink's own inputs, and adds the output's secret backends
+// to the pipeline's spec.secret. From then on the pipeline is one whose sink was written
+// inline: its SECRET[] references, its hash and its status all work as they always have,
+// and an edited output changes the hash of every pipeline using it.
+//
+// A VectorPipeline references VectorOutputs in its own namespace and a
+// ClusterVectorPipeline ClusterVectorOutputs, the same split as secret backends and
+// aggregators: an output's backends must follow the rules of the pipelines it serves.
+// An alias declared by both the pipeline and an output must name the same backend.
+//
+// Only the spec of p is changed, never the stored object. On error p is left untouched.
+func ExpandOutputs(ctx context.Context, c client.Reader, p Pipeline) error {
+	refs, err := OutputRefs(p.GetSpec())
+	if err != nil || len(refs) == 0 {
+		return err
+	}
+
+	spec := p.GetSpec()
+	var sinks map[string]map[string]any
+	if err := json.Unmarshal(spec.Sinks.Raw, &sinks); err != nil {
+		return err
+	}
+	secret := maps.Clone(spec.Secret)
+
+	_, isVP := p.(*v1alpha1.VectorPipeline)
+	kind := "ClusterVectorOutput"
+	if isVP {
+		kind = "VectorOutput"
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(refs)) {
+		ref := refs[name]
+		out, err := getOutput(ctx, c, isVP, p.GetNamespace(), ref)
+		if apierrors.IsNotFound(err) {
+			return fmt.Errorf("%w: sink %s: %s %q not found", ErrInvalidOutputRef, name, kind, ref)
+		}
+		if err != nil {
+			return err
+		}
+
+		var def map[string]any
+		if out.Sink != nil {
+			_ = json.Unmarshal(out.Sink.Raw, &def)
+		}
+		if _, ok := def["type"]; !ok {
+			return fmt.Errorf("%w: sink %s: %s %q: spec.sink has no type", ErrInvalidOutputRef, name, kind, ref)
+		}
+		for _, k := range []string{"inputs", OutputRefKey} {
+			if _, ok := def[k]; ok {
+				return fmt.Errorf("%w: sink %s: %s %q: spec.sink cannot set %s", ErrInvalidOutputRef, name, kind, ref, k)
+			}
+		}
+		if inputs, ok := sinks[name]["inputs"]; ok {
+			def["inputs"] = inputs
+		}
+		def[OutputRefKey] = ref
+		sinks[name] = def
+
+		for alias, backend := range out.Secret {
+			if isVP && backend.Namespace != "" {
+				return fmt.Errorf("%w: sink %s: %s %q: secret backend %q: namespace is not allowed", ErrInvalidOutputRef, name, kind, ref, alias)
+			}
+			if !isVP && backend.Namespace == "" {
+				return fmt.Errorf("%w: sink %s: %s %q: secret backend %q: namespace is required", ErrInvalidOutputRef, name, kind, ref, alias)
+			}
+			if have, ok := secret[alias]; ok && have != backend {
+				return fmt.Errorf("%w: sink %s: %s %q: secret backend %q is declared differently by the pipeline or another output", ErrInvalidOutputRef, name, kind, ref, alias)
+			}
+			if secret == nil {
+				secret = make(map[string]v1alpha1.PipelineSecretBackend)
+			}
+			secret[alias] = backend
+		}
+	}
+
+	raw, err := json.Marshal(sinks)
+	if err != nil {
+		return err
+	}
+	spec.Sinks = &runtime.RawExtension{Raw: raw}
+	spec.Secret = secret
+	switch obj := p.(type) {
+	case *v1alpha1.VectorPipeline:
+		obj.Spec = spec
+	case *v1alpha1.ClusterVectorPipeline:
+		obj.Spec = spec
+	}
+	return nil
+}
+
+// ReferencesOutput reports whether a sink of p references the output name. Used to
+// map an output change to the pipelines to revalidate.
+func ReferencesOutput(p Pipeline, name string) bool {
+	refs, _ := OutputRefs(p.GetSpec())
+	for _, ref := range refs {
+		if ref == name {
+			return true
+		}
+	}
+	return false
+}
+
+func getOutput(ctx context.Context, c client.Reader, namespaced bool, namespace, name string) (*v1alpha1.VectorOutputSpec, error) {
+	if namespaced {
+		vo := &v1alpha1.VectorOutput{}
+		if err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, vo); err != nil {
+			return nil, err
+		}
+		return &vo.Spec, nil
+	}
+	cvo := &v1alpha1.ClusterVectorOutput{}
+	if err := c.Get(ctx, client.ObjectKey{Name: name}, cvo); err != nil {
+		return nil, err
+	}
+	return &cvo.Spec, nil
+}
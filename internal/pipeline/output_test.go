@@ -0,0 +1,137 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/kaasops/vector-operator/api/v1alpha1"
+)
+
+func outputTestVP(sinks string, secret map[string]v1alpha1.PipelineSecretBackend) *v1alpha1.VectorPipeline {
+	return &v1alpha1.VectorPipeline{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "team-a"},
+		Spec: v1alpha1.VectorPipelineSpec{
+			Sources: &runtime.RawExtension{Raw: []byte(`{"logs":{"type":"kubernetes_logs"}}`)},
+			Sinks:   &runtime.RawExtension{Raw: []byte(sinks)},
+			Secret:  secret,
+		},
+	}
+}
+
+func testVectorOutput(namespace, name, sink string, secret map[string]v1alpha1.PipelineSecretBackend) *v1alpha1.VectorOutput {
+	return &v1alpha1.VectorOutput{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: v1alpha1.VectorOutputSpec{
+			Sink:   &runtime.RawExtension{Raw: []byte(sink)},
+			Secret: secret,
+		},
+	}
+}
+
+func sinksOf(t *testing.T, p Pipeline) map[string]map[string]any {
+	t.Helper()
+	var sinks map[string]map[string]any
+	require.NoError(t, json.Unmarshal(p.GetSpec().Sinks.Raw, &sinks))
+	return sinks
+}
+
+func TestOutputRefs(t *testing.T) {
+	refs, err := OutputRefs(outputTestVP(`{"es":{"outputRef":"central","inputs":["logs"]},"debug":{"type":"console","inputs":["logs"]}}`, nil).Spec)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"es": "central"}, refs)
+
+	_, err = OutputRefs(outputTestVP(`{"es":{"outputRef":"central","type":"console"}}`, nil).Spec)
+	require.ErrorIs(t, err, ErrInvalidOutputRef)
+	require.ErrorContains(t, err, "only inputs can be set next to outputRef")
+
+	_, err = OutputRefs(outputTestVP(`{"es":{"outputRef":{"name":"central"}}}`, nil).Spec)
+	require.ErrorIs(t, err, ErrInvalidOutputRef)
+
+	// malformed sinks are config.UnmarshalJson's to report
+	refs, err = OutputRefs(outputTestVP(`{"es":"console"}`, nil).Spec)
+	require.NoError(t, err)
+	require.Empty(t, refs)
+}
+
+func TestExpandOutputs(t *testing.T) {
+	creds := map[string]v1alpha1.PipelineSecretBackend{"es": {Type: "kubernetes_secret", Name: "es-creds"}}
+	c := newStatusTestClient(t,
+		testVectorOutput("team-a", "central", `{"type":"elasticsearch","endpoints":["https://es:9200"],"auth":{"password":"SECRET[es.password]"}}`, creds),
+		testVectorOutput("team-b", "other", `{"type":"console"}`, nil),
+	)
+
+	vp := outputTestVP(`{"es":{"outputRef":"central","inputs":["logs"]}}`, nil)
+	require.NoError(t, ExpandOutputs(context.Background(), c, vp))
+	require.Equal(t, map[string]any{
+		"type":      "elasticsearch",
+		"endpoints": []any{"https://es:9200"},
+		"auth":      map[string]any{"password": "SECRET[es.password]"},
+		"inputs":    []any{"logs"},
+		"outputRef": "central",
+	}, sinksOf(t, vp)["es"])
+	require.Equal(t, creds, vp.Spec.Secret)
+
+	// the hash covers the expanded output, so editing the output revalidates the pipeline
+	raw := outputTestVP(`{"es":{"outputRef":"central","inputs":["logs"]}}`, nil)
+	before, err := GetPipelineHash(raw)
+	require.NoError(t, err)
+	after, err := GetPipelineHash(vp)
+	require.NoError(t, err)
+	require.NotEqual(t, *before, *after)
+
+	// a VectorPipeline only sees outputs of its own namespace
+	vp = outputTestVP(`{"es":{"outputRef":"other","inputs":["logs"]}}`, nil)
+	err = ExpandOutputs(context.Background(), c, vp)
+	require.ErrorIs(t, err, ErrInvalidOutputRef)
+	require.ErrorContains(t, err, `VectorOutput "other" not found`)
+	require.JSONEq(t, `{"es":{"outputRef":"other","inputs":["logs"]}}`, string(vp.Spec.Sinks.Raw), "a failed expansion leaves the pipeline untouched")
+
+	// an alias the pipeline declares for another Secret is a conflict
+	vp = outputTestVP(`{"es":{"outputRef":"central","inputs":["logs"]}}`,
+		map[string]v1alpha1.PipelineSecretBackend{"es": {Type: "kubernetes_secret", Name: "mine"}})
+	require.ErrorContains(t, ExpandOutputs(context.Background(), c, vp), `secret backend "es" is declared differently`)
+}
+
+func TestExpandClusterOutputs(t *testing.T) {
+	c := newStatusTestClient(t,
+		&v1alpha1.ClusterVectorOutput{
+			ObjectMeta: metav1.ObjectMeta{Name: "loki"},
+			Spec: v1alpha1.VectorOutputSpec{
+				Sink:   &runtime.RawExtension{Raw: []byte(`{"type":"loki","endpoint":"http://loki:3100"}`)},
+				Secret: map[string]v1alpha1.PipelineSecretBackend{"loki": {Type: "kubernetes_secret", Name: "loki"}},
+			},
+		},
+	)
+	cvp := &v1alpha1.ClusterVectorPipeline{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+		Spec: v1alpha1.VectorPipelineSpec{
+			Sinks: &runtime.RawExtension{Raw: []byte(`{"out":{"outputRef":"loki","inputs":["logs"]}}`)},
+		},
+	}
+	err := ExpandOutputs(context.Background(), c, cvp)
+	require.ErrorIs(t, err, ErrInvalidOutputRef)
+	require.ErrorContains(t, err, `secret backend "loki": namespace is required`)
+}
+
+func TestGetValidPipelinesExpandsOutputs(t *testing.T) {
+	agent, valid := v1alpha1.VectorPipelineRoleAgent, true
+	withOutput := outputTestVP(`{"es":{"outputRef":"central","inputs":["logs"]}}`, nil)
+	withOutput.Status = v1alpha1.VectorPipelineStatus{Role: &agent, ConfigCheckResult: &valid}
+	orphaned := withOutput.DeepCopy()
+	orphaned.Name = "orphaned"
+	orphaned.Spec.Sinks = &runtime.RawExtension{Raw: []byte(`{"es":{"outputRef":"deleted","inputs":["logs"]}}`)}
+
+	c := newStatusTestClient(t, withOutput, orphaned, testVectorOutput("team-a", "central", `{"type":"console"}`, nil))
+	pipelines, err := GetValidPipelines(context.Background(), c, FilterPipelines{Scope: AllPipelines, Role: agent})
+	require.NoError(t, err)
+
+	// the pipeline whose output is gone is left out rather than failing the listing
+	require.Len(t, pipelines, 1)
+	require.Equal(t, "app", pipelines[0].GetName())
+	require.Equal(t, "console", sinksOf(t, pipelines[0])["es"]["type"])
+}
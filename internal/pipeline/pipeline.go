@@ -21,6 +21,7 @@ import (
 	"fmt"
 
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/utils/ptr"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -35,6 +36,8 @@ type Pipeline interface {
 	SetConfigCheck(bool)
 	SetReason(*string)
 	GetReason() *string
+	SetConfigCheckReport(*v1alpha1.ConfigCheckReport)
+	GetConfigCheckReport() *v1alpha1.ConfigCheckReport
 	GetLastAppliedPipeline() *int64
 	SetLastAppliedPipeline(*int64)
 	GetRelatedSecretsHash() *int64
@@ -45,6 +48,15 @@ type Pipeline interface {
 	GetRole() v1alpha1.VectorPipelineRole
 	SetRole(*v1alpha1.VectorPipelineRole)
 	GetTypeMeta() v1.TypeMeta
+	GetConditions() []v1.Condition
+	SetConditions([]v1.Condition)
+	MarkSucceeded()
+	MarkFailed(conditionType, reason, message string)
+	MarkSuspended()
+	GetWorkloads() []v1alpha1.WorkloadReference
+	SetWorkloads([]v1alpha1.WorkloadReference)
+	GetExcludedFrom() []v1alpha1.WorkloadReference
+	SetExcludedFrom([]v1alpha1.WorkloadReference)
 }
 
 type FilterPipelines struct {
@@ -52,6 +64,9 @@ type FilterPipelines struct {
 	Selector  *v1alpha1.VectorSelectorSpec
 	Role      v1alpha1.VectorPipelineRole
 	Namespace string
+	// Aggregator is the aggregator doing the listing, nil for a Vector. It is what a
+	// pipeline's spec.aggregatorRef is matched against (see Selects).
+	Aggregator *v1alpha1.AggregatorReference
 }
 
 type FilterScope int
@@ -79,9 +94,9 @@ func GetAllPipelines(ctx context.Context, client client.Client, filter FilterPip
 func listPipelines(ctx context.Context, client client.Client, filter FilterPipelines, requireValid bool) ([]Pipeline, error) {
 	var result []Pipeline
 
-	matchLabels := map[string]string{}
-	if filter.Selector != nil && filter.Selector.MatchLabels != nil {
-		matchLabels = filter.Selector.MatchLabels
+	selector, err := NewSelector(filter.Selector, filter.Aggregator)
+	if err != nil {
+		return nil, err
 	}
 
 	if filter.Scope == AllPipelines || filter.Scope == NamespacedPipeline {
@@ -94,16 +109,33 @@ func listPipelines(ctx context.Context, client client.Client, filter FilterPipel
 		if err != nil {
 			return nil, err
 		}
-		if len(vps) != 0 {
-			for _, vp := range vps {
-				if !vp.IsDeleted() &&
-					(!requireValid || vp.IsValid()) &&
-					vp.GetRole() == filter.Role &&
-					(filter.Scope == AllPipelines || vp.Namespace == filter.Namespace) &&
-					k8s.MatchLabels(matchLabels, vp.Labels) {
-					result = append(result, vp.DeepCopy())
+		nsLabels := map[string]labels.Set{}
+		for _, vp := range vps {
+			if vp.IsDeleted() ||
+				vp.Spec.Suspend ||
+				(requireValid && !vp.IsValid()) ||
+				vp.GetRole() != filter.Role ||
+				(filter.Scope != AllPipelines && vp.Namespace != filter.Namespace) {
+				continue
+			}
+			var set labels.Set
+			if selector.SelectsNamespaces() {
+				var ok bool
+				if set, ok = nsLabels[vp.Namespace]; !ok {
+					if set, err = NamespaceLabels(ctx, client, vp.Namespace); err != nil {
+						return nil, err
+					}
+					nsLabels[vp.Namespace] = set
 				}
 			}
+			if !selector.Matches(&vp, set) {
+				continue
+			}
+			if p, err := expanded(ctx, client, vp.DeepCopy()); err != nil {
+				return nil, err
+			} else if p != nil {
+				result = append(result, p)
+			}
 		}
 	}
 
@@ -115,10 +147,15 @@ func listPipelines(ctx context.Context, client client.Client, filter FilterPipel
 		if len(cvps) != 0 {
 			for _, cvp := range cvps {
 				if !cvp.IsDeleted() &&
+					!cvp.Spec.Suspend &&
 					(!requireValid || cvp.IsValid()) &&
 					cvp.GetRole() == filter.Role &&
-					k8s.MatchLabels(matchLabels, cvp.Labels) {
-					result = append(result, cvp.DeepCopy())
+					selector.Matches(&cvp, nil) {
+					if p, err := expanded(ctx, client, cvp.DeepCopy()); err != nil {
+						return nil, err
+					} else if p != nil {
+						result = append(result, p)
+					}
 				}
 			}
 		}
@@ -126,34 +163,152 @@ func listPipelines(ctx context.Context, client client.Client, filter FilterPipel
 	return result, nil
 }
 
+// expanded returns p expanded (Expand), or nil when its template or one of its outputs
+// no longer resolves: the pipeline was valid with what it was checked against, and the
+// pipeline controller, woken by the same change, marks it failed. Leaving it out
+// meanwhile keeps one missing object from failing every workload that lists it.
+func expanded(ctx context.Context, c client.Client, p Pipeline) (Pipeline, error) {
+	if err := Expand(ctx, c, p); err != nil {
+		if IsInvalidReference(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return p, nil
+}
+
 // base is the pipeline as it was read at the start of the reconcile, so the patch carries
 // every status field the reconcile has touched since, the role included. A merge patch only
 // clears the keys it mentions, and base can predate the reason it has to clear, so the base
 // carries a reason whatever it was read with.
+//
+// The conditions follow the same rule: a merge patch replaces a list whole, but only
+// when it differs from base, so base drops its conditions and the patch always carries
+// the full list - a stale base could otherwise hold exactly the list being written
+// while the stored one is a failure written since.
 func SetSuccessStatus(ctx context.Context, c client.Client, p Pipeline, base Pipeline) error {
+	before := k8s.FindCondition(p.GetConditions(), v1alpha1.ConditionReady)
 	base.SetReason(ptr.To(""))
+	base.SetConditions(nil)
+	base.SetConfigCheckReport(&v1alpha1.ConfigCheckReport{})
 	p.SetConfigCheck(true)
 	p.SetReason(nil)
+	p.SetConfigCheckReport(nil)
+	p.MarkSucceeded()
 	hash, err := GetPipelineHash(p)
 	if err != nil {
 		return err
 	}
 	p.SetLastAppliedPipeline(hash)
 
-	return k8s.PatchStatus(ctx, p, base, c)
+	if err := k8s.PatchStatus(ctx, p, base, c); err != nil {
+		return err
+	}
+	k8s.ConditionEvent(ctx, p, v1alpha1.ConditionReady, before, p.GetConditions(), k8s.EventActionPublish, "Pipeline is valid and part of the configs of its workloads")
+	return nil
 }
 
+// SetFailedStatus marks the pipeline invalid because of its config: the spec, the
+// config built from it, or the workload's configcheck. ConfigValid turns False.
 func SetFailedStatus(ctx context.Context, c client.Client, p Pipeline, reason string, base Pipeline) error {
+	return setFailedStatus(ctx, c, p, v1alpha1.ConditionConfigValid, v1alpha1.ReasonConfigInvalid, reason, nil, base)
+}
+
+// SetConfigCheckReportStatus is SetFailedStatus for a failed configcheck, recording its
+// report next to the reason.
+func SetConfigCheckReportStatus(ctx context.Context, c client.Client, p Pipeline, reason string, report *v1alpha1.ConfigCheckReport, base Pipeline) error {
+	return setFailedStatus(ctx, c, p, v1alpha1.ConditionConfigValid, v1alpha1.ReasonConfigInvalid, reason, report, base)
+}
+
+// SetTestsFailedStatus marks the pipeline invalid because its spec.tests fail, with the
+// report of the failed checks. ConfigValid turns False with ReasonTestsFailed.
+func SetTestsFailedStatus(ctx context.Context, c client.Client, p Pipeline, reason string, report *v1alpha1.ConfigCheckReport, base Pipeline) error {
+	return setFailedStatus(ctx, c, p, v1alpha1.ConditionConfigValid, v1alpha1.ReasonTestsFailed, reason, report, base)
+}
+
+// SetSecretsFailedStatus marks the pipeline invalid because of its SECRET[] references:
+// a Secret that does not resolve, or one of the workload-level attribution classes
+// (collision, assets size, waiting for room). SecretsResolved turns False with
+// conditionReason; ConfigValid keeps whatever the last config check said.
+func SetSecretsFailedStatus(ctx context.Context, c client.Client, p Pipeline, conditionReason, reason string, base Pipeline) error {
+	return setFailedStatus(ctx, c, p, v1alpha1.ConditionSecretsResolved, conditionReason, reason, nil, base)
+}
+
+// SetQuotaExceededStatus marks the pipeline left out of a workload's config because its
+// namespace is over a quota (config.DetectQuotaExclusions). The pipeline itself is
+// valid, so ConfigValid keeps the last verdict and Published turns False with
+// ReasonQuotaExceeded.
+func SetQuotaExceededStatus(ctx context.Context, c client.Client, p Pipeline, reason string, base Pipeline) error {
+	return setFailedStatus(ctx, c, p, v1alpha1.ConditionPublished, v1alpha1.ReasonQuotaExceeded, reason, nil, base)
+}
 
+// SetPolicyViolationStatus marks the pipeline invalid because a
+// ClusterVectorPipelinePolicy governing its namespace does not allow it. ConfigValid
+// turns False with ReasonPolicyViolation. No pipeline hash is recorded: the policy is not
+// part of it, and the round after the policy is relaxed has to run in full even though
+// the pipeline did not change.
+func SetPolicyViolationStatus(ctx context.Context, c client.Client, p Pipeline, reason string, base Pipeline) error {
+	before := k8s.FindCondition(p.GetConditions(), v1alpha1.ConditionReady)
+	base.SetConditions(nil)
+	base.SetConfigCheckReport(&v1alpha1.ConfigCheckReport{})
 	p.SetConfigCheck(false)
 	p.SetReason(&reason)
+	p.SetConfigCheckReport(nil)
+	p.MarkFailed(v1alpha1.ConditionConfigValid, v1alpha1.ReasonPolicyViolation, reason)
+	p.SetLastAppliedPipeline(nil)
+
+	if err := k8s.PatchStatus(ctx, p, base, c); err != nil {
+		return err
+	}
+	k8s.ConditionEvent(ctx, p, v1alpha1.ConditionReady, before, p.GetConditions(), k8s.EventActionCheckConfig, "")
+	return nil
+}
+
+// setFailedStatus writes a failure of conditionType. A ConfigValid failure replaces the
+// configcheck report with report, nil when the failure is not a failed check; the others
+// keep it along with the ConfigValid verdict it belongs to.
+func setFailedStatus(ctx context.Context, c client.Client, p Pipeline, conditionType, conditionReason, reason string, report *v1alpha1.ConfigCheckReport, base Pipeline) error {
+	before := k8s.FindCondition(p.GetConditions(), v1alpha1.ConditionReady)
+	base.SetConditions(nil)
+	if conditionType == v1alpha1.ConditionConfigValid {
+		base.SetConfigCheckReport(&v1alpha1.ConfigCheckReport{})
+		p.SetConfigCheckReport(report)
+	}
+	p.SetConfigCheck(false)
+	p.SetReason(&reason)
+	p.MarkFailed(conditionType, conditionReason, reason)
+	hash, err := GetPipelineHash(p)
+	if err != nil {
+		return err
+	}
+	p.SetLastAppliedPipeline(hash)
+
+	if err := k8s.PatchStatus(ctx, p, base, c); err != nil {
+		return err
+	}
+	k8s.ConditionEvent(ctx, p, v1alpha1.ConditionReady, before, p.GetConditions(), k8s.ConditionAction(conditionType), "")
+	return nil
+}
+
+// SetSuspendedStatus records that spec.suspend took the pipeline out of every config.
+// ConfigCheckResult and the reason are left as the last validating round wrote them, so
+// resuming an unchanged pipeline puts it straight back; LastAppliedPipelineHash covers
+// the flag, so resuming is still seen as a change.
+func SetSuspendedStatus(ctx context.Context, c client.Client, p Pipeline, base Pipeline) error {
+	before := k8s.FindCondition(p.GetConditions(), v1alpha1.ConditionSuspended)
+	base.SetConditions(nil)
+	p.MarkSuspended()
 	hash, err := GetPipelineHash(p)
 	if err != nil {
 		return err
 	}
 	p.SetLastAppliedPipeline(hash)
 
-	return k8s.PatchStatus(ctx, p, base, c)
+	if err := k8s.PatchStatus(ctx, p, base, c); err != nil {
+		return err
+	}
+	k8s.ConditionEvent(ctx, p, v1alpha1.ConditionSuspended, before, p.GetConditions(), k8s.EventActionSuspend, "Pipeline left out of every config: spec.suspend is set")
+	return nil
 }
 
 func GetVectorPipelines(ctx context.Context, client client.Client) ([]v1alpha1.VectorPipeline, error) {
@@ -0,0 +1,54 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kaasops/vector-operator/api/v1alpha1"
+)
+
+// ErrInvalidPolicy is returned for a ClusterVectorPipelinePolicy whose namespaceSelector
+// does not parse. Which namespaces it governs is then unknown, so it is taken to govern
+// every one: a broken policy must not open up what it was written to restrict.
+var ErrInvalidPolicy = errors.New("invalid ClusterVectorPipelinePolicy")
+
+// Policies returns the ClusterVectorPipelinePolicies governing p: for a VectorPipeline,
+// every policy whose namespaceSelector matches the labels of its namespace. A
+// ClusterVectorPipeline is governed by none.
+func Policies(ctx context.Context, c client.Reader, p Pipeline) ([]v1alpha1.ClusterVectorPipelinePolicy, error) {
+	if p.GetNamespace() == "" {
+		return nil, nil
+	}
+	list := &v1alpha1.ClusterVectorPipelinePolicyList{}
+	if err := c.List(ctx, list); err != nil {
+		return nil, err
+	}
+	if len(list.Items) == 0 {
+		return nil, nil
+	}
+	nsLabels, err := NamespaceLabels(ctx, c, p.GetNamespace())
+	if err != nil {
+		return nil, err
+	}
+
+	var result []v1alpha1.ClusterVectorPipelinePolicy
+	for _, policy := range list.Items {
+		if policy.Spec.NamespaceSelector == nil {
+			result = append(result, policy)
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(policy.Spec.NamespaceSelector)
+		if err != nil {
+			return nil, fmt.Errorf("%w %s: namespaceSelector: %w", ErrInvalidPolicy, policy.Name, err)
+		}
+		if selector.Matches(labels.Set(nsLabels)) {
+			result = append(result, policy)
+		}
+	}
+	return result, nil
+}
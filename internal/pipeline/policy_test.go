@@ -0,0 +1,49 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kaasops/vector-operator/api/v1alpha1"
+)
+
+func TestPolicies(t *testing.T) {
+	ctx := context.Background()
+	policy := func(name string, selector *metav1.LabelSelector) *v1alpha1.ClusterVectorPipelinePolicy {
+		return &v1alpha1.ClusterVectorPipelinePolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       v1alpha1.ClusterVectorPipelinePolicySpec{NamespaceSelector: selector},
+		}
+	}
+	tenant := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a", Labels: map[string]string{"tenant": "true"}}}
+	vp := &v1alpha1.VectorPipeline{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "team-a"}}
+	system := &v1alpha1.VectorPipeline{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "kube-system"}}
+	cvp := &v1alpha1.ClusterVectorPipeline{ObjectMeta: metav1.ObjectMeta{Name: "cluster"}}
+
+	cl := newStatusTestClient(t, tenant,
+		policy("all", nil),
+		policy("tenants", &metav1.LabelSelector{MatchLabels: map[string]string{"tenant": "true"}}),
+	)
+	names := func(p Pipeline) []string {
+		policies, err := Policies(ctx, cl, p)
+		require.NoError(t, err)
+		var names []string
+		for _, policy := range policies {
+			names = append(names, policy.Name)
+		}
+		return names
+	}
+	require.Equal(t, []string{"all", "tenants"}, names(vp))
+	require.Equal(t, []string{"all"}, names(system), "a namespace that is not labeled is governed by the unrestricted selector only")
+	require.Empty(t, names(cvp), "ClusterVectorPipelines are never governed")
+
+	broken := newStatusTestClient(t, tenant, policy("broken", &metav1.LabelSelector{
+		MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "tenant", Operator: "Matches"}},
+	}))
+	_, err := Policies(ctx, broken, system)
+	require.ErrorIs(t, err, ErrInvalidPolicy)
+}
@@ -0,0 +1,87 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kaasops/vector-operator/api/v1alpha1"
+)
+
+// ErrInvalidSelector is returned for a workload selector whose matchExpressions or
+// namespaceSelector do not parse. Only editing the workload fixes it.
+var ErrInvalidSelector = errors.New("invalid pipeline selector")
+
+// Selector is a workload's spec.selector in a form that can be evaluated against
+// pipelines, together with the identity spec.aggregatorRef is matched against.
+type Selector struct {
+	labels     labels.Selector
+	namespaces labels.Selector
+	aggregator *v1alpha1.AggregatorReference
+}
+
+// NewSelector parses spec. aggregator names the workload when it is an aggregator and
+// is nil for a Vector. A nil spec selects every pipeline, as an unset selector always has.
+func NewSelector(spec *v1alpha1.VectorSelectorSpec, aggregator *v1alpha1.AggregatorReference) (*Selector, error) {
+	s := &Selector{labels: labels.Everything(), aggregator: aggregator}
+	if spec == nil {
+		return s, nil
+	}
+	var err error
+	s.labels, err = metav1.LabelSelectorAsSelector(&metav1.LabelSelector{
+		MatchLabels:      spec.MatchLabels,
+		MatchExpressions: spec.MatchExpressions,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSelector, err)
+	}
+	if spec.NamespaceSelector != nil {
+		s.namespaces, err = metav1.LabelSelectorAsSelector(spec.NamespaceSelector)
+		if err != nil {
+			return nil, fmt.Errorf("%w: namespaceSelector: %w", ErrInvalidSelector, err)
+		}
+	}
+	return s, nil
+}
+
+// SelectsNamespaces reports whether Matches needs the labels of a VectorPipeline's
+// namespace, so callers only read the Namespace when it is consulted.
+func (s *Selector) SelectsNamespaces() bool {
+	return s.namespaces != nil
+}
+
+// Matches reports whether the workload picks up p. A pipeline with spec.aggregatorRef is
+// picked up by the aggregator it names and by nothing else, whatever the selector says;
+// any other pipeline by every workload whose selector matches its labels and, for a
+// VectorPipeline, whose namespaceSelector matches nsLabels, the labels of its namespace.
+func (s *Selector) Matches(p Pipeline, nsLabels labels.Set) bool {
+	if ref := p.GetSpec().AggregatorRef; ref != nil {
+		return s.aggregator != nil && *ref == *s.aggregator
+	}
+	if !s.labels.Matches(labels.Set(p.GetLabels())) {
+		return false
+	}
+	if s.namespaces != nil && p.GetNamespace() != "" {
+		return s.namespaces.Matches(nsLabels)
+	}
+	return true
+}
+
+// NamespaceLabels returns the labels of namespace. A namespace that is already gone has
+// none, so it only matches a namespaceSelector that selects everything.
+func NamespaceLabels(ctx context.Context, c client.Reader, namespace string) (labels.Set, error) {
+	ns := &corev1.Namespace{}
+	if err := c.Get(ctx, client.ObjectKey{Name: namespace}, ns); err != nil {
+		if apierrors.IsNotFound(err) {
+			return labels.Set{}, nil
+		}
+		return nil, err
+	}
+	return labels.Set(ns.Labels), nil
+}
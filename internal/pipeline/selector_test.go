@@ -0,0 +1,131 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/kaasops/vector-operator/api/v1alpha1"
+)
+
+func mustSelector(t *testing.T, spec *v1alpha1.VectorSelectorSpec, aggregator *v1alpha1.AggregatorReference) *Selector {
+	t.Helper()
+	s, err := NewSelector(spec, aggregator)
+	require.NoError(t, err)
+	return s
+}
+
+func TestSelectorMatchLabels(t *testing.T) {
+	spec := &v1alpha1.VectorSelectorSpec{MatchLabels: map[string]string{"team": "a"}}
+	labeled := &v1alpha1.VectorPipeline{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"team": "a"}}}
+
+	require.True(t, mustSelector(t, spec, nil).Matches(labeled, nil))
+	require.False(t, mustSelector(t, spec, nil).Matches(&v1alpha1.VectorPipeline{}, nil))
+	require.True(t, mustSelector(t, nil, nil).Matches(&v1alpha1.VectorPipeline{}, nil), "no selector matches everything")
+	require.True(t, mustSelector(t, &v1alpha1.VectorSelectorSpec{}, nil).Matches(&v1alpha1.VectorPipeline{}, nil), "an empty selector matches everything")
+}
+
+func TestSelectorMatchExpressions(t *testing.T) {
+	s := mustSelector(t, &v1alpha1.VectorSelectorSpec{
+		MatchLabels: map[string]string{"team": "a"},
+		MatchExpressions: []metav1.LabelSelectorRequirement{
+			{Key: "tier", Operator: metav1.LabelSelectorOpNotIn, Values: []string{"experimental"}},
+		},
+	}, nil)
+	withLabels := func(l map[string]string) Pipeline {
+		return &v1alpha1.ClusterVectorPipeline{ObjectMeta: metav1.ObjectMeta{Labels: l}}
+	}
+
+	require.True(t, s.Matches(withLabels(map[string]string{"team": "a"}), nil))
+	require.True(t, s.Matches(withLabels(map[string]string{"team": "a", "tier": "prod"}), nil))
+	require.False(t, s.Matches(withLabels(map[string]string{"team": "a", "tier": "experimental"}), nil))
+	require.False(t, s.Matches(withLabels(map[string]string{"tier": "prod"}), nil), "matchLabels and matchExpressions are ANDed")
+
+	_, err := NewSelector(&v1alpha1.VectorSelectorSpec{
+		MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "tier", Operator: "Near"}},
+	}, nil)
+	require.ErrorIs(t, err, ErrInvalidSelector)
+	_, err = NewSelector(&v1alpha1.VectorSelectorSpec{
+		NamespaceSelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "team", Operator: metav1.LabelSelectorOpIn}}},
+	}, nil)
+	require.ErrorIs(t, err, ErrInvalidSelector)
+	require.ErrorContains(t, err, "namespaceSelector")
+}
+
+func TestSelectorNamespaceSelector(t *testing.T) {
+	s := mustSelector(t, &v1alpha1.VectorSelectorSpec{
+		NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "payments"}},
+	}, nil)
+	require.True(t, s.SelectsNamespaces())
+	require.False(t, mustSelector(t, nil, nil).SelectsNamespaces())
+
+	vp := &v1alpha1.VectorPipeline{ObjectMeta: metav1.ObjectMeta{Namespace: "payments"}}
+	require.True(t, s.Matches(vp, labels.Set{"team": "payments"}))
+	require.False(t, s.Matches(vp, labels.Set{"team": "search"}))
+	require.False(t, s.Matches(vp, nil))
+	// a cluster pipeline has no namespace to select on
+	require.True(t, s.Matches(&v1alpha1.ClusterVectorPipeline{}, nil))
+}
+
+func TestSelectorAggregatorRef(t *testing.T) {
+	spec := &v1alpha1.VectorSelectorSpec{MatchLabels: map[string]string{"team": "a"}}
+	agg := &v1alpha1.AggregatorReference{Kind: "VectorAggregator", Name: "agg"}
+	other := &v1alpha1.AggregatorReference{Kind: "VectorAggregator", Name: "other"}
+
+	labeled := &v1alpha1.VectorPipeline{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"team": "a"}}}
+	require.True(t, mustSelector(t, spec, agg).Matches(labeled, nil))
+	require.True(t, mustSelector(t, nil, other).Matches(labeled, nil))
+
+	// a pinned pipeline ignores the selector both ways
+	pinned := &v1alpha1.VectorPipeline{Spec: v1alpha1.VectorPipelineSpec{AggregatorRef: agg.DeepCopy()}}
+	require.True(t, mustSelector(t, spec, agg).Matches(pinned, nil))
+	require.False(t, mustSelector(t, nil, other).Matches(pinned, nil))
+	require.False(t, mustSelector(t, nil, nil).Matches(pinned, nil), "a Vector never picks up a pinned pipeline")
+	require.False(t, mustSelector(t, nil, &v1alpha1.AggregatorReference{Kind: "ClusterVectorAggregator", Name: "agg"}).Matches(pinned, nil))
+}
+
+func TestGetValidPipelinesNamespaceSelector(t *testing.T) {
+	ctx := context.Background()
+	agent := v1alpha1.VectorPipelineRoleAgent
+	valid := true
+	vp := func(namespace string) *v1alpha1.VectorPipeline {
+		return &v1alpha1.VectorPipeline{
+			ObjectMeta: metav1.ObjectMeta{Name: "logs", Namespace: namespace},
+			Status:     v1alpha1.VectorPipelineStatus{Role: &agent, ConfigCheckResult: &valid},
+		}
+	}
+	cl := newStatusTestClient(t,
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "payments", Labels: map[string]string{"team": "payments"}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "search", Labels: map[string]string{"team": "search"}}},
+		vp("payments"), vp("search"), vp("deleted-namespace"),
+		&v1alpha1.ClusterVectorPipeline{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+			Status:     v1alpha1.VectorPipelineStatus{Role: &agent, ConfigCheckResult: &valid},
+		},
+	)
+
+	got, err := GetValidPipelines(ctx, cl, FilterPipelines{
+		Scope: AllPipelines,
+		Role:  agent,
+		Selector: &v1alpha1.VectorSelectorSpec{
+			NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "payments"}},
+		},
+	})
+	require.NoError(t, err)
+	var keys []string
+	for _, p := range got {
+		keys = append(keys, p.GetNamespace()+"/"+p.GetName())
+	}
+	require.ElementsMatch(t, []string{"payments/logs", "/cluster"}, keys)
+
+	_, err = GetValidPipelines(ctx, cl, FilterPipelines{
+		Scope:    AllPipelines,
+		Role:     agent,
+		Selector: &v1alpha1.VectorSelectorSpec{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "tier", Operator: "Near"}}},
+	})
+	require.ErrorIs(t, err, ErrInvalidSelector)
+}
@@ -5,13 +5,16 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/events"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	crfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	"github.com/kaasops/vector-operator/api/v1alpha1"
+	"github.com/kaasops/vector-operator/internal/utils/k8s"
 )
 
 func statusReason(p Pipeline) *string {
@@ -133,3 +136,196 @@ func TestSetSuccessStatusStalePipeline(t *testing.T) {
 		})
 	}
 }
+
+func newStatusTestClient(t *testing.T, objs ...client.Object) client.Client {
+	t.Helper()
+	s := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(s))
+	require.NoError(t, v1alpha1.AddToScheme(s))
+	return crfake.NewClientBuilder().
+		WithScheme(s).
+		WithStatusSubresource(&v1alpha1.VectorPipeline{}, &v1alpha1.ClusterVectorPipeline{}).
+		WithIndex(&v1alpha1.VectorPipeline{}, WorkloadsField, WorkloadsIndex).
+		WithIndex(&v1alpha1.ClusterVectorPipeline{}, WorkloadsField, WorkloadsIndex).
+		WithObjects(objs...).
+		Build()
+}
+
+func conditionStatus(p Pipeline, condType string) (metav1.ConditionStatus, string) {
+	c := meta.FindStatusCondition(p.GetConditions(), condType)
+	if c == nil {
+		return "", ""
+	}
+	return c.Status, c.Reason
+}
+
+// Each failure class turns its own condition False and takes Ready and Published with
+// it; a later success turns all of them back True. observedGeneration follows every write.
+func TestStatusConditions(t *testing.T) {
+	req := require.New(t)
+	ctx := context.Background()
+
+	seed := &v1alpha1.VectorPipeline{
+		ObjectMeta: metav1.ObjectMeta{Name: "pipeline", Namespace: "vector", Generation: 3},
+		Spec:       v1alpha1.VectorPipelineSpec{Secret: map[string]v1alpha1.PipelineSecretBackend{"es": {Type: "kubernetes_secret", Name: "creds"}}},
+	}
+	cl := newStatusTestClient(t, seed)
+	key := client.ObjectKeyFromObject(seed)
+
+	get := func() *v1alpha1.VectorPipeline {
+		p := &v1alpha1.VectorPipeline{}
+		req.NoError(cl.Get(ctx, key, p))
+		return p
+	}
+
+	p := get()
+	req.NoError(SetFailedStatus(ctx, cl, p, "config check failed", p.DeepCopy()))
+	result := get()
+	req.NotZero(result.Generation)
+	req.Equal(result.Generation, result.Status.ObservedGeneration)
+	for condType, want := range map[string]string{
+		v1alpha1.ConditionConfigValid: v1alpha1.ReasonConfigInvalid,
+		v1alpha1.ConditionPublished:   v1alpha1.ReasonNotPublished,
+		v1alpha1.ConditionReady:       v1alpha1.ReasonConfigInvalid,
+	} {
+		status, reason := conditionStatus(result, condType)
+		req.Equal(metav1.ConditionFalse, status, condType)
+		req.Equal(want, reason, condType)
+	}
+	req.Equal("config check failed", meta.FindStatusCondition(result.Status.Conditions, v1alpha1.ConditionReady).Message)
+
+	p = get()
+	req.NoError(SetSecretsFailedStatus(ctx, cl, p, v1alpha1.ReasonSecretKeyCollision, "secret collision", p.DeepCopy()))
+	result = get()
+	status, reason := conditionStatus(result, v1alpha1.ConditionSecretsResolved)
+	req.Equal(metav1.ConditionFalse, status)
+	req.Equal(v1alpha1.ReasonSecretKeyCollision, reason)
+	status, reason = conditionStatus(result, v1alpha1.ConditionReady)
+	req.Equal(metav1.ConditionFalse, status)
+	req.Equal(v1alpha1.ReasonSecretKeyCollision, reason)
+
+	p = get()
+	req.NoError(SetSuccessStatus(ctx, cl, p, p.DeepCopy()))
+	result = get()
+	req.Equal(result.Generation, result.Status.ObservedGeneration)
+	req.Len(result.Status.Conditions, 4)
+	for _, c := range result.Status.Conditions {
+		req.Equal(metav1.ConditionTrue, c.Status, c.Type)
+		req.Equal(result.Generation, c.ObservedGeneration, c.Type)
+	}
+	_, reason = conditionStatus(result, v1alpha1.ConditionSecretsResolved)
+	req.Equal(v1alpha1.ReasonSecretsResolved, reason)
+}
+
+// A status write emits an event when it changes the pipeline's verdict and stays quiet
+// when a round only writes the same verdict again.
+func TestStatusEvents(t *testing.T) {
+	req := require.New(t)
+	rec := events.NewFakeRecorder(10)
+	ctx := k8s.WithEventRecorder(context.Background(), rec)
+
+	seed := &v1alpha1.VectorPipeline{ObjectMeta: metav1.ObjectMeta{Name: "pipeline", Namespace: "vector"}}
+	cl := newStatusTestClient(t, seed)
+	key := client.ObjectKeyFromObject(seed)
+	get := func() *v1alpha1.VectorPipeline {
+		p := &v1alpha1.VectorPipeline{}
+		req.NoError(cl.Get(ctx, key, p))
+		return p
+	}
+	next := func() string {
+		select {
+		case e := <-rec.Events:
+			return e
+		default:
+			return ""
+		}
+	}
+
+	p := get()
+	req.NoError(SetFailedStatus(ctx, cl, p, "config check failed", p.DeepCopy()))
+	req.Equal("Warning "+v1alpha1.ReasonConfigInvalid+" config check failed", next())
+
+	p = get()
+	req.NoError(SetFailedStatus(ctx, cl, p, "config check failed", p.DeepCopy()))
+	req.Empty(next())
+
+	p = get()
+	req.NoError(SetSecretsFailedStatus(ctx, cl, p, v1alpha1.ReasonSecretKeyCollision, "secret collision", p.DeepCopy()))
+	req.Equal("Warning "+v1alpha1.ReasonSecretKeyCollision+" secret collision", next())
+
+	p = get()
+	req.NoError(SetSuccessStatus(ctx, cl, p, p.DeepCopy()))
+	req.Contains(next(), "Normal ")
+
+	p = get()
+	req.NoError(SetSuccessStatus(ctx, cl, p, p.DeepCopy()))
+	req.Empty(next())
+}
+
+// A success computed from a read that already showed every condition True has nothing
+// to change against that read, yet a failure written since has to be overwritten.
+func TestSetSuccessStatusRestoresUnobservedConditions(t *testing.T) {
+	req := require.New(t)
+	ctx := context.Background()
+
+	seed := &v1alpha1.ClusterVectorPipeline{ObjectMeta: metav1.ObjectMeta{Name: "cluster-pipeline"}}
+	cl := newStatusTestClient(t, seed)
+	key := client.ObjectKeyFromObject(seed)
+
+	p := &v1alpha1.ClusterVectorPipeline{}
+	req.NoError(cl.Get(ctx, key, p))
+	req.NoError(SetSuccessStatus(ctx, cl, p, p.DeepCopy()))
+
+	stale := &v1alpha1.ClusterVectorPipeline{}
+	req.NoError(cl.Get(ctx, key, stale))
+	base := stale.DeepCopy()
+
+	failing := stale.DeepCopy()
+	req.NoError(SetFailedStatus(ctx, cl, failing, "config check failed", failing.DeepCopy()))
+
+	req.NoError(SetSuccessStatus(ctx, cl, stale, base))
+
+	result := &v1alpha1.ClusterVectorPipeline{}
+	req.NoError(cl.Get(ctx, key, result))
+	req.True(meta.IsStatusConditionTrue(result.Status.Conditions, v1alpha1.ConditionReady))
+	req.True(meta.IsStatusConditionTrue(result.Status.Conditions, v1alpha1.ConditionConfigValid))
+	req.True(meta.IsStatusConditionTrue(result.Status.Conditions, v1alpha1.ConditionPublished))
+	_, reason := conditionStatus(result, v1alpha1.ConditionSecretsResolved)
+	req.Equal(v1alpha1.ReasonNoSecrets, reason)
+}
+
+// The configcheck report goes with the ConfigValid verdict it explains: a secrets
+// failure keeps it, and a success or another ConfigValid failure clears it, even from a
+// read that predates it.
+func TestConfigCheckReportStatus(t *testing.T) {
+	req := require.New(t)
+	ctx := context.Background()
+
+	seed := &v1alpha1.VectorPipeline{ObjectMeta: metav1.ObjectMeta{Name: "pipeline", Namespace: "vector"}}
+	cl := newStatusTestClient(t, seed)
+	key := client.ObjectKeyFromObject(seed)
+	get := func() *v1alpha1.VectorPipeline {
+		p := &v1alpha1.VectorPipeline{}
+		req.NoError(cl.Get(ctx, key, p))
+		return p
+	}
+	report := &v1alpha1.ConfigCheckReport{Errors: []v1alpha1.ConfigCheckError{{Component: "vector-pipeline-out", Kind: "Component", Message: "invalid endpoint"}}}
+
+	stale := get()
+	p := get()
+	req.NoError(SetConfigCheckReportStatus(ctx, cl, p, "configcheck failed", report, p.DeepCopy()))
+	req.Equal(report, get().Status.ConfigCheckReport)
+
+	p = get()
+	req.NoError(SetSecretsFailedStatus(ctx, cl, p, v1alpha1.ReasonSecretResolveFailed, "secret missing", p.DeepCopy()))
+	req.Equal(report, get().Status.ConfigCheckReport)
+
+	req.NoError(SetSuccessStatus(ctx, cl, stale, stale.DeepCopy()))
+	req.Nil(get().Status.ConfigCheckReport)
+
+	p = get()
+	req.NoError(SetConfigCheckReportStatus(ctx, cl, p, "configcheck failed", report, p.DeepCopy()))
+	p = get()
+	req.NoError(SetFailedStatus(ctx, cl, p, "spec does not parse", p.DeepCopy()))
+	req.Nil(get().Status.ConfigCheckReport)
+}
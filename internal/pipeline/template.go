@@ -0,0 +1,195 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kaasops/vector-operator/api/v1alpha1"
+)
+
+// ErrInvalidTemplateRef is returned for a spec.templateRef that cannot be rendered: the
+// template does not exist, a parameter is unbound or does not parse, or the template
+// refers to a parameter it does not declare. Reported on the pipeline.
+var ErrInvalidTemplateRef = errors.New("invalid templateRef")
+
+// templateParamRegex matches $(name), and $$(name) - the escape for a literal $(name).
+// Neither ${} (environment interpolation) nor {{ }} (event field templates) is used, so
+// a template can carry both through to Vector untouched.
+var templateParamRegex = regexp.MustCompile(`\$?\$\(([A-Za-z_][A-Za-z0-9_]*)\)`)
+
+// ValidateTemplateRef checks what can be checked of spec.templateRef without the
+// template: the rendered sections must not also be written out.
+func ValidateTemplateRef(spec v1alpha1.VectorPipelineSpec) error {
+	if spec.TemplateRef == nil {
+		return nil
+	}
+	if spec.Sources != nil || spec.Transforms != nil || spec.Sinks != nil {
+		return fmt.Errorf("%w: sources, transforms and sinks are rendered from template %s and cannot be set", ErrInvalidTemplateRef, spec.TemplateRef.Name)
+	}
+	return nil
+}
+
+// ExpandTemplate renders the template p references into its sources, transforms and
+// sinks. Everything downstream - config building, secrets, the hash - then sees an
+// ordinary pipeline, and an edited template changes the hash of every pipeline rendered
+// from it. A pipeline without spec.templateRef is left as it is; on error p is left
+// untouched.
+func ExpandTemplate(ctx context.Context, c client.Reader, p Pipeline) error {
+	spec := p.GetSpec()
+	if spec.TemplateRef == nil {
+		return nil
+	}
+	if err := ValidateTemplateRef(spec); err != nil {
+		return err
+	}
+
+	tpl := &v1alpha1.VectorPipelineTemplate{}
+	if err := c.Get(ctx, client.ObjectKey{Name: spec.TemplateRef.Name}, tpl); err != nil {
+		if apierrors.IsNotFound(err) {
+			return fmt.Errorf("%w: VectorPipelineTemplate %q not found", ErrInvalidTemplateRef, spec.TemplateRef.Name)
+		}
+		return err
+	}
+	values, err := bindTemplateParameters(tpl.Spec.Parameters, spec.TemplateRef.Values)
+	if err != nil {
+		return fmt.Errorf("%w: template %s: %w", ErrInvalidTemplateRef, tpl.Name, err)
+	}
+
+	sections := []struct {
+		from *runtime.RawExtension
+		to   **runtime.RawExtension
+	}{
+		{tpl.Spec.Sources, &spec.Sources},
+		{tpl.Spec.Transforms, &spec.Transforms},
+		{tpl.Spec.Sinks, &spec.Sinks},
+	}
+	for _, s := range sections {
+		if s.from == nil {
+			continue
+		}
+		var doc any
+		if err := json.Unmarshal(s.from.Raw, &doc); err != nil {
+			return fmt.Errorf("%w: template %s: %w", ErrInvalidTemplateRef, tpl.Name, err)
+		}
+		rendered, err := renderTemplateValue(doc, values)
+		if err != nil {
+			return fmt.Errorf("%w: template %s: %w", ErrInvalidTemplateRef, tpl.Name, err)
+		}
+		raw, err := json.Marshal(rendered)
+		if err != nil {
+			return err
+		}
+		*s.to = &runtime.RawExtension{Raw: raw}
+	}
+
+	switch obj := p.(type) {
+	case *v1alpha1.VectorPipeline:
+		obj.Spec = spec
+	case *v1alpha1.ClusterVectorPipeline:
+		obj.Spec = spec
+	}
+	return nil
+}
+
+// bindTemplateParameters resolves every declared parameter to a typed value: the
+// pipeline's value, else the default. Every parameter must end up bound, and a value
+// for a parameter the template does not declare is an error rather than silently
+// ignored - it is most likely a typo of one that then falls back to its default.
+func bindTemplateParameters(params []v1alpha1.TemplateParameter, set map[string]string) (map[string]any, error) {
+	declared := make(map[string]struct{}, len(params))
+	values := make(map[string]any, len(params))
+	for _, param := range params {
+		declared[param.Name] = struct{}{}
+		raw, ok := set[param.Name]
+		if !ok {
+			if param.Default == nil {
+				return nil, fmt.Errorf("parameter %q is not bound: set it in templateRef.values", param.Name)
+			}
+			raw = *param.Default
+		}
+		var v any
+		var err error
+		switch param.Type {
+		case "integer":
+			v, err = strconv.ParseInt(raw, 10, 64)
+		case "boolean":
+			v, err = strconv.ParseBool(raw)
+		default:
+			v = raw
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parameter %q: %q is not a valid %s", param.Name, raw, param.Type)
+		}
+		values[param.Name] = v
+	}
+	for name := range set {
+		if _, ok := declared[name]; !ok {
+			return nil, fmt.Errorf("parameter %q is not declared by the template", name)
+		}
+	}
+	return values, nil
+}
+
+// renderTemplateValue substitutes parameters throughout doc, map keys included. A
+// string that is exactly one placeholder becomes the parameter's typed value.
+func renderTemplateValue(doc any, values map[string]any) (any, error) {
+	switch v := doc.(type) {
+	case string:
+		if m := templateParamRegex.FindStringSubmatch(v); m != nil && m[0] == v && v[1] == '(' {
+			value, ok := values[m[1]]
+			if !ok {
+				return nil, fmt.Errorf("$(%s) is not a declared parameter", m[1])
+			}
+			return value, nil
+		}
+		return renderTemplateString(v, values)
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, item := range v {
+			key, err := renderTemplateString(k, values)
+			if err != nil {
+				return nil, err
+			}
+			if out[key], err = renderTemplateValue(item, values); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			var err error
+			if out[i], err = renderTemplateValue(item, values); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+	default:
+		return v, nil
+	}
+}
+
+func renderTemplateString(s string, values map[string]any) (string, error) {
+	var err error
+	out := templateParamRegex.ReplaceAllStringFunc(s, func(m string) string {
+		if m[1] == '$' {
+			return m[1:]
+		}
+		name := m[2 : len(m)-1]
+		value, ok := values[name]
+		if !ok {
+			err = fmt.Errorf("$(%s) is not a declared parameter", name)
+			return m
+		}
+		return fmt.Sprint(value)
+	})
+	return out, err
+}
@@ -0,0 +1,112 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+
+	"github.com/kaasops/vector-operator/api/v1alpha1"
+)
+
+func testTemplate() *v1alpha1.VectorPipelineTemplate {
+	return &v1alpha1.VectorPipelineTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "es-per-team"},
+		Spec: v1alpha1.VectorPipelineTemplateSpec{
+			Parameters: []v1alpha1.TemplateParameter{
+				{Name: "app", Type: "string"},
+				{Name: "index", Type: "string"},
+				{Name: "retention_days", Type: "integer", Default: ptr.To("7")},
+				{Name: "compress", Type: "boolean", Default: ptr.To("true")},
+			},
+			Sources: &runtime.RawExtension{Raw: []byte(`{"logs":{"type":"kubernetes_logs","extra_label_selector":"app=$(app)"}}`)},
+			Sinks: &runtime.RawExtension{Raw: []byte(`{"es-$(app)":{"type":"elasticsearch","inputs":["logs"],` +
+				`"bulk":{"index":"$(index)-{{ kubernetes.pod_name }}"},"retention":"$(retention_days)","compression":"$(compress)",` +
+				`"literal":"$$(app) ${HOME}"}}`)},
+		},
+	}
+}
+
+func templatedVP(values map[string]string) *v1alpha1.VectorPipeline {
+	return &v1alpha1.VectorPipeline{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "team-a"},
+		Spec: v1alpha1.VectorPipelineSpec{
+			TemplateRef: &v1alpha1.TemplateReference{Name: "es-per-team", Values: values},
+		},
+	}
+}
+
+func TestExpandTemplate(t *testing.T) {
+	c := newStatusTestClient(t, testTemplate())
+
+	vp := templatedVP(map[string]string{"app": "web", "index": "team-a", "retention_days": "30"})
+	require.NoError(t, ExpandTemplate(context.Background(), c, vp))
+	require.JSONEq(t, `{"logs":{"type":"kubernetes_logs","extra_label_selector":"app=web"}}`, string(vp.Spec.Sources.Raw))
+	require.Nil(t, vp.Spec.Transforms)
+	// whole-string placeholders take the parameter's type; Vector's own {{ }} and ${}
+	// templating and the $$() escape pass through
+	require.JSONEq(t, `{"es-web":{"type":"elasticsearch","inputs":["logs"],"bulk":{"index":"team-a-{{ kubernetes.pod_name }}"},`+
+		`"retention":30,"compression":true,"literal":"$(app) ${HOME}"}}`, string(vp.Spec.Sinks.Raw))
+
+	// the rendered spec is what gets hashed, so a template edit revalidates the pipeline
+	before, err := GetPipelineHash(vp)
+	require.NoError(t, err)
+	tpl := testTemplate()
+	tpl.Spec.Parameters[2].Default = ptr.To("14")
+	c = newStatusTestClient(t, tpl)
+	vp = templatedVP(map[string]string{"app": "web", "index": "team-a"})
+	require.NoError(t, ExpandTemplate(context.Background(), c, vp))
+	after, err := GetPipelineHash(vp)
+	require.NoError(t, err)
+	require.NotEqual(t, *before, *after)
+}
+
+func TestExpandTemplateErrors(t *testing.T) {
+	c := newStatusTestClient(t, testTemplate())
+	for name, tc := range map[string]struct {
+		vp   *v1alpha1.VectorPipeline
+		want string
+	}{
+		"unbound":    {templatedVP(map[string]string{"app": "web"}), `parameter "index" is not bound`},
+		"undeclared": {templatedVP(map[string]string{"app": "web", "index": "i", "indx": "i"}), `parameter "indx" is not declared`},
+		"mistyped":   {templatedVP(map[string]string{"app": "web", "index": "i", "compress": "yes please"}), `"yes please" is not a valid boolean`},
+		"missing": {&v1alpha1.VectorPipeline{Spec: v1alpha1.VectorPipelineSpec{
+			TemplateRef: &v1alpha1.TemplateReference{Name: "nope"}}}, `VectorPipelineTemplate "nope" not found`},
+		"inline sections": {&v1alpha1.VectorPipeline{Spec: v1alpha1.VectorPipelineSpec{
+			TemplateRef: &v1alpha1.TemplateReference{Name: "es-per-team"},
+			Sinks:       &runtime.RawExtension{Raw: []byte(`{}`)}}}, "cannot be set"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			before := tc.vp.DeepCopy()
+			err := ExpandTemplate(context.Background(), c, tc.vp)
+			require.ErrorIs(t, err, ErrInvalidTemplateRef)
+			require.ErrorContains(t, err, tc.want)
+			require.Equal(t, before, tc.vp, "a failed expansion leaves the pipeline untouched")
+		})
+	}
+
+	tpl := testTemplate()
+	tpl.Spec.Sources = &runtime.RawExtension{Raw: []byte(`{"logs":{"type":"kubernetes_logs","extra_label_selector":"team=$(team)"}}`)}
+	err := ExpandTemplate(context.Background(), newStatusTestClient(t, tpl), templatedVP(map[string]string{"app": "web", "index": "i"}))
+	require.ErrorIs(t, err, ErrInvalidTemplateRef)
+	require.ErrorContains(t, err, "$(team) is not a declared parameter")
+}
+
+// A template's sinks can name outputs, which Expand resolves after rendering.
+func TestExpandTemplateThenOutputs(t *testing.T) {
+	tpl := &v1alpha1.VectorPipelineTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "es-per-team"},
+		Spec: v1alpha1.VectorPipelineTemplateSpec{
+			Parameters: []v1alpha1.TemplateParameter{{Name: "output"}},
+			Sources:    &runtime.RawExtension{Raw: []byte(`{"logs":{"type":"kubernetes_logs"}}`)},
+			Sinks:      &runtime.RawExtension{Raw: []byte(`{"es":{"outputRef":"$(output)","inputs":["logs"]}}`)},
+		},
+	}
+	c := newStatusTestClient(t, tpl, testVectorOutput("team-a", "central", `{"type":"console"}`, nil))
+	vp := templatedVP(map[string]string{"output": "central"})
+	require.NoError(t, Expand(context.Background(), c, vp))
+	require.Equal(t, "console", sinksOf(t, vp)["es"]["type"])
+}
@@ -0,0 +1,163 @@
+package pipeline
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kaasops/vector-operator/api/v1alpha1"
+)
+
+// WorkloadsField indexes the pipelines by the workloads in their .status.workloads, so
+// that SyncWorkload reads only the pipelines a workload is listed in. IndexWorkloads
+// registers it with the manager.
+const WorkloadsField = "status.workloads"
+
+// IndexWorkloads registers WorkloadsField for both pipeline kinds.
+func IndexWorkloads(ctx context.Context, indexer client.FieldIndexer) error {
+	for _, obj := range []client.Object{&v1alpha1.VectorPipeline{}, &v1alpha1.ClusterVectorPipeline{}} {
+		if err := indexer.IndexField(ctx, obj, WorkloadsField, WorkloadsIndex); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WorkloadsIndex is the WorkloadsField index function.
+func WorkloadsIndex(obj client.Object) []string {
+	p, ok := obj.(Pipeline)
+	if !ok {
+		return nil
+	}
+	keys := make([]string, 0, len(p.GetWorkloads()))
+	for _, w := range p.GetWorkloads() {
+		keys = append(keys, workloadKey(w))
+	}
+	return keys
+}
+
+func workloadKey(w v1alpha1.WorkloadReference) string {
+	return w.Kind + "/" + w.Namespace + "/" + w.Name
+}
+
+// SyncWorkload records in .status.workloads of every pipeline whether the config the
+// workload just published includes it: the workload is added to the pipelines in
+// included and removed from every other pipeline that still lists it. A workload being
+// deleted passes no pipelines and so drops out everywhere. Only the pipelines in
+// included and those listing the workload (WorkloadsField) are read, and only those
+// whose list changes are written.
+//
+// Every workload writes the same list, and a merge patch replaces a list whole, so each
+// write is guarded by the pipeline's resourceVersion and retried on a fresh read:
+// otherwise two aggregators publishing at once would drop each other's entry.
+func SyncWorkload(ctx context.Context, c client.Client, workload v1alpha1.WorkloadReference, included []Pipeline) error {
+	want := make(map[client.ObjectKey]struct{}, len(included))
+	for _, p := range included {
+		want[client.ObjectKeyFromObject(p)] = struct{}{}
+	}
+
+	listed, err := listWorkloadPipelines(ctx, c, workload)
+	if err != nil {
+		return err
+	}
+	for _, p := range listed {
+		if _, include := want[client.ObjectKeyFromObject(p)]; include {
+			continue
+		}
+		if err := setWorkload(ctx, c, p, includedIn, workload, false); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+	for _, p := range included {
+		if slices.Contains(p.GetWorkloads(), workload) {
+			continue
+		}
+		// the caller's copy is left as it was
+		p = p.DeepCopyObject().(Pipeline)
+		if err := setWorkload(ctx, c, p, includedIn, workload, true); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExcludeFromWorkload records in .status.excludedFrom of p that the configcheck of
+// workload fails with p, so that the workload leaves p out of its config. It reports
+// whether workload was not listed yet. The pipeline controller clears the list when it
+// validates p again.
+func ExcludeFromWorkload(ctx context.Context, c client.Client, p Pipeline, workload v1alpha1.WorkloadReference) (bool, error) {
+	if slices.Contains(p.GetExcludedFrom(), workload) {
+		return false, nil
+	}
+	return true, setWorkload(ctx, c, p, excludedFrom, workload, true)
+}
+
+// ExcludedFrom reports whether workload leaves p out of its config, see
+// ExcludeFromWorkload.
+func ExcludedFrom(p Pipeline, workload v1alpha1.WorkloadReference) bool {
+	return slices.Contains(p.GetExcludedFrom(), workload)
+}
+
+// workloadList is one of the lists of workloads in a pipeline's status.
+type workloadList struct {
+	get func(Pipeline) []v1alpha1.WorkloadReference
+	set func(Pipeline, []v1alpha1.WorkloadReference)
+}
+
+var (
+	includedIn   = workloadList{get: Pipeline.GetWorkloads, set: Pipeline.SetWorkloads}
+	excludedFrom = workloadList{get: Pipeline.GetExcludedFrom, set: Pipeline.SetExcludedFrom}
+)
+
+// setWorkload adds workload to, or removes it from, list of p.
+func setWorkload(ctx context.Context, c client.Client, p Pipeline, list workloadList, workload v1alpha1.WorkloadReference, include bool) error {
+	first := true
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if !first {
+			if err := c.Get(ctx, client.ObjectKeyFromObject(p), p); err != nil {
+				return err
+			}
+		}
+		first = false
+
+		base := p.DeepCopyObject().(Pipeline)
+		workloads := slices.DeleteFunc(slices.Clone(list.get(p)), func(w v1alpha1.WorkloadReference) bool {
+			return w == workload
+		})
+		if include {
+			workloads = append(workloads, workload)
+			slices.SortFunc(workloads, func(a, b v1alpha1.WorkloadReference) int {
+				return cmp.Or(cmp.Compare(a.Kind, b.Kind), cmp.Compare(a.Namespace, b.Namespace), cmp.Compare(a.Name, b.Name))
+			})
+		}
+		if len(workloads) == 0 {
+			workloads = nil
+		}
+		list.set(p, workloads)
+		return c.Status().Patch(ctx, p, client.MergeFromWithOptions(base, client.MergeFromWithOptimisticLock{}))
+	})
+}
+
+// listWorkloadPipelines returns the pipelines whose .status.workloads lists workload.
+func listWorkloadPipelines(ctx context.Context, c client.Client, workload v1alpha1.WorkloadReference) ([]Pipeline, error) {
+	byWorkload := client.MatchingFields{WorkloadsField: workloadKey(workload)}
+	vps := v1alpha1.VectorPipelineList{}
+	if err := c.List(ctx, &vps, byWorkload); err != nil {
+		return nil, err
+	}
+	cvps := v1alpha1.ClusterVectorPipelineList{}
+	if err := c.List(ctx, &cvps, byWorkload); err != nil {
+		return nil, err
+	}
+	result := make([]Pipeline, 0, len(vps.Items)+len(cvps.Items))
+	for i := range vps.Items {
+		result = append(result, &vps.Items[i])
+	}
+	for i := range cvps.Items {
+		result = append(result, &cvps.Items[i])
+	}
+	return result, nil
+}
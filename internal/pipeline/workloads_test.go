@@ -0,0 +1,83 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kaasops/vector-operator/api/v1alpha1"
+)
+
+func TestGetValidPipelinesHonoursAggregatorRef(t *testing.T) {
+	ctx := context.Background()
+	aggregator := v1alpha1.VectorPipelineRoleAggregator
+	valid := true
+	vp := func(name string, ref *v1alpha1.AggregatorReference) *v1alpha1.VectorPipeline {
+		return &v1alpha1.VectorPipeline{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "team-a"},
+			Spec:       v1alpha1.VectorPipelineSpec{AggregatorRef: ref},
+			Status:     v1alpha1.VectorPipelineStatus{Role: &aggregator, ConfigCheckResult: &valid},
+		}
+	}
+	cl := newStatusTestClient(t,
+		vp("unpinned", nil),
+		vp("pinned-a", &v1alpha1.AggregatorReference{Kind: "VectorAggregator", Name: "a"}),
+		vp("pinned-b", &v1alpha1.AggregatorReference{Kind: "VectorAggregator", Name: "b"}),
+	)
+
+	got, err := GetValidPipelines(ctx, cl, FilterPipelines{
+		Scope:      NamespacedPipeline,
+		Role:       aggregator,
+		Namespace:  "team-a",
+		Aggregator: &v1alpha1.AggregatorReference{Kind: "VectorAggregator", Name: "a"},
+	})
+	require.NoError(t, err)
+	var names []string
+	for _, p := range got {
+		names = append(names, p.GetName())
+	}
+	require.ElementsMatch(t, []string{"unpinned", "pinned-a"}, names)
+}
+
+// Each workload only adds and removes its own entry, so one aggregator publishing
+// never drops another's.
+func TestSyncWorkload(t *testing.T) {
+	req := require.New(t)
+	ctx := context.Background()
+
+	agent := v1alpha1.WorkloadReference{Kind: "Vector", Namespace: "vector", Name: "agent"}
+	agg := v1alpha1.WorkloadReference{Kind: "ClusterVectorAggregator", Name: "agg"}
+
+	kept := &v1alpha1.VectorPipeline{
+		ObjectMeta: metav1.ObjectMeta{Name: "kept", Namespace: "team-a"},
+		Status:     v1alpha1.VectorPipelineStatus{Workloads: []v1alpha1.WorkloadReference{agent}},
+	}
+	dropped := &v1alpha1.VectorPipeline{
+		ObjectMeta: metav1.ObjectMeta{Name: "dropped", Namespace: "team-a"},
+		Status:     v1alpha1.VectorPipelineStatus{Workloads: []v1alpha1.WorkloadReference{agent}},
+	}
+	cluster := &v1alpha1.ClusterVectorPipeline{ObjectMeta: metav1.ObjectMeta{Name: "cluster"}}
+	cl := newStatusTestClient(t, kept, dropped, cluster)
+
+	workloads := func(obj Pipeline) []v1alpha1.WorkloadReference {
+		req.NoError(cl.Get(ctx, client.ObjectKeyFromObject(obj), obj))
+		return obj.GetWorkloads()
+	}
+
+	req.NoError(SyncWorkload(ctx, cl, agg, []Pipeline{kept, cluster}))
+	req.Equal([]v1alpha1.WorkloadReference{agg, agent}, workloads(&v1alpha1.VectorPipeline{ObjectMeta: kept.ObjectMeta}))
+	req.Equal([]v1alpha1.WorkloadReference{agg}, workloads(&v1alpha1.ClusterVectorPipeline{ObjectMeta: cluster.ObjectMeta}))
+	req.Equal([]v1alpha1.WorkloadReference{agent}, workloads(&v1alpha1.VectorPipeline{ObjectMeta: dropped.ObjectMeta}))
+
+	req.NoError(SyncWorkload(ctx, cl, agent, []Pipeline{kept}))
+	req.Equal([]v1alpha1.WorkloadReference{agg, agent}, workloads(&v1alpha1.VectorPipeline{ObjectMeta: kept.ObjectMeta}))
+	req.Empty(workloads(&v1alpha1.VectorPipeline{ObjectMeta: dropped.ObjectMeta}))
+
+	// a deleted workload drops out everywhere
+	req.NoError(SyncWorkload(ctx, cl, agg, nil))
+	req.Equal([]v1alpha1.WorkloadReference{agent}, workloads(&v1alpha1.VectorPipeline{ObjectMeta: kept.ObjectMeta}))
+	req.Empty(workloads(&v1alpha1.ClusterVectorPipeline{ObjectMeta: cluster.ObjectMeta}))
+}
@@ -0,0 +1,541 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package render builds the configs the operator would publish for a set of
+// manifests, without a cluster: pipelines are validated, expanded, selected and
+// built by the same code the reconcilers run, over an in-memory client holding
+// nothing but the manifests.
+package render
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/types"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kaasops/vector-operator/api/v1alpha1"
+	"github.com/kaasops/vector-operator/internal/common"
+	"github.com/kaasops/vector-operator/internal/config"
+	"github.com/kaasops/vector-operator/internal/pipeline"
+	"github.com/kaasops/vector-operator/internal/vector/aggregator"
+	"github.com/kaasops/vector-operator/internal/vector/vectoragent"
+)
+
+// Options are the operator flags that change the generated configs.
+type Options struct {
+	// OptimizeSources is -enable-config-optimization.
+	OptimizeSources bool
+	// EnrichMetadata is -enable-metadata-enrichment.
+	EnrichMetadata bool
+}
+
+// Config is the config one workload would publish.
+type Config struct {
+	Kind      string
+	Namespace string
+	Name      string
+	// JSON is the config as it goes into the workload's config Secret, and
+	// VectorConfig the config it is marshaled from; both are nil when the build failed
+	// with Err.
+	JSON         []byte
+	VectorConfig *config.VectorConfig
+	// Pipelines are the pipelines in the config, as "namespace/name" for a
+	// VectorPipeline and "name" for a ClusterVectorPipeline.
+	Pipelines []string
+	Err       error
+}
+
+// ID is "Kind namespace/name", or "Kind name" for a cluster-scoped workload.
+func (c Config) ID() string {
+	return objectID(c.Kind, c.Namespace, c.Name)
+}
+
+// Problem is a pipeline left out of the configs: of every config when it is invalid,
+// as the pipeline controller would mark it, or of the config of Workload only when
+// that workload has no room for it.
+type Problem struct {
+	Pipeline string
+	Workload string
+	Reason   string
+}
+
+func (p Problem) String() string {
+	if p.Workload == "" {
+		return fmt.Sprintf("%s is invalid: %s", p.Pipeline, p.Reason)
+	}
+	return fmt.Sprintf("%s is left out of %s: %s", p.Pipeline, p.Workload, p.Reason)
+}
+
+// Result is what Render builds.
+type Result struct {
+	// Configs holds one entry per Vector, VectorAggregator and ClusterVectorAggregator,
+	// in that order and by namespace and name.
+	Configs  []Config
+	Problems []Problem
+	// Placeholders are the Secrets pipelines reference that are not among the
+	// manifests, as "namespace/name". The configs only name secret values, so they
+	// come out the same; every key referenced resolves to an empty value.
+	Placeholders []string
+}
+
+// Failed reports whether a pipeline is invalid or left out, or a config did not build.
+func (r *Result) Failed() bool {
+	return len(r.Problems) > 0 || slices.ContainsFunc(r.Configs, func(c Config) bool { return c.Err != nil })
+}
+
+var scheme = runtime.NewScheme()
+
+func init() {
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		panic(err)
+	}
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		panic(err)
+	}
+}
+
+// Decode reads the objects Render uses from a stream of YAML or JSON documents:
+// Namespaces (for their labels), Secrets, and the operator's own kinds. Any other
+// document, such as a Deployment or a kind this scheme does not know, is skipped, so a
+// whole GitOps tree can be read as it is. Namespaced objects without a namespace get
+// namespace, as kubectl would give them the namespace of its context.
+func Decode(r io.Reader, namespace string) ([]client.Object, error) {
+	decoder := serializer.NewCodecFactory(scheme).UniversalDeserializer()
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(r))
+	var objects []client.Object
+	for {
+		doc, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return objects, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+		obj, _, err := decoder.Decode(doc, nil, nil)
+		if runtime.IsNotRegisteredError(err) || runtime.IsMissingKind(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch obj := obj.(type) {
+		case *corev1.Secret, *v1alpha1.Vector, *v1alpha1.VectorAggregator, *v1alpha1.VectorPipeline, *v1alpha1.VectorOutput:
+			o := obj.(client.Object)
+			if o.GetNamespace() == "" {
+				o.SetNamespace(namespace)
+			}
+			objects = append(objects, o)
+		case *corev1.Namespace, *v1alpha1.ClusterVectorAggregator, *v1alpha1.ClusterVectorPipeline,
+			*v1alpha1.VectorPipelineTemplate, *v1alpha1.ClusterVectorOutput, *v1alpha1.ClusterVectorPipelinePolicy:
+			objects = append(objects, obj.(client.Object))
+		}
+	}
+}
+
+// Render builds the config of every workload among objects. It follows a workload
+// reconcile: pipelines are validated as the pipeline controller validates them (spec,
+// template and outputs, policies) and the invalid ones left out, then each workload
+// lists the pipelines its selector, role and aggregatorRef pick up, admits them against
+// the namespace quotas and secret flat-key collisions, and builds its config with the
+// same parameters as its controller. What only a cluster can tell - configcheck,
+// secret-assets size limits, pipelines held back while the assets Secret catches up -
+// is not modelled.
+func Render(ctx context.Context, objects []client.Object, opts Options) (*Result, error) {
+	seen := make(map[string]struct{}, len(objects))
+	for _, obj := range objects {
+		gvk, _, err := scheme.ObjectKinds(obj)
+		if err != nil {
+			return nil, err
+		}
+		id := objectID(gvk[0].Kind, obj.GetNamespace(), obj.GetName())
+		if _, ok := seen[id]; ok {
+			return nil, fmt.Errorf("%s is defined more than once", id)
+		}
+		seen[id] = struct{}{}
+	}
+
+	// The workload and pipeline code reads through a client. The fake client serves it
+	// from memory; without a status subresource, Update writes the role the validation
+	// computes as the pipeline controller would.
+	r := &renderer{
+		c:            fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build(),
+		opts:         opts,
+		placeholders: make(map[types.NamespacedName][]string),
+		result:       &Result{},
+	}
+	if err := r.validatePipelines(ctx); err != nil {
+		return nil, err
+	}
+	if err := r.addPlaceholders(ctx); err != nil {
+		return nil, err
+	}
+	if err := r.renderWorkloads(ctx); err != nil {
+		return nil, err
+	}
+	return r.result, nil
+}
+
+type renderer struct {
+	c            client.Client
+	opts         Options
+	placeholders map[types.NamespacedName][]string
+	result       *Result
+}
+
+func (r *renderer) validatePipelines(ctx context.Context) error {
+	vps := &v1alpha1.VectorPipelineList{}
+	if err := r.c.List(ctx, vps); err != nil {
+		return err
+	}
+	cvps := &v1alpha1.ClusterVectorPipelineList{}
+	if err := r.c.List(ctx, cvps); err != nil {
+		return err
+	}
+	var all []pipeline.Pipeline
+	for i := range vps.Items {
+		vps.Items[i].Status = v1alpha1.VectorPipelineStatus{}
+		all = append(all, &vps.Items[i])
+	}
+	for i := range cvps.Items {
+		cvps.Items[i].Status = v1alpha1.VectorPipelineStatus{}
+		all = append(all, &cvps.Items[i])
+	}
+
+	for _, p := range all {
+		if p.GetSpec().Suspend {
+			continue
+		}
+		role, err := r.validate(ctx, p)
+		if err != nil {
+			r.result.Problems = append(r.result.Problems, Problem{Pipeline: pipelineID(p), Reason: err.Error()})
+			if err := r.c.Delete(ctx, p); err != nil {
+				return err
+			}
+			continue
+		}
+		p.SetRole(role)
+		if err := r.c.Update(ctx, p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validate checks p as the pipeline controller does: its spec expanded with its
+// template and outputs (config.ValidatePipeline), then against the policies governing
+// its namespace. It notes the Secrets p references that are missing for
+// addPlaceholders and returns the role of p.
+func (r *renderer) validate(ctx context.Context, p pipeline.Pipeline) (*v1alpha1.VectorPipelineRole, error) {
+	expanded := p.DeepCopyObject().(pipeline.Pipeline)
+	if err := pipeline.Expand(ctx, r.c, expanded); err != nil {
+		return nil, err
+	}
+	// the template is rendered in, so the spec is checked as written out
+	switch obj := expanded.(type) {
+	case *v1alpha1.VectorPipeline:
+		obj.Spec.TemplateRef = nil
+	case *v1alpha1.ClusterVectorPipeline:
+		obj.Spec.TemplateRef = nil
+	}
+	role, err := config.ValidatePipeline(expanded)
+	if err != nil {
+		return nil, err
+	}
+	policies, err := pipeline.Policies(ctx, r.c, expanded)
+	if err != nil {
+		return nil, err
+	}
+	if len(policies) > 0 {
+		cfg := &config.PipelineConfig{}
+		if err := config.UnmarshalJson(expanded.GetSpec(), cfg); err != nil {
+			return nil, err
+		}
+		if err := config.CheckPolicies(policies, cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	keys, err := config.SecretKeys(expanded)
+	if err != nil {
+		return nil, err
+	}
+	for alias, names := range keys {
+		backend := expanded.GetSpec().Secret[alias]
+		key := types.NamespacedName{Namespace: expanded.GetNamespace(), Name: backend.Name}
+		if key.Namespace == "" {
+			key.Namespace = backend.Namespace
+		}
+		err := r.c.Get(ctx, key, &corev1.Secret{})
+		if apierrors.IsNotFound(err) {
+			r.placeholders[key] = append(r.placeholders[key], names...)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return role, nil
+}
+
+// addPlaceholders stands in a Secret for every one the pipelines reference and the
+// manifests leave out, holding an empty value under every key referenced.
+func (r *renderer) addPlaceholders(ctx context.Context) error {
+	for key, names := range r.placeholders {
+		secret := &corev1.Secret{Data: make(map[string][]byte, len(names))}
+		secret.Namespace, secret.Name = key.Namespace, key.Name
+		for _, name := range names {
+			secret.Data[name] = []byte{}
+		}
+		if err := r.c.Create(ctx, secret); err != nil {
+			return err
+		}
+		r.result.Placeholders = append(r.result.Placeholders, key.String())
+	}
+	slices.Sort(r.result.Placeholders)
+	return nil
+}
+
+// workload is what a workload reconciler lists pipelines with and builds its config from.
+type workload struct {
+	kind, namespace, name string
+	filter                pipeline.FilterPipelines
+	build                 func([]pipeline.Pipeline) (*config.VectorConfig, []byte, error)
+}
+
+func (r *renderer) renderWorkloads(ctx context.Context) error {
+	getter := r.secretGetter(ctx)
+	policies := func(_ context.Context, p pipeline.Pipeline) ([]v1alpha1.ClusterVectorPipelinePolicy, error) {
+		return pipeline.Policies(ctx, r.c, p)
+	}
+	var workloads []workload
+
+	vectors := &v1alpha1.VectorList{}
+	if err := r.c.List(ctx, vectors); err != nil {
+		return err
+	}
+	for i := range vectors.Items {
+		v := vectoragent.NewController(&vectors.Items[i], nil, nil).Vector
+		params := config.VectorConfigParams{
+			ApiEnabled:           v.Spec.Agent.Api.Enabled,
+			PlaygroundEnabled:    v.Spec.Agent.Api.Playground,
+			UseApiServerCache:    v.Spec.UseApiServerCache,
+			InternalMetrics:      v.Spec.Agent.InternalMetrics,
+			ExpireMetricsSecs:    v.Spec.Agent.ExpireMetricsSecs,
+			OptimizeSources:      r.opts.OptimizeSources && v.Annotations[common.AnnotationConfigOptimization] != common.AnnotationValueDisabled,
+			EnrichMetadata:       r.opts.EnrichMetadata,
+			PipelineSecretGetter: getter,
+			PipelinePolicies:     policies,
+		}
+		workloads = append(workloads, workload{
+			kind: "Vector", namespace: v.Namespace, name: v.Name,
+			filter: pipeline.FilterPipelines{
+				Scope:    pipeline.AllPipelines,
+				Selector: v.Spec.Selector,
+				Role:     v1alpha1.VectorPipelineRoleAgent,
+			},
+			build: func(pipelines []pipeline.Pipeline) (*config.VectorConfig, []byte, error) {
+				return config.BuildAgentConfig(params, pipelines...)
+			},
+		})
+	}
+
+	aggregators := &v1alpha1.VectorAggregatorList{}
+	if err := r.c.List(ctx, aggregators); err != nil {
+		return err
+	}
+	for i := range aggregators.Items {
+		v := &aggregators.Items[i]
+		agg := aggregator.NewController(v, nil, nil)
+		workloads = append(workloads, workload{
+			kind: "VectorAggregator", namespace: v.Namespace, name: v.Name,
+			filter: pipeline.FilterPipelines{
+				Scope:      pipeline.NamespacedPipeline,
+				Selector:   v.Spec.Selector,
+				Role:       v1alpha1.VectorPipelineRoleAggregator,
+				Namespace:  v.Namespace,
+				Aggregator: &v1alpha1.AggregatorReference{Kind: "VectorAggregator", Name: v.Name},
+			},
+			build: buildAggregator(config.VectorConfigParams{
+				AggregatorName:       agg.Name,
+				ApiEnabled:           agg.Spec.Api.Enabled,
+				PlaygroundEnabled:    agg.Spec.Api.Playground,
+				InternalMetrics:      agg.Spec.InternalMetrics,
+				ExpireMetricsSecs:    agg.Spec.ExpireMetricsSecs,
+				EnrichMetadata:       r.opts.EnrichMetadata,
+				PipelineSecretGetter: getter,
+				PipelinePolicies:     policies,
+				KubernetesEventsTLS:  agg.Spec.EventCollector.TLS,
+			}),
+		})
+	}
+
+	clusterAggregators := &v1alpha1.ClusterVectorAggregatorList{}
+	if err := r.c.List(ctx, clusterAggregators); err != nil {
+		return err
+	}
+	for i := range clusterAggregators.Items {
+		v := &clusterAggregators.Items[i]
+		agg := aggregator.NewController(v, nil, nil)
+		workloads = append(workloads, workload{
+			kind: "ClusterVectorAggregator", name: v.Name,
+			filter: pipeline.FilterPipelines{
+				Scope:      pipeline.ClusterPipelines,
+				Selector:   v.Spec.Selector,
+				Role:       v1alpha1.VectorPipelineRoleAggregator,
+				Aggregator: &v1alpha1.AggregatorReference{Kind: "ClusterVectorAggregator", Name: v.Name},
+			},
+			build: buildAggregator(config.VectorConfigParams{
+				AggregatorName:       agg.Name,
+				ApiEnabled:           agg.Spec.Api.Enabled,
+				PlaygroundEnabled:    agg.Spec.Api.Playground,
+				InternalMetrics:      agg.Spec.InternalMetrics,
+				ExpireMetricsSecs:    agg.Spec.ExpireMetricsSecs,
+				EnrichMetadata:       r.opts.EnrichMetadata,
+				PipelineSecretGetter: getter,
+				KubernetesEventsTLS:  agg.Spec.EventCollector.TLS,
+			}),
+		})
+	}
+
+	for _, w := range workloads {
+		cfg, err := r.render(ctx, w, policies, getter)
+		if err != nil {
+			return err
+		}
+		r.result.Configs = append(r.result.Configs, cfg)
+	}
+	return nil
+}
+
+// render lists the pipelines of w and builds its config. Only a failure to read the
+// in-memory objects is returned; everything the workload's status would report is
+// left in the Config.
+func (r *renderer) render(
+	ctx context.Context,
+	w workload,
+	policies func(context.Context, pipeline.Pipeline) ([]v1alpha1.ClusterVectorPipelinePolicy, error),
+	getter func(context.Context, string, string) (*corev1.Secret, error),
+) (Config, error) {
+	cfg := Config{Kind: w.kind, Namespace: w.namespace, Name: w.name}
+	pipelines, err := pipeline.GetAllPipelines(ctx, r.c, w.filter)
+	if errors.Is(err, pipeline.ErrInvalidSelector) {
+		cfg.Err = err
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+	slices.SortFunc(pipelines, func(a, b pipeline.Pipeline) int {
+		return cmp.Compare(pipelineID(a), pipelineID(b))
+	})
+
+	exclusions, err := config.DetectQuotaExclusions(ctx, policies, pipelines...)
+	if err != nil {
+		return cfg, err
+	}
+	left := make(map[pipeline.Pipeline]string, len(exclusions))
+	for _, ex := range exclusions {
+		left[ex.Victim] = fmt.Sprintf("namespace quota exceeded: %s of ClusterVectorPipelinePolicy %s: the older pipelines of the namespace use %d, this one adds %d, and the quota is %d",
+			ex.Limit, ex.Policy, ex.Used, ex.Requested, ex.Max)
+	}
+	admitted := slices.DeleteFunc(slices.Clone(pipelines), func(p pipeline.Pipeline) bool {
+		_, ok := left[p]
+		return ok
+	})
+	// a scan that fails is reported by the build, as it is in the reconcilers
+	if collisions, err := config.DetectSecretCollisions(getter, admitted...); err == nil {
+		for _, col := range collisions {
+			left[col.Victim] = fmt.Sprintf("secret flat key %q collides with pipeline %s", col.FlatKey, col.Survivor)
+		}
+	}
+	admitted = slices.DeleteFunc(admitted, func(p pipeline.Pipeline) bool {
+		_, ok := left[p]
+		return ok
+	})
+	for _, p := range pipelines {
+		if reason, ok := left[p]; ok {
+			r.result.Problems = append(r.result.Problems, Problem{Pipeline: pipelineID(p), Workload: cfg.ID(), Reason: reason})
+		}
+	}
+
+	cfg.VectorConfig, cfg.JSON, cfg.Err = w.build(admitted)
+	if cfg.Err != nil {
+		cfg.VectorConfig, cfg.JSON = nil, nil
+		return cfg, nil
+	}
+	for _, p := range admitted {
+		name := p.GetName()
+		if p.GetNamespace() != "" {
+			name = p.GetNamespace() + "/" + name
+		}
+		cfg.Pipelines = append(cfg.Pipelines, name)
+	}
+	return cfg, nil
+}
+
+func buildAggregator(params config.VectorConfigParams) func([]pipeline.Pipeline) (*config.VectorConfig, []byte, error) {
+	return func(pipelines []pipeline.Pipeline) (*config.VectorConfig, []byte, error) {
+		cfg, err := config.BuildAggregatorConfig(params, pipelines...)
+		if err != nil {
+			return nil, nil, err
+		}
+		data, err := cfg.MarshalJSON()
+		return cfg, data, err
+	}
+}
+
+func (r *renderer) secretGetter(ctx context.Context) func(context.Context, string, string) (*corev1.Secret, error) {
+	return func(_ context.Context, namespace, name string) (*corev1.Secret, error) {
+		secret := &corev1.Secret{}
+		if err := r.c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, secret); err != nil {
+			return nil, err
+		}
+		return secret, nil
+	}
+}
+
+// pipelineID is "Kind namespace/name", or "Kind name" for a ClusterVectorPipeline.
+func pipelineID(p pipeline.Pipeline) string {
+	kind := "ClusterVectorPipeline"
+	if _, ok := p.(*v1alpha1.VectorPipeline); ok {
+		kind = "VectorPipeline"
+	}
+	return objectID(kind, p.GetNamespace(), p.GetName())
+}
+
+func objectID(kind, namespace, name string) string {
+	if namespace == "" {
+		return kind + " " + name
+	}
+	return kind + " " + namespace + "/" + name
+}
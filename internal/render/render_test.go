@@ -0,0 +1,207 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package render
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kaasops/vector-operator/api/v1alpha1"
+	"github.com/kaasops/vector-operator/internal/config"
+)
+
+const manifests = `
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: team-a
+  labels:
+    logs: "true"
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: team-a
+---
+apiVersion: example.com/v1
+kind: Unknown
+metadata:
+  name: skipped
+---
+apiVersion: observability.kaasops.io/v1alpha1
+kind: Vector
+metadata:
+  name: agent
+spec:
+  agent: {}
+  selector:
+    namespaceSelector:
+      matchLabels:
+        logs: "true"
+---
+apiVersion: observability.kaasops.io/v1alpha1
+kind: VectorAggregator
+metadata:
+  name: central
+  namespace: vector
+spec: {}
+---
+apiVersion: observability.kaasops.io/v1alpha1
+kind: VectorPipeline
+metadata:
+  name: web
+  namespace: team-a
+spec:
+  secret:
+    loki:
+      type: kubernetes_secret
+      name: loki-auth
+  sources:
+    logs:
+      type: kubernetes_logs
+  sinks:
+    out:
+      type: http
+      inputs: ["logs"]
+      uri: http://loki:3100
+      auth:
+        strategy: bearer
+        token: SECRET[loki.token]
+---
+apiVersion: observability.kaasops.io/v1alpha1
+kind: VectorPipeline
+metadata:
+  name: elsewhere
+  namespace: team-b
+spec:
+  sources:
+    logs:
+      type: kubernetes_logs
+  sinks:
+    out:
+      type: blackhole
+      inputs: ["logs"]
+---
+apiVersion: observability.kaasops.io/v1alpha1
+kind: VectorPipeline
+metadata:
+  name: broken
+  namespace: team-a
+spec:
+  sources:
+    logs:
+      type: kubernetes_logs
+      extra_namespace_label_selector: kubernetes.io/metadata.name=team-b
+  sinks:
+    out:
+      type: blackhole
+      inputs: ["logs"]
+---
+apiVersion: observability.kaasops.io/v1alpha1
+kind: VectorPipeline
+metadata:
+  name: ingest
+  namespace: vector
+spec:
+  sources:
+    in:
+      type: vector
+      address: 0.0.0.0:9000
+  sinks:
+    out:
+      type: blackhole
+      inputs: ["in"]
+`
+
+func TestRender(t *testing.T) {
+	objects, err := Decode(strings.NewReader(manifests), "vector")
+	require.NoError(t, err)
+	require.Len(t, objects, 7, "the Deployment and the unknown kind are skipped")
+	assert.Equal(t, "vector", objects[1].GetNamespace(), "a namespaced object without a namespace gets the default")
+
+	result, err := Render(context.Background(), objects, Options{EnrichMetadata: true})
+	require.NoError(t, err)
+	require.Len(t, result.Configs, 2)
+	assert.True(t, result.Failed())
+	assert.Equal(t, []Problem{{Pipeline: "VectorPipeline team-a/broken", Reason: result.Problems[0].Reason}}, result.Problems)
+	assert.Equal(t, []string{"team-a/loki-auth"}, result.Placeholders)
+
+	agent := result.Configs[0]
+	require.NoError(t, agent.Err)
+	assert.Equal(t, "Vector vector/agent", agent.ID())
+	assert.Equal(t, []string{"team-a/web"}, agent.Pipelines, "team-b is not selected, and the aggregator pipeline is not an agent's")
+
+	// the same bytes as a reconcile building from the pipeline as stored
+	web := objects[3].(*v1alpha1.VectorPipeline).DeepCopy()
+	_, want, err := config.BuildAgentConfig(config.VectorConfigParams{
+		EnrichMetadata: true,
+		PipelineSecretGetter: func(context.Context, string, string) (*corev1.Secret, error) {
+			return &corev1.Secret{Data: map[string][]byte{"token": {}}}, nil
+		},
+	}, web)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(want), string(agent.JSON))
+	assert.Contains(t, string(agent.JSON), "SECRET[k8s.team_a_web_loki_token]")
+
+	aggregator := result.Configs[1]
+	require.NoError(t, aggregator.Err)
+	assert.Equal(t, "VectorAggregator vector/central", aggregator.ID())
+	assert.Equal(t, []string{"vector/ingest"}, aggregator.Pipelines)
+}
+
+func TestRenderTemplatesAndQuotas(t *testing.T) {
+	template := &v1alpha1.VectorPipelineTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "logs"},
+		Spec: v1alpha1.VectorPipelineTemplateSpec{
+			Sources: &runtime.RawExtension{Raw: []byte(`{"logs": {"type": "kubernetes_logs"}}`)},
+			Sinks:   &runtime.RawExtension{Raw: []byte(`{"out": {"type": "blackhole", "inputs": ["logs"]}}`)},
+		},
+	}
+	pipeline := func(name string, created int64) *v1alpha1.VectorPipeline {
+		return &v1alpha1.VectorPipeline{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "team-a", CreationTimestamp: metav1.Unix(created, 0)},
+			Spec:       v1alpha1.VectorPipelineSpec{TemplateRef: &v1alpha1.TemplateReference{Name: "logs"}},
+		}
+	}
+	policy := &v1alpha1.ClusterVectorPipelinePolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "tenants"},
+		Spec:       v1alpha1.ClusterVectorPipelinePolicySpec{Quota: &v1alpha1.PipelineQuota{MaxPipelines: ptr.To(int64(1))}},
+	}
+	vector := &v1alpha1.Vector{ObjectMeta: metav1.ObjectMeta{Name: "agent", Namespace: "vector"}}
+
+	result, err := Render(context.Background(), []client.Object{template, pipeline("old", 1), pipeline("young", 2), policy, vector}, Options{})
+	require.NoError(t, err)
+	require.Len(t, result.Configs, 1)
+	assert.Equal(t, []string{"team-a/old"}, result.Configs[0].Pipelines, "templated pipelines are built expanded")
+	require.Len(t, result.Problems, 1)
+	assert.Equal(t, "VectorPipeline team-a/young", result.Problems[0].Pipeline)
+	assert.Equal(t, "Vector vector/agent", result.Problems[0].Workload)
+	assert.Contains(t, result.Problems[0].Reason, "maxPipelines")
+
+	_, err = Render(context.Background(), []client.Object{vector, vector.DeepCopy()}, Options{})
+	require.Error(t, err, "an object defined twice")
+}
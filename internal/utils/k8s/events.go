@@ -0,0 +1,207 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/time/rate"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/events"
+
+	"github.com/kaasops/vector-operator/api/v1alpha1"
+)
+
+// maxEventNoteLength is the API server's limit on the note of an events.k8s.io Event.
+// Notes carrying configcheck output can exceed it, and an over-long event is dropped.
+const maxEventNoteLength = 1024
+
+// Actions of the events the status helpers emit, one per stage a condition describes.
+const (
+	EventActionCheckConfig    = "CheckConfig"
+	EventActionResolveSecrets = "ResolveSecrets"
+	EventActionPublish        = "Publish"
+	EventActionSuspend        = "Suspend"
+)
+
+// ConditionAction is the action of an event about a condType failure.
+func ConditionAction(condType string) string {
+	switch condType {
+	case v1alpha1.ConditionSecretsResolved:
+		return EventActionResolveSecrets
+	case v1alpha1.ConditionPublished:
+		return EventActionPublish
+	}
+	return EventActionCheckConfig
+}
+
+type eventRecorderKey struct{}
+
+// WithEventRecorder returns a context the status helpers emit events through. The
+// recorder travels with the context, like the logger does, because the helpers that
+// write status - and so know when a condition transitions - are shared by every
+// reconciler and sit several calls below the one that owns the recorder.
+func WithEventRecorder(ctx context.Context, recorder events.EventRecorder) context.Context {
+	if recorder == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, eventRecorderKey{}, recorder)
+}
+
+// Eventf emits an event about regarding through the recorder of ctx, if any. The note is
+// cut to the API server's limit.
+func Eventf(ctx context.Context, regarding runtime.Object, eventtype, reason, action, note string, args ...any) {
+	recorder, ok := ctx.Value(eventRecorderKey{}).(events.EventRecorder)
+	if !ok {
+		return
+	}
+	if len(args) > 0 {
+		note = fmt.Sprintf(note, args...)
+	}
+	if len(note) > maxEventNoteLength {
+		// cut on a rune boundary: the API server rejects a note that is not valid UTF-8
+		n := maxEventNoteLength - len("...")
+		for n > 0 && !utf8.RuneStart(note[n]) {
+			n--
+		}
+		note = note[:n] + "..."
+	}
+	recorder.Eventf(regarding, nil, eventtype, reason, action, "%s", note)
+}
+
+// FindCondition returns a copy of the condType condition in conditions, or nil. Status
+// helpers take it before they mutate the conditions, for ConditionEvent.
+func FindCondition(conditions []metav1.Condition, condType string) *metav1.Condition {
+	c := meta.FindStatusCondition(conditions, condType)
+	if c == nil {
+		return nil
+	}
+	copied := *c
+	return &copied
+}
+
+// ConditionEvent emits the event of a status write that moved obj's condType condition
+// from before to its state in conditions. Nothing is emitted when status, reason and
+// message are unchanged, so a reconcile that writes the same verdict again stays quiet.
+// A condition that turned True gets a Normal event with readyNote; a False one gets a
+// Warning with its own reason and message, also when it was False already for another
+// reason - a pipeline that fails differently is news too.
+func ConditionEvent(ctx context.Context, obj runtime.Object, condType string, before *metav1.Condition, conditions []metav1.Condition, action, readyNote string) {
+	after := meta.FindStatusCondition(conditions, condType)
+	if after == nil {
+		return
+	}
+	if before != nil && before.Status == after.Status && before.Reason == after.Reason && before.Message == after.Message {
+		return
+	}
+	if after.Status == metav1.ConditionTrue {
+		if before == nil || before.Status != metav1.ConditionTrue {
+			Eventf(ctx, obj, corev1.EventTypeNormal, after.Reason, action, readyNote)
+		}
+		return
+	}
+	Eventf(ctx, obj, corev1.EventTypeWarning, after.Reason, action, after.Message)
+}
+
+// PublishedEvent emits the event of a workload's successful round: Published when it
+// wrote a new config, otherwise Ready when it recovered from a failure with the config
+// it already had.
+func PublishedEvent(ctx context.Context, obj runtime.Object, before *metav1.Condition, conditions []metav1.Condition, configPublished bool) {
+	if configPublished {
+		Eventf(ctx, obj, corev1.EventTypeNormal, v1alpha1.ReasonPublished, EventActionPublish, "Published a new config")
+		return
+	}
+	ConditionEvent(ctx, obj, v1alpha1.ConditionReady, before, conditions, EventActionPublish, "Config is valid and published")
+}
+
+// RateLimitedRecorder passes events on to the wrapped recorder within a token bucket per
+// regarding object: burst events at once, then one per interval. A pipeline flapping
+// between two verdicts on every reconcile would otherwise write an event each time, and
+// the events API is shared with everything else in the cluster. Events over the limit
+// are dropped, not delayed: the status still holds the current verdict.
+type RateLimitedRecorder struct {
+	next     events.EventRecorder
+	burst    int
+	interval time.Duration
+
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter
+	now      func() time.Time
+}
+
+var _ events.EventRecorder = &RateLimitedRecorder{}
+
+// DefaultEventBurst and DefaultEventInterval are the operator's limits: ten events about
+// an object at once, then one a minute. A round rarely emits more than two about one
+// object, so only an object that keeps changing state runs into them.
+const (
+	DefaultEventBurst    = 10
+	DefaultEventInterval = time.Minute
+)
+
+// maxTrackedObjects bounds the limiters kept; past it, those of objects that have been
+// quiet long enough to have a full bucket again are forgotten.
+const maxTrackedObjects = 4096
+
+// NewRateLimitedRecorder limits next to burst events per object, then one per interval.
+func NewRateLimitedRecorder(next events.EventRecorder, burst int, interval time.Duration) *RateLimitedRecorder {
+	return &RateLimitedRecorder{
+		next:     next,
+		burst:    burst,
+		interval: interval,
+		limiters: map[string]*rate.Limiter{},
+		now:      time.Now,
+	}
+}
+
+func (r *RateLimitedRecorder) Eventf(regarding runtime.Object, related runtime.Object, eventtype, reason, action, note string, args ...any) {
+	if !r.allow(regarding) {
+		return
+	}
+	r.next.Eventf(regarding, related, eventtype, reason, action, note, args...)
+}
+
+func (r *RateLimitedRecorder) allow(regarding runtime.Object) bool {
+	key := fmt.Sprintf("%T", regarding)
+	if obj, err := meta.Accessor(regarding); err == nil {
+		key += "/" + obj.GetNamespace() + "/" + obj.GetName()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.now()
+	limiter, ok := r.limiters[key]
+	if !ok {
+		if len(r.limiters) >= maxTrackedObjects {
+			for k, l := range r.limiters {
+				if l.TokensAt(now) >= float64(r.burst) {
+					delete(r.limiters, k)
+				}
+			}
+		}
+		limiter = rate.NewLimiter(rate.Every(r.interval), r.burst)
+		r.limiters[key] = limiter
+	}
+	return limiter.AllowN(now, 1)
+}
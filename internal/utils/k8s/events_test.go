@@ -0,0 +1,132 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8s
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/events"
+)
+
+func drainEvents(rec *events.FakeRecorder) []string {
+	var out []string
+	for {
+		select {
+		case e := <-rec.Events:
+			out = append(out, e)
+		default:
+			return out
+		}
+	}
+}
+
+func readyCondition(status metav1.ConditionStatus, reason, message string) []metav1.Condition {
+	return []metav1.Condition{{Type: "Ready", Status: status, Reason: reason, Message: message}}
+}
+
+func TestConditionEvent(t *testing.T) {
+	obj := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "p", Namespace: "ns"}}
+	invalid := readyCondition(metav1.ConditionFalse, "ConfigInvalid", "bad transform")
+	ready := readyCondition(metav1.ConditionTrue, "Ready", "")
+
+	tests := []struct {
+		name   string
+		before []metav1.Condition
+		after  []metav1.Condition
+		want   []string
+	}{
+		{name: "first verdict failed", after: invalid, want: []string{"Warning ConfigInvalid bad transform"}},
+		{name: "first verdict ready", after: ready, want: []string{"Normal Ready valid"}},
+		{name: "recovered", before: invalid, after: ready, want: []string{"Normal Ready valid"}},
+		{name: "broke", before: ready, after: invalid, want: []string{"Warning ConfigInvalid bad transform"}},
+		{name: "same failure again", before: invalid, after: invalid},
+		{name: "still ready", before: ready, after: ready},
+		{
+			name:   "failed differently",
+			before: invalid,
+			after:  readyCondition(metav1.ConditionFalse, "SecretCollision", "owned by another pipeline"),
+			want:   []string{"Warning SecretCollision owned by another pipeline"},
+		},
+		{name: "no condition", before: ready},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := events.NewFakeRecorder(10)
+			ctx := WithEventRecorder(context.Background(), rec)
+			ConditionEvent(ctx, obj, "Ready", FindCondition(tt.before, "Ready"), tt.after, EventActionCheckConfig, "valid")
+			require.Equal(t, tt.want, drainEvents(rec))
+		})
+	}
+}
+
+func TestEventfWithoutRecorder(t *testing.T) {
+	// reconcilers built without a recorder, like those in most tests, emit nothing
+	require.NotPanics(t, func() {
+		Eventf(context.Background(), &corev1.ConfigMap{}, corev1.EventTypeNormal, "Ready", EventActionPublish, "note")
+	})
+	require.Equal(t, context.Background(), WithEventRecorder(context.Background(), nil))
+}
+
+func TestEventfTruncatesNote(t *testing.T) {
+	rec := events.NewFakeRecorder(1)
+	ctx := WithEventRecorder(context.Background(), rec)
+	note := strings.Repeat("a", maxEventNoteLength-4) + strings.Repeat("é", 100)
+
+	Eventf(ctx, &corev1.ConfigMap{}, corev1.EventTypeWarning, "ConfigInvalid", EventActionCheckConfig, note)
+
+	got := strings.TrimPrefix(<-rec.Events, "Warning ConfigInvalid ")
+	require.LessOrEqual(t, len(got), maxEventNoteLength)
+	require.True(t, strings.HasSuffix(got, "..."))
+	require.True(t, utf8.ValidString(got))
+}
+
+func TestRateLimitedRecorder(t *testing.T) {
+	fake := events.NewFakeRecorder(100)
+	rec := NewRateLimitedRecorder(fake, 2, time.Minute)
+	now := time.Unix(0, 0)
+	rec.now = func() time.Time { return now }
+
+	a := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "ns"}}
+	b := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "b", Namespace: "ns"}}
+	emit := func(obj *corev1.ConfigMap) {
+		rec.Eventf(obj, nil, corev1.EventTypeWarning, "ConfigInvalid", EventActionCheckConfig, "%s", obj.Name)
+	}
+
+	for range 5 {
+		emit(a)
+	}
+	require.Equal(t, []string{"Warning ConfigInvalid a", "Warning ConfigInvalid a"}, drainEvents(fake), "burst per object")
+
+	emit(b)
+	require.Len(t, drainEvents(fake), 1, "each object has its own bucket")
+
+	now = now.Add(30 * time.Second)
+	emit(a)
+	require.Empty(t, drainEvents(fake))
+
+	now = now.Add(30 * time.Second)
+	emit(a)
+	emit(a)
+	require.Len(t, drainEvents(fake), 1, "one more per interval")
+}
@@ -67,6 +67,10 @@ func CreateOrUpdateResource(ctx context.Context, obj client.Object, c client.Cli
 		return createOrUpdateClusterRole(ctx, o, c)
 	case *rbacv1.ClusterRoleBinding:
 		return createOrUpdateClusterRoleBinding(ctx, o, c)
+	case *rbacv1.Role:
+		return createOrUpdateRole(ctx, o, c)
+	case *rbacv1.RoleBinding:
+		return createOrUpdateRoleBinding(ctx, o, c)
 	case *monitorv1.PodMonitor:
 		return createOrUpdatePodMonitor(ctx, o, c)
 	case *policyv1.PodDisruptionBudget:
@@ -289,6 +293,39 @@ func createOrUpdateClusterRoleBinding(ctx context.Context, desired *rbacv1.Clust
 	return nil
 }
 
+func createOrUpdateRole(ctx context.Context, desired *rbacv1.Role, c client.Client) error {
+	existing := desired.DeepCopy()
+	_, err := controllerutil.CreateOrUpdate(ctx, c, existing, func() error {
+		existing.Labels = desired.Labels
+		existing.Annotations = mergeMaps(desired.Annotations, existing.Annotations)
+		existing.OwnerReferences = desired.OwnerReferences
+		existing.Rules = desired.Rules
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create or update Role: %w", err)
+	}
+	existing.DeepCopyInto(desired)
+	return nil
+}
+
+func createOrUpdateRoleBinding(ctx context.Context, desired *rbacv1.RoleBinding, c client.Client) error {
+	existing := desired.DeepCopy()
+	_, err := controllerutil.CreateOrUpdate(ctx, c, existing, func() error {
+		existing.Labels = desired.Labels
+		existing.Annotations = mergeMaps(desired.Annotations, existing.Annotations)
+		existing.OwnerReferences = desired.OwnerReferences
+		existing.RoleRef = desired.RoleRef
+		existing.Subjects = desired.Subjects
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create or update RoleBinding: %w", err)
+	}
+	existing.DeepCopyInto(desired)
+	return nil
+}
+
 func createOrUpdatePodMonitor(ctx context.Context, desired *monitorv1.PodMonitor, c client.Client) error {
 	existing := desired.DeepCopy()
 	_, err := controllerutil.CreateOrUpdate(ctx, c, existing, func() error {
@@ -413,8 +450,15 @@ func DeleteSecret(ctx context.Context, secret *corev1.Secret, c client.Client) e
 }
 
 func GetPodLogs(ctx context.Context, pod *corev1.Pod, cs kubernetes.Interface) (string, error) {
+	return GetContainerLogs(ctx, pod, "", cs)
+}
+
+// GetContainerLogs returns the last 100 lines of the logs of the pod's container; an
+// empty container is the pod's only one.
+func GetContainerLogs(ctx context.Context, pod *corev1.Pod, container string, cs kubernetes.Interface) (string, error) {
 	count := int64(100)
 	podLogOptions := corev1.PodLogOptions{
+		Container: container,
 		TailLines: &count,
 	}
 
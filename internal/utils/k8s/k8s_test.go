@@ -613,8 +613,44 @@ func TestCreateOrUpdateResource(t *testing.T) {
 	}
 	cases = append(cases, clusterRoleBindingCases...)
 
-	// Not supported type case
-	notSupportedCase := []objCase{
+	// Role and RoleBinding cases
+	roleCases := []objCase{
+		{
+			name: "Create without Name case",
+			initObj: &rbacv1.Role{
+				ObjectMeta: getInitObjectMeta(),
+			},
+			obj: &rbacv1.Role{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "test-namespace"},
+			},
+			want: fmt.Errorf("failed to create or update Role: %w", nameRequiredError(schema.GroupKind{Group: "rbac.authorization.k8s.io", Kind: "Role"})),
+		},
+		{
+			name: "Update exist case",
+			initObj: &rbacv1.Role{
+				ObjectMeta: getInitObjectMeta(),
+			},
+			obj: &rbacv1.Role{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "init",
+					Namespace: "test-namespace",
+					Labels: map[string]string{
+						"test": "test",
+					},
+				},
+			},
+			want: nil,
+		},
+		{
+			name: "Create without Name case",
+			initObj: &rbacv1.RoleBinding{
+				ObjectMeta: getInitObjectMeta(),
+			},
+			obj: &rbacv1.RoleBinding{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "test-namespace"},
+			},
+			want: fmt.Errorf("failed to create or update RoleBinding: %w", nameRequiredError(schema.GroupKind{Group: "rbac.authorization.k8s.io", Kind: "RoleBinding"})),
+		},
 		{
 			name: "Update exist case",
 			initObj: &rbacv1.RoleBinding{
@@ -629,7 +665,28 @@ func TestCreateOrUpdateResource(t *testing.T) {
 					},
 				},
 			},
-			want: k8s.NewNotSupportedError(&rbacv1.RoleBinding{
+			want: nil,
+		},
+	}
+	cases = append(cases, roleCases...)
+
+	// Not supported type case
+	notSupportedCase := []objCase{
+		{
+			name: "Update exist case",
+			initObj: &corev1.PersistentVolumeClaim{
+				ObjectMeta: getInitObjectMeta(),
+			},
+			obj: &corev1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "init",
+					Namespace: "test-namespace",
+					Labels: map[string]string{
+						"test": "test",
+					},
+				},
+			},
+			want: k8s.NewNotSupportedError(&corev1.PersistentVolumeClaim{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      "init",
 					Namespace: "test-namespace",
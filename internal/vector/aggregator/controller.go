@@ -3,6 +3,7 @@ package aggregator
 import (
 	"context"
 	"fmt"
+	"time"
 
 	monitorv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
 	appsv1 "k8s.io/api/apps/v1"
@@ -20,6 +21,7 @@ import (
 	vectorv1alpha1 "github.com/kaasops/vector-operator/api/v1alpha1"
 	"github.com/kaasops/vector-operator/internal/buildinfo"
 	"github.com/kaasops/vector-operator/internal/config"
+	"github.com/kaasops/vector-operator/internal/evconfig"
 	"github.com/kaasops/vector-operator/internal/utils/k8s"
 )
 
@@ -481,18 +483,33 @@ func (ctrl *Controller) setDefault() {
 	if ctrl.Spec.EventCollector.MaxBatchSize <= 0 {
 		ctrl.Spec.EventCollector.MaxBatchSize = 250
 	}
+	if ctrl.Spec.EventCollector.QueueSize <= 0 {
+		ctrl.Spec.EventCollector.QueueSize = evconfig.DefaultQueueSize
+	}
+	if ctrl.Spec.EventCollector.OverflowPolicy == "" {
+		ctrl.Spec.EventCollector.OverflowPolicy = string(evconfig.OverflowDropOldest)
+	}
+	if ctrl.Spec.EventCollector.MaxReplayWindow == nil {
+		ctrl.Spec.EventCollector.MaxReplayWindow = &metav1.Duration{Duration: time.Hour}
+	}
 }
 
-// statusPatchBase returns the patch base for a status write that clears the reason. A merge
-// patch only clears the keys it mentions, and the base can predate the reason it has to
-// clear, so the base carries a reason whatever it was read with.
+// statusPatchBase returns the patch base for a status write. A merge patch only clears the
+// keys it mentions, and the base can predate the reason a success has to clear, so the
+// base carries a reason whatever it was read with. It carries no conditions for the same
+// reason: a list is only sent when it differs from the base, and every write has to land
+// the full list.
 func (ctrl *Controller) statusPatchBase() client.Object {
 	base := ctrl.VectorAggregator.DeepCopyObject().(client.Object)
 	switch agg := base.(type) {
 	case *vectorv1alpha1.VectorAggregator:
 		agg.Status.Reason = ptr.To("")
+		agg.Status.Conditions = nil
+		agg.Status.ConfigCheckReport = &vectorv1alpha1.ConfigCheckReport{}
 	case *vectorv1alpha1.ClusterVectorAggregator:
 		agg.Status.Reason = ptr.To("")
+		agg.Status.Conditions = nil
+		agg.Status.ConfigCheckReport = &vectorv1alpha1.ConfigCheckReport{}
 	}
 	return base
 }
@@ -502,17 +519,24 @@ func (ctrl *Controller) statusPatchBase() client.Object {
 // SetSuccessStatus for the full rationale, including why LastConfigPublishedAt is
 // also seeded when still nil regardless of configPublished.
 func (ctrl *Controller) SetSuccessStatus(ctx context.Context, hash, globCfgHash *int64, configPublished bool) error {
+	before := k8s.FindCondition(ctrl.Status.Conditions, vectorv1alpha1.ConditionReady)
 	base := ctrl.statusPatchBase()
 	var status = true
 	ctrl.Status.ConfigCheckResult = &status
 	ctrl.Status.Reason = nil
+	ctrl.Status.ConfigCheckReport = nil
 	ctrl.Status.LastAppliedConfigHash = hash
 	ctrl.Status.LastAppliedGlobalConfigHash = globCfgHash
 	if configPublished || ctrl.Status.LastConfigPublishedAt == nil {
 		now := metav1.Now()
 		ctrl.Status.LastConfigPublishedAt = &now
 	}
-	return k8s.PatchStatus(ctx, ctrl.VectorAggregator, base, ctrl.Client)
+	ctrl.Status.MarkSucceeded(ctrl.VectorAggregator.GetGeneration(), len(ctrl.SecretAssets) > 0)
+	if err := k8s.PatchStatus(ctx, ctrl.VectorAggregator, base, ctrl.Client); err != nil {
+		return err
+	}
+	k8s.PublishedEvent(ctx, ctrl.VectorAggregator, before, ctrl.Status.Conditions, configPublished)
+	return nil
 }
 
 // StampConfigPublishing writes ONLY LastConfigPublishedAt - see the agent's
@@ -561,12 +585,40 @@ func (ctrl *Controller) StampConfigPublishing(ctx context.Context) error {
 	return nil
 }
 
+// SetFailedStatus marks the aggregator's config invalid (the ConfigValid condition).
 func (ctrl *Controller) SetFailedStatus(ctx context.Context, reason string) error {
-	base := ctrl.VectorAggregator.DeepCopyObject().(client.Object)
+	return ctrl.setFailedStatus(ctx, vectorv1alpha1.ConditionConfigValid, vectorv1alpha1.ReasonConfigInvalid, reason, nil)
+}
+
+// SetConfigCheckFailedStatus is SetFailedStatus for a failed configcheck, recording its
+// report next to the reason.
+func (ctrl *Controller) SetConfigCheckFailedStatus(ctx context.Context, reason string, report *vectorv1alpha1.ConfigCheckReport) error {
+	return ctrl.setFailedStatus(ctx, vectorv1alpha1.ConditionConfigValid, vectorv1alpha1.ReasonConfigInvalid, reason, report)
+}
+
+// SetSecretsFailedStatus marks the aggregator's config unbuildable because a
+// pipeline's SECRET[] reference did not resolve (the SecretsResolved condition).
+func (ctrl *Controller) SetSecretsFailedStatus(ctx context.Context, reason string) error {
+	return ctrl.setFailedStatus(ctx, vectorv1alpha1.ConditionSecretsResolved, vectorv1alpha1.ReasonSecretResolveFailed, reason, nil)
+}
+
+// setFailedStatus writes a failure of conditionType. A ConfigValid failure replaces the
+// configcheck report with report; a SecretsResolved one keeps it.
+func (ctrl *Controller) setFailedStatus(ctx context.Context, conditionType, conditionReason, reason string, report *vectorv1alpha1.ConfigCheckReport) error {
+	before := k8s.FindCondition(ctrl.Status.Conditions, vectorv1alpha1.ConditionReady)
+	base := ctrl.statusPatchBase()
 	var status = false
 	ctrl.Status.ConfigCheckResult = &status
 	ctrl.Status.Reason = &reason
-	return k8s.PatchStatus(ctx, ctrl.VectorAggregator, base, ctrl.Client)
+	if conditionType == vectorv1alpha1.ConditionConfigValid {
+		ctrl.Status.ConfigCheckReport = report
+	}
+	ctrl.Status.MarkFailed(ctrl.VectorAggregator.GetGeneration(), conditionType, conditionReason, reason)
+	if err := k8s.PatchStatus(ctx, ctrl.VectorAggregator, base, ctrl.Client); err != nil {
+		return err
+	}
+	k8s.ConditionEvent(ctx, ctrl.VectorAggregator, vectorv1alpha1.ConditionReady, before, ctrl.Status.Conditions, k8s.ConditionAction(conditionType), "")
+	return nil
 }
 
 func (ctrl *Controller) matchLabelsForVectorAggregator() map[string]string {
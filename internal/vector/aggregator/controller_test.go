@@ -11,6 +11,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	policyv1 "k8s.io/api/policy/v1"
 	api_errors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
@@ -284,6 +285,8 @@ func TestSetSuccessStatusClearsUnobservedReason(t *testing.T) {
 			g.Expect(cl.Get(ctx, key, result)).To(Succeed())
 			g.Expect(tc.read(result).ConfigCheckResult).To(HaveValue(BeTrue()))
 			g.Expect(tc.read(result).Reason).To(BeNil(), "a success must not keep a failure reason")
+			g.Expect(meta.IsStatusConditionTrue(tc.read(result).Conditions, vectorv1alpha1.ConditionReady)).To(BeTrue())
+			g.Expect(meta.IsStatusConditionTrue(tc.read(result).Conditions, vectorv1alpha1.ConditionConfigValid)).To(BeTrue())
 		})
 	}
 }
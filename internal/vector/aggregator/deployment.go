@@ -274,6 +274,12 @@ func (ctrl *Controller) generateVectorAggregatorVolume() []corev1.Volume {
 		})
 	}
 
+	// Operator-owned the same way: the kubernetes_events sources read their
+	// certificates from config.KubernetesEventsTLSMountPath.
+	if ctrl.Spec.EventCollector.TLS != nil {
+		volume = k8s.SetAuthoritativeVolume(volume, ctrl.eventCollectorTLSVolume())
+	}
+
 	return volume
 }
 
@@ -329,6 +335,10 @@ func (ctrl *Controller) generateVectorAggregatorVolumeMounts() []corev1.VolumeMo
 		})
 	}
 
+	if ctrl.Spec.EventCollector.TLS != nil {
+		volumeMount = k8s.SetAuthoritativeVolumeMount(volumeMount, eventCollectorTLSVolumeMount())
+	}
+
 	return volumeMount
 }
 
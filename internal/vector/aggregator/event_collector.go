@@ -3,17 +3,22 @@ package aggregator
 import (
 	"context"
 	"encoding/json"
+	"path"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	api_errors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
-	"github.com/kaasops/vector-operator/internal/evcollector"
+	vectorv1alpha1 "github.com/kaasops/vector-operator/api/v1alpha1"
+	"github.com/kaasops/vector-operator/internal/config"
+	"github.com/kaasops/vector-operator/internal/evconfig"
 	"github.com/kaasops/vector-operator/internal/utils/k8s"
 )
 
@@ -23,6 +28,20 @@ func (ctrl *Controller) ensureEventCollector(ctx context.Context) error {
 		return ctrl.cleanupEventCollector(ctx)
 	}
 	cfg.MaxBatchSize = ctrl.Spec.EventCollector.MaxBatchSize
+	cfg.Queue = evconfig.QueueParams{
+		Size:           ctrl.Spec.EventCollector.QueueSize,
+		OverflowPolicy: evconfig.OverflowPolicy(ctrl.Spec.EventCollector.OverflowPolicy),
+	}
+	if w := ctrl.Spec.EventCollector.MaxReplayWindow; w != nil && w.Duration > 0 {
+		cfg.MaxReplayWindow = w.Duration
+		cfg.CheckpointConfigMap = ctrl.eventCollectorStateName()
+		cfg.CheckpointNamespace = ctrl.Namespace
+	}
+	// Elected also with a single replica: a rolling update runs the old and the new pod
+	// side by side, and without the Leases both would push every event.
+	cfg.LeaseNamespace = ctrl.Namespace
+	cfg.LeasePrefix = ctrl.Name + "-event-collector"
+	cfg.TLS = ctrl.eventCollectorTLS()
 
 	// config
 	eventCollectorConfig, err := ctrl.createEventCollectorConfig(cfg)
@@ -35,6 +54,11 @@ func (ctrl *Controller) ensureEventCollector(ctx context.Context) error {
 		return err
 	}
 
+	// checkpoints
+	if err := ctrl.ensureEventCollectorState(ctx); err != nil {
+		return err
+	}
+
 	// rbac
 	if err := ctrl.ensureEventCollectorRBAC(ctx); err != nil {
 		return err
@@ -50,10 +74,18 @@ func (ctrl *Controller) ensureEventCollector(ctx context.Context) error {
 		return err
 	}
 
+	// pdb
+	if err := ctrl.ensureEventCollectorPodDisruptionBudget(ctx); err != nil {
+		return err
+	}
+
 	return nil
 }
 
 func (ctrl *Controller) cleanupEventCollector(ctx context.Context) error {
+	if err := ctrl.Delete(ctx, ctrl.createEventCollectorPodDisruptionBudget()); err != nil && !api_errors.IsNotFound(err) {
+		return err
+	}
 	if err := ctrl.Delete(ctx, ctrl.createEventCollectorDeployment()); err != nil && !api_errors.IsNotFound(err) {
 		return err
 	}
@@ -63,6 +95,15 @@ func (ctrl *Controller) cleanupEventCollector(ctx context.Context) error {
 	if err := ctrl.Delete(ctx, ctrl.createEventCollectorClusterRoleBinding()); err != nil && !api_errors.IsNotFound(err) {
 		return err
 	}
+	if err := ctrl.Delete(ctx, ctrl.createEventCollectorRoleBinding()); err != nil && !api_errors.IsNotFound(err) {
+		return err
+	}
+	if err := ctrl.Delete(ctx, ctrl.createEventCollectorRole()); err != nil && !api_errors.IsNotFound(err) {
+		return err
+	}
+	if err := ctrl.Delete(ctx, ctrl.createEventCollectorState()); err != nil && !api_errors.IsNotFound(err) {
+		return err
+	}
 	if err := ctrl.Delete(ctx, ctrl.createEventCollectorClusterRole()); err != nil && !api_errors.IsNotFound(err) {
 		return err
 	}
@@ -100,7 +141,7 @@ func (ctrl *Controller) createEventCollectorService() *corev1.Service {
 	return svc
 }
 
-func (ctrl *Controller) createEventCollectorConfig(params *evcollector.Config) (*corev1.ConfigMap, error) {
+func (ctrl *Controller) createEventCollectorConfig(params *evconfig.Config) (*corev1.ConfigMap, error) {
 	labels := ctrl.labelsForEventCollector()
 	annotations := ctrl.annotationsForVectorAggregator()
 	bytes, err := json.Marshal(params)
@@ -118,6 +159,41 @@ func (ctrl *Controller) createEventCollectorConfig(params *evcollector.Config) (
 	return cfg, nil
 }
 
+func (ctrl *Controller) eventCollectorStateName() string {
+	return ctrl.Name + "-event-collector-state"
+}
+
+// ensureEventCollectorState creates the ConfigMap the collectors keep their checkpoints
+// in. It is only ever created: its data is the collectors' to write, and an update from
+// here would reset every checkpoint.
+func (ctrl *Controller) ensureEventCollectorState(ctx context.Context) error {
+	state := ctrl.createEventCollectorState()
+	err := ctrl.Get(ctx, client.ObjectKeyFromObject(state), &corev1.ConfigMap{})
+	if !api_errors.IsNotFound(err) {
+		return err
+	}
+	if err := ctrl.Create(ctx, state); err != nil && !api_errors.IsAlreadyExists(err) {
+		return err
+	}
+	return nil
+}
+
+func (ctrl *Controller) createEventCollectorState() *corev1.ConfigMap {
+	labels := ctrl.labelsForEventCollector()
+	annotations := ctrl.annotationsForVectorAggregator()
+	state := &corev1.ConfigMap{
+		ObjectMeta: ctrl.objectMetaVectorAggregator(labels, annotations, ctrl.Namespace),
+	}
+	state.Name = ctrl.eventCollectorStateName()
+	// Not a controller reference, only one for garbage collection: the collectors patch
+	// the ConfigMap every few seconds, and the reconcilers' Owns(&corev1.ConfigMap{})
+	// would turn each patch into a reconcile of the aggregator.
+	for i := range state.OwnerReferences {
+		state.OwnerReferences[i].Controller = nil
+	}
+	return state
+}
+
 func (ctrl *Controller) createEventCollectorDeployment() *appsv1.Deployment {
 	labels := ctrl.labelsForEventCollector()
 	annotations := ctrl.annotationsForVectorAggregator()
@@ -132,7 +208,7 @@ func (ctrl *Controller) createEventCollectorDeployment() *appsv1.Deployment {
 		ObjectMeta: ctrl.objectMetaVectorAggregator(labels, annotations, ctrl.Namespace),
 		Spec: appsv1.DeploymentSpec{
 			Selector: &metav1.LabelSelector{MatchLabels: labels},
-			Replicas: ptr.To[int32](1),
+			Replicas: ptr.To(ctrl.eventCollectorReplicas()),
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: ctrl.objectMetaVectorAggregator(labels, annotations, ctrl.Namespace),
 				Spec: corev1.PodSpec{
@@ -157,8 +233,39 @@ func (ctrl *Controller) createEventCollectorDeployment() *appsv1.Deployment {
 	return deployment
 }
 
+func (ctrl *Controller) eventCollectorReplicas() int32 {
+	if r := ctrl.Spec.EventCollector.Replicas; r != nil {
+		return *r
+	}
+	return 1
+}
+
+// ensureEventCollectorPodDisruptionBudget keeps a drain from evicting every replica at
+// once. Like the aggregator's, it is opt-in and only exists with more than one replica.
+func (ctrl *Controller) ensureEventCollectorPodDisruptionBudget(ctx context.Context) error {
+	pdb := ctrl.createEventCollectorPodDisruptionBudget()
+	if !ctrl.Spec.EventCollector.PodDisruptionBudget.Enabled || ctrl.eventCollectorReplicas() <= 1 {
+		if err := ctrl.Delete(ctx, pdb); err != nil && !api_errors.IsNotFound(err) {
+			return err
+		}
+		return nil
+	}
+	return k8s.CreateOrUpdateResource(ctx, pdb, ctrl.Client)
+}
+
+func (ctrl *Controller) createEventCollectorPodDisruptionBudget() *policyv1.PodDisruptionBudget {
+	labels := ctrl.labelsForEventCollector()
+	annotations := ctrl.annotationsForVectorAggregator()
+	pdb := &policyv1.PodDisruptionBudget{
+		ObjectMeta: ctrl.objectMetaVectorAggregator(labels, annotations, ctrl.Namespace),
+		Spec:       podDisruptionBudgetSpec(ctrl.Spec.EventCollector.PodDisruptionBudget, labels),
+	}
+	pdb.Name = ctrl.Name + "-event-collector"
+	return pdb
+}
+
 func (ctrl *Controller) eventCollectorContainer() *corev1.Container {
-	return &corev1.Container{
+	container := &corev1.Container{
 		Name:            "event-collector",
 		Image:           ctrl.Spec.EventCollector.Image,
 		ImagePullPolicy: ctrl.Spec.EventCollector.ImagePullPolicy,
@@ -171,10 +278,18 @@ func (ctrl *Controller) eventCollectorContainer() *corev1.Container {
 			},
 		},
 	}
+	if ctrl.Spec.EventCollector.TLS != nil {
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+			Name:      eventCollectorTLSVolumeName,
+			MountPath: eventCollectorTLSMountPath,
+			ReadOnly:  true,
+		})
+	}
+	return container
 }
 
 func (ctrl *Controller) generateEventCollectorVolume() []corev1.Volume {
-	return append(ctrl.Spec.Volumes, corev1.Volume{
+	volumes := append(ctrl.Spec.Volumes, corev1.Volume{
 		Name: "event-collector-config",
 		VolumeSource: corev1.VolumeSource{
 			ConfigMap: &corev1.ConfigMapVolumeSource{
@@ -184,6 +299,69 @@ func (ctrl *Controller) generateEventCollectorVolume() []corev1.Volume {
 			},
 		},
 	})
+	if ctrl.Spec.EventCollector.TLS != nil {
+		volumes = k8s.SetAuthoritativeVolume(volumes, ctrl.eventCollectorTLSVolume())
+	}
+	return volumes
+}
+
+const (
+	// eventCollectorTLSVolumeName is the volume of the Secret of EventCollector.TLS, in
+	// the collector as well as in the aggregator.
+	eventCollectorTLSVolumeName = "event-collector-tls"
+	eventCollectorTLSMountPath  = "/etc/event-collector/tls"
+)
+
+// eventCollectorTLS points the collector at the files of the Secret of
+// EventCollector.TLS; the zero value without it pushes in plaintext.
+func (ctrl *Controller) eventCollectorTLS() evconfig.TLSParams {
+	t := ctrl.Spec.EventCollector.TLS
+	if t == nil {
+		return evconfig.TLSParams{}
+	}
+	params := evconfig.TLSParams{
+		CAFile:     path.Join(eventCollectorTLSMountPath, config.TLSCAKey),
+		ServerName: t.ServerName,
+	}
+	if t.Mutual {
+		params.CertFile = path.Join(eventCollectorTLSMountPath, corev1.TLSCertKey)
+		params.KeyFile = path.Join(eventCollectorTLSMountPath, corev1.TLSPrivateKeyKey)
+	}
+	return params
+}
+
+func (ctrl *Controller) eventCollectorTLSVolume() corev1.Volume {
+	return corev1.Volume{
+		Name: eventCollectorTLSVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{
+				SecretName: ctrl.Spec.EventCollector.TLS.SecretName,
+			},
+		},
+	}
+}
+
+// eventCollectorTLSVolumeMount mounts the Secret of EventCollector.TLS where the
+// kubernetes_events sources of the generated config read it from.
+func eventCollectorTLSVolumeMount() corev1.VolumeMount {
+	return corev1.VolumeMount{
+		Name:      eventCollectorTLSVolumeName,
+		MountPath: config.KubernetesEventsTLSMountPath,
+		ReadOnly:  true,
+	}
+}
+
+// ConfigCheckCommon is the VectorCommon to build the configcheck pod from: the spec's,
+// plus the Secret of EventCollector.TLS, because vector validate loads the certificates
+// of the kubernetes_events sources.
+func (ctrl *Controller) ConfigCheckCommon() *vectorv1alpha1.VectorCommon {
+	if ctrl.Spec.EventCollector.TLS == nil {
+		return &ctrl.Spec.VectorCommon
+	}
+	common := ctrl.Spec.VectorCommon
+	common.Volumes = k8s.SetAuthoritativeVolume(common.Volumes, ctrl.eventCollectorTLSVolume())
+	common.VolumeMounts = k8s.SetAuthoritativeVolumeMount(common.VolumeMounts, eventCollectorTLSVolumeMount())
+	return &common
 }
 
 // rbac
@@ -202,6 +380,12 @@ func (ctrl *Controller) ensureEventCollectorRBAC(ctx context.Context) error {
 	if err := ctrl.ensureEventCollectorClusterRoleBinding(ctx); err != nil {
 		return err
 	}
+	if err := k8s.CreateOrUpdateResource(ctx, ctrl.createEventCollectorRole(), ctrl.Client); err != nil {
+		return err
+	}
+	if err := k8s.CreateOrUpdateResource(ctx, ctrl.createEventCollectorRoleBinding(), ctrl.Client); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -236,6 +420,58 @@ func (ctrl *Controller) createEventCollectorClusterRole() *rbacv1.ClusterRole {
 	return clusterRole
 }
 
+// createEventCollectorRole lets the collectors read and patch their checkpoint
+// ConfigMap, and no other: it is namespaced and names the ConfigMap, which is why the
+// operator creates it rather than the collectors. The Leases are named after the
+// receivers, which change with the pipelines, so those are granted by namespace only.
+func (ctrl *Controller) createEventCollectorRole() *rbacv1.Role {
+	labels := ctrl.labelsForEventCollector()
+	annotations := ctrl.annotationsForVectorAggregator()
+
+	role := &rbacv1.Role{
+		ObjectMeta: ctrl.objectMetaVectorAggregator(labels, annotations, ctrl.Namespace),
+		Rules: []rbacv1.PolicyRule{
+			{
+				APIGroups:     []string{""},
+				Resources:     []string{"configmaps"},
+				ResourceNames: []string{ctrl.eventCollectorStateName()},
+				Verbs:         []string{"get", "patch"},
+			},
+			{
+				APIGroups: []string{"coordination.k8s.io"},
+				Resources: []string{"leases"},
+				Verbs:     []string{"get", "create", "update"},
+			},
+		},
+	}
+
+	role.Name = ctrl.Name + "-event-collector"
+	return role
+}
+
+func (ctrl *Controller) createEventCollectorRoleBinding() *rbacv1.RoleBinding {
+	labels := ctrl.labelsForEventCollector()
+	annotations := ctrl.annotationsForVectorAggregator()
+
+	roleBinding := &rbacv1.RoleBinding{
+		ObjectMeta: ctrl.objectMetaVectorAggregator(labels, annotations, ctrl.Namespace),
+		RoleRef: rbacv1.RoleRef{
+			Kind:     "Role",
+			APIGroup: "rbac.authorization.k8s.io",
+			Name:     ctrl.Name + "-event-collector",
+		},
+		Subjects: []rbacv1.Subject{
+			{
+				Kind:      "ServiceAccount",
+				Name:      ctrl.Name + "-event-collector",
+				Namespace: ctrl.Namespace,
+			},
+		},
+	}
+	roleBinding.Name = ctrl.Name + "-event-collector"
+	return roleBinding
+}
+
 func (ctrl *Controller) createEventCollectorServiceAccount() *corev1.ServiceAccount {
 	labels := ctrl.labelsForEventCollector()
 	annotations := ctrl.annotationsForVectorAggregator()
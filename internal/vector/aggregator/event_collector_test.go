@@ -0,0 +1,164 @@
+package aggregator
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	api_errors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+
+	vectorv1alpha1 "github.com/kaasops/vector-operator/api/v1alpha1"
+	"github.com/kaasops/vector-operator/internal/config"
+	"github.com/kaasops/vector-operator/internal/evconfig"
+)
+
+// The checkpoint ConfigMap belongs to the collectors once created: a reconcile must not
+// reset the checkpoints they wrote, and must not be triggered by their writes either.
+func TestEnsureEventCollectorState(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	va := &vectorv1alpha1.VectorAggregator{
+		ObjectMeta: metav1.ObjectMeta{Name: "va", Namespace: "default", UID: "uid"},
+	}
+	cl := newFakeClient(g)
+	ctrl := NewController(va, cl, nil)
+
+	g.Expect(ctrl.ensureEventCollectorState(ctx)).To(Succeed())
+	key := types.NamespacedName{Name: "va-event-collector-state", Namespace: "default"}
+	state := &corev1.ConfigMap{}
+	g.Expect(cl.Get(ctx, key, state)).To(Succeed())
+	g.Expect(state.OwnerReferences).To(HaveLen(1))
+	g.Expect(state.OwnerReferences[0].UID).To(BeEquivalentTo("uid"))
+	g.Expect(state.OwnerReferences[0].Controller).To(BeNil(), "a controller reference makes every checkpoint a reconcile")
+
+	state.Data = map[string]string{"receiver.default": "2026-01-01T00:00:00Z"}
+	g.Expect(cl.Update(ctx, state)).To(Succeed())
+	g.Expect(ctrl.ensureEventCollectorState(ctx)).To(Succeed())
+	g.Expect(cl.Get(ctx, key, state)).To(Succeed())
+	g.Expect(state.Data).To(HaveKeyWithValue("receiver.default", "2026-01-01T00:00:00Z"))
+}
+
+func TestEventCollectorRole(t *testing.T) {
+	g := NewWithT(t)
+
+	va := &vectorv1alpha1.VectorAggregator{ObjectMeta: metav1.ObjectMeta{Name: "va", Namespace: "default"}}
+	ctrl := NewController(va, nil, nil)
+
+	role := ctrl.createEventCollectorRole()
+	g.Expect(role.Namespace).To(Equal("default"))
+	g.Expect(role.Rules).To(HaveLen(2))
+	g.Expect(role.Rules[1].Resources).To(Equal([]string{"leases"}))
+	g.Expect(role.Rules[0].Resources).To(Equal([]string{"configmaps"}))
+	g.Expect(role.Rules[0].ResourceNames).To(Equal([]string{"va-event-collector-state"}))
+	g.Expect(role.Rules[0].Verbs).To(Equal([]string{"get", "patch"}))
+
+	binding := ctrl.createEventCollectorRoleBinding()
+	g.Expect(binding.RoleRef.Name).To(Equal(role.Name))
+	g.Expect(binding.Subjects).To(HaveLen(1))
+	g.Expect(binding.Subjects[0].Name).To(Equal(ctrl.createEventCollectorServiceAccount().Name))
+}
+
+func TestEventCollectorDefaults(t *testing.T) {
+	g := NewWithT(t)
+
+	va := &vectorv1alpha1.VectorAggregator{ObjectMeta: metav1.ObjectMeta{Name: "va", Namespace: "default"}}
+	spec := NewController(va, nil, nil).Spec.EventCollector
+	g.Expect(spec.MaxReplayWindow.Duration.String()).To(Equal("1h0m0s"))
+	g.Expect(spec.QueueSize).To(BeEquivalentTo(10000))
+	g.Expect(spec.OverflowPolicy).To(Equal("DropOldest"))
+
+	va.Spec.EventCollector.MaxReplayWindow = &metav1.Duration{}
+	g.Expect(NewController(va, nil, nil).Spec.EventCollector.MaxReplayWindow.Duration).To(BeZero(), "0s turns resuming off and is kept")
+}
+
+// The collector's budget is opt-in and, like the aggregator's, only exists with more
+// than one replica to keep available.
+func TestEnsureEventCollectorPodDisruptionBudget(t *testing.T) {
+	ctx := context.Background()
+	key := types.NamespacedName{Name: "va-event-collector", Namespace: "default"}
+
+	tests := []struct {
+		name     string
+		replicas *int32
+		enabled  bool
+		want     bool
+	}{
+		{name: "disabled", replicas: ptr.To[int32](2)},
+		{name: "single replica", enabled: true},
+		{name: "enabled with replicas", replicas: ptr.To[int32](2), enabled: true, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			cl := newFakeClient(g)
+			ctrl := createTestController("va", "default", &vectorv1alpha1.VectorAggregatorCommon{
+				EventCollector: vectorv1alpha1.EventCollector{
+					Replicas:            tt.replicas,
+					PodDisruptionBudget: vectorv1alpha1.PodDisruptionBudget{Enabled: tt.enabled},
+				},
+			}, false)
+			ctrl.Client = cl
+
+			g.Expect(ctrl.ensureEventCollectorPodDisruptionBudget(ctx)).To(Succeed())
+			pdb := &policyv1.PodDisruptionBudget{}
+			err := cl.Get(ctx, key, pdb)
+			if !tt.want {
+				g.Expect(api_errors.IsNotFound(err)).To(BeTrue())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(pdb.Spec.Selector.MatchLabels).To(Equal(ctrl.labelsForEventCollector()))
+			g.Expect(ctrl.createEventCollectorDeployment().Spec.Replicas).To(Equal(tt.replicas))
+		})
+	}
+}
+
+// The Secret of EventCollector.TLS goes wherever its files are read: into the collector,
+// into the aggregator for its kubernetes_events sources, and into the configcheck pod,
+// whose vector validate loads them too.
+func TestEventCollectorTLS(t *testing.T) {
+	g := NewWithT(t)
+
+	va := &vectorv1alpha1.VectorAggregator{ObjectMeta: metav1.ObjectMeta{Name: "va", Namespace: "default"}}
+	va.Spec.Volumes = []corev1.Volume{{Name: "user"}}
+	ctrl := NewController(va, nil, nil)
+	g.Expect(ctrl.eventCollectorTLS()).To(BeZero())
+	g.Expect(ctrl.createEventCollectorDeployment().Spec.Template.Spec.Volumes).NotTo(ContainElement(HaveField("Name", eventCollectorTLSVolumeName)))
+	g.Expect(ctrl.generateVectorAggregatorVolume()).NotTo(ContainElement(HaveField("Name", eventCollectorTLSVolumeName)))
+	g.Expect(ctrl.ConfigCheckCommon()).To(BeIdenticalTo(&ctrl.Spec.VectorCommon))
+
+	va.Spec.EventCollector.TLS = &vectorv1alpha1.EventCollectorTLS{SecretName: "events-tls", ServerName: "events.default"}
+	ctrl = NewController(va, nil, nil)
+	g.Expect(ctrl.eventCollectorTLS()).To(Equal(evconfig.TLSParams{
+		CAFile:     "/etc/event-collector/tls/ca.crt",
+		ServerName: "events.default",
+	}))
+
+	secretVolume := HaveField("VolumeSource.Secret.SecretName", "events-tls")
+	deployment := ctrl.createEventCollectorDeployment()
+	g.Expect(deployment.Spec.Template.Spec.Volumes).To(ContainElement(secretVolume))
+	g.Expect(deployment.Spec.Template.Spec.Containers[0].VolumeMounts).To(ContainElement(HaveField("MountPath", "/etc/event-collector/tls")))
+
+	g.Expect(ctrl.generateVectorAggregatorVolume()).To(ContainElement(secretVolume))
+	g.Expect(ctrl.generateVectorAggregatorVolumeMounts()).To(ContainElement(HaveField("MountPath", config.KubernetesEventsTLSMountPath)))
+
+	common := ctrl.ConfigCheckCommon()
+	g.Expect(common.Volumes).To(ContainElement(secretVolume))
+	g.Expect(common.VolumeMounts).To(ContainElement(HaveField("MountPath", config.KubernetesEventsTLSMountPath)))
+	g.Expect(ctrl.Spec.Volumes).To(Equal([]corev1.Volume{{Name: "user"}}), "the spec itself is left alone")
+
+	va.Spec.EventCollector.TLS.Mutual = true
+	ctrl = NewController(va, nil, nil)
+	g.Expect(ctrl.eventCollectorTLS()).To(Equal(evconfig.TLSParams{
+		CAFile:     "/etc/event-collector/tls/ca.crt",
+		CertFile:   "/etc/event-collector/tls/tls.crt",
+		KeyFile:    "/etc/event-collector/tls/tls.key",
+		ServerName: "events.default",
+	}))
+}
@@ -9,6 +9,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
+	vectorv1alpha1 "github.com/kaasops/vector-operator/api/v1alpha1"
 	"github.com/kaasops/vector-operator/internal/utils/k8s"
 )
 
@@ -47,8 +48,14 @@ func (ctrl *Controller) createVectorAggregatorPodDisruptionBudget() *policyv1.Po
 	matchLabels := ctrl.matchLabelsForVectorAggregator()
 	annotations := ctrl.annotationsForVectorAggregator()
 
-	cfg := ctrl.Spec.PodDisruptionBudget
+	return &policyv1.PodDisruptionBudget{
+		ObjectMeta: ctrl.objectMetaVectorAggregator(labels, annotations, ctrl.Namespace),
+		Spec:       podDisruptionBudgetSpec(ctrl.Spec.PodDisruptionBudget, matchLabels),
+	}
+}
 
+// podDisruptionBudgetSpec is the budget cfg asks for over the pods matchLabels selects.
+func podDisruptionBudgetSpec(cfg vectorv1alpha1.PodDisruptionBudget, matchLabels map[string]string) policyv1.PodDisruptionBudgetSpec {
 	spec := policyv1.PodDisruptionBudgetSpec{
 		Selector: &metav1.LabelSelector{
 			MatchLabels: matchLabels,
@@ -76,8 +83,5 @@ func (ctrl *Controller) createVectorAggregatorPodDisruptionBudget() *policyv1.Po
 		spec.UnhealthyPodEvictionPolicy = &policy
 	}
 
-	return &policyv1.PodDisruptionBudget{
-		ObjectMeta: ctrl.objectMetaVectorAggregator(labels, annotations, ctrl.Namespace),
-		Spec:       spec,
-	}
+	return spec
 }
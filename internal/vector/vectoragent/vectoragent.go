@@ -92,21 +92,32 @@ func NewController(v *vectorv1alpha1.Vector, c client.Client, cs *kubernetes.Cli
 // published" rather than silently unlocking an immediate prune with no reference
 // point to measure the grace period against.
 func (ctrl *Controller) SetSuccessStatus(ctx context.Context, cfgHash, globCfgHash *int64, configPublished bool) error {
+	before := k8s.FindCondition(ctrl.Vector.Status.Conditions, vectorv1alpha1.ConditionReady)
 	base := ctrl.Vector.DeepCopy()
 	// A merge patch only clears the keys it mentions, and base can predate the reason it
-	// has to clear, so make the patch carry reason whatever base was read with.
+	// has to clear, so make the patch carry reason whatever base was read with. The same
+	// goes for the conditions: a list is only sent when it differs from base, so base
+	// drops its own and the patch always carries the full list.
 	base.Status.Reason = ptr.To("")
+	base.Status.Conditions = nil
+	base.Status.ConfigCheckReport = &vectorv1alpha1.ConfigCheckReport{}
 	var status = true
 	ctrl.Vector.Status.ConfigCheckResult = &status
 	ctrl.Vector.Status.Reason = nil
+	ctrl.Vector.Status.ConfigCheckReport = nil
 	ctrl.Vector.Status.LastAppliedConfigHash = cfgHash
 	ctrl.Vector.Status.LastAppliedGlobalConfigHash = globCfgHash
 	if configPublished || ctrl.Vector.Status.LastConfigPublishedAt == nil {
 		now := metav1.Now()
 		ctrl.Vector.Status.LastConfigPublishedAt = &now
 	}
+	ctrl.Vector.Status.MarkSucceeded(ctrl.Vector.Generation, len(ctrl.SecretAssets) > 0)
 
-	return k8s.PatchStatus(ctx, ctrl.Vector, base, ctrl.Client)
+	if err := k8s.PatchStatus(ctx, ctrl.Vector, base, ctrl.Client); err != nil {
+		return err
+	}
+	k8s.PublishedEvent(ctx, ctrl.Vector, before, ctrl.Vector.Status.Conditions, configPublished)
+	return nil
 }
 
 // StampConfigPublishing writes ONLY LastConfigPublishedAt, deliberately touching
@@ -163,11 +174,41 @@ func (ctrl *Controller) StampConfigPublishing(ctx context.Context) error {
 	return nil
 }
 
+// SetFailedStatus marks the agent's config invalid (the ConfigValid condition).
 func (ctrl *Controller) SetFailedStatus(ctx context.Context, reason string) error {
+	return ctrl.setFailedStatus(ctx, vectorv1alpha1.ConditionConfigValid, vectorv1alpha1.ReasonConfigInvalid, reason, nil)
+}
+
+// SetConfigCheckFailedStatus is SetFailedStatus for a failed configcheck, recording its
+// report next to the reason.
+func (ctrl *Controller) SetConfigCheckFailedStatus(ctx context.Context, reason string, report *vectorv1alpha1.ConfigCheckReport) error {
+	return ctrl.setFailedStatus(ctx, vectorv1alpha1.ConditionConfigValid, vectorv1alpha1.ReasonConfigInvalid, reason, report)
+}
+
+// SetSecretsFailedStatus marks the agent's config unbuildable because a pipeline's
+// SECRET[] reference did not resolve (the SecretsResolved condition).
+func (ctrl *Controller) SetSecretsFailedStatus(ctx context.Context, reason string) error {
+	return ctrl.setFailedStatus(ctx, vectorv1alpha1.ConditionSecretsResolved, vectorv1alpha1.ReasonSecretResolveFailed, reason, nil)
+}
+
+// setFailedStatus writes a failure of conditionType. A ConfigValid failure replaces the
+// configcheck report with report; a SecretsResolved one keeps it.
+func (ctrl *Controller) setFailedStatus(ctx context.Context, conditionType, conditionReason, reason string, report *vectorv1alpha1.ConfigCheckReport) error {
+	before := k8s.FindCondition(ctrl.Vector.Status.Conditions, vectorv1alpha1.ConditionReady)
 	base := ctrl.Vector.DeepCopy()
+	base.Status.Conditions = nil
 	var status = false
 	ctrl.Vector.Status.ConfigCheckResult = &status
 	ctrl.Vector.Status.Reason = &reason
+	if conditionType == vectorv1alpha1.ConditionConfigValid {
+		base.Status.ConfigCheckReport = &vectorv1alpha1.ConfigCheckReport{}
+		ctrl.Vector.Status.ConfigCheckReport = report
+	}
+	ctrl.Vector.Status.MarkFailed(ctrl.Vector.Generation, conditionType, conditionReason, reason)
 
-	return k8s.PatchStatus(ctx, ctrl.Vector, base, ctrl.Client)
+	if err := k8s.PatchStatus(ctx, ctrl.Vector, base, ctrl.Client); err != nil {
+		return err
+	}
+	k8s.ConditionEvent(ctx, ctrl.Vector, vectorv1alpha1.ConditionReady, before, ctrl.Vector.Status.Conditions, k8s.ConditionAction(conditionType), "")
+	return nil
 }
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
@@ -94,4 +95,40 @@ func TestSetSuccessStatusClearsUnobservedReason(t *testing.T) {
 	g.Expect(cl.Get(ctx, key, result)).To(Succeed())
 	g.Expect(result.Status.ConfigCheckResult).To(HaveValue(BeTrue()))
 	g.Expect(result.Status.Reason).To(BeNil(), "a success must not keep a failure reason")
+	g.Expect(meta.IsStatusConditionTrue(result.Status.Conditions, vectorv1alpha1.ConditionReady)).To(BeTrue())
+}
+
+// A failed round turns its own condition and Ready False but leaves Published alone: the
+// config Secret still holds what the last successful round published.
+func TestSetFailedStatusConditions(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	v := &vectorv1alpha1.Vector{
+		ObjectMeta: metav1.ObjectMeta{Name: "agent", Namespace: "vector", Generation: 2},
+		Spec:       vectorv1alpha1.VectorSpec{Agent: &vectorv1alpha1.VectorAgent{}},
+	}
+	cl := newStatusTestClient(g, v)
+	key := client.ObjectKeyFromObject(v)
+
+	current := &vectorv1alpha1.Vector{}
+	g.Expect(cl.Get(ctx, key, current)).To(Succeed())
+	cfgHash, globalHash := int64(1), int64(2)
+	g.Expect(NewController(current, cl, nil).SetSuccessStatus(ctx, &cfgHash, &globalHash, false)).To(Succeed())
+
+	current = &vectorv1alpha1.Vector{}
+	g.Expect(cl.Get(ctx, key, current)).To(Succeed())
+	g.Expect(NewController(current, cl, nil).SetSecretsFailedStatus(ctx, "secret not found")).To(Succeed())
+
+	result := &vectorv1alpha1.Vector{}
+	g.Expect(cl.Get(ctx, key, result)).To(Succeed())
+	g.Expect(result.Status.ObservedGeneration).To(Equal(result.Generation))
+	secrets := meta.FindStatusCondition(result.Status.Conditions, vectorv1alpha1.ConditionSecretsResolved)
+	g.Expect(secrets).NotTo(BeNil())
+	g.Expect(secrets.Status).To(Equal(metav1.ConditionFalse))
+	g.Expect(secrets.Reason).To(Equal(vectorv1alpha1.ReasonSecretResolveFailed))
+	g.Expect(secrets.Message).To(Equal("secret not found"))
+	g.Expect(meta.IsStatusConditionFalse(result.Status.Conditions, vectorv1alpha1.ConditionReady)).To(BeTrue())
+	g.Expect(meta.IsStatusConditionTrue(result.Status.Conditions, vectorv1alpha1.ConditionConfigValid)).To(BeTrue())
+	g.Expect(meta.IsStatusConditionTrue(result.Status.Conditions, vectorv1alpha1.ConditionPublished)).To(BeTrue())
 }
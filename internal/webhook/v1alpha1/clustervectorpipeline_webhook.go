@@ -0,0 +1,59 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/kaasops/vector-operator/api/v1alpha1"
+)
+
+// SetupClusterVectorPipelineWebhookWithManager registers the validating webhook for
+// ClusterVectorPipeline with the manager's webhook server.
+func SetupClusterVectorPipelineWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr, &v1alpha1.ClusterVectorPipeline{}).
+		WithValidator(&ClusterVectorPipelineCustomValidator{}).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/validate-observability-kaasops-io-v1alpha1-clustervectorpipeline,mutating=false,failurePolicy=fail,sideEffects=None,groups=observability.kaasops.io,resources=clustervectorpipelines,verbs=create;update,versions=v1alpha1,name=vclustervectorpipeline-v1alpha1.kb.io,admissionReviewVersions=v1
+
+// ClusterVectorPipelineCustomValidator is VectorPipelineCustomValidator for the
+// cluster-scoped kind. The namespace rules differ (no kubernetes_logs restriction, a
+// secret backend must name its namespace), and config.ValidatePipeline already tells
+// the two apart by type.
+type ClusterVectorPipelineCustomValidator struct{}
+
+var _ admission.Validator[*v1alpha1.ClusterVectorPipeline] = &ClusterVectorPipelineCustomValidator{}
+
+func (v *ClusterVectorPipelineCustomValidator) ValidateCreate(_ context.Context, obj *v1alpha1.ClusterVectorPipeline) (admission.Warnings, error) {
+	return nil, validatePipeline(obj)
+}
+
+func (v *ClusterVectorPipelineCustomValidator) ValidateUpdate(_ context.Context, oldObj, newObj *v1alpha1.ClusterVectorPipeline) (admission.Warnings, error) {
+	if skipUpdateValidation(oldObj, newObj) {
+		return nil, nil
+	}
+	return nil, validatePipeline(newObj)
+}
+
+func (v *ClusterVectorPipelineCustomValidator) ValidateDelete(_ context.Context, _ *v1alpha1.ClusterVectorPipeline) (admission.Warnings, error) {
+	return nil, nil
+}
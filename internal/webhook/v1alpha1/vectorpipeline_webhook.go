@@ -0,0 +1,100 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/kaasops/vector-operator/api/v1alpha1"
+	"github.com/kaasops/vector-operator/internal/config"
+	"github.com/kaasops/vector-operator/internal/pipeline"
+)
+
+// SetupVectorPipelineWebhookWithManager registers the validating webhook for
+// VectorPipeline with the manager's webhook server.
+func SetupVectorPipelineWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr, &v1alpha1.VectorPipeline{}).
+		WithValidator(&VectorPipelineCustomValidator{}).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/validate-observability-kaasops-io-v1alpha1-vectorpipeline,mutating=false,failurePolicy=fail,sideEffects=None,groups=observability.kaasops.io,resources=vectorpipelines,verbs=create;update,versions=v1alpha1,name=vvectorpipeline-v1alpha1.kb.io,admissionReviewVersions=v1
+
+// VectorPipelineCustomValidator rejects a VectorPipeline whose spec the pipeline
+// controller would mark invalid on its own (see config.ValidatePipeline), so the
+// author gets the error from kubectl instead of from .status.reason a reconcile later.
+type VectorPipelineCustomValidator struct{}
+
+var _ admission.Validator[*v1alpha1.VectorPipeline] = &VectorPipelineCustomValidator{}
+
+func (v *VectorPipelineCustomValidator) ValidateCreate(_ context.Context, obj *v1alpha1.VectorPipeline) (admission.Warnings, error) {
+	return nil, validatePipeline(obj)
+}
+
+func (v *VectorPipelineCustomValidator) ValidateUpdate(_ context.Context, oldObj, newObj *v1alpha1.VectorPipeline) (admission.Warnings, error) {
+	if skipUpdateValidation(oldObj, newObj) {
+		return nil, nil
+	}
+	return nil, validatePipeline(newObj)
+}
+
+func (v *VectorPipelineCustomValidator) ValidateDelete(_ context.Context, _ *v1alpha1.VectorPipeline) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// skipUpdateValidation lets through updates that cannot make a pipeline any less valid:
+// ones leaving the spec untouched (labels, annotations, finalizers on a pipeline that
+// was admitted before the webhook existed, or before a rule tightened) and any update
+// to an object already being deleted, so a finalizer can always be removed.
+// Flipping spec.suspend alone is let through as well: suspending is how an operator
+// takes a misbehaving pipeline out of the config, and it must work on one that would
+// no longer pass validation.
+func skipUpdateValidation(oldObj, newObj pipeline.Pipeline) bool {
+	if newObj.IsDeleted() {
+		return true
+	}
+	oldSpec, newSpec := oldObj.GetSpec(), newObj.GetSpec()
+	oldSpec.Suspend = newSpec.Suspend
+	return equality.Semantic.DeepEqual(oldSpec, newSpec)
+}
+
+// validatePipeline runs config.ValidatePipeline and reports a failure as an Invalid
+// error on .spec, which the API server returns to the client as a 422.
+func validatePipeline(p pipeline.Pipeline) error {
+	if _, err := config.ValidatePipeline(p); err != nil {
+		gk := schema.GroupKind{Group: v1alpha1.GroupVersion.Group, Kind: pipelineKind(p)}
+		return apierrors.NewInvalid(gk, p.GetName(), field.ErrorList{
+			field.Invalid(field.NewPath("spec"), "<pipeline spec>", err.Error()),
+		})
+	}
+	return nil
+}
+
+// pipelineKind names the kind of p; a decoded object's TypeMeta is not relied on.
+func pipelineKind(p pipeline.Pipeline) string {
+	if _, ok := p.(*v1alpha1.ClusterVectorPipeline); ok {
+		return "ClusterVectorPipeline"
+	}
+	return "VectorPipeline"
+}
@@ -0,0 +1,119 @@
+package v1alpha1
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/kaasops/vector-operator/api/v1alpha1"
+)
+
+const testSinks = `{"out":{"type":"blackhole","inputs":["logs"]}}`
+
+func testVP(sources string) *v1alpha1.VectorPipeline {
+	return &v1alpha1.VectorPipeline{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "team-a"},
+		Spec: v1alpha1.VectorPipelineSpec{
+			Sources: &runtime.RawExtension{Raw: []byte(sources)},
+			Sinks:   &runtime.RawExtension{Raw: []byte(testSinks)},
+		},
+	}
+}
+
+func testCVP(sources string, secret map[string]v1alpha1.PipelineSecretBackend) *v1alpha1.ClusterVectorPipeline {
+	return &v1alpha1.ClusterVectorPipeline{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+		Spec: v1alpha1.VectorPipelineSpec{
+			Sources: &runtime.RawExtension{Raw: []byte(sources)},
+			Sinks:   &runtime.RawExtension{Raw: []byte(testSinks)},
+			Secret:  secret,
+		},
+	}
+}
+
+func TestVectorPipelineValidateCreate(t *testing.T) {
+	v := &VectorPipelineCustomValidator{}
+
+	_, err := v.ValidateCreate(context.Background(), testVP(`{"logs":{"type":"kubernetes_logs"}}`))
+	require.NoError(t, err)
+
+	_, err = v.ValidateCreate(context.Background(), testVP(`{"logs":{"type":"file","include":["/var/log/*"]}}`))
+	require.Error(t, err)
+	require.True(t, apierrors.IsInvalid(err), "want an Invalid status error, got %v", err)
+	require.ErrorContains(t, err, `VectorPipeline.observability.kaasops.io "app" is invalid`)
+	require.ErrorContains(t, err, "type kubernetes_logs only allowed")
+}
+
+// The event collector is the one to apply the filter of a kubernetes_events source, so
+// a malformed one is rejected here rather than when the aggregator builds.
+func TestVectorPipelineValidateKubernetesEvents(t *testing.T) {
+	v := &VectorPipelineCustomValidator{}
+
+	_, err := v.ValidateCreate(context.Background(), testVP(`{"logs":{"type":"kubernetes_events","include":{"types":["Warning"]},"field_selector":"involvedObject.kind=Pod"}}`))
+	require.NoError(t, err)
+
+	for name, sources := range map[string]string{
+		"typo in include":      `{"logs":{"type":"kubernetes_events","include":{"reason":["BackOff"]}}}`,
+		"unsupported selector": `{"logs":{"type":"kubernetes_events","field_selector":"message=x"}}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := v.ValidateCreate(context.Background(), testVP(sources))
+			require.True(t, apierrors.IsInvalid(err), "want an Invalid status error, got %v", err)
+			require.ErrorContains(t, err, "source logs:")
+		})
+	}
+}
+
+func TestVectorPipelineValidateUpdate(t *testing.T) {
+	v := &VectorPipelineCustomValidator{}
+	invalid := testVP(`{"logs":{"type":"kubernetes_logs","extra_namespace_label_selector":"kubernetes.io/metadata.name=team-b"}}`)
+
+	// a spec change into an invalid spec is rejected
+	_, err := v.ValidateUpdate(context.Background(), testVP(`{"logs":{"type":"kubernetes_logs"}}`), invalid)
+	require.ErrorContains(t, err, "logs from external namespace not allowed")
+
+	// an already-invalid pipeline admitted earlier can still have its metadata edited
+	relabeled := invalid.DeepCopy()
+	relabeled.Labels = map[string]string{"team": "a"}
+	_, err = v.ValidateUpdate(context.Background(), invalid, relabeled)
+	require.NoError(t, err)
+
+	// and suspended, which is how it is taken out of the config
+	suspended := invalid.DeepCopy()
+	suspended.Spec.Suspend = true
+	_, err = v.ValidateUpdate(context.Background(), invalid, suspended)
+	require.NoError(t, err)
+
+	// and its finalizers dropped once it is being deleted, whatever the spec says
+	deleting := testVP(`{"logs":{"type":"file"}}`)
+	now := metav1.Now()
+	deleting.DeletionTimestamp = &now
+	_, err = v.ValidateUpdate(context.Background(), invalid, deleting)
+	require.NoError(t, err)
+}
+
+func TestVectorPipelineValidateDelete(t *testing.T) {
+	_, err := (&VectorPipelineCustomValidator{}).ValidateDelete(context.Background(), testVP(`{}`))
+	require.NoError(t, err)
+}
+
+func TestClusterVectorPipelineValidate(t *testing.T) {
+	v := &ClusterVectorPipelineCustomValidator{}
+	sources := `{"logs":{"type":"kubernetes_logs","extra_namespace_label_selector":"env=prod"}}`
+
+	_, err := v.ValidateCreate(context.Background(), testCVP(sources, nil))
+	require.NoError(t, err)
+
+	_, err = v.ValidateCreate(context.Background(), testCVP(sources,
+		map[string]v1alpha1.PipelineSecretBackend{"es": {Type: "kubernetes_secret", Name: "creds"}}))
+	require.True(t, apierrors.IsInvalid(err), "want an Invalid status error, got %v", err)
+	require.ErrorContains(t, err, `ClusterVectorPipeline.observability.kaasops.io "cluster" is invalid`)
+	require.ErrorContains(t, err, "namespace is required")
+
+	_, err = v.ValidateUpdate(context.Background(), testCVP(sources, nil), testCVP(`{}`, nil))
+	require.ErrorContains(t, err, "sources list is empty")
+}